@@ -17,15 +17,22 @@ import (
 )
 
 var commands = []*util.Command{
+	nomsBackup,
+	nomsBlob,
 	nomsCommit,
 	nomsConfig,
+	nomsDedup,
 	nomsDiff,
 	nomsDs,
+	nomsFsck,
 	nomsLog,
 	nomsMerge,
+	nomsMigrate,
+	nomsRestore,
 	nomsRoot,
 	nomsServe,
 	nomsShow,
+	nomsSquash,
 	nomsSync,
 	nomsVersion,
 }
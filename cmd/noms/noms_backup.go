@@ -0,0 +1,101 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/attic-labs/noms/cmd/util"
+	"github.com/attic-labs/noms/go/config"
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/hash"
+	"github.com/attic-labs/noms/go/util/verbose"
+	humanize "github.com/dustin/go-humanize"
+	flag "github.com/juju/gnuflag"
+)
+
+var (
+	backupSince    string
+	backupManifest string
+)
+
+var nomsBackup = &util.Command{
+	Run:       runBackup,
+	UsageLine: "backup [options] <database> <file>",
+	Short:     "Writes every chunk of a database to a single archive",
+	Long:      "Writes the chunks reachable from the root of the database, which are all the chunks of its datasets and their histories, to file, or to stdout if file is -. A manifest describing the backup is written to <file>.manifest, or to the --manifest file. With --since, the backup is incremental: only the chunks which aren't in the backup described by the given manifest are written. Backups are restored with noms restore. See Spelling Objects at https://github.com/attic-labs/noms/blob/master/doc/spelling.md for details on the database argument.",
+	Flags:     setupBackupFlags,
+	Nargs:     2,
+}
+
+func setupBackupFlags() *flag.FlagSet {
+	backupFlagSet := flag.NewFlagSet("backup", flag.ExitOnError)
+	backupFlagSet.StringVar(&backupSince, "since", "", "manifest of a previous backup to make an incremental backup on top of")
+	backupFlagSet.StringVar(&backupManifest, "manifest", "", "file to write the manifest to, instead of <file>.manifest")
+	verbose.RegisterVerboseFlags(backupFlagSet)
+	return backupFlagSet
+}
+
+func runBackup(args []string) int {
+	manifestFile := backupManifest
+	if manifestFile == "" && args[1] != "-" {
+		manifestFile = args[1] + ".manifest"
+	}
+
+	base := hash.Hash{}
+	if backupSince != "" {
+		since, err := readBackupManifest(backupSince)
+		d.CheckErrorNoUsage(err)
+		base = hash.Parse(since.Root)
+	}
+
+	cfg := config.NewResolver()
+	db, err := cfg.GetDatabase(args[0])
+	d.CheckErrorNoUsage(err)
+	defer db.Close()
+
+	var out io.Writer = os.Stdout
+	if args[1] != "-" {
+		f, err := os.Create(args[1])
+		d.CheckErrorNoUsage(err)
+		defer f.Close()
+		out = f
+	}
+	bw := bufio.NewWriter(out)
+	manifest, err := datas.Backup(db, bw, base)
+	d.CheckErrorNoUsage(err)
+	d.CheckErrorNoUsage(bw.Flush())
+
+	if manifestFile != "" {
+		data, err := json.MarshalIndent(manifest, "", "  ")
+		d.PanicIfError(err)
+		d.CheckErrorNoUsage(ioutil.WriteFile(manifestFile, append(data, '\n'), 0644))
+	}
+	if args[1] != "-" {
+		fmt.Printf("Backed up %s chunks (%s) of root %s\n", humanize.Comma(int64(manifest.Chunks)), humanize.Bytes(manifest.Bytes), manifest.Root)
+	}
+	return 0
+}
+
+func readBackupManifest(file string) (datas.BackupManifest, error) {
+	manifest := datas.BackupManifest{}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return manifest, err
+	}
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("Invalid manifest %s: %s", file, err)
+	}
+	if _, ok := hash.MaybeParse(manifest.Root); !ok {
+		return manifest, fmt.Errorf("Invalid manifest %s: bad root %q", file, manifest.Root)
+	}
+	return manifest, nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/spec"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/clienttest"
+	"github.com/attic-labs/testify/suite"
+)
+
+func TestNomsBackup(t *testing.T) {
+	suite.Run(t, &nomsBackupTestSuite{})
+}
+
+type nomsBackupTestSuite struct {
+	clienttest.ClientTestSuite
+}
+
+// commitBoth commits to two datasets, so that the chunks of each backup are
+// split between two NBS tables in the source, and restored into one.
+// Otherwise, the restored table would have the same name as the source's but a
+// different layout, which NBS's index cache, shared by every store in the
+// process, doesn't allow for.
+func commitBoth(db datas.Database, v string) {
+	_, err := db.CommitValue(db.GetDataset("ds"), types.String(v))
+	d.PanicIfError(err)
+	_, err = db.CommitValue(db.GetDataset("other"), types.String(v+"!"))
+	d.PanicIfError(err)
+}
+
+func (s *nomsBackupTestSuite) TestBackupAndRestore() {
+	dbSpecStr := spec.CreateDatabaseSpecString("nbs", s.DBDir)
+	sp, err := spec.ForDatabase(dbSpecStr)
+	s.NoError(err)
+	defer sp.Close()
+
+	db := sp.GetDatabase()
+	commitBoth(db, "one")
+
+	full := path.Join(s.TempDir, "full.bak")
+	out, _ := s.MustRun(main, []string{"backup", dbSpecStr, full})
+	s.Contains(out, "Backed up 3 chunks")
+	_, err = os.Stat(full + ".manifest")
+	s.NoError(err)
+
+	commitBoth(db, "two")
+	incremental := path.Join(s.TempDir, "incremental.bak")
+	out, _ = s.MustRun(main, []string{"backup", "--since", full + ".manifest", dbSpecStr, incremental})
+	s.Contains(out, "Backed up 3 chunks")
+
+	restoreDir := path.Join(s.TempDir, "restored")
+	s.NoError(os.MkdirAll(restoreDir, 0777))
+	restoreSpecStr := spec.CreateDatabaseSpecString("nbs", restoreDir)
+	_, _, exitErr := s.Run(main, []string{"restore", incremental, restoreSpecStr})
+	s.Equal(clienttest.ExitError{Code: 1}, exitErr)
+
+	out, _ = s.MustRun(main, []string{"restore", full, restoreSpecStr})
+	s.Contains(out, "Restored 3 chunks")
+	out, _ = s.MustRun(main, []string{"show", spec.CreateValueSpecString("nbs", restoreDir, "ds.value")})
+	s.Equal("\"one\"\n", out)
+
+	out, _ = s.MustRun(main, []string{"restore", incremental, restoreSpecStr})
+	s.Contains(out, "Restored 3 chunks")
+	out, _ = s.MustRun(main, []string{"show", spec.CreateValueSpecString("nbs", restoreDir, "ds.value")})
+	s.Equal("\"two\"\n", out)
+	out, _ = s.MustRun(main, []string{"fsck", restoreSpecStr})
+	s.Contains(out, "Checked 5 chunks")
+	s.Contains(out, "found 0 problems")
+}
@@ -0,0 +1,142 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/attic-labs/noms/cmd/util"
+	"github.com/attic-labs/noms/go/config"
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/profile"
+	"github.com/attic-labs/noms/go/util/status"
+	"github.com/attic-labs/noms/go/util/verbose"
+	humanize "github.com/dustin/go-humanize"
+	flag "github.com/juju/gnuflag"
+)
+
+var blobConcurrency int
+
+// blobExportPartSize is the number of bytes of a Blob each worker reads and
+// writes at a time during export.
+var blobExportPartSize = int64(1 << 22)
+
+var nomsBlob = &util.Command{
+	Run:       runBlob,
+	UsageLine: "blob [options] (export <blob-path> <file> | cat <blob-path>)",
+	Short:     "Copies the contents of a Blob out of a database",
+	Long:      "export writes the Blob at blob-path to file, reading ranges of it concurrently. cat writes it to stdout.\n\nSee Spelling Objects at https://github.com/attic-labs/noms/blob/master/doc/spelling.md for details on the blob-path argument.",
+	Flags:     setupBlobFlags,
+	Nargs:     2,
+}
+
+func setupBlobFlags() *flag.FlagSet {
+	blobFlagSet := flag.NewFlagSet("blob", flag.ExitOnError)
+	blobFlagSet.IntVar(&blobConcurrency, "concurrency", runtime.NumCPU(), "number of ranges of the blob to read concurrently during export")
+	verbose.RegisterVerboseFlags(blobFlagSet)
+	profile.RegisterProfileFlags(blobFlagSet)
+	status.RegisterStatusFlags(blobFlagSet)
+	return blobFlagSet
+}
+
+func runBlob(args []string) int {
+	switch {
+	case args[0] == "export" && len(args) == 3:
+	case args[0] == "cat" && len(args) == 2:
+	default:
+		d.CheckError(fmt.Errorf("Unknown blob command: %s", args))
+	}
+
+	cfg := config.NewResolver()
+	db, val, err := cfg.GetPath(args[1])
+	d.CheckErrorNoUsage(err)
+	defer db.Close()
+	if val == nil {
+		d.CheckErrorNoUsage(fmt.Errorf("No value at %s", args[1]))
+	}
+	blob, ok := val.(types.Blob)
+	if !ok {
+		d.CheckErrorNoUsage(fmt.Errorf("Value at %s is not a blob", args[1]))
+	}
+
+	defer profile.MaybeStartProfile().Stop()
+
+	if args[0] == "cat" {
+		blob.Reader().Copy(os.Stdout)
+		return 0
+	}
+
+	file, err := os.Create(args[2])
+	d.CheckErrorNoUsage(err)
+
+	start := time.Now()
+	expected := humanize.Bytes(blob.Len())
+	err = exportBlob(blob, file, blobConcurrency, func(written uint64) {
+		if status.WillPrint() {
+			elapsed := time.Since(start).Seconds()
+			rate := uint64(float64(written) / elapsed)
+			status.Printf("%s of %s written in %ds (%s/s)...", humanize.Bytes(written), expected, int(elapsed), humanize.Bytes(rate))
+		}
+	})
+	d.CheckErrorNoUsage(err)
+	d.CheckErrorNoUsage(file.Close())
+	status.Done()
+	return 0
+}
+
+// exportBlob copies blob to w, with concurrency workers each reading and
+// writing blobExportPartSize bytes at a time. progress is called with the
+// total number of bytes written after each part is done.
+func exportBlob(blob types.Blob, w io.WriterAt, concurrency int, progress func(written uint64)) error {
+	length := int64(blob.Len())
+	offsets := make(chan int64, (length+blobExportPartSize-1)/blobExportPartSize)
+	for off := int64(0); off < length; off += blobExportPartSize {
+		offsets <- off
+	}
+	close(offsets)
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	mu := &sync.Mutex{}
+	var written uint64
+	var firstErr error
+
+	wg := &sync.WaitGroup{}
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			buff := make([]byte, blobExportPartSize)
+			for off := range offsets {
+				p := buff
+				if off+int64(len(p)) > length {
+					p = p[:length-off]
+				}
+				_, err := blob.ReadAt(p, off)
+				if err == nil {
+					_, err = w.WriteAt(p, off)
+				}
+
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				written += uint64(len(p))
+				progress(written)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,62 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/attic-labs/noms/go/spec"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/clienttest"
+	"github.com/attic-labs/testify/suite"
+)
+
+func TestNomsBlob(t *testing.T) {
+	suite.Run(t, &nomsBlobTestSuite{})
+}
+
+type nomsBlobTestSuite struct {
+	clienttest.ClientTestSuite
+}
+
+func (s *nomsBlobTestSuite) TestBlobExportAndCat() {
+	defer func(size int64) { blobExportPartSize = size }(blobExportPartSize)
+	blobExportPartSize = 1000
+
+	data := make([]byte, 123456)
+	rand.New(rand.NewSource(42)).Read(data)
+
+	sp, err := spec.ForDataset(spec.CreateValueSpecString("nbs", s.DBDir, "blob"))
+	s.NoError(err)
+	db := sp.GetDatabase()
+	_, err = db.CommitValue(sp.GetDataset(), types.NewStreamingBlob(db, bytes.NewReader(data)))
+	s.NoError(err)
+	sp.Close()
+
+	blobPath := spec.CreateValueSpecString("nbs", s.DBDir, "blob.value")
+	filePath := filepath.Join(s.TempDir, "out")
+	s.MustRun(main, []string{"blob", "--concurrency", "4", "export", blobPath, filePath})
+	fileBytes, err := ioutil.ReadFile(filePath)
+	s.NoError(err)
+	s.Equal(data, fileBytes)
+
+	stdout, _ := s.MustRun(main, []string{"blob", "cat", blobPath})
+	s.Equal(data, []byte(stdout))
+}
+
+func (s *nomsBlobTestSuite) TestBlobNotABlob() {
+	sp, err := spec.ForDataset(spec.CreateValueSpecString("nbs", s.DBDir, "num"))
+	s.NoError(err)
+	_, err = sp.GetDatabase().CommitValue(sp.GetDataset(), types.Number(42))
+	s.NoError(err)
+	sp.Close()
+
+	_, _, exitErr := s.Run(main, []string{"blob", "cat", spec.CreateValueSpecString("nbs", s.DBDir, "num.value")})
+	s.Equal(clienttest.ExitError{1}, exitErr)
+}
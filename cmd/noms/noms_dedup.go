@@ -0,0 +1,86 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/attic-labs/noms/cmd/util"
+	"github.com/attic-labs/noms/go/config"
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/hash"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/verbose"
+	humanize "github.com/dustin/go-humanize"
+	flag "github.com/juju/gnuflag"
+)
+
+var nomsDedup = &util.Command{
+	Run:       runDedup,
+	UsageLine: "dedup <database> | dedup <object1> <object2>",
+	Short:     "Reports how many chunks are shared between values, or in a whole database",
+	Long:      "With one argument, counts the chunks reachable from the root of the database, both once each and once per reference to them, and reports the ratio of the two sizes. With two arguments, counts the chunks reachable from each object, e.g. the heads of two datasets, and reports how many are shared. Both objects must be in the same database. See Spelling Objects at https://github.com/attic-labs/noms/blob/master/doc/spelling.md for details on the arguments.",
+	Flags:     setupDedupFlags,
+	Nargs:     1,
+}
+
+func setupDedupFlags() *flag.FlagSet {
+	dedupFlagSet := flag.NewFlagSet("dedup", flag.ExitOnError)
+	verbose.RegisterVerboseFlags(dedupFlagSet)
+	return dedupFlagSet
+}
+
+func runDedup(args []string) int {
+	cfg := config.NewResolver()
+	if len(args) == 1 {
+		db, err := cfg.GetDatabase(args[0])
+		d.CheckErrorNoUsage(err)
+		defer db.Close()
+
+		stats := datas.StoreDedupStats(db)
+		fmt.Printf("unique:     %s\n", formatChunkCount(stats.Unique.Chunks, stats.Unique.Bytes))
+		fmt.Printf("referenced: %s\n", formatChunkCount(stats.Referenced.Chunks, stats.Referenced.Bytes))
+		fmt.Printf("dedup ratio: %.2f\n", stats.Ratio())
+		return 0
+	}
+	if len(args) != 2 {
+		d.CheckError(fmt.Errorf("Expected one database or two objects"))
+	}
+
+	db, a, err := cfg.GetPath(args[0])
+	d.CheckErrorNoUsage(err)
+	defer db.Close()
+	if a == nil {
+		d.CheckErrorNoUsage(fmt.Errorf("Object not found: %s", args[0]))
+	}
+	db2, b, err := cfg.GetPath(args[1])
+	d.CheckErrorNoUsage(err)
+	defer db2.Close()
+	if b == nil {
+		d.CheckErrorNoUsage(fmt.Errorf("Object not found: %s", args[1]))
+	}
+
+	// The chunks of b are read from a's database, so they have to be there.
+	bHashes := hash.HashSet{}
+	b.WalkRefs(func(r types.Ref) {
+		bHashes.Insert(r.TargetHash())
+	})
+	if len(db.HasMany(bHashes)) != len(bHashes) {
+		fmt.Fprintf(os.Stderr, "%s and %s must be in the same database\n", args[0], args[1])
+		return 1
+	}
+
+	cs := types.CompareChunks(a, db, b, db)
+	fmt.Printf("only in %s: %s\n", args[0], formatChunkCount(cs.Old, cs.OldBytes))
+	fmt.Printf("only in %s: %s\n", args[1], formatChunkCount(cs.New, cs.NewBytes))
+	fmt.Printf("shared: %s\n", formatChunkCount(cs.Shared, cs.SharedBytes))
+	return 0
+}
+
+func formatChunkCount(chunks, bytes uint64) string {
+	return fmt.Sprintf("%s chunks (%s)", humanize.Comma(int64(chunks)), humanize.Bytes(bytes))
+}
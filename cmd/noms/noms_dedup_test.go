@@ -0,0 +1,48 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"testing"
+
+	"github.com/attic-labs/noms/go/spec"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/clienttest"
+	"github.com/attic-labs/testify/suite"
+)
+
+func TestNomsDedup(t *testing.T) {
+	suite.Run(t, &nomsDedupTestSuite{})
+}
+
+type nomsDedupTestSuite struct {
+	clienttest.ClientTestSuite
+}
+
+func (s *nomsDedupTestSuite) TestDedup() {
+	dbSpecStr := spec.CreateDatabaseSpecString("nbs", s.DBDir)
+	sp, err := spec.ForDatabase(dbSpecStr)
+	s.NoError(err)
+	defer sp.Close()
+
+	db := sp.GetDatabase()
+	l := types.NewList(db.WriteValue(types.String("one")), db.WriteValue(types.String("two")))
+	_, err = db.CommitValue(db.GetDataset("ds1"), l)
+	s.NoError(err)
+	_, err = db.CommitValue(db.GetDataset("ds2"), l.Append(db.WriteValue(types.String("three"))))
+	s.NoError(err)
+
+	out, _ := s.MustRun(main, []string{"dedup", dbSpecStr})
+	s.Contains(out, "unique:     6 chunks")
+	s.Contains(out, "referenced: 8 chunks")
+	s.Contains(out, "dedup ratio: ")
+
+	ds1 := spec.CreateValueSpecString("nbs", s.DBDir, "ds1.value")
+	ds2 := spec.CreateValueSpecString("nbs", s.DBDir, "ds2.value")
+	out, _ = s.MustRun(main, []string{"dedup", ds1, ds2})
+	s.Contains(out, "only in "+ds1+": 1 chunks")
+	s.Contains(out, "only in "+ds2+": 2 chunks")
+	s.Contains(out, "shared: 2 chunks")
+}
@@ -10,17 +10,19 @@ import (
 	"github.com/attic-labs/noms/cmd/util"
 	"github.com/attic-labs/noms/go/config"
 	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/datas"
 	"github.com/attic-labs/noms/go/diff"
+	"github.com/attic-labs/noms/go/types"
 	"github.com/attic-labs/noms/go/util/outputpager"
 	"github.com/attic-labs/noms/go/util/verbose"
 	flag "github.com/juju/gnuflag"
 )
 
-var summarize bool
+var summarize, compareChunks bool
 
 var nomsDiff = &util.Command{
 	Run:       runDiff,
-	UsageLine: "diff [--summarize] <object1> <object2>",
+	UsageLine: "diff [--summarize] [--chunks] <object1> <object2>",
 	Short:     "Shows the difference between two objects",
 	Long:      "See Spelling Objects at https://github.com/attic-labs/noms/blob/master/doc/spelling.md for details on the object arguments.",
 	Flags:     setupDiffFlags,
@@ -30,6 +32,7 @@ var nomsDiff = &util.Command{
 func setupDiffFlags() *flag.FlagSet {
 	diffFlagSet := flag.NewFlagSet("diff", flag.ExitOnError)
 	diffFlagSet.BoolVar(&summarize, "summarize", false, "Writes a summary of the changes instead")
+	diffFlagSet.BoolVar(&compareChunks, "chunks", false, "Writes how many of object2's chunks are shared with object1 instead, to measure deduplication")
 	outputpager.RegisterOutputpagerFlags(diffFlagSet)
 	verbose.RegisterVerboseFlags(diffFlagSet)
 
@@ -57,6 +60,18 @@ func runDiff(args []string) int {
 		return 0
 	}
 
+	if compareChunks {
+		if datas.IsCommit(value1) && datas.IsCommit(value2) {
+			// Comparing the commits themselves would walk their entire history.
+			fmt.Println("Comparing commit values")
+			value1 = value1.(types.Struct).Get(datas.ValueField)
+			value2 = value2.(types.Struct).Get(datas.ValueField)
+		}
+		cs := types.CompareChunks(value1, db1, value2, db2)
+		fmt.Printf("%d of %d chunks shared (%.2f%%), %d new\n", cs.Shared, cs.Total(), cs.SharedFraction()*100, cs.New)
+		return 0
+	}
+
 	pgr := outputpager.Start()
 	defer pgr.Stop()
 
@@ -40,6 +40,26 @@ func (s *nomsDiffTestSuite) TestNomsDiffOutputNotTruncated() {
 	s.True(strings.HasSuffix(out, "\"second commit\"\n  }\n"), out)
 }
 
+func (s *nomsDiffTestSuite) TestNomsDiffAcrossDatabases() {
+	sp1, err := spec.ForDataset(spec.CreateValueSpecString("nbs", s.DBDir, "diffAcross"))
+	s.NoError(err)
+	defer sp1.Close()
+	sp2, err := spec.ForDataset(spec.CreateValueSpecString("nbs", s.DBDir2, "diffAcrossCopy"))
+	s.NoError(err)
+	defer sp2.Close()
+
+	ds1, err := addCommitWithValue(sp1.GetDataset(), types.NewList(types.Number(1), types.Number(2)))
+	s.NoError(err)
+	_, err = addCommitWithValue(sp2.GetDataset(), types.NewList(types.Number(1), types.Number(3)))
+	s.NoError(err)
+
+	r1 := spec.CreateValueSpecString("nbs", s.DBDir, "#"+ds1.HeadRef().TargetHash().String()+".value")
+	r2 := spec.CreateValueSpecString("nbs", s.DBDir2, "diffAcrossCopy.value")
+	out, _ := s.MustRun(main, []string{"diff", r1, r2})
+	s.Contains(out, "-   2")
+	s.Contains(out, "+   3")
+}
+
 func (s *nomsDiffTestSuite) TestNomsDiffSummarize() {
 	sp, err := spec.ForDataset(spec.CreateValueSpecString("nbs", s.DBDir, "diffSummarizeTest"))
 	s.NoError(err)
@@ -72,4 +92,41 @@ func (s *nomsDiffTestSuite) TestNomsDiffSummarize() {
 
 	out, _ = s.MustRun(main, []string{"diff", "--summarize", r3, r4})
 	s.Contains(out, "1 insertion (25.00%), 2 deletions (50.00%), 0 changes (0.00%), (4 values vs 3 values)")
+
+	// Struct fields which are collections are summarized too.
+	ds, err = db.CommitValue(ds, types.NewStruct("", types.StructData{"rows": types.NewMap(types.Number(1), types.Number(1)), "name": types.String("a")}))
+	s.NoError(err)
+	r5 := spec.CreateHashSpecString("nbs", s.DBDir, ds.HeadRef().TargetHash()) + ".value"
+
+	ds, err = db.CommitValue(ds, types.NewStruct("", types.StructData{"rows": types.NewMap(types.Number(1), types.Number(2), types.Number(3), types.Number(3)), "name": types.String("a")}))
+	s.NoError(err)
+	r6 := spec.CreateHashSpecString("nbs", s.DBDir, ds.HeadRef().TargetHash()) + ".value"
+
+	out, _ = s.MustRun(main, []string{"diff", "--summarize", r5, r6})
+	s.Contains(out, "0 insertions (0.00%), 0 deletions (0.00%), 1 change (50.00%), (2 fields vs 2 fields)")
+	s.Contains(out, "  .rows: 1 insertion (100.00%), 0 deletions (0.00%), 1 change (100.00%), (1 entry vs 2 entries)\n")
+}
+
+func (s *nomsDiffTestSuite) TestNomsDiffChunks() {
+	sp, err := spec.ForDataset(spec.CreateValueSpecString("nbs", s.DBDir, "diffChunksTest"))
+	s.NoError(err)
+	defer sp.Close()
+
+	db := sp.GetDatabase()
+	r1 := db.WriteValue(types.String("one"))
+	r2 := db.WriteValue(types.String("two"))
+
+	ds, err := db.CommitValue(sp.GetDataset(), types.NewList(r1, r2))
+	s.NoError(err)
+	c1 := spec.CreateHashSpecString("nbs", s.DBDir, ds.HeadRef().TargetHash())
+
+	ds, err = db.CommitValue(ds, types.NewList(r1))
+	s.NoError(err)
+	c2 := spec.CreateHashSpecString("nbs", s.DBDir, ds.HeadRef().TargetHash())
+
+	out, _ := s.MustRun(main, []string{"diff", "--chunks", c1, c2})
+	s.Equal("Comparing commit values\n1 of 2 chunks shared (50.00%), 1 new\n", out)
+
+	out, _ = s.MustRun(main, []string{"diff", "--chunks", c1 + ".value", c1 + ".value"})
+	s.Equal("3 of 3 chunks shared (100.00%), 0 new\n", out)
 }
@@ -6,22 +6,35 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
 
 	"github.com/attic-labs/noms/cmd/util"
 	"github.com/attic-labs/noms/go/config"
 	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/spec"
 	"github.com/attic-labs/noms/go/types"
 	"github.com/attic-labs/noms/go/util/verbose"
 	flag "github.com/juju/gnuflag"
 )
 
-var toDelete string
+var (
+	toDelete string
+	toCopy   bool
+	toRename bool
+	toStats  bool
+	force    bool
+)
 
 var nomsDs = &util.Command{
 	Run:       runDs,
-	UsageLine: "ds [<database> | -d <dataset>]",
+	UsageLine: "ds [<database> | -d <dataset> | --copy <src-dataset> <dst-dataset> | --rename [--force] <src-dataset> <dst-dataset> | --stats <database>]",
 	Short:     "Noms dataset management",
-	Long:      "See Spelling Objects at https://github.com/attic-labs/noms/blob/master/doc/spelling.md for details on the database and dataset arguments.",
+	Long:      "--copy sets the head of dst-dataset to the head of src-dataset, pulling the data across if they're in different databases. --rename moves the head of src-dataset to dst-dataset, which must be in the same database, and refuses to replace an existing dst-dataset unless --force is given.\n\n--stats lists how many times each dataset of a database served by noms serve has been read and written since the server started, and when it was last read and written, e.g. to find datasets which could be archived.\n\nSee Spelling Objects at https://github.com/attic-labs/noms/blob/master/doc/spelling.md for details on the database and dataset arguments.",
 	Flags:     setupDsFlags,
 	Nargs:     0,
 }
@@ -29,13 +42,54 @@ var nomsDs = &util.Command{
 func setupDsFlags() *flag.FlagSet {
 	dsFlagSet := flag.NewFlagSet("ds", flag.ExitOnError)
 	dsFlagSet.StringVar(&toDelete, "d", "", "dataset to delete")
+	dsFlagSet.BoolVar(&toCopy, "copy", false, "copy the head of the first dataset to the second")
+	dsFlagSet.BoolVar(&toRename, "rename", false, "rename the first dataset to the second")
+	dsFlagSet.BoolVar(&force, "force", false, "with --rename, replace the second dataset if it exists")
+	dsFlagSet.BoolVar(&toStats, "stats", false, "list the reads and writes of each dataset counted by the server of the database")
 	verbose.RegisterVerboseFlags(dsFlagSet)
 	return dsFlagSet
 }
 
 func runDs(args []string) int {
 	cfg := config.NewResolver()
-	if toDelete != "" {
+	if toCopy || toRename {
+		if toCopy && toRename {
+			d.CheckErrorNoUsage(fmt.Errorf("Cannot use --copy with --rename"))
+		}
+		if len(args) != 2 {
+			d.CheckError(fmt.Errorf("Expected a source and a destination dataset"))
+		}
+		src, dst := openDatasets(cfg, args[0], args[1])
+		defer src.Database().Close()
+		if dst.Database() != src.Database() {
+			defer dst.Database().Close()
+		}
+
+		var err error
+		if toRename {
+			dst, err = datas.RenameDataset(src, dst, force)
+			d.CheckErrorNoUsage(err)
+			fmt.Printf("Renamed %v to %v (#%v)\n", args[0], args[1], dst.HeadRef().TargetHash().String())
+		} else {
+			dst, err = datas.CopyDataset(src, dst)
+			d.CheckErrorNoUsage(err)
+			fmt.Printf("Copied %v to %v (#%v)\n", args[0], args[1], dst.HeadRef().TargetHash().String())
+		}
+	} else if toStats {
+		if len(args) != 1 {
+			d.CheckError(fmt.Errorf("Expected a database"))
+		}
+		db, err := cfg.GetDatabase(args[0])
+		d.CheckError(err)
+		defer db.Close()
+		rdb, ok := db.(*datas.RemoteDatabaseClient)
+		if !ok {
+			d.CheckErrorNoUsage(fmt.Errorf("%s isn't served by noms serve, so it has no stats", args[0]))
+		}
+		stats, err := rdb.DatasetStats()
+		d.CheckErrorNoUsage(err)
+		printDatasetStats(os.Stdout, stats)
+	} else if toDelete != "" {
 		db, set, err := cfg.GetDataset(toDelete)
 		d.CheckError(err)
 		defer db.Close()
@@ -64,3 +118,43 @@ func runDs(args []string) int {
 	}
 	return 0
 }
+
+// openDatasets opens the Datasets named by srcStr and dstStr. If both are in
+// the same database, it's only opened once, so that copying between them
+// moves no data.
+func openDatasets(cfg *config.Resolver, srcStr, dstStr string) (src, dst datas.Dataset) {
+	srcSpec, err := spec.ForDataset(cfg.ResolvePathSpec(srcStr))
+	d.CheckErrorNoUsage(err)
+	dstSpec, err := spec.ForDataset(cfg.ResolvePathSpec(dstStr))
+	d.CheckErrorNoUsage(err)
+
+	src = srcSpec.GetDataset()
+	if srcSpec.Protocol == dstSpec.Protocol && srcSpec.DatabaseName == dstSpec.DatabaseName {
+		return src, src.Database().GetDataset(dstSpec.Path.Dataset)
+	}
+	return src, dstSpec.GetDataset()
+}
+
+// printDatasetStats writes stats to w as a table, with a row for each dataset in order of name.
+func printDatasetStats(w io.Writer, stats datas.DatasetStats) {
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return "never"
+		}
+		return t.Format(time.RFC3339)
+	}
+	ids := make([]string, 0, len(stats.Datasets))
+	for id := range stats.Datasets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	fmt.Fprintf(w, "Since %s:\n", formatTime(stats.Since))
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATASET\tREADS\tWRITES\tLAST READ\tLAST WRITE")
+	for _, id := range ids {
+		a := stats.Datasets[id]
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\n", id, a.Reads, a.Writes, formatTime(a.LastRead), formatTime(a.LastWrite))
+	}
+	tw.Flush()
+}
@@ -5,6 +5,10 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/attic-labs/noms/go/datas"
@@ -83,3 +87,74 @@ func (s *nomsDsTestSuite) TestNomsDs() {
 	rtnVal, _ = s.MustRun(main, []string{"ds", dbSpec})
 	s.Equal("", rtnVal)
 }
+
+func (s *nomsDsTestSuite) TestNomsDsCopyAndRename() {
+	db := datas.NewDatabase(nbs.NewLocalStore(s.DBDir, clienttest.DefaultMemTableSize))
+	tmp, err := db.CommitValue(db.GetDataset("tmp"), types.String("staged"))
+	s.NoError(err)
+	_, err = db.CommitValue(db.GetDataset("main"), types.String("old"))
+	s.NoError(err)
+	head := tmp.HeadRef().TargetHash().String()
+	s.NoError(db.Close())
+
+	dbSpec := spec.CreateDatabaseSpecString("nbs", s.DBDir)
+	tmpName := spec.CreateValueSpecString("nbs", s.DBDir, "tmp")
+	mainName := spec.CreateValueSpecString("nbs", s.DBDir, "main")
+	otherName := spec.CreateValueSpecString("nbs", s.DBDir2, "main")
+
+	rtnVal, _ := s.MustRun(main, []string{"ds", "--copy", tmpName, mainName})
+	s.Equal("Copied "+tmpName+" to "+mainName+" (#"+head+")\n", rtnVal)
+	rtnVal, _ = s.MustRun(main, []string{"ds", dbSpec})
+	s.Equal("main\ntmp\n", rtnVal)
+
+	// Copying to another database pulls the data.
+	rtnVal, _ = s.MustRun(main, []string{"ds", "--copy", tmpName, otherName})
+	s.Equal("Copied "+tmpName+" to "+otherName+" (#"+head+")\n", rtnVal)
+	rtnVal, _ = s.MustRun(main, []string{"show", otherName + ".value"})
+	s.Equal("\"staged\"\n", rtnVal)
+
+	_, _, exitErr := s.Run(main, []string{"ds", "--rename", tmpName, otherName})
+	s.Equal(clienttest.ExitError{1}, exitErr)
+
+	rtnVal, _ = s.MustRun(main, []string{"ds", "--rename", mainName, spec.CreateValueSpecString("nbs", s.DBDir, "prod")})
+	s.Contains(rtnVal, "Renamed")
+	rtnVal, _ = s.MustRun(main, []string{"ds", dbSpec})
+	s.Equal("prod\ntmp\n", rtnVal)
+
+	// An existing dataset is only replaced with --force.
+	prodName := spec.CreateValueSpecString("nbs", s.DBDir, "prod")
+	_, _, exitErr = s.Run(main, []string{"ds", "--rename", tmpName, prodName})
+	s.Equal(clienttest.ExitError{1}, exitErr)
+	rtnVal, _ = s.MustRun(main, []string{"ds", "--rename", "--force", tmpName, prodName})
+	s.Equal("Renamed "+tmpName+" to "+prodName+" (#"+head+")\n", rtnVal)
+	rtnVal, _ = s.MustRun(main, []string{"ds", dbSpec})
+	s.Equal("prod\n", rtnVal)
+}
+
+func (s *nomsDsTestSuite) TestNomsDsStats() {
+	dir := filepath.Join(s.TempDir, "stats")
+	s.NoError(os.Mkdir(dir, 0777))
+	server := datas.NewRemoteDatabaseServer(nbs.NewLocalStore(dir, clienttest.DefaultMemTableSize), 0)
+	ready := make(chan struct{})
+	server.Ready = func() { close(ready) }
+	go server.Run()
+	defer server.Stop()
+	<-ready
+
+	url := fmt.Sprintf("http://localhost:%d", server.Port())
+	db := datas.NewRemoteDatabase(url, "")
+	_, err := db.CommitValue(db.GetDataset("written"), types.String("a"))
+	s.NoError(err)
+	db.Close()
+
+	stdout, _ := s.MustRun(main, []string{"ds", "--stats", url})
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	s.Len(lines, 3)
+	s.True(strings.HasPrefix(lines[0], "Since "))
+	s.Equal([]string{"DATASET", "READS", "WRITES", "LAST", "READ", "LAST", "WRITE"}, strings.Fields(lines[1]))
+	fields := strings.Fields(lines[2])
+	s.Equal([]string{"written", "1", "1"}, fields[:3])
+
+	_, _, exitErr := s.Run(main, []string{"ds", "--stats", spec.CreateDatabaseSpecString("nbs", s.DBDir2)})
+	s.Equal(clienttest.ExitError{1}, exitErr)
+}
@@ -0,0 +1,64 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/attic-labs/noms/cmd/util"
+	"github.com/attic-labs/noms/go/config"
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/util/status"
+	"github.com/attic-labs/noms/go/util/verbose"
+	humanize "github.com/dustin/go-humanize"
+	flag "github.com/juju/gnuflag"
+)
+
+var fsckP int
+
+var nomsFsck = &util.Command{
+	Run:       runFsck,
+	UsageLine: "fsck [options] <database>",
+	Short:     "Checks that every chunk reachable in a database is present and intact",
+	Long:      "Walks every chunk reachable from the root of the database, checking that each is present, that its content matches its hash, and that it decodes. Missing and corrupt chunks are listed along with a chunk which refers to them, and the exit status is 1 if there are any. See Spelling Objects at https://github.com/attic-labs/noms/blob/master/doc/spelling.md for details on the database argument.",
+	Flags:     setupFsckFlags,
+	Nargs:     1,
+}
+
+func setupFsckFlags() *flag.FlagSet {
+	fsckFlagSet := flag.NewFlagSet("fsck", flag.ExitOnError)
+	fsckFlagSet.IntVar(&fsckP, "p", 16, "parallelism")
+	verbose.RegisterVerboseFlags(fsckFlagSet)
+	status.RegisterStatusFlags(fsckFlagSet)
+	return fsckFlagSet
+}
+
+func runFsck(args []string) int {
+	if fsckP < 1 {
+		d.CheckError(fmt.Errorf("-p must be positive"))
+	}
+
+	cfg := config.NewResolver()
+	db, err := cfg.GetDatabase(args[0])
+	d.CheckErrorNoUsage(err)
+	defer db.Close()
+
+	last := datas.FsckProgress{}
+	problems := datas.Fsck(db, fsckP, func(p datas.FsckProgress) {
+		last = p
+		status.Printf("Checked %s chunks (%s), %s pending, %d problems...", humanize.Comma(int64(p.Checked)), humanize.Bytes(p.Bytes), humanize.Comma(int64(p.Pending)), p.Problems)
+	})
+	status.Clear()
+
+	for _, p := range problems {
+		fmt.Println(p.Error())
+	}
+	fmt.Printf("Checked %s chunks (%s), found %d problems\n", humanize.Comma(int64(last.Checked)), humanize.Bytes(last.Bytes), len(problems))
+	if len(problems) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/attic-labs/noms/go/hash"
+	"github.com/attic-labs/noms/go/spec"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/clienttest"
+	"github.com/attic-labs/testify/suite"
+)
+
+func TestNomsFsck(t *testing.T) {
+	suite.Run(t, &nomsFsckTestSuite{})
+}
+
+type nomsFsckTestSuite struct {
+	clienttest.ClientTestSuite
+}
+
+func (s *nomsFsckTestSuite) TestFsck() {
+	dbSpecStr := spec.CreateDatabaseSpecString("nbs", s.DBDir)
+	sp, err := spec.ForDatabase(dbSpecStr)
+	s.NoError(err)
+	defer sp.Close()
+
+	db := sp.GetDatabase()
+	_, err = db.CommitValue(db.GetDataset("ds"), types.NewList(db.WriteValue(types.String("one"))))
+	s.NoError(err)
+
+	out, _ := s.MustRun(main, []string{"fsck", dbSpecStr})
+	s.Contains(out, "Checked 3 chunks (95 B), found 0 problems")
+}
+
+func (s *nomsFsckTestSuite) TestFsckMissingRoot() {
+	dir := path.Join(s.DBDir, "missing")
+	s.NoError(os.MkdirAll(dir, 0777))
+	dbSpecStr := spec.CreateDatabaseSpecString("nbs", dir)
+	sp, err := spec.ForDatabase(dbSpecStr)
+	s.NoError(err)
+	defer sp.Close()
+
+	missing := hash.Of([]byte("missing"))
+	cs := sp.NewChunkStore()
+	s.True(cs.UpdateRoot(missing, cs.Root()))
+	cs.Close()
+
+	out, _, exitErr := s.Run(main, []string{"fsck", dbSpecStr})
+	s.Equal(clienttest.ExitError{Code: 1}, exitErr)
+	s.Contains(out, missing.String()+" (root): Chunk is missing")
+	s.Contains(out, "found 1 problems")
+}
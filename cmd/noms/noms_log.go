@@ -6,6 +6,7 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -17,6 +18,7 @@ import (
 	"github.com/attic-labs/noms/go/d"
 	"github.com/attic-labs/noms/go/datas"
 	"github.com/attic-labs/noms/go/diff"
+	"github.com/attic-labs/noms/go/hash"
 	"github.com/attic-labs/noms/go/spec"
 	"github.com/attic-labs/noms/go/types"
 	"github.com/attic-labs/noms/go/util/datetime"
@@ -36,6 +38,7 @@ var (
 	oneline    bool
 	showGraph  bool
 	showValue  bool
+	logSince   string
 )
 
 const parallelism = 16
@@ -44,7 +47,7 @@ var nomsLog = &util.Command{
 	Run:       runLog,
 	UsageLine: "log [options] <path-spec>",
 	Short:     "Displays the history of a path",
-	Long:      "Displays the history of a path. See Spelling Values at https://github.com/attic-labs/noms/blob/master/doc/spelling.md for details on the <path-spec> parameter.",
+	Long:      "Displays the history of a path. With --since, only the commits which aren't in the history of another commit are displayed; it may be in another database, e.g. one a dataset was copied from. See Spelling Values at https://github.com/attic-labs/noms/blob/master/doc/spelling.md for details on the <path-spec> parameter.",
 	Flags:     setupLogFlags,
 	Nargs:     1,
 }
@@ -57,6 +60,7 @@ func setupLogFlags() *flag.FlagSet {
 	logFlagSet.BoolVar(&oneline, "oneline", false, "show a summary of each commit on a single line")
 	logFlagSet.BoolVar(&showGraph, "graph", false, "show ascii-based commit hierarchy on left side of output")
 	logFlagSet.BoolVar(&showValue, "show-value", false, "show commit value rather than diff information")
+	logFlagSet.StringVar(&logSince, "since", "", "only show commits which aren't in the history of the commit at this path-spec, which may be in another database")
 	outputpager.RegisterOutputpagerFlags(logFlagSet)
 	verbose.RegisterVerboseFlags(logFlagSet)
 	return logFlagSet
@@ -90,12 +94,25 @@ func runLog(args []string) int {
 		d.CheckError(fmt.Errorf("%s does not reference a Commit object", args[0]))
 	}
 
-	iter := NewCommitIterator(database, origCommit)
+	var iter logIterator = NewCommitIterator(database, origCommit)
 	displayed := 0
 	if maxCommits <= 0 {
 		maxCommits = math.MaxInt32
 	}
 
+	if logSince != "" {
+		if showGraph {
+			d.CheckErrorNoUsage(errors.New("Cannot use --since with --graph"))
+		}
+		sinceDB, sinceCommit, err := cfg.GetPath(logSince)
+		d.CheckErrorNoUsage(err)
+		defer sinceDB.Close()
+		if sinceCommit == nil || !datas.IsCommit(sinceCommit) {
+			d.CheckErrorNoUsage(fmt.Errorf("%s does not reference a Commit object", logSince))
+		}
+		iter = newSinceIterator(types.NewRef(sinceCommit), types.NewRef(origCommit), databasesReader{database, sinceDB})
+	}
+
 	bytesChan := make(chan chan []byte, parallelism)
 
 	var done = false
@@ -133,6 +150,64 @@ func runLog(args []string) int {
 	return 0
 }
 
+// logIterator returns the commits to be printed in the log, one at a time.
+type logIterator interface {
+	Next() (LogNode, bool)
+}
+
+// sinceIterator returns the commits in the history of until, but not that of
+// since, reading them a page at a time as they're asked for. Its LogNodes have
+// no graph.
+type sinceIterator struct {
+	cr   *datas.CommitRange
+	vr   types.ValueReader
+	page types.RefSlice
+}
+
+func newSinceIterator(since, until types.Ref, vr types.ValueReader) *sinceIterator {
+	cr := datas.CommitsBetween(since, until, vr)
+	return &sinceIterator{cr, vr, cr.Next(sinceIteratorPageSize)}
+}
+
+const sinceIteratorPageSize = 256
+
+func (iter *sinceIterator) Next() (LogNode, bool) {
+	if len(iter.page) == 0 {
+		return LogNode{}, false
+	}
+	r := iter.page[0]
+	if iter.page = iter.page[1:]; len(iter.page) == 0 {
+		iter.page = iter.cr.Next(sinceIteratorPageSize)
+	}
+	return LogNode{
+		cr:         r,
+		commit:     iter.vr.ReadValue(r.TargetHash()).(types.Struct),
+		lastCommit: len(iter.page) == 0,
+	}, true
+}
+
+// databasesReader reads values from the first of its databases which has
+// them, so that the histories of commits in different databases, e.g. of a
+// dataset and a copy of it, can be compared.
+type databasesReader []datas.Database
+
+func (dr databasesReader) ReadValue(h hash.Hash) types.Value {
+	for _, db := range dr {
+		if v := db.ReadValue(h); v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+func (dr databasesReader) ReadManyValues(hashes hash.HashSet, foundValues chan<- types.Value) {
+	for h := range hashes {
+		if v := dr.ReadValue(h); v != nil {
+			foundValues <- v
+		}
+	}
+}
+
 // Prints the information for one commit in the log, including ascii graph on left side of commits if
 // -graph arg is true.
 func printCommit(node LogNode, path types.Path, w io.Writer, db datas.Database) (err error) {
@@ -5,6 +5,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/attic-labs/noms/go/datas"
@@ -127,6 +128,57 @@ func (s *nomsLogTestSuite) TestNArg() {
 	s.Contains(res, h1.String())
 }
 
+func (s *nomsLogTestSuite) TestSinceAcrossDatabases() {
+	sp1, err := spec.ForDataset(spec.CreateValueSpecString("nbs", s.DBDir, "orig"))
+	s.NoError(err)
+	defer sp1.Close()
+	sp2, err := spec.ForDataset(spec.CreateValueSpecString("nbs", s.DBDir2, "renamed"))
+	s.NoError(err)
+	defer sp2.Close()
+
+	// Copy orig to the other database under another name, and add to it there.
+	orig := sp1.GetDataset()
+	hashes := []string{}
+	for _, v := range []string{"1", "2", "3"} {
+		orig, err = addCommit(orig, v)
+		s.NoError(err)
+		hashes = append(hashes, orig.HeadRef().TargetHash().String())
+	}
+	db2 := sp2.GetDatabase()
+	datas.PullWithFlush(sp1.GetDatabase(), db2, orig.HeadRef(), types.Ref{}, 1, nil)
+	renamed, err := db2.SetHead(sp2.GetDataset(), orig.HeadRef())
+	s.NoError(err)
+	for _, v := range []string{"4", "5"} {
+		renamed, err = addCommit(renamed, v)
+		s.NoError(err)
+		hashes = append(hashes, renamed.HeadRef().TargetHash().String())
+	}
+
+	origSpec := spec.CreateValueSpecString("nbs", s.DBDir, "orig")
+	renamedSpec := spec.CreateValueSpecString("nbs", s.DBDir2, "renamed")
+	res, _ := s.MustRun(main, []string{"log", "--since", origSpec, renamedSpec})
+	// Each commit's hash is on a line of its own; those of its parents follow "Parent: ".
+	lines := strings.Split(res, "\n")
+	for i, h := range hashes {
+		s.Equal(i >= 3, contains(lines, h), "commit %d", i+1)
+	}
+
+	res, _ = s.MustRun(main, []string{"log", "--since", renamedSpec, origSpec})
+	s.Equal("", res)
+
+	_, _, exitErr := s.Run(main, []string{"log", "--graph", "--since", renamedSpec, origSpec})
+	s.Equal(clienttest.ExitError{1}, exitErr)
+}
+
+func contains(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *nomsLogTestSuite) TestEmptyCommit() {
 	sp, err := spec.ForDatabase(spec.CreateDatabaseSpecString("nbs", s.DBDir))
 	s.NoError(err)
@@ -0,0 +1,104 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/attic-labs/noms/cmd/util"
+	"github.com/attic-labs/noms/go/config"
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/nbs"
+	"github.com/attic-labs/noms/go/util/status"
+	"github.com/attic-labs/noms/go/util/verbose"
+	humanize "github.com/dustin/go-humanize"
+	flag "github.com/juju/gnuflag"
+)
+
+var migrateFeatures string
+
+var nomsMigrate = &util.Command{
+	Run:       runMigrate,
+	UsageLine: "migrate [options] <source-database> <dest-database>",
+	Short:     "Copies a database into a new store, with the store's encoding features",
+	Long:      "Writes every chunk reachable from the root of the source database to the destination, which must be an empty local or AWS store, then sets the destination's root to the source's. With --features, the destination gets those features first, such as a new encoding of values, so that everything written to it uses them. The encoding of every chunk is checked on the way. A migration which is interrupted can be resumed by running it again, which doesn't write the chunks which were already written. See Spelling Objects at https://github.com/attic-labs/noms/blob/master/doc/spelling.md for details on the database arguments.",
+	Flags:     setupMigrateFlags,
+	Nargs:     2,
+}
+
+func setupMigrateFlags() *flag.FlagSet {
+	migrateFlagSet := flag.NewFlagSet("migrate", flag.ExitOnError)
+	migrateFlagSet.StringVar(&migrateFeatures, "features", "", "comma-separated features to enable in the destination store")
+	status.RegisterStatusFlags(migrateFlagSet)
+	verbose.RegisterVerboseFlags(migrateFlagSet)
+	return migrateFlagSet
+}
+
+func runMigrate(args []string) int {
+	if args[0] == args[1] {
+		d.CheckError(errors.New("The source and destination must be different stores"))
+	}
+
+	cfg := config.NewResolver()
+	src, err := cfg.GetDatabase(args[0])
+	d.CheckErrorNoUsage(err)
+	defer src.Close()
+
+	cs, err := cfg.GetChunkStore(args[1])
+	d.CheckErrorNoUsage(err)
+	if cs == nil {
+		d.CheckErrorNoUsage(fmt.Errorf("Can't migrate to %s: the destination must be a local or AWS store", args[1]))
+	}
+	defer cs.Close()
+
+	if migrateFeatures != "" {
+		store, ok := cs.(*nbs.NomsBlockStore)
+		if !ok {
+			d.CheckErrorNoUsage(fmt.Errorf("%s doesn't have features", args[1]))
+		}
+		for _, f := range strings.Split(migrateFeatures, ",") {
+			d.CheckErrorNoUsage(store.EnableFeature(nbs.Feature(strings.TrimSpace(f))))
+		}
+	}
+
+	start := time.Now()
+	progress := make(chan datas.MigrateProgress)
+	done := make(chan datas.MigrateProgress)
+	go func() {
+		var last datas.MigrateProgress
+		for p := range progress {
+			last = p
+			if status.WillPrint() {
+				status.Printf("Migrating - %s chunks read, %s written (%s/s)", humanize.Comma(int64(p.Chunks)), humanize.Comma(int64(p.Written)), bytesPerSec(p.Bytes, start))
+			}
+		}
+		done <- last
+	}()
+
+	// Stop on interrupt, keeping what's been written, so that the migration can be resumed.
+	ctx, cancel := cancelOnInterrupt()
+	defer cancel()
+
+	root, err := datas.Migrate(ctx, src, cs, progress)
+	close(progress)
+	last := <-done
+	status.Done()
+	if err == context.Canceled {
+		err = errors.New("Migration interrupted; run it again to resume")
+	}
+	d.CheckErrorNoUsage(err)
+
+	if last.Chunks == 0 && !root.IsEmpty() {
+		fmt.Printf("Root %s is already migrated\n", root)
+		return 0
+	}
+	fmt.Printf("Migrated %s chunks (%s), of which %s were written, of root %s\n", humanize.Comma(int64(last.Chunks)), humanize.Bytes(last.Bytes), humanize.Comma(int64(last.Written)), root)
+	return 0
+}
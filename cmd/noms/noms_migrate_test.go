@@ -0,0 +1,69 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/attic-labs/noms/go/spec"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/clienttest"
+	"github.com/attic-labs/testify/suite"
+)
+
+func TestNomsMigrate(t *testing.T) {
+	suite.Run(t, &nomsMigrateTestSuite{})
+}
+
+type nomsMigrateTestSuite struct {
+	clienttest.ClientTestSuite
+}
+
+func (s *nomsMigrateTestSuite) TestMigrate() {
+	srcSpecStr := spec.CreateDatabaseSpecString("nbs", s.DBDir)
+	sp, err := spec.ForDatabase(srcSpecStr)
+	s.NoError(err)
+	defer sp.Close()
+
+	db := sp.GetDatabase()
+	_, err = db.CommitValue(db.GetDataset("ds"), types.NewList(types.String("one"), types.String("two")))
+	s.NoError(err)
+
+	destDir := filepath.Join(s.TempDir, "migrated")
+	s.NoError(os.Mkdir(destDir, 0777))
+	destSpecStr := spec.CreateDatabaseSpecString("nbs", destDir)
+	out, _ := s.MustRun(main, []string{"migrate", srcSpecStr, destSpecStr})
+	s.Contains(out, "Migrated 2 chunks")
+	s.Contains(out, "of root "+db.Datasets().Hash().String())
+
+	out, _ = s.MustRun(main, []string{"fsck", destSpecStr})
+	s.Contains(out, "found 0 problems")
+	out, _ = s.MustRun(main, []string{"show", destSpecStr + "::ds"})
+	s.Contains(out, `"two"`)
+
+	// The destination must be empty, or already have been migrated.
+	out, _ = s.MustRun(main, []string{"migrate", srcSpecStr, destSpecStr})
+	s.Contains(out, "is already migrated")
+
+	otherSpecStr := spec.CreateDatabaseSpecString("nbs", s.DBDir2)
+	otherSp, err := spec.ForDatabase(otherSpecStr)
+	s.NoError(err)
+	defer otherSp.Close()
+	otherDB := otherSp.GetDatabase()
+	_, err = otherDB.CommitValue(otherDB.GetDataset("ds"), types.Bool(true))
+	s.NoError(err)
+	_, stderr, recovered := s.Run(main, []string{"migrate", otherSpecStr, destSpecStr})
+	s.Equal(clienttest.ExitError{Code: 1}, recovered)
+	s.Contains(stderr, "isn't empty")
+
+	// This version of Noms doesn't know of any features.
+	rleDir := filepath.Join(s.TempDir, "rle")
+	s.NoError(os.Mkdir(rleDir, 0777))
+	_, stderr, recovered = s.Run(main, []string{"migrate", "--features", "rle", srcSpecStr, spec.CreateDatabaseSpecString("nbs", rleDir)})
+	s.Equal(clienttest.ExitError{Code: 1}, recovered)
+	s.Contains(stderr, "doesn't support feature rle")
+}
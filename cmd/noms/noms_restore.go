@@ -0,0 +1,55 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/attic-labs/noms/cmd/util"
+	"github.com/attic-labs/noms/go/config"
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/util/verbose"
+	humanize "github.com/dustin/go-humanize"
+	flag "github.com/juju/gnuflag"
+)
+
+var nomsRestore = &util.Command{
+	Run:       runRestore,
+	UsageLine: "restore <file> <database>",
+	Short:     "Restores a database from an archive written by noms backup",
+	Long:      "Reads the archive from file, or from stdin if file is -, and writes its chunks to the database, which may be of any kind. The database's root is then set to the root of the backup. A full backup must be restored into an empty database. The incremental backups made after it are then restored in the order they were made. See Spelling Objects at https://github.com/attic-labs/noms/blob/master/doc/spelling.md for details on the database argument.",
+	Flags:     setupRestoreFlags,
+	Nargs:     2,
+}
+
+func setupRestoreFlags() *flag.FlagSet {
+	restoreFlagSet := flag.NewFlagSet("restore", flag.ExitOnError)
+	verbose.RegisterVerboseFlags(restoreFlagSet)
+	return restoreFlagSet
+}
+
+func runRestore(args []string) int {
+	var in io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		d.CheckErrorNoUsage(err)
+		defer f.Close()
+		in = f
+	}
+
+	cfg := config.NewResolver()
+	cs, err := cfg.GetChunkStore(args[1])
+	d.CheckErrorNoUsage(err)
+	defer cs.Close()
+
+	manifest, err := datas.Restore(cs, bufio.NewReader(in))
+	d.CheckErrorNoUsage(err)
+	fmt.Printf("Restored %s chunks (%s) of root %s\n", humanize.Comma(int64(manifest.Chunks)), humanize.Bytes(manifest.Bytes), manifest.Root)
+	return 0
+}
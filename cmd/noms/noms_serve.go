@@ -5,6 +5,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
@@ -19,14 +20,16 @@ import (
 )
 
 var (
-	port int
+	port     int
+	readOnly bool
+	upstream string
 )
 
 var nomsServe = &util.Command{
 	Run:       runServe,
 	UsageLine: "serve [options] <database>",
 	Short:     "Serves a Noms database over HTTP",
-	Long:      "See Spelling Objects at https://github.com/attic-labs/noms/blob/master/doc/spelling.md for details on the database argument.",
+	Long:      "With --upstream, serves the upstream database instead, keeping a copy of every chunk read from it in <database>, so that each chunk is only fetched from upstream once. The root is always read from upstream, and writes are passed through to it.\n\nSee Spelling Objects at https://github.com/attic-labs/noms/blob/master/doc/spelling.md for details on the database arguments.",
 	Flags:     setupServeFlags,
 	Nargs:     0,
 }
@@ -34,6 +37,8 @@ var nomsServe = &util.Command{
 func setupServeFlags() *flag.FlagSet {
 	serveFlagSet := flag.NewFlagSet("serve", flag.ExitOnError)
 	serveFlagSet.IntVar(&port, "port", 8000, "port to listen on for HTTP requests")
+	serveFlagSet.BoolVar(&readOnly, "read-only", false, "reject requests that would write to the database")
+	serveFlagSet.StringVar(&upstream, "upstream", "", "database to proxy, using <database> as a read-through cache of its chunks")
 	verbose.RegisterVerboseFlags(serveFlagSet)
 	profile.RegisterProfileFlags(serveFlagSet)
 	return serveFlagSet
@@ -47,7 +52,16 @@ func runServe(args []string) int {
 	}
 	cs, err := cfg.GetChunkStore(db)
 	d.CheckError(err)
+	if upstream != "" {
+		if cs == nil {
+			d.CheckError(fmt.Errorf("The cache database %s must be local", db))
+		}
+		upstreamDB, err := cfg.GetDatabase(upstream)
+		d.CheckError(err)
+		cs = datas.NewReadThroughStore(upstreamDB, cs)
+	}
 	server := datas.NewRemoteDatabaseServer(cs, port)
+	server.ReadOnly = readOnly
 
 	// Shutdown server gracefully so that profile may be written
 	c := make(chan os.Signal, 1)
@@ -0,0 +1,94 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/attic-labs/noms/cmd/util"
+	"github.com/attic-labs/noms/go/config"
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/hash"
+	"github.com/attic-labs/noms/go/spec"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/verbose"
+	flag "github.com/juju/gnuflag"
+)
+
+var (
+	squashSince string
+	squashKeep  int
+)
+
+var nomsSquash = &util.Command{
+	Run:       runSquash,
+	UsageLine: "squash [options] <dataset>",
+	Short:     "Rewrites the history of a dataset to be shorter",
+	Long:      "By default, replaces the whole history of dataset with a single commit of its head value. With --since, only the commits after the given commit are replaced. With --keep, the newest commits are kept as they are, but the oldest of them loses its parents. The dataset's head value is never changed. See Spelling Objects at https://github.com/attic-labs/noms/blob/master/doc/spelling.md for details on the dataset argument.",
+	Flags:     setupSquashFlags,
+	Nargs:     1,
+}
+
+func setupSquashFlags() *flag.FlagSet {
+	squashFlagSet := flag.NewFlagSet("squash", flag.ExitOnError)
+	squashFlagSet.StringVar(&squashSince, "since", "", "hash of a commit in the dataset's history; only the commits after it are squashed")
+	squashFlagSet.IntVar(&squashKeep, "keep", 0, "instead of squashing, drop all but the newest n commits")
+	spec.RegisterCommitMetaFlags(squashFlagSet)
+	verbose.RegisterVerboseFlags(squashFlagSet)
+	return squashFlagSet
+}
+
+func runSquash(args []string) int {
+	if squashSince != "" && squashKeep != 0 {
+		d.CheckError(errors.New("--since and --keep can't be used together"))
+	}
+	if squashKeep < 0 {
+		d.CheckError(errors.New("--keep must be positive"))
+	}
+
+	cfg := config.NewResolver()
+	db, ds, err := cfg.GetDataset(args[0])
+	d.CheckError(err)
+	defer db.Close()
+
+	oldHeadRef, ok := ds.MaybeHeadRef()
+	if !ok {
+		d.CheckErrorNoUsage(fmt.Errorf("Dataset %s has no head", args[0]))
+	}
+
+	if squashKeep > 0 {
+		ds, err = datas.TruncateHistory(db, ds, squashKeep)
+	} else {
+		var since types.Ref
+		if squashSince != "" {
+			h, ok := hash.MaybeParse(strings.TrimPrefix(squashSince, "#"))
+			if !ok {
+				d.CheckError(fmt.Errorf("Invalid commit hash: %s", squashSince))
+			}
+			c := db.ReadValue(h)
+			if c == nil || !datas.IsCommit(c) {
+				d.CheckErrorNoUsage(fmt.Errorf("Commit not found: %s", squashSince))
+			}
+			since = types.NewRef(c)
+		}
+
+		var meta types.Struct
+		meta, err = spec.CreateCommitMetaStruct(db, "", "", nil, nil)
+		d.CheckErrorNoUsage(err)
+		ds, err = datas.Squash(db, ds, since, meta)
+	}
+	d.CheckErrorNoUsage(err)
+
+	if ds.HeadRef().TargetHash() == oldHeadRef.TargetHash() {
+		fmt.Fprintf(os.Stdout, "Nothing to squash, head is #%s\n", oldHeadRef.TargetHash())
+	} else {
+		fmt.Fprintf(os.Stdout, "New head #%s (was #%s)\n", ds.HeadRef().TargetHash(), oldHeadRef.TargetHash())
+	}
+	return 0
+}
@@ -0,0 +1,78 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"testing"
+
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/spec"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/clienttest"
+	"github.com/attic-labs/testify/suite"
+)
+
+type nomsSquashTestSuite struct {
+	clienttest.ClientTestSuite
+}
+
+func TestNomsSquash(t *testing.T) {
+	suite.Run(t, &nomsSquashTestSuite{})
+}
+
+func (s *nomsSquashTestSuite) setupDataset(name string, n int) (sp spec.Spec, refs []types.Ref) {
+	sp, err := spec.ForDataset(spec.CreateValueSpecString("nbs", s.DBDir, name))
+	s.NoError(err)
+	ds := sp.GetDataset()
+	for i := 0; i < n; i++ {
+		ds, err = sp.GetDatabase().CommitValue(ds, types.Number(i))
+		s.NoError(err)
+		refs = append(refs, ds.HeadRef())
+	}
+	return
+}
+
+func (s *nomsSquashTestSuite) parents(sp spec.Spec) types.Set {
+	sp, err := spec.ForDataset(sp.String())
+	s.NoError(err)
+	defer sp.Close()
+	return sp.GetDataset().Head().Get(datas.ParentsField).(types.Set)
+}
+
+func (s *nomsSquashTestSuite) TestSquashAll() {
+	sp, _ := s.setupDataset("all", 5)
+	sp.Close()
+
+	stdout, stderr := s.MustRun(main, []string{"squash", sp.String()})
+	s.Empty(stderr)
+	s.Contains(stdout, "New head #")
+	s.True(s.parents(sp).Empty())
+
+	stdout, _ = s.MustRun(main, []string{"squash", sp.String()})
+	s.Contains(stdout, "Nothing to squash")
+}
+
+func (s *nomsSquashTestSuite) TestSquashSince() {
+	sp, refs := s.setupDataset("since", 5)
+	sp.Close()
+
+	s.MustRun(main, []string{"squash", "--since", "#" + refs[1].TargetHash().String(), sp.String()})
+	s.True(s.parents(sp).Equals(types.NewSet(refs[1])))
+
+	sp, err := spec.ForDataset(sp.String())
+	s.NoError(err)
+	defer sp.Close()
+	s.True(sp.GetDataset().HeadValue().Equals(types.Number(4)))
+}
+
+func (s *nomsSquashTestSuite) TestSquashKeep() {
+	sp, refs := s.setupDataset("keep", 5)
+	sp.Close()
+
+	s.MustRun(main, []string{"squash", "--keep", "2", sp.String()})
+	parents := s.parents(sp)
+	s.Equal(uint64(1), parents.Len())
+	s.NotEqual(refs[3], parents.First())
+}
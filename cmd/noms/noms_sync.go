@@ -5,8 +5,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/attic-labs/noms/cmd/util"
@@ -22,14 +29,15 @@ import (
 )
 
 var (
-	p int
+	p            int
+	syncDatasets string
 )
 
 var nomsSync = &util.Command{
 	Run:       runSync,
 	UsageLine: "sync [options] <source-object> <dest-dataset>",
 	Short:     "Moves datasets between or within databases",
-	Long:      "See Spelling Objects at https://github.com/attic-labs/noms/blob/master/doc/spelling.md for details on the object and dataset arguments.",
+	Long:      "See Spelling Objects at https://github.com/attic-labs/noms/blob/master/doc/spelling.md for details on the object and dataset arguments.\n\nWith --datasets, the arguments are instead <source-db> <dest-db>, and every dataset in source-db matching one of the comma-separated names or glob patterns is synced to the dataset of the same name in dest-db, in a single pass.",
 	Flags:     setupSyncFlags,
 	Nargs:     2,
 }
@@ -37,12 +45,18 @@ var nomsSync = &util.Command{
 func setupSyncFlags() *flag.FlagSet {
 	syncFlagSet := flag.NewFlagSet("sync", flag.ExitOnError)
 	syncFlagSet.IntVar(&p, "p", 512, "parallelism")
+	syncFlagSet.StringVar(&syncDatasets, "datasets", "", "comma-separated dataset names or glob patterns to sync between <source-db> and <dest-db>")
 	verbose.RegisterVerboseFlags(syncFlagSet)
 	profile.RegisterProfileFlags(syncFlagSet)
+	status.RegisterStatusFlags(syncFlagSet)
 	return syncFlagSet
 }
 
 func runSync(args []string) int {
+	if syncDatasets != "" {
+		return runSyncDatasets(args)
+	}
+
 	cfg := config.NewResolver()
 	sourceStore, sourceObj, err := cfg.GetPath(args[0])
 	d.CheckError(err)
@@ -57,36 +71,23 @@ func runSync(args []string) int {
 	defer sinkDB.Close()
 
 	start := time.Now()
-	progressCh := make(chan datas.PullProgress)
-	lastProgressCh := make(chan datas.PullProgress)
-
-	go func() {
-		var last datas.PullProgress
-
-		for info := range progressCh {
-			if info.KnownCount == 1 {
-				// It's better to print "up to date" than "0% (0/1); 100% (1/1)".
-				continue
-			}
-
-			last = info
-			if status.WillPrint() {
-				pct := 100.0 * float64(info.DoneCount) / float64(info.KnownCount)
-				status.Printf("Syncing - %.2f%% (%s/s)", pct, bytesPerSec(info.ApproxWrittenBytes, start))
-			}
-		}
+	progressCh, lastProgressCh := startSyncProgress(start)
 
-		lastProgressCh <- last
-	}()
+	// Abandon the sync, leaving the sink dataset untouched, on interrupt.
+	ctx, cancel := cancelOnInterrupt()
+	defer cancel()
 
 	sourceRef := types.NewRef(sourceObj)
 	sinkRef, sinkExists := sinkDataset.MaybeHeadRef()
 	nonFF := false
 	err = d.Try(func() {
 		defer profile.MaybeStartProfile().Stop()
-		datas.PullWithFlush(sourceStore, sinkDB, sourceRef, sinkRef, p, progressCh)
+		err := datas.PullWithFlushContext(ctx, sourceStore, sinkDB, sourceRef, sinkRef, p, progressCh)
+		if err == context.Canceled {
+			err = errors.New("Sync cancelled")
+		}
+		d.PanicIfError(err)
 
-		var err error
 		sinkDataset, err = sinkDB.FastForward(sinkDataset, sourceRef)
 		if err == datas.ErrMergeNeeded {
 			sinkDataset, err = sinkDB.SetHead(sinkDataset, sourceRef)
@@ -115,6 +116,131 @@ func runSync(args []string) int {
 	return 0
 }
 
+func runSyncDatasets(args []string) int {
+	cfg := config.NewResolver()
+	sourceDB, err := cfg.GetDatabase(args[0])
+	d.CheckError(err)
+	defer sourceDB.Close()
+
+	sinkDB, err := cfg.GetDatabase(args[1])
+	d.CheckError(err)
+	defer sinkDB.Close()
+
+	patterns := strings.Split(syncDatasets, ",")
+	for _, pattern := range patterns {
+		_, err := path.Match(pattern, "")
+		d.CheckError(err)
+	}
+
+	ids := []string{}
+	sourceDB.Datasets().IterAll(func(k, v types.Value) {
+		id := string(k.(types.String))
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, id); ok {
+				ids = append(ids, id)
+				return
+			}
+		}
+	})
+	if len(ids) == 0 {
+		d.CheckErrorNoUsage(fmt.Errorf("No datasets in %s match %s", args[0], syncDatasets))
+	}
+
+	sourceRefs, sinkRefs := types.RefSlice{}, types.RefSlice{}
+	for _, id := range ids {
+		sourceRefs = append(sourceRefs, sourceDB.GetDataset(id).HeadRef())
+		sinkRef, _ := sinkDB.GetDataset(id).MaybeHeadRef()
+		sinkRefs = append(sinkRefs, sinkRef)
+	}
+
+	start := time.Now()
+	progressCh, lastProgressCh := startSyncProgress(start)
+
+	// Abandon the sync, leaving the sink datasets untouched, on interrupt.
+	ctx, cancel := cancelOnInterrupt()
+	defer cancel()
+
+	updated := 0
+	err = d.Try(func() {
+		defer profile.MaybeStartProfile().Stop()
+		err := datas.PullManyWithFlushContext(ctx, sourceDB, sinkDB, sourceRefs, sinkRefs, p, progressCh)
+		if err == context.Canceled {
+			err = errors.New("Sync cancelled")
+		}
+		d.PanicIfError(err)
+
+		for i, id := range ids {
+			if sourceRefs[i].TargetHash() == sinkRefs[i].TargetHash() {
+				continue
+			}
+			sinkDataset := sinkDB.GetDataset(id)
+			sinkDataset, err = sinkDB.FastForward(sinkDataset, sourceRefs[i])
+			if err == datas.ErrMergeNeeded {
+				sinkDataset, err = sinkDB.SetHead(sinkDataset, sourceRefs[i])
+			}
+			d.PanicIfError(err)
+			updated++
+		}
+	})
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	close(progressCh)
+	if last := <-lastProgressCh; last.DoneCount > 0 {
+		status.Printf("Done - Synced %s in %s (%s/s)",
+			humanize.Bytes(last.ApproxWrittenBytes), since(start), bytesPerSec(last.ApproxWrittenBytes, start))
+		status.Done()
+	}
+	fmt.Printf("Updated %d of %d datasets.\n", updated, len(ids))
+
+	return 0
+}
+
+// startSyncProgress prints the progress reported on the returned progress
+// channel. Once that channel is closed, the last progress worth reporting is
+// sent on the second channel.
+func startSyncProgress(start time.Time) (chan datas.PullProgress, chan datas.PullProgress) {
+	progressCh := make(chan datas.PullProgress)
+	lastProgressCh := make(chan datas.PullProgress)
+
+	go func() {
+		var last datas.PullProgress
+
+		for info := range progressCh {
+			if info.KnownCount == 1 {
+				// It's better to print "up to date" than "0% (0/1); 100% (1/1)".
+				continue
+			}
+
+			last = info
+			if status.WillPrint() {
+				pct := 100.0 * float64(info.DoneCount) / float64(info.KnownCount)
+				status.Printf("Syncing - %.2f%% (%s/s)", pct, bytesPerSec(info.ApproxWrittenBytes, start))
+			}
+		}
+
+		lastProgressCh <- last
+	}()
+	return progressCh, lastProgressCh
+}
+
+func cancelOnInterrupt() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sigChan:
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(sigChan)
+	}()
+	return ctx, cancel
+}
+
 func bytesPerSec(bytes uint64, start time.Time) string {
 	bps := float64(bytes) / float64(time.Since(start).Seconds())
 	return humanize.Bytes(uint64(bps))
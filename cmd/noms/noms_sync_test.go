@@ -140,3 +140,32 @@ func (s *nomsSyncTestSuite) TestRewind() {
 	s.True(types.Number(42).Equals(dest.HeadValue()))
 	db.Close()
 }
+
+func (s *nomsSyncTestSuite) TestSyncDatasets() {
+	defer s.NoError(os.RemoveAll(s.DBDir2))
+
+	sourceDB := datas.NewDatabase(nbs.NewLocalStore(s.DBDir, clienttest.DefaultMemTableSize))
+	for i, id := range []string{"foo-1", "foo-2", "bar"} {
+		_, err := sourceDB.CommitValue(sourceDB.GetDataset(id), types.Number(i))
+		s.NoError(err)
+	}
+	sourceDB.Close()
+
+	sourceSpec := spec.CreateDatabaseSpecString("nbs", s.DBDir)
+	sinkSpec := spec.CreateDatabaseSpecString("nbs", s.DBDir2)
+	sout, _ := s.MustRun(main, []string{"sync", "--datasets", "foo-*", sourceSpec, sinkSpec})
+	s.Regexp("Updated 2 of 2 datasets", sout)
+
+	db := datas.NewDatabase(nbs.NewLocalStore(s.DBDir2, clienttest.DefaultMemTableSize))
+	s.True(types.Number(0).Equals(db.GetDataset("foo-1").HeadValue()))
+	s.True(types.Number(1).Equals(db.GetDataset("foo-2").HeadValue()))
+	s.False(db.GetDataset("bar").HasHead())
+	db.Close()
+
+	sout, _ = s.MustRun(main, []string{"sync", "--datasets", "foo-1,bar", sourceSpec, sinkSpec})
+	s.Regexp("Updated 1 of 2 datasets", sout)
+
+	db = datas.NewDatabase(nbs.NewLocalStore(s.DBDir2, clienttest.DefaultMemTableSize))
+	s.True(types.Number(2).Equals(db.GetDataset("bar").HeadValue()))
+	db.Close()
+}
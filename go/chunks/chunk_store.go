@@ -11,7 +11,9 @@ import (
 )
 
 // ChunkStore is the core storage abstraction in noms. We can put data anyplace we have a
-// ChunkStore implementation for.
+// ChunkStore implementation for. Its methods don't take a context.Context:
+// operations built on top of it which can be cancelled, like
+// datas.PullContext, check for cancellation between calls instead.
 type ChunkStore interface {
 	ChunkSource
 	ChunkSink
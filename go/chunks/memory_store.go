@@ -62,6 +62,18 @@ func (ms *MemoryStore) HasMany(hashes hash.HashSet) hash.HashSet {
 	return present
 }
 
+func (ms *MemoryStore) Root() hash.Hash {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.memoryRootTracker.Root()
+}
+
+func (ms *MemoryStore) UpdateRoot(current, last hash.Hash) bool {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.memoryRootTracker.UpdateRoot(current, last)
+}
+
 func (ms *MemoryStore) Version() string {
 	return constants.NomsVersion
 }
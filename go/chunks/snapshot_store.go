@@ -0,0 +1,91 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package chunks
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/hash"
+)
+
+// SnapshotStore is a MemoryStore which is loaded from a snapshot file when it's created, if the file exists, and written back to it when it's closed. This gives memory speed with optional durability, e.g. for tests and perf runs.
+type SnapshotStore struct {
+	*MemoryStore
+	path string
+}
+
+// NewSnapshotStore returns a SnapshotStore which reads from and writes to the snapshot file at path.
+func NewSnapshotStore(path string) *SnapshotStore {
+	ss := &SnapshotStore{NewMemoryStore(), path}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return ss
+	}
+	d.PanicIfError(err)
+	defer f.Close()
+	d.PanicIfError(ss.MemoryStore.ReadSnapshot(bufio.NewReader(f)))
+	return ss
+}
+
+// Close writes the snapshot. The file is replaced atomically, so a crash while writing leaves the previous snapshot intact.
+func (ss *SnapshotStore) Close() error {
+	tmp, err := ioutil.TempFile(filepath.Dir(ss.path), filepath.Base(ss.path))
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	ss.MemoryStore.WriteSnapshot(w)
+	if err = w.Flush(); err == nil {
+		err = tmp.Close()
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), ss.path)
+}
+
+// WriteSnapshot writes the root of ms followed by all of its chunks to w, in a format ReadSnapshot can read back.
+func (ms *MemoryStore) WriteSnapshot(w io.Writer) {
+	root := ms.Root()
+	_, err := w.Write(root[:])
+	d.PanicIfError(err)
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	for _, c := range ms.data {
+		Serialize(c, w)
+	}
+}
+
+// ReadSnapshot adds the chunks in a snapshot written by WriteSnapshot to ms, and sets ms's root to the snapshot's.
+func (ms *MemoryStore) ReadSnapshot(r io.Reader) error {
+	root := hash.Hash{}
+	if _, err := io.ReadFull(r, root[:]); err != nil {
+		return err
+	}
+
+	chunkChan := make(chan *Chunk, 16)
+	errChan := make(chan error, 1)
+	go func() {
+		defer close(chunkChan)
+		errChan <- Deserialize(r, chunkChan)
+	}()
+	for c := range chunkChan {
+		ms.Put(*c)
+	}
+	if err := <-errChan; err != nil {
+		return err
+	}
+
+	ms.UpdateRoot(root, ms.Root())
+	return nil
+}
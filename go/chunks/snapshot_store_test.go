@@ -0,0 +1,59 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package chunks
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/attic-labs/testify/assert"
+	"github.com/attic-labs/testify/suite"
+)
+
+func TestSnapshotStoreTestSuite(t *testing.T) {
+	suite.Run(t, &SnapshotStoreTestSuite{})
+}
+
+type SnapshotStoreTestSuite struct {
+	ChunkStoreTestSuite
+	dir string
+}
+
+func (suite *SnapshotStoreTestSuite) SetupTest() {
+	var err error
+	suite.dir, err = ioutil.TempDir("", "")
+	suite.NoError(err)
+	suite.Store = NewSnapshotStore(filepath.Join(suite.dir, "snapshot"))
+}
+
+func (suite *SnapshotStoreTestSuite) TearDownTest() {
+	suite.NoError(suite.Store.Close())
+	os.RemoveAll(suite.dir)
+}
+
+func TestSnapshotStoreReopen(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshot")
+
+	c1, c2 := NewChunk([]byte("abc")), NewChunk([]byte("def"))
+	ss := NewSnapshotStore(path)
+	ss.PutMany([]Chunk{c1, c2})
+	assert.True(ss.UpdateRoot(c2.Hash(), ss.Root()))
+	assert.NoError(ss.Close())
+
+	ss = NewSnapshotStore(path)
+	assert.Equal(2, ss.Len())
+	assert.Equal(c2.Hash(), ss.Root())
+	assert.Equal(c1.Data(), ss.Get(c1.Hash()).Data())
+
+	// A corrupt snapshot is an error, not an empty store.
+	assert.NoError(ioutil.WriteFile(path, []byte("short"), 0644))
+	assert.Panics(func() { NewSnapshotStore(path) })
+}
@@ -34,18 +34,28 @@ var NoConfig = errors.New(fmt.Sprintf("no %s found", NomsConfigFile))
 
 // Find the closest directory containing .nomsconfig starting
 // in cwd and then searching up ancestor tree.
-// Look first looking in cwd and then up through its ancestors
+// Look first looking in cwd and then up through its ancestors.
+//
+// Any .nomsconfig files further up the tree are merged in, with the closest
+// file's definition of each db alias taking precedence. This allows e.g.
+// named remotes such as "origin" to be defined once in a parent directory
+// (or the home directory), while each project directory sets its own default
+// db. The returned Config's File is the closest .nomsconfig.
 func FindNomsConfig() (*Config, error) {
 	curDir, err := os.Getwd()
 	if err != nil {
 		return nil, err
 	}
+	var found *Config
 	for {
 		nomsConfig := filepath.Join(curDir, NomsConfigFile)
 		info, err := os.Stat(nomsConfig)
 		if err == nil && !info.IsDir() {
-			// found
-			return ReadConfig(nomsConfig)
+			c, err := ReadConfig(nomsConfig)
+			if err != nil {
+				return nil, err
+			}
+			found = mergeConfig(found, c)
 		} else if err != nil && !os.IsNotExist(err) {
 			// can't read
 			return nil, err
@@ -53,10 +63,30 @@ func FindNomsConfig() (*Config, error) {
 		nextDir := filepath.Dir(curDir)
 		if nextDir == curDir {
 			// stop at root
-			return nil, NoConfig
+			break
 		}
 		curDir = nextDir
 	}
+	if found == nil {
+		return nil, NoConfig
+	}
+	return found, nil
+}
+
+// mergeConfig adds the db aliases in parent which aren't defined in c to c. If c is nil, parent is returned.
+func mergeConfig(c, parent *Config) *Config {
+	if c == nil {
+		return parent
+	}
+	if c.Db == nil {
+		c.Db = map[string]DbConfig{}
+	}
+	for k, r := range parent.Db {
+		if _, ok := c.Db[k]; !ok {
+			c.Db[k] = r
+		}
+	}
+	return c
 }
 
 func ReadConfig(name string) (*Config, error) {
@@ -91,21 +121,22 @@ func (c *Config) WriteTo(configHome string) (string, error) {
 	return file, nil
 }
 
-// Replace relative directory in path part of spec with an absolute
-// directory. Assumes the path is relative to the location of the config file
+// Replace relative directory in path part of spec (or the snapshot file of a
+// mem spec) with an absolute directory. Assumes the path is relative to the
+// location of the config file
 func absDbSpec(configHome string, url string) string {
 	dbSpec, err := spec.ForDatabase(url)
 	if err != nil {
 		return url
 	}
-	if dbSpec.Protocol != "nbs" {
+	if dbSpec.Protocol != "nbs" && dbSpec.Protocol != "mem" || dbSpec.DatabaseName == "" {
 		return url
 	}
 	dbName := dbSpec.DatabaseName
 	if !filepath.IsAbs(dbName) {
 		dbName = filepath.Join(configHome, dbName)
 	}
-	return "nbs:" + dbName
+	return dbSpec.Protocol + ":" + dbName
 }
 
 func qualifyPaths(configPath string, c *Config) (*Config, error) {
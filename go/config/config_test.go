@@ -199,3 +199,33 @@ func TestCwd(t *testing.T) {
 
 	assert.Equal(cwd, abs)
 }
+
+func TestConfigInheritsFromParentDirs(t *testing.T) {
+	assert := assert.New(t)
+	path := getPaths(assert, "home.inherit")
+	writeConfig(assert, ldbConfig, path.home)
+
+	// subdir only overrides the default db, and inherits origin from its parent.
+	subdir := filepath.Join(path.home, "staging")
+	subConfig := &Config{"", map[string]DbConfig{DefaultDbAlias: {memSpec}}}
+	subFile := writeConfig(assert, subConfig, subdir)
+
+	assert.NoError(os.Chdir(subdir))
+	c, err := FindNomsConfig()
+	assert.NoError(err)
+	validateConfig(assert, subFile, &Config{"", map[string]DbConfig{
+		DefaultDbAlias: {memSpec},
+		remoteAlias:    {httpSpec},
+	}}, c)
+
+	r := NewResolver()
+	assert.Equal(memSpec+"::ds", r.ResolvePathSpec("ds"))
+	assert.Equal(httpSpec+"::ds", r.ResolvePathSpec(remoteAlias+"::ds"))
+}
+
+func TestQualifyingMemSnapshotPaths(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("mem", absDbSpec("/home", "mem"))
+	assert.Equal("mem:/home/snapshot", absDbSpec("/home", "mem:snapshot"))
+	assert.Equal("mem:/tmp/snapshot", absDbSpec("/home", "mem:/tmp/snapshot"))
+}
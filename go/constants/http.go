@@ -10,6 +10,9 @@ const (
 	GetBlobPath    = "/getBlob/"
 	HasRefsPath    = "/hasRefs/"
 	WriteValuePath = "/writeValue/"
+	LockPath       = "/lock/"
+	UnlockPath     = "/unlock/"
+	StatsPath      = "/stats/"
 	BasePath       = "/"
 
 	GraphQLPath = "/graphql/"
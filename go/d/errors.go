@@ -0,0 +1,89 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package d
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error is an error returned by library code, with the context it happened
+// in: what was being done, and where. Fields which don't apply are empty.
+// Library code which can fail on its input should return an Error, rather
+// than panicking, so that it can be used in long running programs; only
+// commands should turn errors into panics or exits.
+type Error struct {
+	// Op is what failed, e.g. "read row".
+	Op string
+	// File is the file, or other named input, being read.
+	File string
+	// Offset is the 1-based position in File, e.g. the row of a CSV file,
+	// at which the error happened, or 0 if it isn't known.
+	Offset uint64
+	// Dataset is the dataset being read or written.
+	Dataset string
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *Error) Error() string {
+	where := []string{}
+	if e.File != "" {
+		where = append(where, e.File)
+	}
+	if e.Offset != 0 {
+		where = append(where, fmt.Sprintf("offset %d", e.Offset))
+	}
+	if e.Dataset != "" {
+		where = append(where, "dataset "+e.Dataset)
+	}
+	msg := e.Op
+	if len(where) > 0 {
+		if msg != "" {
+			msg += " "
+		}
+		msg += "(" + strings.Join(where, ", ") + ")"
+	}
+	if msg == "" {
+		return e.Err.Error()
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Annotate adds file and dataset to err's context, if err is an Error and
+// doesn't already say which file or dataset it's from, and returns err.
+// Callers further up, which know more about where an error happened than the
+// code which returned it, use it to fill in the rest.
+func Annotate(err error, file, dataset string) error {
+	if e, ok := err.(*Error); ok {
+		if e.File == "" {
+			e.File = file
+		}
+		if e.Dataset == "" {
+			e.Dataset = dataset
+		}
+	}
+	return err
+}
+
+// RootCause returns the error at the bottom of err, which may be wrapped in
+// any number of Errors and WrappedErrors.
+func RootCause(err error) error {
+	for {
+		switch e := err.(type) {
+		case *Error:
+			err = e.Err
+		case WrappedError:
+			err = e.Cause()
+		default:
+			return err
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package d
+
+import (
+	"testing"
+
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestError(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("te", (&Error{Err: te}).Error())
+	assert.Equal("read row: te", (&Error{Op: "read row", Err: te}).Error())
+	assert.Equal("read row (offset 12): te", (&Error{Op: "read row", Offset: 12, Err: te}).Error())
+	assert.Equal("read row (a.csv, offset 12, dataset ds): te", (&Error{Op: "read row", File: "a.csv", Offset: 12, Dataset: "ds", Err: te}).Error())
+
+	err := Annotate(&Error{Op: "read row", Offset: 3, Err: te}, "a.csv", "ds")
+	assert.Equal("read row (a.csv, offset 3, dataset ds): te", err.Error())
+	err = Annotate(err, "b.csv", "ds2")
+	assert.Equal("read row (a.csv, offset 3, dataset ds): te", err.Error())
+	assert.Equal(te, Annotate(te, "a.csv", "ds"))
+
+	assert.Equal(te, RootCause(&Error{Err: Wrap(&Error{Err: te})}))
+	assert.Equal(te, RootCause(te))
+	assert.Equal(te, (&Error{Err: te}).Unwrap())
+}
@@ -0,0 +1,167 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/hash"
+	"github.com/attic-labs/noms/go/types"
+)
+
+/*
+  Backup archive:
+    Magic  // backupMagic
+    Root   // 20-byte hash of the root of the backed up Database
+    Base   // 20-byte hash of the root the backup is incremental on, or the empty hash
+    Chunks // in the format of chunks.Serialize
+*/
+
+var backupMagic = []byte("NOMSBAK1")
+
+var (
+	// ErrNotBackup is returned by Restore if its input isn't a backup archive.
+	ErrNotBackup = errors.New("Not a backup archive")
+	// ErrBackupBaseMismatch is returned by Restore if the root of the ChunkStore isn't the base of the backup.
+	ErrBackupBaseMismatch = errors.New("The store's root isn't the backup's base: restore a full backup into an empty store, and incremental backups in the order they were made")
+)
+
+// BackupManifest describes a backup archive. An incremental backup is made by
+// passing the Root of the previous backup's manifest to Backup as its base.
+type BackupManifest struct {
+	Root   string `json:"root"`
+	Base   string `json:"base,omitempty"`
+	Chunks uint64 `json:"chunks"`
+	Bytes  uint64 `json:"bytes"`
+}
+
+// Backup writes an archive of the chunks reachable from the root of db to w.
+// If base isn't the empty hash, the backup is incremental: it's the root of a
+// previous backup, and the chunks reachable from it are left out. The archive
+// is written as the chunks are read, in a single pass, so w may be a stream.
+func Backup(db Database, w io.Writer, base hash.Hash) (BackupManifest, error) {
+	bs := db.validatingBatchStore()
+	root := bs.Root()
+	manifest := BackupManifest{Root: root.String()}
+
+	skip := hash.HashSet{}
+	if !base.IsEmpty() {
+		manifest.Base = base.String()
+		baseRoot := db.ReadValue(base)
+		if baseRoot == nil {
+			return manifest, fmt.Errorf("Base root %s not found", base)
+		}
+		if missing := walkChunks(db, []types.Value{baseRoot}, func(c chunks.Chunk, v types.Value) bool {
+			skip.Insert(c.Hash())
+			return true
+		}); len(missing) > 0 {
+			return manifest, fmt.Errorf("Chunks reachable from base root %s are missing, e.g. %s", base, anyHash(missing))
+		}
+	}
+
+	if _, err := w.Write(backupMagic); err != nil {
+		return manifest, err
+	}
+	if _, err := w.Write(root[:]); err != nil {
+		return manifest, err
+	}
+	if _, err := w.Write(base[:]); err != nil {
+		return manifest, err
+	}
+	if root.IsEmpty() {
+		return manifest, nil
+	}
+
+	// Walk the root written to the header, which may be newer than the one db has cached.
+	rootValue := db.ReadValue(root)
+	if rootValue == nil {
+		return manifest, fmt.Errorf("Root %s not found", root)
+	}
+	buf := &bytes.Buffer{}
+	err := d.Try(func() {
+		missing := walkChunks(db, []types.Value{rootValue}, func(c chunks.Chunk, v types.Value) bool {
+			if skip.Has(c.Hash()) {
+				return false
+			}
+			buf.Reset()
+			chunks.Serialize(c, buf)
+			_, err := w.Write(buf.Bytes())
+			d.PanicIfError(err)
+			manifest.Chunks++
+			manifest.Bytes += uint64(len(c.Data()))
+			return true
+		})
+		if len(missing) > 0 {
+			d.Panic("Chunks reachable from root %s are missing, e.g. %s", root, anyHash(missing))
+		}
+	})
+	return manifest, d.Unwrap(err)
+}
+
+// Restore puts the chunks in the backup archive read from r into cs, then
+// sets the root of cs to the root of the backup. If the backup is
+// incremental, the root of cs must already be its base, i.e. the backups must
+// be restored in the order they were made. Otherwise, cs should be empty.
+func Restore(cs chunks.ChunkStore, r io.Reader) (BackupManifest, error) {
+	header := make([]byte, len(backupMagic)+2*hash.ByteLen)
+	if _, err := io.ReadFull(r, header); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return BackupManifest{}, ErrNotBackup
+	} else if err != nil {
+		return BackupManifest{}, err
+	}
+	if !bytes.Equal(header[:len(backupMagic)], backupMagic) {
+		return BackupManifest{}, ErrNotBackup
+	}
+	root, base := hash.Hash{}, hash.Hash{}
+	copy(root[:], header[len(backupMagic):])
+	copy(base[:], header[len(backupMagic)+hash.ByteLen:])
+
+	manifest := BackupManifest{Root: root.String()}
+	if !base.IsEmpty() {
+		manifest.Base = base.String()
+	}
+	current := cs.Root()
+	if current != base {
+		return manifest, ErrBackupBaseMismatch
+	}
+
+	chunkChan := make(chan *chunks.Chunk, 16)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- d.Try(func() {
+			d.PanicIfError(chunks.Deserialize(r, chunkChan))
+		})
+		close(chunkChan)
+	}()
+	for c := range chunkChan {
+		cs.Put(*c)
+		manifest.Chunks++
+		manifest.Bytes += uint64(len(c.Data()))
+	}
+	if err := <-errChan; err != nil {
+		return manifest, d.Unwrap(err)
+	}
+
+	cs.Flush()
+	if !root.IsEmpty() && !cs.Has(root) {
+		return manifest, fmt.Errorf("Backup doesn't contain its root %s", root)
+	}
+	if !cs.UpdateRoot(root, current) {
+		return manifest, ErrBackupBaseMismatch
+	}
+	return manifest, nil
+}
+
+func anyHash(hs hash.HashSet) hash.Hash {
+	for h := range hs {
+		return h
+	}
+	return hash.Hash{}
+}
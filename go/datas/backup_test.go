@@ -0,0 +1,124 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/hash"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	assert := assert.New(t)
+	db := NewDatabase(chunks.NewMemoryStore())
+	defer db.Close()
+
+	values := make(types.ValueSlice, 10000)
+	for i := range values {
+		values[i] = types.Number(i)
+	}
+	ds, err := db.CommitValue(db.GetDataset("ds"), types.NewList(values...))
+	assert.NoError(err)
+
+	head1 := ds.HeadRef()
+
+	full := &bytes.Buffer{}
+	m1, err := Backup(db, full, hash.Hash{})
+	assert.NoError(err)
+	assert.Equal(ds.Database().Datasets().Hash().String(), m1.Root)
+	assert.Equal("", m1.Base)
+	assert.True(m1.Chunks > 2)
+
+	// An incremental backup has only the chunks which have changed since.
+	values[5000] = types.String("changed")
+	ds, err = db.CommitValue(ds, types.NewList(values...))
+	assert.NoError(err)
+	incremental := &bytes.Buffer{}
+	m2, err := Backup(db, incremental, hash.Parse(m1.Root))
+	assert.NoError(err)
+	assert.Equal(m1.Root, m2.Base)
+	assert.True(m2.Chunks > 2 && m2.Chunks < m1.Chunks, "%d, %d", m1.Chunks, m2.Chunks)
+	assert.True(incremental.Len() < full.Len())
+
+	// Restoring them in order reproduces the database.
+	cs := chunks.NewMemoryStore()
+	incrementalData := incremental.Bytes()
+	_, err = Restore(cs, bytes.NewReader(incrementalData))
+	assert.Equal(ErrBackupBaseMismatch, err)
+
+	rm1, err := Restore(cs, full)
+	assert.NoError(err)
+	assert.Equal(m1, rm1)
+	restored := NewDatabase(cs)
+	assert.True(restored.GetDataset("ds").HeadRef().Equals(head1))
+	assert.Empty(Fsck(restored, 4, nil))
+
+	rm2, err := Restore(cs, bytes.NewReader(incrementalData))
+	assert.NoError(err)
+	assert.Equal(m2, rm2)
+	restored = NewDatabase(cs)
+	assert.True(restored.GetDataset("ds").HeadRef().Equals(ds.HeadRef()))
+	assert.Empty(Fsck(restored, 4, nil))
+}
+
+func TestBackupWalksRecordedRoot(t *testing.T) {
+	assert := assert.New(t)
+	cs := chunks.NewMemoryStore()
+	// db's cached root goes stale when another Database of the same store commits.
+	db, writer := NewDatabase(cs), NewDatabase(cs)
+	defer db.Close()
+	defer writer.Close()
+	ds, err := writer.CommitValue(writer.GetDataset("ds"), types.String("hi"))
+	assert.NoError(err)
+
+	buf := &bytes.Buffer{}
+	m, err := Backup(db, buf, hash.Hash{})
+	assert.NoError(err)
+	assert.Equal(cs.Root().String(), m.Root)
+
+	restoredCS := chunks.NewMemoryStore()
+	_, err = Restore(restoredCS, buf)
+	assert.NoError(err)
+	restored := NewDatabase(restoredCS)
+	assert.True(restored.GetDataset("ds").HeadRef().Equals(ds.HeadRef()))
+	assert.Empty(Fsck(restored, 4, nil))
+}
+
+func TestBackupEmptyAndRestoreErrors(t *testing.T) {
+	assert := assert.New(t)
+	db := NewDatabase(chunks.NewMemoryStore())
+	defer db.Close()
+
+	buf := &bytes.Buffer{}
+	m, err := Backup(db, buf, hash.Hash{})
+	assert.NoError(err)
+	assert.Equal(uint64(0), m.Chunks)
+	cs := chunks.NewMemoryStore()
+	_, err = Restore(cs, buf)
+	assert.NoError(err)
+	assert.True(cs.Root().IsEmpty())
+
+	_, err = Restore(cs, bytes.NewBufferString("not a backup at all, but long enough to have a header"))
+	assert.Equal(ErrNotBackup, err)
+	_, err = Restore(cs, bytes.NewBufferString("short"))
+	assert.Equal(ErrNotBackup, err)
+
+	_, err = db.CommitValue(db.GetDataset("ds"), types.String("hi"))
+	assert.NoError(err)
+	buf.Reset()
+	_, err = Backup(db, buf, hash.Hash{})
+	assert.NoError(err)
+	truncated := buf.Bytes()[:buf.Len()-1]
+	_, err = Restore(cs, bytes.NewReader(truncated))
+	assert.Error(err)
+	assert.True(cs.Root().IsEmpty())
+
+	_, err = Backup(db, &bytes.Buffer{}, hash.Of([]byte("nope")))
+	assert.Error(err)
+}
@@ -12,6 +12,7 @@ import (
 
 type chunkHaver interface {
 	Has(h hash.Hash) bool
+	HasMany(hashes hash.HashSet) (present hash.HashSet)
 }
 
 type cachingChunkHaver struct {
@@ -33,6 +34,32 @@ func (ccs *cachingChunkHaver) Has(r hash.Hash) bool {
 	return has
 }
 
+// HasMany returns the subset of hashes which are present, only asking the backing chunkHaver about those which aren't cached.
+func (ccs *cachingChunkHaver) HasMany(hashes hash.HashSet) (present hash.HashSet) {
+	present = hash.HashSet{}
+	remaining := hash.HashSet{}
+	for h := range hashes {
+		if has, ok := checkCache(ccs, h); !ok {
+			remaining.Insert(h)
+		} else if has {
+			present.Insert(h)
+		}
+	}
+	if len(remaining) == 0 {
+		return
+	}
+
+	found := ccs.backing.HasMany(remaining)
+	for h := range remaining {
+		has := found.Has(h)
+		setCache(ccs, h, has)
+		if has {
+			present.Insert(h)
+		}
+	}
+	return
+}
+
 func checkCache(ccs *cachingChunkHaver, r hash.Hash) (has, ok bool) {
 	ccs.mu.RLock()
 	defer ccs.mu.RUnlock()
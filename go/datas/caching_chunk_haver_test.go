@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/hash"
 	"github.com/attic-labs/testify/assert"
 )
 
@@ -30,3 +31,22 @@ func TestCachingChunkHaver(t *testing.T) {
 	assert.True(ccs.Has(c.Hash()))
 	assert.Equal(ts.Hases, 2)
 }
+
+func TestCachingChunkHaverHasMany(t *testing.T) {
+	assert := assert.New(t)
+	ts := chunks.NewTestStore()
+	ccs := newCachingChunkHaver(ts)
+
+	c1, c2 := chunks.NewChunk([]byte("abc")), chunks.NewChunk([]byte("def"))
+	ts.Put(c1)
+	assert.True(ccs.Has(c1.Hash()))
+	assert.Equal(1, ts.Hases)
+
+	// Only c2 needs to be looked up.
+	present := ccs.HasMany(hash.NewHashSet(c1.Hash(), c2.Hash()))
+	assert.Equal(hash.NewHashSet(c1.Hash()), present)
+	assert.Equal(2, ts.Hases)
+
+	assert.False(ccs.Has(c2.Hash()))
+	assert.Equal(2, ts.Hases)
+}
@@ -0,0 +1,125 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/hash"
+	"github.com/attic-labs/noms/go/types"
+)
+
+// ChunkCount is a number of chunks and their total size in bytes.
+type ChunkCount struct {
+	Chunks, Bytes uint64
+}
+
+func (cc *ChunkCount) add(size uint64) {
+	cc.Chunks++
+	cc.Bytes += size
+}
+
+// DedupStats describes the chunks reachable from the root of a Database.
+type DedupStats struct {
+	// Unique counts each chunk once: what the Database stores.
+	Unique ChunkCount
+	// Referenced counts each chunk once for every Ref to it, plus once for
+	// the root: what the Database would store if chunks weren't shared
+	// between the values which refer to them.
+	Referenced ChunkCount
+}
+
+// Ratio is the ratio of Referenced to Unique bytes, or 1 for an empty
+// Database.
+func (ds DedupStats) Ratio() float64 {
+	if ds.Unique.Bytes == 0 {
+		return 1
+	}
+	return float64(ds.Referenced.Bytes) / float64(ds.Unique.Bytes)
+}
+
+// StoreDedupStats counts the chunks reachable from the root of db, which are
+// all the chunks of its datasets and their histories, to measure how much
+// storage content addressing saves.
+func StoreDedupStats(db Database) DedupStats {
+	root := db.Datasets()
+	if root.Empty() {
+		return DedupStats{}
+	}
+
+	sizes := map[hash.Hash]uint64{}
+	refs := map[hash.Hash]uint64{}
+	refs[root.Hash()] = 1
+	walkChunks(db, []types.Value{root}, func(c chunks.Chunk, v types.Value) bool {
+		sizes[c.Hash()] = uint64(len(c.Data()))
+		v.WalkRefs(func(r types.Ref) {
+			refs[r.TargetHash()]++
+		})
+		return true
+	})
+
+	stats := DedupStats{}
+	for h, size := range sizes {
+		stats.Unique.add(size)
+		stats.Referenced.Chunks += refs[h]
+		stats.Referenced.Bytes += refs[h] * size
+	}
+	return stats
+}
+
+// walkChunks calls visit once with each distinct chunk reachable from roots,
+// and the value it decodes to, breadth-first. The roots are visited as they're
+// encoded, whether or not they're stored in chunks of their own. Each level of
+// the walk is read from db with a single GetMany. If visit returns false, the
+// chunk's refs aren't followed. Chunks missing from db are skipped, and
+// returned.
+func walkChunks(db Database, roots []types.Value, visit func(c chunks.Chunk, v types.Value) bool) (missing hash.HashSet) {
+	missing = hash.HashSet{}
+	visited := hash.HashSet{}
+	next := hash.HashSet{}
+	follow := func(v types.Value) {
+		v.WalkRefs(func(r types.Ref) {
+			if h := r.TargetHash(); !visited.Has(h) {
+				visited.Insert(h)
+				next.Insert(h)
+			}
+		})
+	}
+
+	for _, v := range roots {
+		c := types.EncodeValue(v, nil)
+		if visited.Has(c.Hash()) {
+			continue
+		}
+		visited.Insert(c.Hash())
+		if visit(c, v) {
+			follow(v)
+		}
+	}
+
+	bs := db.validatingBatchStore()
+	for len(next) > 0 {
+		batch := next
+		next = hash.HashSet{}
+		foundChunks := make(chan *chunks.Chunk, 16)
+		go func() {
+			bs.GetMany(batch, foundChunks)
+			close(foundChunks)
+		}()
+		found := hash.HashSet{}
+		for c := range foundChunks {
+			found.Insert(c.Hash())
+			v := types.DecodeValue(*c, db)
+			if visit(*c, v) {
+				follow(v)
+			}
+		}
+		for h := range batch {
+			if !found.Has(h) {
+				missing.Insert(h)
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,42 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"testing"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+func chunkSize(v types.Value) uint64 {
+	return uint64(len(types.EncodeValue(v, nil).Data()))
+}
+
+func TestStoreDedupStats(t *testing.T) {
+	assert := assert.New(t)
+	db := NewDatabase(chunks.NewMemoryStore())
+	defer db.Close()
+
+	assert.Equal(DedupStats{}, StoreDedupStats(db))
+	assert.Equal(1.0, StoreDedupStats(db).Ratio())
+
+	v1, v2 := types.String("one"), types.String("two")
+	l := types.NewList(db.WriteValue(v1), db.WriteValue(v2))
+	ds1, err := db.CommitValue(db.GetDataset("ds1"), l)
+	assert.NoError(err)
+	// The same commit, so both datasets share all of their chunks.
+	ds2, err := db.CommitValue(db.GetDataset("ds2"), l)
+	assert.NoError(err)
+	assert.Equal(ds1.HeadRef(), ds2.HeadRef())
+
+	root, commit := chunkSize(db.Datasets()), chunkSize(ds1.Head())
+	stats := StoreDedupStats(db)
+	assert.Equal(ChunkCount{4, root + commit + chunkSize(v1) + chunkSize(v2)}, stats.Unique)
+	// The commit is referred to by both datasets, and the values by the commit.
+	assert.Equal(ChunkCount{5, root + 2*commit + chunkSize(v1) + chunkSize(v2)}, stats.Referenced)
+	assert.True(stats.Ratio() > 1)
+}
@@ -0,0 +1,116 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"github.com/attic-labs/noms/go/hash"
+	"github.com/attic-labs/noms/go/types"
+)
+
+// IsAncestor returns whether ancestor is c, or one of c's ancestors. Since a
+// commit is higher than all its ancestors, only the commits between c and
+// ancestor's height are read.
+func IsAncestor(ancestor, c types.Ref, vr types.ValueReader) bool {
+	q := &types.RefByHeight{c}
+	seen := map[hash.Hash]bool{}
+	for !q.Empty() && q.MaxHeight() >= ancestor.Height() {
+		ht := q.MaxHeight()
+		refs := q.PopRefsOfHeight(ht)
+		if ht == ancestor.Height() {
+			for _, r := range refs {
+				if r.TargetHash() == ancestor.TargetHash() {
+					return true
+				}
+			}
+			return false
+		}
+		parentsToQueue(unseen(refs, seen), q, vr)
+	}
+	return false
+}
+
+// MergeBase returns the most recent common ancestor of the heads of ds1 and
+// ds2, as FindCommonAncestor does. ok is false if they have none, or either
+// has no head.
+func MergeBase(ds1, ds2 Dataset, vr types.ValueReader) (base types.Ref, ok bool) {
+	h1, ok1 := ds1.MaybeHeadRef()
+	h2, ok2 := ds2.MaybeHeadRef()
+	if !ok1 || !ok2 {
+		return
+	}
+	return FindCommonAncestor(h1, h2, vr)
+}
+
+// CommitRange iterates over the commits returned by CommitsBetween, a page at
+// a time.
+type CommitRange struct {
+	vr           types.ValueReader
+	since, until *types.RefByHeight
+	excluded     map[hash.Hash]bool
+	seen         map[hash.Hash]bool
+	// pending are commits which have been found, but not yet returned.
+	pending types.RefSlice
+}
+
+// CommitsBetween returns the commits which are ancestors of until, or until
+// itself, but not ancestors of since or since itself, highest first. If
+// since is the zero Ref, every ancestor of until is included. Commits are
+// read as pages of them are asked for, and only as far back as needed.
+func CommitsBetween(since, until types.Ref, vr types.ValueReader) *CommitRange {
+	cr := &CommitRange{
+		vr:       vr,
+		since:    &types.RefByHeight{},
+		until:    &types.RefByHeight{until},
+		excluded: map[hash.Hash]bool{},
+		seen:     map[hash.Hash]bool{},
+	}
+	if (since != types.Ref{}) {
+		cr.since.PushBack(since)
+	}
+	return cr
+}
+
+// Next returns the next page of at most n commits, or none once all have been
+// returned.
+func (cr *CommitRange) Next(n int) types.RefSlice {
+	for len(cr.pending) < n && !cr.until.Empty() {
+		ht := cr.until.MaxHeight()
+		// The commits reachable from since at this height have all been found,
+		// since the commits they're reachable through are higher.
+		for !cr.since.Empty() && cr.since.MaxHeight() >= ht {
+			refs := unseen(cr.since.PopRefsOfHeight(cr.since.MaxHeight()), cr.excluded)
+			parentsToQueue(refs, cr.since, cr.vr)
+		}
+
+		refs := types.RefSlice{}
+		for _, r := range unseen(cr.until.PopRefsOfHeight(ht), cr.seen) {
+			if !cr.excluded[r.TargetHash()] {
+				refs = append(refs, r)
+			}
+		}
+		parentsToQueue(refs, cr.until, cr.vr)
+		cr.pending = append(cr.pending, refs...)
+	}
+
+	if n > len(cr.pending) {
+		n = len(cr.pending)
+	}
+	page := append(types.RefSlice{}, cr.pending[:n]...)
+	cr.pending = cr.pending[n:]
+	return page
+}
+
+// unseen returns the refs which aren't in seen, without duplicates, and adds
+// them to it.
+func unseen(refs types.RefSlice, seen map[hash.Hash]bool) types.RefSlice {
+	out := types.RefSlice{}
+	for _, r := range refs {
+		if !seen[r.TargetHash()] {
+			seen[r.TargetHash()] = true
+			out = append(out, r)
+		}
+	}
+	return out
+}
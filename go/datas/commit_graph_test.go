@@ -0,0 +1,91 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestCommitGraph(t *testing.T) {
+	assert := assert.New(t)
+	db := NewDatabase(chunks.NewTestStore())
+	defer db.Close()
+
+	addCommit := func(datasetID string, val string, parents ...types.Ref) types.Ref {
+		ds := db.GetDataset(datasetID)
+		set := types.NewSet()
+		for _, p := range parents {
+			set = set.Insert(p)
+		}
+		ds, err := db.Commit(ds, types.String(val), CommitOptions{Parents: set})
+		assert.NoError(err)
+		return ds.HeadRef()
+	}
+
+	// ds-a: a1<-a2<-a3<-a4
+	//            ^       |
+	//             \      V
+	// ds-b:        b3<-b4<-b5
+	//
+	// ds-c: c1
+	a, b := "ds-a", "ds-b"
+	a1 := addCommit(a, "a1")
+	a2 := addCommit(a, "a2", a1)
+	a3 := addCommit(a, "a3", a2)
+	b3 := addCommit(b, "b3", a2)
+	a4 := addCommit(a, "a4", a3)
+	b4 := addCommit(b, "b4", b3)
+	b5 := addCommit(b, "b5", b4, a4)
+	c1 := addCommit("ds-c", "c1")
+
+	assert.True(IsAncestor(a1, a1, db))
+	assert.True(IsAncestor(a1, b5, db))
+	assert.True(IsAncestor(a3, b5, db))
+	assert.True(IsAncestor(b3, b5, db))
+	assert.False(IsAncestor(b3, a4, db))
+	assert.False(IsAncestor(a4, a3, db))
+	assert.False(IsAncestor(c1, b5, db))
+
+	base, ok := MergeBase(db.GetDataset(a), db.GetDataset(b), db)
+	assert.True(ok)
+	assert.Equal(a4.TargetHash(), base.TargetHash())
+	_, ok = MergeBase(db.GetDataset(a), db.GetDataset("ds-c"), db)
+	assert.False(ok)
+	_, ok = MergeBase(db.GetDataset(a), db.GetDataset("none"), db)
+	assert.False(ok)
+
+	values := func(refs types.RefSlice) string {
+		vals := []string{}
+		for _, r := range refs {
+			vals = append(vals, string(r.TargetValue(db).(types.Struct).Get(ValueField).(types.String)))
+		}
+		// Commits of the same height may come in either order.
+		sort.Strings(vals)
+		return strings.Join(vals, ",")
+	}
+	between := func(since, until types.Ref) string {
+		return values(CommitsBetween(since, until, db).Next(100))
+	}
+	assert.Equal("a1,a2,a3,a4,b3,b4,b5", between(types.Ref{}, b5))
+	assert.Equal("a3,a4,b3,b4,b5", between(a2, b5))
+	assert.Equal("b3,b4,b5", between(a4, b5))
+	assert.Equal("", between(b5, a4))
+	assert.Equal("a3,a4", between(b3, a4))
+	assert.Equal("a1,a2,a3,a4", between(c1, a4))
+
+	// Pages are highest first.
+	cr := CommitsBetween(a1, b5, db)
+	assert.Equal("b5", values(cr.Next(1)))
+	assert.Equal("a4,b4", values(cr.Next(2)))
+	assert.Equal("a3,b3", values(cr.Next(2)))
+	assert.Equal("a2", values(cr.Next(2)))
+	assert.Empty(cr.Next(2))
+}
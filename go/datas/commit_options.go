@@ -25,3 +25,11 @@ type CommitOptions struct {
 	// might also be called multiple times with different values.
 	Policy merge.Policy
 }
+
+// DatasetCommit is one of the commits made by Database.CommitMany: Value is
+// committed to Dataset with Options, as if by Commit.
+type DatasetCommit struct {
+	Dataset Dataset
+	Value   types.Value
+	Options CommitOptions
+}
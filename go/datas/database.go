@@ -63,6 +63,15 @@ type Database interface {
 	// of a conflict, Commit returns an 'ErrMergeNeeded' error.
 	CommitValue(ds Dataset, v types.Value) (Dataset, error)
 
+	// CommitMany is like Commit, but commits a new value to each of several
+	// Datasets in a single atomic update of the Database's root, so that
+	// readers either see all of the new heads or none of them. This is
+	// useful for keeping related Datasets, such as a table and an index of
+	// it, consistent with each other. If any of the commits fails, e.g.
+	// with 'ErrMergeNeeded', none of them are made. The returned Datasets
+	// are the newest snapshots of each Dataset, in the order of commits.
+	CommitMany(commits []DatasetCommit) ([]Dataset, error)
+
 	// Delete removes the Dataset named ds.ID() from the map at the root of
 	// the Database. The Dataset data is not necessarily cleaned up at this
 	// time, but may be garbage collected in the future.
@@ -94,6 +103,12 @@ type Database interface {
 	// Regardless, Datasets() is updated to match backing storage upon return.
 	FastForward(ds Dataset, newHeadRef types.Ref) (Dataset, error)
 
+	// HasMany returns the subset of hashes which are the hashes of chunks
+	// present in the Database. Against a remote Database, this takes far
+	// fewer round trips than checking each hash on its own. Use
+	// ReadManyValues() to read many Values at once.
+	HasMany(hashes hash.HashSet) (present hash.HashSet)
+
 	// validatingBatchStore returns the BatchStore used to read and write
 	// groups of values to the database efficiently. This interface is a low-
 	// level detail of the database that should infrequently be needed by
@@ -101,6 +116,9 @@ type Database interface {
 	validatingBatchStore() types.BatchStore
 
 	has(h hash.Hash) bool
+
+	// doRename moves the head of one dataset to another, for RenameDataset.
+	doRename(srcID, dstID string, force bool) error
 }
 
 func NewDatabase(cs chunks.ChunkStore) Database {
@@ -6,6 +6,8 @@ package datas
 
 import (
 	"errors"
+	"fmt"
+	"sync"
 
 	"github.com/attic-labs/noms/go/chunks"
 	"github.com/attic-labs/noms/go/d"
@@ -16,8 +18,11 @@ import (
 
 type databaseCommon struct {
 	*types.ValueStore
-	cch      *cachingChunkHaver
-	rt       chunks.RootTracker
+	cch *cachingChunkHaver
+	rt  chunks.RootTracker
+
+	// rootMu guards rootHash and datasets, so that goroutines sharing a Database can commit concurrently.
+	rootMu   *sync.Mutex
 	rootHash hash.Hash
 	datasets *types.Map
 }
@@ -25,10 +30,11 @@ type databaseCommon struct {
 var (
 	ErrOptimisticLockFailed = errors.New("Optimistic lock failed on database Root update")
 	ErrMergeNeeded          = errors.New("Dataset head is not ancestor of commit")
+	ErrRootNotUpdated       = errors.New("The store didn't update its Root, though it hasn't moved, e.g. because it's read-only")
 )
 
 func newDatabaseCommon(cch *cachingChunkHaver, vs *types.ValueStore, rt chunks.RootTracker) databaseCommon {
-	return databaseCommon{ValueStore: vs, cch: cch, rt: rt, rootHash: rt.Root()}
+	return databaseCommon{ValueStore: vs, cch: cch, rt: rt, rootMu: &sync.Mutex{}, rootHash: rt.Root()}
 }
 
 func (dbc *databaseCommon) validatingBatchStore() types.BatchStore {
@@ -36,6 +42,8 @@ func (dbc *databaseCommon) validatingBatchStore() types.BatchStore {
 }
 
 func (dbc *databaseCommon) Datasets() types.Map {
+	dbc.rootMu.Lock()
+	defer dbc.rootMu.Unlock()
 	if dbc.datasets == nil {
 		if dbc.rootHash.IsEmpty() {
 			emptyMap := types.NewMap()
@@ -65,10 +73,22 @@ func getDataset(db Database, datasetID string) Dataset {
 	return Dataset{store: db, id: datasetID}
 }
 
+func getDatasets(db Database, commits []DatasetCommit) []Dataset {
+	datasets := make([]Dataset, len(commits))
+	for i, dc := range commits {
+		datasets[i] = db.GetDataset(dc.Dataset.ID())
+	}
+	return datasets
+}
+
 func (dbc *databaseCommon) has(h hash.Hash) bool {
 	return dbc.cch.Has(h)
 }
 
+func (dbc *databaseCommon) HasMany(hashes hash.HashSet) (present hash.HashSet) {
+	return dbc.cch.HasMany(hashes)
+}
+
 func (dbc *databaseCommon) Close() error {
 	return dbc.ValueStore.Close()
 }
@@ -78,7 +98,7 @@ func (dbc *databaseCommon) doSetHead(ds Dataset, newHeadRef types.Ref) error {
 		return nil
 	}
 	commit := dbc.validateRefAsCommit(newHeadRef)
-	defer func() { dbc.rootHash, dbc.datasets = dbc.rt.Root(), nil }()
+	defer dbc.rootChanged()
 
 	currentRootHash, currentDatasets := dbc.getRootAndDatasets()
 	commitRef := dbc.WriteValue(commit) // will be orphaned if the tryUpdateRoot() below fails
@@ -100,56 +120,113 @@ func (dbc *databaseCommon) doFastForward(ds Dataset, newHeadRef types.Ref) error
 
 // doCommit manages concurrent access the single logical piece of mutable state: the current Root. doCommit is optimistic in that it is attempting to update head making the assumption that currentRootHash is the hash of the current head. The call to UpdateRoot below will return an 'ErrOptimisticLockFailed' error if that assumption fails (e.g. because of a race with another writer) and the entire algorithm must be tried again. This method will also fail and return an 'ErrMergeNeeded' error if the |commit| is not a descendent of the current dataset head
 func (dbc *databaseCommon) doCommit(datasetID string, commit types.Struct, mergePolicy merge.Policy) error {
-	if !IsCommit(commit) {
-		d.Panic("Can't commit a non-Commit struct to dataset %s", datasetID)
+	return dbc.doCommitMany([]pendingCommit{{datasetID, commit, mergePolicy}})
+}
+
+type pendingCommit struct {
+	datasetID   string
+	commit      types.Struct
+	mergePolicy merge.Policy
+}
+
+// doCommitMany is like doCommit, but moves the heads of several datasets in a single update of the Root, so that readers observe either all or none of the new heads. If any of the commits needs a merge which can't be done, none of them are made.
+func (dbc *databaseCommon) doCommitMany(commits []pendingCommit) error {
+	seen := map[string]bool{}
+	for _, pc := range commits {
+		if !IsCommit(pc.commit) {
+			d.Panic("Can't commit a non-Commit struct to dataset %s", pc.datasetID)
+		}
+		if seen[pc.datasetID] {
+			d.Panic("Can't commit to dataset %s more than once", pc.datasetID)
+		}
+		seen[pc.datasetID] = true
 	}
-	defer func() { dbc.rootHash, dbc.datasets = dbc.rt.Root(), nil }()
+	return dbc.updateDatasets(func(currentRootHash hash.Hash, currentDatasets types.Map) (types.Map, error) {
+		for _, pc := range commits {
+			commitRef, err := dbc.mergeCommit(currentRootHash, currentDatasets, pc)
+			if err != nil {
+				return types.Map{}, err
+			}
+			currentDatasets = currentDatasets.Set(types.String(pc.datasetID), types.ToRefOfValue(commitRef))
+		}
+		return currentDatasets, nil
+	})
+}
+
+// doRename moves the head of srcID to dstID in a single update of the Root, so that readers observe the head in exactly one of them. If dstID already has a head it's replaced if force is set, and otherwise ErrDatasetExists is returned.
+func (dbc *databaseCommon) doRename(srcID, dstID string, force bool) error {
+	return dbc.updateDatasets(func(currentRootHash hash.Hash, currentDatasets types.Map) (types.Map, error) {
+		r, ok := currentDatasets.MaybeGet(types.String(srcID))
+		if !ok {
+			return types.Map{}, fmt.Errorf("Dataset %s not found", srcID)
+		}
+		if !force && currentDatasets.Has(types.String(dstID)) {
+			return types.Map{}, ErrDatasetExists
+		}
+		return currentDatasets.Set(types.String(dstID), r).Remove(types.String(srcID)), nil
+	})
+}
+
+// updateDatasets sets the Root to the datasets returned by update, which is passed the current Root and its datasets, retrying with the new ones if another writer moves the Root first. If update returns an error, the Root is left as it is.
+func (dbc *databaseCommon) updateDatasets(update func(currentRootHash hash.Hash, currentDatasets types.Map) (types.Map, error)) error {
+	defer dbc.rootChanged()
 
 	// This could loop forever, given enough simultaneous committers. BUG 2565
 	var err error
 	for err = ErrOptimisticLockFailed; err == ErrOptimisticLockFailed; {
 		currentRootHash, currentDatasets := dbc.getRootAndDatasets()
-		commitRef := dbc.WriteValue(commit) // will be orphaned if the tryUpdateRoot() below fails
-
-		// If there's nothing in the DB yet, skip all this logic.
-		if !currentRootHash.IsEmpty() {
-			r, hasHead := currentDatasets.MaybeGet(types.String(datasetID))
-
-			// First commit in dataset is always fast-forward, so go through all this iff there's already a Head for datasetID.
-			if hasHead {
-				head := r.(types.Ref).TargetValue(dbc)
-				currentHeadRef := types.NewRef(head)
-				ancestorRef, found := FindCommonAncestor(commitRef, currentHeadRef, dbc)
-				if !found {
-					return ErrMergeNeeded
-				}
-
-				// This covers all cases where currentHeadRef is not an ancestor of commit, including the following edge cases:
-				//   - commit is a duplicate of currentHead.
-				//   - we hit an ErrOptimisticLockFailed and looped back around because some other process changed the Head out from under us.
-				if currentHeadRef.TargetHash() != ancestorRef.TargetHash() || currentHeadRef.TargetHash() == commitRef.TargetHash() {
-					if mergePolicy == nil {
-						return ErrMergeNeeded
-					}
-
-					ancestor, currentHead := dbc.validateRefAsCommit(ancestorRef), dbc.validateRefAsCommit(currentHeadRef)
-					merged, err := mergePolicy(commit.Get(ValueField), currentHead.Get(ValueField), ancestor.Get(ValueField), dbc, nil)
-					if err != nil {
-						return err
-					}
-					commitRef = dbc.WriteValue(NewCommit(merged, types.NewSet(commitRef, currentHeadRef), types.EmptyStruct))
-				}
-			}
+		if currentDatasets, err = update(currentRootHash, currentDatasets); err != nil {
+			return err
 		}
-		currentDatasets = currentDatasets.Set(types.String(datasetID), types.ToRefOfValue(commitRef))
 		err = dbc.tryUpdateRoot(currentDatasets, currentRootHash)
 	}
 	return err
 }
 
+// mergeCommit writes pc.commit and returns a Ref to the commit which should become the new head of pc.datasetID in currentDatasets: either pc.commit itself, if it descends from the current head, or the result of merging the two with pc.mergePolicy.
+func (dbc *databaseCommon) mergeCommit(currentRootHash hash.Hash, currentDatasets types.Map, pc pendingCommit) (types.Ref, error) {
+	commitRef := dbc.WriteValue(pc.commit) // will be orphaned if the tryUpdateRoot() below fails
+
+	// If there's nothing in the DB yet, skip all this logic.
+	if currentRootHash.IsEmpty() {
+		return commitRef, nil
+	}
+
+	r, hasHead := currentDatasets.MaybeGet(types.String(pc.datasetID))
+
+	// First commit in dataset is always fast-forward, so go through all this iff there's already a Head for datasetID.
+	if !hasHead {
+		return commitRef, nil
+	}
+
+	head := r.(types.Ref).TargetValue(dbc)
+	currentHeadRef := types.NewRef(head)
+	ancestorRef, found := FindCommonAncestor(commitRef, currentHeadRef, dbc)
+	if !found {
+		return types.Ref{}, ErrMergeNeeded
+	}
+
+	// This covers all cases where currentHeadRef is not an ancestor of commit, including the following edge cases:
+	//   - commit is a duplicate of currentHead.
+	//   - we hit an ErrOptimisticLockFailed and looped back around because some other process changed the Head out from under us.
+	if currentHeadRef.TargetHash() != ancestorRef.TargetHash() || currentHeadRef.TargetHash() == commitRef.TargetHash() {
+		if pc.mergePolicy == nil {
+			return types.Ref{}, ErrMergeNeeded
+		}
+
+		ancestor, currentHead := dbc.validateRefAsCommit(ancestorRef), dbc.validateRefAsCommit(currentHeadRef)
+		merged, err := pc.mergePolicy(pc.commit.Get(ValueField), currentHead.Get(ValueField), ancestor.Get(ValueField), dbc, nil)
+		if err != nil {
+			return types.Ref{}, err
+		}
+		commitRef = dbc.WriteValue(NewCommit(merged, types.NewSet(commitRef, currentHeadRef), types.EmptyStruct))
+	}
+	return commitRef, nil
+}
+
 // doDelete manages concurrent access the single logical piece of mutable state: the current Root. doDelete is optimistic in that it is attempting to update head making the assumption that currentRootHash is the hash of the current head. The call to UpdateRoot below will return an 'ErrOptimisticLockFailed' error if that assumption fails (e.g. because of a race with another writer) and the entire algorithm must be tried again.
 func (dbc *databaseCommon) doDelete(datasetIDstr string) error {
-	defer func() { dbc.rootHash, dbc.datasets = dbc.rt.Root(), nil }()
+	defer dbc.rootChanged()
 
 	datasetID := types.String(datasetIDstr)
 	currentRootHash, currentDatasets := dbc.getRootAndDatasets()
@@ -177,6 +254,14 @@ func (dbc *databaseCommon) doDelete(datasetIDstr string) error {
 	return err
 }
 
+// rootChanged forgets the datasets of the old root, after an attempt to update it.
+func (dbc *databaseCommon) rootChanged() {
+	root := dbc.rt.Root()
+	dbc.rootMu.Lock()
+	defer dbc.rootMu.Unlock()
+	dbc.rootHash, dbc.datasets = root, nil
+}
+
 func (dbc *databaseCommon) getRootAndDatasets() (currentRootHash hash.Hash, currentDatasets types.Map) {
 	currentRootHash = dbc.rt.Root()
 	currentDatasets = dbc.Datasets()
@@ -195,6 +280,10 @@ func (dbc *databaseCommon) tryUpdateRoot(currentDatasets types.Map, currentRootH
 	// If the root has been updated by another process in the short window since we read it, this call will fail. See issue #404
 	if !dbc.rt.UpdateRoot(newRootHash, currentRootHash) {
 		err = ErrOptimisticLockFailed
+		if dbc.rt.Root() == currentRootHash {
+			// Retrying would fail the same way forever.
+			err = ErrRootNotUpdated
+		}
 	}
 	return
 }
@@ -211,6 +300,14 @@ func (dbc *databaseCommon) validateRefAsCommit(r types.Ref) types.Struct {
 	return v.(types.Struct)
 }
 
+func (dbc *databaseCommon) doCommitDatasets(commits []DatasetCommit) error {
+	pending := make([]pendingCommit, len(commits))
+	for i, dc := range commits {
+		pending[i] = pendingCommit{dc.Dataset.ID(), buildNewCommit(dc.Dataset, dc.Value, dc.Options), dc.Options.Policy}
+	}
+	return dbc.doCommitMany(pending)
+}
+
 func buildNewCommit(ds Dataset, v types.Value, opts CommitOptions) types.Struct {
 	parents := opts.Parents
 	if (parents == types.Set{}) {
@@ -27,8 +27,12 @@ type RemoteDatabaseServer struct {
 	l       *net.Listener
 	csChan  chan *connectionState
 	closing bool
+	locks   *datasetLocks
+	stats   *datasetStats
 	// Called just before the server is started.
 	Ready func()
+	// ReadOnly, if set before Run(), causes the server to reject all requests that would write to the database.
+	ReadOnly bool
 }
 
 func NewRemoteDatabaseServer(cs chunks.ChunkStore, port int) *RemoteDatabaseServer {
@@ -37,7 +41,7 @@ func NewRemoteDatabaseServer(cs chunks.ChunkStore, port int) *RemoteDatabaseServ
 		d.Panic("SDK version %s is incompatible with data of version %s", constants.NomsVersion, dataVersion)
 	}
 	return &RemoteDatabaseServer{
-		cs, port, nil, make(chan *connectionState, 16), false, func() {},
+		cs, port, nil, make(chan *connectionState, 16), false, newDatasetLocks(), newDatasetStats(cs.Root()), func() {}, false,
 	}
 }
 
@@ -66,10 +70,15 @@ func (s *RemoteDatabaseServer) Run() {
 	router.POST(constants.HasRefsPath, s.corsHandle(s.makeHandle(HandleHasRefs)))
 	router.OPTIONS(constants.HasRefsPath, s.corsHandle(noopHandle))
 	router.GET(constants.RootPath, s.corsHandle(s.makeHandle(HandleRootGet)))
-	router.POST(constants.RootPath, s.corsHandle(s.makeHandle(HandleRootPost)))
+	router.POST(constants.RootPath, s.corsHandle(s.makeWriteHandle(HandleRootPost)))
 	router.OPTIONS(constants.RootPath, s.corsHandle(noopHandle))
-	router.POST(constants.WriteValuePath, s.corsHandle(s.makeHandle(HandleWriteValue)))
+	router.POST(constants.WriteValuePath, s.corsHandle(s.makeWriteHandle(HandleWriteValue)))
 	router.OPTIONS(constants.WriteValuePath, s.corsHandle(noopHandle))
+	router.POST(constants.LockPath, s.corsHandle(s.makeWriteHandle(createHandler(s.locks.handleLock, true))))
+	router.OPTIONS(constants.LockPath, s.corsHandle(noopHandle))
+	router.POST(constants.UnlockPath, s.corsHandle(s.makeWriteHandle(createHandler(s.locks.handleUnlock, true))))
+	router.OPTIONS(constants.UnlockPath, s.corsHandle(noopHandle))
+	router.GET(constants.StatsPath, s.corsHandle(s.makeHandle(createHandler(s.stats.handleStats, false))))
 	router.GET(constants.BasePath, s.corsHandle(s.makeHandle(HandleBaseGet)))
 
 	router.GET(constants.GraphQLPath, s.corsHandle(s.makeHandle(HandleGraphQL)))
@@ -104,10 +113,18 @@ func (s *RemoteDatabaseServer) Run() {
 
 func (s *RemoteDatabaseServer) makeHandle(hndlr Handler) httprouter.Handle {
 	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		hndlr(w, req, ps, s.cs)
+		hndlr(w, req, ps, statsStore{s.cs, s.stats})
 	}
 }
 
+// makeWriteHandle is like makeHandle, but for endpoints that modify the database. If the server is read-only, these endpoints respond with 403.
+func (s *RemoteDatabaseServer) makeWriteHandle(hndlr Handler) httprouter.Handle {
+	if s.ReadOnly {
+		return s.makeHandle(HandleReadOnly)
+	}
+	return s.makeHandle(hndlr)
+}
+
 func noopHandle(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 }
 
@@ -5,7 +5,10 @@
 package datas
 
 import (
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/attic-labs/noms/go/chunks"
 	"github.com/attic-labs/noms/go/hash"
@@ -36,6 +39,51 @@ func TestValidateRef(t *testing.T) {
 	assert.Panics(t, func() { db.validateRefAsCommit(types.NewRef(b)) })
 }
 
+// readOnlyStore refuses to update its root.
+type readOnlyStore struct {
+	*chunks.TestStore
+}
+
+func (s readOnlyStore) UpdateRoot(current, last hash.Hash) bool {
+	return false
+}
+
+func TestDatabaseCommitReadOnly(t *testing.T) {
+	db := newLocalDatabase(readOnlyStore{chunks.NewTestStore()})
+	defer db.Close()
+
+	_, err := db.CommitValue(db.GetDataset("ds"), types.String("a"))
+	assert.Equal(t, ErrRootNotUpdated, err)
+}
+
+func TestDatabaseConcurrentCommits(t *testing.T) {
+	assert := assert.New(t)
+	db := NewDatabase(chunks.NewMemoryStore())
+	defer db.Close()
+
+	const workers, commits = 8, 10
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			ds := db.GetDataset(fmt.Sprintf("ds%d", w))
+			for i := 0; i < commits; i++ {
+				var err error
+				ds, err = db.CommitValue(ds, types.NewList(types.Number(w), types.Number(i)))
+				assert.NoError(err)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	assert.Equal(uint64(workers), db.Datasets().Len())
+	for w := 0; w < workers; w++ {
+		head := db.GetDataset(fmt.Sprintf("ds%d", w)).HeadValue()
+		assert.True(types.NewList(types.Number(w), types.Number(commits-1)).Equals(head))
+	}
+}
+
 type DatabaseSuite struct {
 	suite.Suite
 	cs     *chunks.TestStore
@@ -61,7 +109,7 @@ func (suite *RemoteDatabaseSuite) SetupTest() {
 	suite.cs = chunks.NewTestStore()
 	suite.makeDb = func(cs chunks.ChunkStore) Database {
 		hbs := NewHTTPBatchStoreForTest(cs)
-		return &RemoteDatabaseClient{newDatabaseCommon(newCachingChunkHaver(hbs), types.NewValueStore(hbs), hbs)}
+		return &RemoteDatabaseClient{newDatabaseCommon(newCachingChunkHaver(hbs), types.NewValueStore(hbs), hbs), hbs}
 	}
 	suite.db = suite.makeDb(suite.cs)
 }
@@ -98,6 +146,22 @@ func (suite *DatabaseSuite) TestReadWriteCachePersists() {
 	suite.NoError(err)
 }
 
+func (suite *DatabaseSuite) TestHasMany() {
+	l := types.NewList(types.String("a"), types.String("b"))
+	r := suite.db.WriteValue(l)
+	_, err := suite.db.CommitValue(suite.db.GetDataset("foo"), r)
+	suite.NoError(err)
+
+	notPresent := types.String("c").Hash()
+	present := suite.db.HasMany(hash.NewHashSet(r.TargetHash(), notPresent))
+	suite.Equal(hash.NewHashSet(r.TargetHash()), present)
+
+	// Answered from the cache the second time round.
+	hases := suite.cs.Hases
+	suite.Equal(present, suite.db.HasMany(hash.NewHashSet(r.TargetHash(), notPresent)))
+	suite.Equal(hases, suite.cs.Hases)
+}
+
 func (suite *RemoteDatabaseSuite) TestWriteRefToNonexistentValue() {
 	ds := suite.db.GetDataset("foo")
 	r := types.NewRef(types.Bool(true))
@@ -193,6 +257,42 @@ func (suite *DatabaseSuite) TestDatabaseCommit() {
 	suite.Equal(uint64(2), datasets2.Len())
 }
 
+func (suite *DatabaseSuite) TestDatabaseCommitMany() {
+	data, index := suite.db.GetDataset("data"), suite.db.GetDataset("index")
+	a, b := types.String("a"), types.String("b")
+	datasets, err := suite.db.CommitMany([]DatasetCommit{{Dataset: data, Value: a}, {Dataset: index, Value: b}})
+	suite.NoError(err)
+	suite.Len(datasets, 2)
+	data, index = datasets[0], datasets[1]
+	suite.True(data.HeadValue().Equals(a))
+	suite.True(index.HeadValue().Equals(b))
+
+	// Both heads were set by one root update.
+	suite.Equal(suite.db.Datasets().Hash(), suite.cs.Root())
+	newDB := suite.makeDb(suite.cs)
+	suite.True(newDB.GetDataset("data").HeadValue().Equals(a))
+	suite.True(newDB.GetDataset("index").HeadValue().Equals(b))
+	newDB.Close()
+
+	// Move data on, so that committing to a stale data fails, and index isn't updated either.
+	staleData := data
+	data, err = suite.db.CommitValue(data, types.String("a2"))
+	suite.NoError(err)
+	root := suite.cs.Root()
+	datasets, err = suite.db.CommitMany([]DatasetCommit{
+		{Dataset: index, Value: types.String("b2")},
+		{Dataset: staleData, Value: types.String("a3"), Options: newOpts(staleData.HeadRef())},
+	})
+	suite.IsType(ErrMergeNeeded, err)
+	suite.Equal(root, suite.cs.Root())
+	suite.True(datasets[0].HeadValue().Equals(b))
+	suite.True(datasets[1].HeadValue().Equals(types.String("a2")))
+
+	suite.Panics(func() {
+		suite.db.CommitMany([]DatasetCommit{{Dataset: data, Value: a}, {Dataset: data, Value: b}})
+	})
+}
+
 func (suite *DatabaseSuite) TestDatasetsMapType() {
 	dsID1, dsID2 := "ds1", "ds2"
 
@@ -556,3 +656,15 @@ func (suite *DatabaseSuite) TestMetaOption() {
 	c := ds.Head()
 	suite.Equal(types.String("arv"), c.Get("meta").(types.Struct).Get("author"))
 }
+
+func (suite *RemoteDatabaseSuite) TestLockDataset() {
+	rdb := suite.db.(*RemoteDatabaseClient)
+	ds := rdb.GetDataset("ds1")
+
+	token, err := rdb.LockDataset(ds, "", time.Minute)
+	suite.NoError(err)
+	_, err = rdb.LockDataset(ds, "", time.Minute)
+	suite.Equal(ErrDatasetLocked, err)
+	suite.Equal(ErrDatasetLocked, rdb.UnlockDataset(ds, "bogus"))
+	suite.NoError(rdb.UnlockDataset(ds, token))
+}
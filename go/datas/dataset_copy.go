@@ -0,0 +1,53 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDatasetExists is returned by RenameDataset if its destination already
+// has a head, and it isn't forced.
+var ErrDatasetExists = errors.New("Destination dataset already exists")
+
+// copyConcurrency is the parallelism of the Pull done by CopyDataset when
+// src and dst are in different Databases.
+const copyConcurrency = 512
+
+// CopyDataset sets the head of dst to the head of src, replacing any head dst
+// already has. If src and dst are in the same Database only the head is
+// copied, which is cheap however much data src holds. Otherwise the chunks
+// reachable from src's head which dst's Database lacks are pulled into it
+// first. The returned Dataset is the newest snapshot of dst.
+func CopyDataset(src, dst Dataset) (Dataset, error) {
+	srcRef, ok := src.MaybeHeadRef()
+	if !ok {
+		return dst, fmt.Errorf("Dataset %s not found", src.ID())
+	}
+	dstDB := dst.Database()
+	if srcDB := src.Database(); srcDB != dstDB {
+		dstRef, _ := dst.MaybeHeadRef()
+		PullWithFlush(srcDB, dstDB, srcRef, dstRef, copyConcurrency, nil)
+	}
+	return dstDB.SetHead(dst, srcRef)
+}
+
+// RenameDataset moves the head of src to dst, in a single update of the root
+// of their Database, so that readers see it in one or the other. src and dst
+// must be in the same Database, so that the rename moves no data. If dst
+// already has a head, ErrDatasetExists is returned unless force is set, in
+// which case it's replaced. The returned Dataset is the newest snapshot of dst.
+func RenameDataset(src, dst Dataset, force bool) (Dataset, error) {
+	db := src.Database()
+	if db != dst.Database() {
+		return dst, fmt.Errorf("Can't rename %s to %s in another database", src.ID(), dst.ID())
+	}
+	if src.ID() == dst.ID() {
+		return dst, nil
+	}
+	err := db.doRename(src.ID(), dst.ID(), force)
+	return db.GetDataset(dst.ID()), err
+}
@@ -0,0 +1,70 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"testing"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestCopyDataset(t *testing.T) {
+	assert := assert.New(t)
+	db := NewDatabase(chunks.NewTestStore())
+	defer db.Close()
+
+	tmp, err := db.CommitValue(db.GetDataset("tmp"), types.NewList(types.Number(1), types.Number(2)))
+	assert.NoError(err)
+	main, err := db.CommitValue(db.GetDataset("main"), types.String("old"))
+	assert.NoError(err)
+
+	// Within a Database, main's head is replaced even though it isn't an
+	// ancestor of tmp's.
+	main, err = CopyDataset(tmp, main)
+	assert.NoError(err)
+	assert.True(tmp.HeadRef().Equals(main.HeadRef()))
+	assert.True(tmp.HeadRef().Equals(db.GetDataset("main").HeadRef()))
+
+	_, err = CopyDataset(db.GetDataset("none"), main)
+	assert.Error(err)
+	assert.True(tmp.HeadRef().Equals(db.GetDataset("main").HeadRef()))
+
+	// Across Databases, the data is pulled too.
+	cs := chunks.NewTestStore()
+	other := NewDatabase(cs)
+	defer other.Close()
+	copied, err := CopyDataset(tmp, other.GetDataset("copy"))
+	assert.NoError(err)
+	assert.True(tmp.HeadRef().Equals(copied.HeadRef()))
+	other2 := NewDatabase(cs)
+	defer other2.Close()
+	assert.True(tmp.HeadValue().Equals(other2.GetDataset("copy").HeadValue()))
+
+	renamed, err := RenameDataset(db.GetDataset("tmp"), db.GetDataset("staged"), false)
+	assert.NoError(err)
+	assert.True(tmp.HeadRef().Equals(renamed.HeadRef()))
+	_, ok := db.GetDataset("tmp").MaybeHeadRef()
+	assert.False(ok)
+
+	// An existing dataset is only replaced if the rename is forced.
+	_, err = RenameDataset(db.GetDataset("staged"), db.GetDataset("main"), false)
+	assert.Equal(ErrDatasetExists, err)
+	assert.True(db.GetDataset("staged").HeadRef().Equals(tmp.HeadRef()))
+	renamed, err = RenameDataset(db.GetDataset("staged"), db.GetDataset("main"), true)
+	assert.NoError(err)
+	assert.True(tmp.HeadRef().Equals(renamed.HeadRef()))
+	_, ok = db.GetDataset("staged").MaybeHeadRef()
+	assert.False(ok)
+
+	_, err = RenameDataset(db.GetDataset("none"), db.GetDataset("staged"), false)
+	assert.Error(err)
+
+	_, err = RenameDataset(db.GetDataset("main"), other.GetDataset("staged"), false)
+	assert.Error(err)
+	_, ok = other.GetDataset("staged").MaybeHeadRef()
+	assert.False(ok)
+}
@@ -0,0 +1,118 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/d"
+)
+
+// DefaultDatasetLockTTL is how long a dataset lock is held if the client doesn't ask for a specific duration.
+const DefaultDatasetLockTTL = 10 * time.Minute
+
+// datasetLocks tracks advisory write locks on datasets. The locks are not enforced by Commit(); they exist so that cooperating writers (e.g. several import jobs targeting the same dataset) can serialize their commits instead of racing and retrying. Every lock expires after its TTL so that a crashed client can't hold a dataset forever.
+type datasetLocks struct {
+	mu    *sync.Mutex
+	locks map[string]datasetLock
+}
+
+type datasetLock struct {
+	token   string
+	expires time.Time
+}
+
+func newDatasetLocks() *datasetLocks {
+	return &datasetLocks{&sync.Mutex{}, map[string]datasetLock{}}
+}
+
+// acquire takes the lock on datasetID for ttl. If the lock is currently held and token matches the holder's token, the lock is renewed. Returns the token of the lock and whether it was acquired.
+func (dl *datasetLocks) acquire(datasetID, token string, ttl time.Duration, now time.Time) (string, bool) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	if l, ok := dl.locks[datasetID]; ok && now.Before(l.expires) && l.token != token {
+		return "", false
+	}
+	if token == "" {
+		token = newLockToken()
+	}
+	dl.locks[datasetID] = datasetLock{token, now.Add(ttl)}
+	return token, true
+}
+
+// release gives up the lock on datasetID, which must be held with token. Releasing an expired lock or a lock that isn't held succeeds.
+func (dl *datasetLocks) release(datasetID, token string, now time.Time) bool {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	l, ok := dl.locks[datasetID]
+	if !ok || !now.Before(l.expires) {
+		delete(dl.locks, datasetID)
+		return true
+	}
+	if l.token != token {
+		return false
+	}
+	delete(dl.locks, datasetID)
+	return true
+}
+
+func newLockToken() string {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	d.PanicIfError(err)
+	return hex.EncodeToString(b)
+}
+
+// handleLock handles HTTP POST requests to the lock/ server endpoint. It expects a `ds` query param naming the dataset to lock, and optionally `ttl` (a Go duration string) and `token` (to renew a lock already held). The response body is the lock token on success; if another client holds the lock the response is 409.
+func (dl *datasetLocks) handleLock(w http.ResponseWriter, req *http.Request, ps URLParams, cs chunks.ChunkStore) {
+	if req.Method != "POST" {
+		d.Panic("Expected post method.")
+	}
+
+	params := req.URL.Query()
+	datasetID := params.Get("ds")
+	if !DatasetFullRe.MatchString(datasetID) {
+		d.Panic("Invalid dataset ID: %s", datasetID)
+	}
+	ttl := DefaultDatasetLockTTL
+	if s := params.Get("ttl"); s != "" {
+		var err error
+		ttl, err = time.ParseDuration(s)
+		d.PanicIfError(err)
+		if ttl <= 0 {
+			d.Panic("ttl must be positive")
+		}
+	}
+
+	token, ok := dl.acquire(datasetID, params.Get("token"), ttl, time.Now())
+	if !ok {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	w.Header().Add("content-type", "text/plain")
+	fmt.Fprint(w, token)
+}
+
+// handleUnlock handles HTTP POST requests to the unlock/ server endpoint. It expects `ds` and `token` query params. If the lock on `ds` is held with a different token the response is 409.
+func (dl *datasetLocks) handleUnlock(w http.ResponseWriter, req *http.Request, ps URLParams, cs chunks.ChunkStore) {
+	if req.Method != "POST" {
+		d.Panic("Expected post method.")
+	}
+
+	params := req.URL.Query()
+	datasetID := params.Get("ds")
+	if datasetID == "" {
+		d.Panic(`Expected "ds" query param value`)
+	}
+	if !dl.release(datasetID, params.Get("token"), time.Now()) {
+		w.WriteHeader(http.StatusConflict)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestDatasetLocksExpire(t *testing.T) {
+	assert := assert.New(t)
+	dl := newDatasetLocks()
+	now := time.Now()
+
+	token, ok := dl.acquire("ds", "", time.Minute, now)
+	assert.True(ok)
+	_, ok = dl.acquire("ds", "", time.Minute, now.Add(time.Second))
+	assert.False(ok)
+
+	// Once the lock has expired anyone can take it, and the old token no longer releases it.
+	token2, ok := dl.acquire("ds", "", time.Minute, now.Add(2*time.Minute))
+	assert.True(ok)
+	assert.NotEqual(token, token2)
+	assert.False(dl.release("ds", token, now.Add(2*time.Minute)))
+	assert.True(dl.release("ds", token2, now.Add(2*time.Minute)))
+}
+
+func TestHandleLock(t *testing.T) {
+	assert := assert.New(t)
+	cs := chunks.NewTestStore()
+	dl := newDatasetLocks()
+	handleLock := createHandler(dl.handleLock, true)
+	handleUnlock := createHandler(dl.handleUnlock, true)
+
+	w := httptest.NewRecorder()
+	handleLock(w, newRequest("POST", "", "/lock/?ds=ds1", nil, nil), params{}, cs)
+	assert.Equal(http.StatusOK, w.Code, "Handler error:\n%s", string(w.Body.Bytes()))
+	token := w.Body.String()
+	assert.NotEmpty(token)
+
+	w = httptest.NewRecorder()
+	handleLock(w, newRequest("POST", "", "/lock/?ds=ds1", nil, nil), params{}, cs)
+	assert.Equal(http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	handleUnlock(w, newRequest("POST", "", "/unlock/?ds=ds1&token=bogus", nil, nil), params{}, cs)
+	assert.Equal(http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	handleUnlock(w, newRequest("POST", "", "/unlock/?ds=ds1&token="+token, nil, nil), params{}, cs)
+	assert.Equal(http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	handleLock(w, newRequest("POST", "", "/lock/?ds=ds1&ttl=-1s", nil, nil), params{}, cs)
+	assert.Equal(http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	handleLock(w, newRequest("POST", "", "/lock/?ds=not%20valid", nil, nil), params{}, cs)
+	assert.Equal(http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,212 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/hash"
+	"github.com/attic-labs/noms/go/types"
+)
+
+// DatasetAccess counts the reads and writes of a dataset served by a RemoteDatabaseServer.
+type DatasetAccess struct {
+	Reads  uint64 `json:"reads"`
+	Writes uint64 `json:"writes"`
+	// LastRead and LastWrite are zero if the dataset hasn't been read or written.
+	LastRead  time.Time `json:"lastRead"`
+	LastWrite time.Time `json:"lastWrite"`
+}
+
+// DatasetStats are the DatasetAccess of each dataset served by a RemoteDatabaseServer since Since, when it started. Every dataset in the database is listed, as are datasets which were deleted since then.
+type DatasetStats struct {
+	Since    time.Time                `json:"since"`
+	Datasets map[string]DatasetAccess `json:"datasets"`
+}
+
+// datasetStats tracks the DatasetStats of a RemoteDatabaseServer. Clients of the server read and write chunks and the root, not datasets, so a read of a dataset is counted when a client reads the chunk of its head commit, which GetDataset() does, and so does Commit(), to return the new Dataset, as does a GraphQL query of the dataset. A client which already has that chunk, e.g. because another dataset has the same head, doesn't read it again. A write of a dataset is counted when the root is updated to give it a different head, or to delete it.
+type datasetStats struct {
+	// mu guards access, which is only locked to count an access of a dataset, not on every read of a chunk.
+	mu     *sync.Mutex
+	since  time.Time
+	access map[string]DatasetAccess
+	// latest is the *rootHeads of the latest root that's been read or written. Reads are of the datasets at latest, which saves getting the root of the ChunkStore, which may be remote, on every read. Its heads are only read when the root changes, under headsMu.
+	latest  atomic.Value
+	headsMu *sync.Mutex
+	now     func() time.Time
+}
+
+// rootHeads are the datasets at root by their heads, or nil if they haven't been read yet.
+type rootHeads struct {
+	root  hash.Hash
+	heads map[hash.Hash][]string
+}
+
+func newDatasetStats(root hash.Hash) *datasetStats {
+	s := &datasetStats{mu: &sync.Mutex{}, since: time.Now(), access: map[string]DatasetAccess{}, headsMu: &sync.Mutex{}, now: time.Now}
+	s.latest.Store(&rootHeads{root: root})
+	return s
+}
+
+// datasetHeads returns the datasets of the root of cs, root, by their heads.
+func datasetHeads(cs chunks.ChunkStore, root hash.Hash) map[hash.Hash][]string {
+	heads := map[hash.Hash][]string{}
+	if root.IsEmpty() {
+		return heads
+	}
+	// The root Map may be chunked, so its chunks are read from cs.
+	m, ok := types.NewValueStore(types.NewBatchStoreAdaptor(cs)).ReadValue(root).(types.Map)
+	if !ok {
+		return heads
+	}
+	m.IterAll(func(k, v types.Value) {
+		h := v.(types.Ref).TargetHash()
+		heads[h] = append(heads[h], string(k.(types.String)))
+	})
+	return heads
+}
+
+// headsAt returns the datasets by their heads at root, and makes root the latest root. They're read from cs only if root isn't the latest root, or they haven't been read yet.
+func (s *datasetStats) headsAt(cs chunks.ChunkStore, root hash.Hash) map[hash.Hash][]string {
+	if rh := s.latest.Load().(*rootHeads); rh.root == root && rh.heads != nil {
+		return rh.heads
+	}
+	s.headsMu.Lock()
+	defer s.headsMu.Unlock()
+	rh := s.latest.Load().(*rootHeads)
+	if rh.root != root || rh.heads == nil {
+		rh = &rootHeads{root, datasetHeads(cs, root)}
+		s.latest.Store(rh)
+	}
+	return rh.heads
+}
+
+// rootRead records that root, of cs, is the latest root.
+func (s *datasetStats) rootRead(cs chunks.ChunkStore, root hash.Hash) {
+	if s.latest.Load().(*rootHeads).root != root {
+		s.headsAt(cs, root)
+	}
+}
+
+// read counts a read of the datasets whose head is one of hashes, which were read from cs.
+func (s *datasetStats) read(cs chunks.ChunkStore, hashes hash.HashSet) {
+	heads := s.headsAt(cs, s.latest.Load().(*rootHeads).root)
+	ids := []string{}
+	for h := range hashes {
+		ids = append(ids, heads[h]...)
+	}
+	if len(ids) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for _, id := range ids {
+		a := s.access[id]
+		a.Reads++
+		a.LastRead = now
+		s.access[id] = a
+	}
+}
+
+// rootUpdated counts a write of each dataset whose head differs between the roots last and current of cs.
+func (s *datasetStats) rootUpdated(cs chunks.ChunkStore, current, last hash.Hash) {
+	byID := func(heads map[hash.Hash][]string) map[string]hash.Hash {
+		m := map[string]hash.Hash{}
+		for h, ids := range heads {
+			for _, id := range ids {
+				m[id] = h
+			}
+		}
+		return m
+	}
+	before := byID(s.headsAt(cs, last))
+	after := byID(s.headsAt(cs, current))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	write := func(id string) {
+		a := s.access[id]
+		a.Writes++
+		a.LastWrite = now
+		s.access[id] = a
+	}
+	for id, h := range after {
+		if before[id] != h {
+			write(id)
+		}
+	}
+	for id := range before {
+		if _, ok := after[id]; !ok {
+			write(id)
+		}
+	}
+}
+
+// stats returns the DatasetStats of the datasets in the database at the root of cs, and those which have been accessed.
+func (s *datasetStats) stats(cs chunks.ChunkStore) DatasetStats {
+	heads := s.headsAt(cs, cs.Root())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	datasets := map[string]DatasetAccess{}
+	for _, ids := range heads {
+		for _, id := range ids {
+			datasets[id] = DatasetAccess{}
+		}
+	}
+	for id, a := range s.access {
+		datasets[id] = a
+	}
+	return DatasetStats{s.since, datasets}
+}
+
+// handleStats handles HTTP GET requests to the stats/ server endpoint. The response is the server's DatasetStats, as JSON.
+func (s *datasetStats) handleStats(w http.ResponseWriter, req *http.Request, ps URLParams, cs chunks.ChunkStore) {
+	if req.Method != "GET" {
+		d.Panic("Expected get method.")
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	d.PanicIfError(json.NewEncoder(w).Encode(s.stats(cs)))
+}
+
+// statsStore is the ChunkStore of a RemoteDatabaseServer, as passed to its handlers, which counts the reads and writes of datasets in stats.
+type statsStore struct {
+	chunks.ChunkStore
+	stats *datasetStats
+}
+
+func (ss statsStore) Get(h hash.Hash) chunks.Chunk {
+	c := ss.ChunkStore.Get(h)
+	if !c.IsEmpty() {
+		ss.stats.read(ss.ChunkStore, hash.HashSet{h: struct{}{}})
+	}
+	return c
+}
+
+func (ss statsStore) GetMany(hashes hash.HashSet, foundChunks chan *chunks.Chunk) {
+	ss.ChunkStore.GetMany(hashes, foundChunks)
+	ss.stats.read(ss.ChunkStore, hashes)
+}
+
+func (ss statsStore) Root() hash.Hash {
+	root := ss.ChunkStore.Root()
+	ss.stats.rootRead(ss.ChunkStore, root)
+	return root
+}
+
+func (ss statsStore) UpdateRoot(current, last hash.Hash) bool {
+	if !ss.ChunkStore.UpdateRoot(current, last) {
+		return false
+	}
+	ss.stats.rootUpdated(ss.ChunkStore, current, last)
+	return true
+}
@@ -0,0 +1,96 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestDatasetStats(t *testing.T) {
+	assert := assert.New(t)
+	server := NewRemoteDatabaseServer(chunks.NewTestStore(), 0)
+	now := time.Unix(1000, 0).UTC()
+	server.stats.now = func() time.Time { return now }
+	ready := make(chan struct{})
+	server.Ready = func() { close(ready) }
+	go server.Run()
+	defer server.Stop()
+	<-ready
+	// Each client has a cache of its own.
+	newClient := func() *RemoteDatabaseClient {
+		return NewRemoteDatabase(fmt.Sprintf("http://localhost:%d", server.Port()), "")
+	}
+
+	db := newClient()
+	_, err := db.CommitValue(db.GetDataset("ds1"), types.String("a"))
+	assert.NoError(err)
+	_, err = db.CommitValue(db.GetDataset("ds2"), types.String("b"))
+	assert.NoError(err)
+	_, err = db.CommitValue(db.GetDataset("gone"), types.String("c"))
+	assert.NoError(err)
+	_, err = db.Delete(db.GetDataset("gone"))
+	assert.NoError(err)
+
+	now = now.Add(time.Minute)
+	for i := 0; i < 2; i++ {
+		assert.True(types.String("a").Equals(newClient().GetDataset("ds1").HeadValue()))
+	}
+
+	s, err := db.DatasetStats()
+	assert.NoError(err)
+	// Committing reads the new head back, to return the Dataset.
+	before := now.Add(-time.Minute)
+	assert.Equal(DatasetAccess{Reads: 3, Writes: 1, LastRead: now, LastWrite: before}, s.Datasets["ds1"])
+	assert.Equal(DatasetAccess{Reads: 1, Writes: 1, LastRead: before, LastWrite: before}, s.Datasets["ds2"])
+	assert.Equal(DatasetAccess{Reads: 1, Writes: 2, LastRead: before, LastWrite: before}, s.Datasets["gone"])
+	assert.Len(s.Datasets, 3)
+}
+
+func TestHandleStats(t *testing.T) {
+	assert := assert.New(t)
+	cs := chunks.NewTestStore()
+	db := NewDatabase(cs)
+	_, err := db.CommitValue(db.GetDataset("ds1"), types.String("a"))
+	assert.NoError(err)
+
+	// A dataset which hasn't been accessed is listed too.
+	stats := newDatasetStats(cs.Root())
+	w := httptest.NewRecorder()
+	createHandler(stats.handleStats, false)(w, newRequest("GET", "", "/stats/", nil, nil), params{}, cs)
+	assert.Equal(http.StatusOK, w.Code, "Handler error:\n%s", string(w.Body.Bytes()))
+	s := DatasetStats{}
+	assert.NoError(json.Unmarshal(w.Body.Bytes(), &s))
+	assert.Equal(map[string]DatasetAccess{"ds1": {}}, s.Datasets)
+}
+
+func TestDatasetStatsChunkedRoot(t *testing.T) {
+	assert := assert.New(t)
+	cs := chunks.NewTestStore()
+	db := NewDatabase(cs)
+
+	// So many datasets that the root Map is chunked.
+	commits := make([]DatasetCommit, 500)
+	for i := range commits {
+		commits[i] = DatasetCommit{Dataset: db.GetDataset(fmt.Sprintf("ds%d", i)), Value: types.Number(i)}
+	}
+	datasets, err := db.CommitMany(commits)
+	assert.NoError(err)
+
+	stats := newDatasetStats(cs.Root())
+	ss := statsStore{cs, stats}
+	ss.Get(datasets[42].HeadRef().TargetHash())
+	s := stats.stats(cs)
+	assert.Len(s.Datasets, 500)
+	assert.Equal(uint64(1), s.Datasets["ds42"].Reads)
+}
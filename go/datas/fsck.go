@@ -0,0 +1,145 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/hash"
+	"github.com/attic-labs/noms/go/types"
+)
+
+var (
+	// ErrChunkMissing is the Err of an FsckProblem for a chunk which is referred to but isn't in the Database.
+	ErrChunkMissing = errors.New("Chunk is missing")
+	// ErrHashMismatch is the Err of an FsckProblem for a chunk whose content doesn't hash to its hash.
+	ErrHashMismatch = errors.New("Chunk content doesn't match its hash")
+)
+
+// FsckProblem is a chunk which Fsck found to be missing or corrupt.
+type FsckProblem struct {
+	// Hash is the hash of the chunk.
+	Hash hash.Hash
+	// Parent is the hash of a chunk which refers to it, or the empty hash if
+	// it's the root of the Database.
+	Parent hash.Hash
+	// Err is ErrChunkMissing, ErrHashMismatch, or the error decoding it.
+	Err error
+}
+
+func (p FsckProblem) Error() string {
+	if p.Parent.IsEmpty() {
+		return fmt.Sprintf("%s (root): %s", p.Hash, p.Err)
+	}
+	return fmt.Sprintf("%s (referred to by %s): %s", p.Hash, p.Parent, p.Err)
+}
+
+// FsckProgress reports the progress of Fsck.
+type FsckProgress struct {
+	// Checked is the number of chunks checked so far, and Bytes their total size.
+	Checked, Bytes uint64
+	// Pending is the number of chunks known to be reachable which haven't
+	// been checked yet. It grows as the walk discovers more.
+	Pending uint64
+	// Problems is the number of FsckProblems found so far.
+	Problems uint64
+}
+
+type fsckWork struct {
+	h, parent hash.Hash
+}
+
+// Fsck checks every chunk reachable from the root of db, which are all the
+// chunks of its datasets and their histories: that each is present, that its
+// content matches its hash, and that it decodes. The chunks each Ref refers to
+// are checked in turn, so a missing chunk is reported along with a chunk which
+// refers to it, and nothing beneath a missing or corrupt chunk is checked.
+//
+// Chunks are read and checked by workers goroutines at a time. If progress
+// isn't nil, it's called after each chunk is checked. Calls to progress are
+// never concurrent.
+func Fsck(db Database, workers int, progress func(FsckProgress)) []FsckProblem {
+	d.PanicIfFalse(workers > 0)
+	bs := db.validatingBatchStore()
+	root := bs.Root()
+	if root.IsEmpty() {
+		return nil
+	}
+
+	mu := &sync.Mutex{}
+	problems := []FsckProblem{}
+	p := FsckProgress{Pending: 1}
+	visited := hash.NewHashSet(root)
+
+	for level := []fsckWork{{h: root}}; len(level) > 0; {
+		next := []fsckWork{}
+		work := make(chan fsckWork)
+		go func(level []fsckWork) {
+			for _, w := range level {
+				work <- w
+			}
+			close(work)
+		}(level)
+
+		wg := &sync.WaitGroup{}
+		wg.Add(workers)
+		for i := 0; i < workers; i++ {
+			go func() {
+				defer wg.Done()
+				for w := range work {
+					refs, size, err := fsckChunk(db, w.h)
+
+					mu.Lock()
+					p.Checked++
+					p.Bytes += uint64(size)
+					p.Pending--
+					if err != nil {
+						problems = append(problems, FsckProblem{w.h, w.parent, err})
+						p.Problems++
+					}
+					for _, r := range refs {
+						if !visited.Has(r) {
+							visited.Insert(r)
+							next = append(next, fsckWork{r, w.h})
+							p.Pending++
+						}
+					}
+					if progress != nil {
+						progress(p)
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		level = next
+	}
+	return problems
+}
+
+// fsckChunk checks the chunk with hash h, returning the size of the chunk and the hashes it refers to.
+func fsckChunk(db Database, h hash.Hash) (refs []hash.Hash, size int, err error) {
+	c := db.validatingBatchStore().Get(h)
+	if c.IsEmpty() {
+		return nil, 0, ErrChunkMissing
+	}
+	size = len(c.Data())
+	if hash.Of(c.Data()) != h {
+		return nil, size, ErrHashMismatch
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			refs, err = nil, fmt.Errorf("Chunk doesn't decode: %v", r)
+		}
+	}()
+	types.DecodeFromBytes(c.Data(), db).WalkRefs(func(r types.Ref) {
+		refs = append(refs, r.TargetHash())
+	})
+	return refs, size, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"testing"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/hash"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+// hidingStore is a ChunkStore from which some chunks have gone missing.
+type hidingStore struct {
+	*chunks.MemoryStore
+	hidden hash.HashSet
+}
+
+func (s hidingStore) Get(h hash.Hash) chunks.Chunk {
+	if s.hidden.Has(h) {
+		return chunks.EmptyChunk
+	}
+	return s.MemoryStore.Get(h)
+}
+
+func (s hidingStore) Has(h hash.Hash) bool {
+	return !s.hidden.Has(h) && s.MemoryStore.Has(h)
+}
+
+func TestFsck(t *testing.T) {
+	assert := assert.New(t)
+	cs := chunks.NewMemoryStore()
+	db := NewDatabase(cs)
+
+	assert.Empty(Fsck(db, 1, nil))
+
+	values := make(types.ValueSlice, 10000)
+	for i := range values {
+		values[i] = types.Number(i)
+	}
+	ds, err := db.CommitValue(db.GetDataset("ds"), types.NewList(values...))
+	assert.NoError(err)
+	ds, err = db.CommitValue(ds, types.NewList(values[:5000]...))
+	assert.NoError(err)
+	s := db.WriteValue(types.String("leaf"))
+	_, err = db.CommitValue(db.GetDataset("other"), types.NewList(s))
+	assert.NoError(err)
+
+	calls := 0
+	last := FsckProgress{}
+	assert.Empty(Fsck(db, 4, func(p FsckProgress) {
+		calls++
+		last = p
+	}))
+	assert.Equal(uint64(calls), last.Checked)
+	assert.True(last.Checked > 10, "%+v", last)
+	assert.True(last.Bytes > 0)
+	assert.Equal(uint64(0), last.Pending)
+	assert.Equal(uint64(0), last.Problems)
+
+	// A missing chunk is reported along with the commit which refers to it.
+	hs := hidingStore{cs, hash.NewHashSet(s.TargetHash())}
+	problems := Fsck(NewDatabase(hs), 4, nil)
+	other := db.GetDataset("other").HeadRef().TargetHash()
+	assert.Equal([]FsckProblem{{s.TargetHash(), other, ErrChunkMissing}}, problems)
+
+	// So is a chunk whose content doesn't match its hash, or doesn't decode.
+	cs.Put(chunks.NewChunkWithHash(s.TargetHash(), types.EncodeValue(types.String("changed"), nil).Data()))
+	problems = Fsck(NewDatabase(cs), 4, nil)
+	assert.Equal([]FsckProblem{{s.TargetHash(), other, ErrHashMismatch}}, problems)
+
+	garbage := chunks.NewChunk([]byte{0xff, 0xff, 0xff})
+	cs.Put(garbage)
+	assert.True(cs.UpdateRoot(garbage.Hash(), cs.Root()))
+	problems = Fsck(NewDatabase(cs), 4, nil)
+	assert.Len(problems, 1)
+	assert.Equal(garbage.Hash(), problems[0].Hash)
+	assert.True(problems[0].Parent.IsEmpty())
+	assert.Contains(problems[0].Error(), "(root): Chunk doesn't decode")
+}
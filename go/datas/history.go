@@ -0,0 +1,86 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"errors"
+
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/types"
+)
+
+var (
+	// ErrNotAncestor is returned by Squash if the base commit isn't an ancestor of the dataset's head.
+	ErrNotAncestor = errors.New("Base commit is not an ancestor of the dataset head")
+	// ErrMergeInHistory is returned by TruncateHistory if one of the commits it would rewrite is a merge.
+	ErrMergeInHistory = errors.New("Can't rewrite history containing a merge commit")
+)
+
+// Squash replaces all the commits in the history of ds since base with a single commit of the current head's value, whose only parent is base and whose metadata is meta. If base is the zero Ref, the new commit has no parents, so it replaces all of ds's history. This keeps frequently updated datasets, e.g. ones which are re-imported periodically, from accumulating long commit chains.
+//
+// The new head is set with SetHead, because it doesn't descend from the old one. The replaced commits are no longer reachable from ds, but they remain in the database.
+func Squash(db Database, ds Dataset, base types.Ref, meta types.Struct) (Dataset, error) {
+	headRef, ok := ds.MaybeHeadRef()
+	if !ok {
+		return ds, nil
+	}
+
+	parents := types.NewSet()
+	if (base != types.Ref{}) {
+		if !IsAncestor(base, headRef, db) {
+			return ds, ErrNotAncestor
+		}
+		if base.TargetHash() == headRef.TargetHash() {
+			return ds, nil
+		}
+		parents = parents.Insert(base)
+	} else if ds.Head().Get(ParentsField).(types.Set).Empty() {
+		return ds, nil
+	}
+
+	if meta.IsZeroValue() {
+		meta = types.EmptyStruct
+	}
+	r := db.WriteValue(NewCommit(ds.HeadValue(), parents, meta))
+	return db.SetHead(ds, r)
+}
+
+// TruncateHistory rewrites the history of ds so that only its newest keep commits remain, the oldest of which has no parents. Their values and metadata are unchanged, but since their parents change, so do their hashes. If ds has keep commits or fewer, it is returned unchanged. None of the commits which are rewritten, other than the oldest, may be merges.
+//
+// As with Squash, the new head is set with SetHead, and the dropped commits remain in the database.
+func TruncateHistory(db Database, ds Dataset, keep int) (Dataset, error) {
+	d.PanicIfFalse(keep > 0)
+	head, ok := ds.MaybeHead()
+	if !ok {
+		return ds, nil
+	}
+
+	// Walk back from the head to find the commits to keep, newest first.
+	commits := []types.Struct{head}
+	for len(commits) < keep {
+		parents := commits[len(commits)-1].Get(ParentsField).(types.Set)
+		switch parents.Len() {
+		case 0:
+			return ds, nil
+		case 1:
+			commits = append(commits, parents.First().(types.Ref).TargetValue(db).(types.Struct))
+		default:
+			return ds, ErrMergeInHistory
+		}
+	}
+	if commits[keep-1].Get(ParentsField).(types.Set).Empty() {
+		return ds, nil
+	}
+
+	// Rebuild them oldest first, each pointing at the rewritten version of its parent.
+	parents := types.NewSet()
+	var r types.Ref
+	for i := keep - 1; i >= 0; i-- {
+		c := commits[i]
+		r = db.WriteValue(NewCommit(c.Get(ValueField), parents, c.Get(MetaField).(types.Struct)))
+		parents = types.NewSet(r)
+	}
+	return db.SetHead(ds, r)
+}
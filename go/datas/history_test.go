@@ -0,0 +1,106 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"testing"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+func commitNumbers(assert *assert.Assertions, db Database, ds Dataset, from, to int) (Dataset, []types.Ref) {
+	refs := []types.Ref{}
+	for i := from; i < to; i++ {
+		var err error
+		ds, err = db.Commit(ds, types.Number(i), CommitOptions{Meta: types.NewStruct("Meta", types.StructData{"i": types.Number(i)})})
+		assert.NoError(err)
+		refs = append(refs, ds.HeadRef())
+	}
+	return ds, refs
+}
+
+func historyLen(ds Dataset) (n int) {
+	for c, ok := ds.MaybeHead(); ok; {
+		n++
+		parents := c.Get(ParentsField).(types.Set)
+		if parents.Empty() {
+			break
+		}
+		c = parents.First().(types.Ref).TargetValue(ds.Database()).(types.Struct)
+	}
+	return
+}
+
+func TestSquash(t *testing.T) {
+	assert := assert.New(t)
+	db := NewDatabase(chunks.NewMemoryStore())
+	defer db.Close()
+
+	ds, refs := commitNumbers(assert, db, db.GetDataset("ds"), 0, 10)
+	assert.Equal(10, historyLen(ds))
+
+	ds, err := Squash(db, ds, refs[4], types.EmptyStruct)
+	assert.NoError(err)
+	assert.True(ds.HeadValue().Equals(types.Number(9)))
+	assert.Equal(6, historyLen(ds))
+	assert.True(ds.Head().Get(ParentsField).Equals(types.NewSet(refs[4])))
+
+	// Later commits build on the squashed head as usual.
+	ds, _ = commitNumbers(assert, db, ds, 10, 11)
+	assert.Equal(7, historyLen(ds))
+
+	// Squashing onto a commit which isn't an ancestor fails.
+	other, otherRefs := commitNumbers(assert, db, db.GetDataset("other"), 100, 101)
+	_, err = Squash(db, ds, otherRefs[0], types.EmptyStruct)
+	assert.Equal(ErrNotAncestor, err)
+	_, err = Squash(db, ds, refs[7], types.EmptyStruct) // no longer in ds's history
+	assert.Equal(ErrNotAncestor, err)
+
+	// With no base, all history is replaced.
+	ds, err = Squash(db, ds, types.Ref{}, types.EmptyStruct)
+	assert.NoError(err)
+	assert.Equal(1, historyLen(ds))
+	assert.True(ds.HeadValue().Equals(types.Number(10)))
+	assert.True(other.HeadValue().Equals(types.Number(100)))
+}
+
+func TestTruncateHistory(t *testing.T) {
+	assert := assert.New(t)
+	db := NewDatabase(chunks.NewMemoryStore())
+	defer db.Close()
+
+	ds, _ := commitNumbers(assert, db, db.GetDataset("ds"), 0, 10)
+	head := ds.HeadRef()
+
+	ds, err := TruncateHistory(db, ds, 10)
+	assert.NoError(err)
+	assert.Equal(head, ds.HeadRef())
+
+	ds, err = TruncateHistory(db, ds, 3)
+	assert.NoError(err)
+	assert.Equal(3, historyLen(ds))
+	c := ds.Head()
+	for i := 9; i >= 7; i-- {
+		assert.True(c.Get(ValueField).Equals(types.Number(i)))
+		assert.True(c.Get(MetaField).(types.Struct).Get("i").Equals(types.Number(i)))
+		if parents := c.Get(ParentsField).(types.Set); !parents.Empty() {
+			c = parents.First().(types.Ref).TargetValue(db).(types.Struct)
+		}
+	}
+
+	// Merges can't be rewritten.
+	a, _ := commitNumbers(assert, db, db.GetDataset("a"), 0, 1)
+	b, _ := commitNumbers(assert, db, db.GetDataset("b"), 1, 2)
+	merged, err := db.Commit(a, types.Number(2), CommitOptions{Parents: types.NewSet(a.HeadRef(), b.HeadRef())})
+	assert.NoError(err)
+	merged, _ = commitNumbers(assert, db, merged, 3, 4)
+	_, err = TruncateHistory(db, merged, 3)
+	assert.Equal(ErrMergeInHistory, err)
+	merged, err = TruncateHistory(db, merged, 2)
+	assert.NoError(err)
+	assert.Equal(2, historyLen(merged))
+}
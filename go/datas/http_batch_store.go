@@ -8,6 +8,7 @@ import (
 	"bufio"
 	"bytes"
 	"compress/gzip"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -22,6 +23,7 @@ import (
 	"github.com/attic-labs/noms/go/d"
 	"github.com/attic-labs/noms/go/hash"
 	"github.com/attic-labs/noms/go/nbs"
+	"github.com/attic-labs/noms/go/types"
 	"github.com/attic-labs/noms/go/util/verbose"
 	"github.com/golang/snappy"
 	"github.com/julienschmidt/httprouter"
@@ -54,6 +56,9 @@ type httpBatchStore struct {
 
 	cacheMu       *sync.RWMutex
 	unwrittenPuts *nbs.NomsBlockCache
+
+	retry   RetryPolicy
+	metrics types.ValueStoreMetrics
 }
 
 func NewHTTPBatchStore(baseURL, auth string) *httpBatchStore {
@@ -75,6 +80,7 @@ func NewHTTPBatchStore(baseURL, auth string) *httpBatchStore {
 		workerWg:      &sync.WaitGroup{},
 		cacheMu:       &sync.RWMutex{},
 		unwrittenPuts: nbs.NewCache(),
+		retry:         DefaultRetryPolicy,
 	}
 	buffSink.batchGetRequests()
 	buffSink.batchHasRequests()
@@ -85,6 +91,12 @@ type httpDoer interface {
 	Do(req *http.Request) (resp *http.Response, err error)
 }
 
+// SetMetrics installs m to be notified of retried requests. ValueStore
+// calls it from its own SetMetrics.
+func (bhcs *httpBatchStore) SetMetrics(m types.ValueStoreMetrics) {
+	bhcs.metrics = m
+}
+
 func (bhcs *httpBatchStore) Flush() {
 	bhcs.sendWriteRequests()
 	bhcs.requestWg.Wait()
@@ -169,6 +181,42 @@ func (bhcs *httpBatchStore) Has(h hash.Hash) bool {
 	return <-ch
 }
 
+// HasMany returns the subset of hashes which are present. Requests for each hash are queued together, so they are sent to the server in as few batches as possible.
+func (bhcs *httpBatchStore) HasMany(hashes hash.HashSet) (present hash.HashSet) {
+	present = hash.HashSet{}
+	remaining := hash.HashSet{}
+	func() {
+		bhcs.cacheMu.RLock()
+		defer bhcs.cacheMu.RUnlock()
+		for h := range hashes {
+			if bhcs.unwrittenPuts.Has(h) {
+				present.Insert(h)
+			} else {
+				remaining.Insert(h)
+			}
+		}
+	}()
+
+	mu := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	for h := range remaining {
+		ch := make(chan bool)
+		wg.Add(1)
+		go func(h hash.Hash) {
+			defer wg.Done()
+			if <-ch {
+				mu.Lock()
+				defer mu.Unlock()
+				present.Insert(h)
+			}
+		}(h)
+		bhcs.requestWg.Add(1)
+		bhcs.hasQueue <- chunks.NewHasRequest(h, ch)
+	}
+	wg.Wait()
+	return
+}
+
 func (bhcs *httpBatchStore) batchHasRequests() {
 	bhcs.batchReadRequests(bhcs.hasQueue, bhcs.hasRefs)
 }
@@ -238,12 +286,12 @@ func (bhcs *httpBatchStore) getRefs(hashes hash.HashSet, batch chunks.ReadBatch)
 	u := *bhcs.host
 	u.Path = httprouter.CleanPath(bhcs.host.Path + constants.GetRefsPath)
 
-	req := newRequest("POST", bhcs.auth, u.String(), buildHashesRequest(hashes), http.Header{
-		"Accept-Encoding": {"x-snappy-framed"},
-		"Content-Type":    {"application/x-www-form-urlencoded"},
-	})
-
-	res, err := bhcs.httpClient.Do(req)
+	res, _, err := bhcs.do(func() *http.Request {
+		return newRequest("POST", bhcs.auth, u.String(), buildHashesRequest(hashes), http.Header{
+			"Accept-Encoding": {"x-snappy-framed"},
+			"Content-Type":    {"application/x-www-form-urlencoded"},
+		})
+	}, true)
 	d.Chk.NoError(err)
 	expectVersion(res)
 	reader := resBodyReader(res)
@@ -269,12 +317,12 @@ func (bhcs *httpBatchStore) hasRefs(hashes hash.HashSet, batch chunks.ReadBatch)
 	u := *bhcs.host
 	u.Path = httprouter.CleanPath(bhcs.host.Path + constants.HasRefsPath)
 
-	req := newRequest("POST", bhcs.auth, u.String(), buildHashesRequest(hashes), http.Header{
-		"Accept-Encoding": {"x-snappy-framed"},
-		"Content-Type":    {"application/x-www-form-urlencoded"},
-	})
-
-	res, err := bhcs.httpClient.Do(req)
+	res, _, err := bhcs.do(func() *http.Request {
+		return newRequest("POST", bhcs.auth, u.String(), buildHashesRequest(hashes), http.Header{
+			"Accept-Encoding": {"x-snappy-framed"},
+			"Content-Type":    {"application/x-www-form-urlencoded"},
+		})
+	}, true)
 	d.Chk.NoError(err)
 	expectVersion(res)
 	reader := resBodyReader(res)
@@ -341,23 +389,25 @@ func (bhcs *httpBatchStore) sendWriteRequests() {
 	}()
 
 	verbose.Log("Sending %d chunks", count)
-	chunkChan := make(chan *chunks.Chunk, 1024)
-	go func() {
-		bhcs.unwrittenPuts.ExtractChunks(chunkChan)
-		close(chunkChan)
-	}()
-
-	body := buildWriteValueRequest(chunkChan)
 	url := *bhcs.host
 	url.Path = httprouter.CleanPath(bhcs.host.Path + constants.WriteValuePath)
-	// TODO: Make this accept snappy encoding
-	req := newRequest("POST", bhcs.auth, url.String(), body, http.Header{
-		"Accept-Encoding":  {"gzip"},
-		"Content-Encoding": {"x-snappy-framed"},
-		"Content-Type":     {"application/octet-stream"},
-	})
-
-	res, err := bhcs.httpClient.Do(req)
+
+	// Writing chunks is idempotent, so a failed write is retried by extracting them all again.
+	res, _, err := bhcs.do(func() *http.Request {
+		chunkChan := make(chan *chunks.Chunk, 1024)
+		go func() {
+			bhcs.unwrittenPuts.ExtractChunks(chunkChan)
+			close(chunkChan)
+		}()
+
+		body := buildWriteValueRequest(chunkChan)
+		// TODO: Make this accept snappy encoding
+		return newRequest("POST", bhcs.auth, url.String(), body, http.Header{
+			"Accept-Encoding":  {"gzip"},
+			"Content-Encoding": {"x-snappy-framed"},
+			"Content-Type":     {"application/octet-stream"},
+		})
+	}, true)
 	d.PanicIfError(err)
 	expectVersion(res)
 	defer closeResponse(res.Body)
@@ -370,7 +420,7 @@ func (bhcs *httpBatchStore) sendWriteRequests() {
 
 func (bhcs *httpBatchStore) Root() hash.Hash {
 	// GET http://<host>/root. Response will be ref of root.
-	res := bhcs.requestRoot("GET", hash.Hash{}, hash.Hash{})
+	res, _ := bhcs.requestRoot("GET", hash.Hash{}, hash.Hash{})
 	expectVersion(res)
 	defer closeResponse(res.Body)
 
@@ -387,7 +437,7 @@ func (bhcs *httpBatchStore) UpdateRoot(current, last hash.Hash) bool {
 	// POST http://<host>/root?current=<ref>&last=<ref>. Response will be 200 on success, 409 if current is outdated.
 	bhcs.Flush()
 
-	res := bhcs.requestRoot("POST", current, last)
+	res, retried := bhcs.requestRoot("POST", current, last)
 	expectVersion(res)
 	defer closeResponse(res.Body)
 
@@ -395,7 +445,8 @@ func (bhcs *httpBatchStore) UpdateRoot(current, last hash.Hash) bool {
 	case http.StatusOK:
 		return true
 	case http.StatusConflict:
-		return false
+		// If an earlier attempt updated the root, but its response was lost, the retry conflicts with it.
+		return retried && bhcs.Root() == current
 	default:
 		buf := bytes.Buffer{}
 		buf.ReadFrom(res.Body)
@@ -408,7 +459,8 @@ func (bhcs *httpBatchStore) UpdateRoot(current, last hash.Hash) bool {
 	}
 }
 
-func (bhcs *httpBatchStore) requestRoot(method string, current, last hash.Hash) *http.Response {
+// requestRoot gets or updates the root. retried is true if the request was retried, so that an update may have been made by an earlier attempt.
+func (bhcs *httpBatchStore) requestRoot(method string, current, last hash.Hash) (res *http.Response, retried bool) {
 	u := *bhcs.host
 	u.Path = httprouter.CleanPath(bhcs.host.Path + constants.RootPath)
 	if method == "POST" {
@@ -421,11 +473,92 @@ func (bhcs *httpBatchStore) requestRoot(method string, current, last hash.Hash)
 		u.RawQuery = params.Encode()
 	}
 
-	req := newRequest(method, bhcs.auth, u.String(), nil, nil)
-
-	res, err := bhcs.httpClient.Do(req)
+	res, retried, err := bhcs.do(func() *http.Request {
+		return newRequest(method, bhcs.auth, u.String(), nil, nil)
+	}, true)
 	d.PanicIfError(err)
 
+	return res, retried
+}
+
+// lockDataset asks the server for the advisory write lock on datasetID. If token is non-empty, a lock already held with that token is renewed. Returns the lock token, or ok=false if someone else holds the lock.
+func (bhcs *httpBatchStore) lockDataset(datasetID, token string, ttl time.Duration) (string, bool) {
+	// POST http://<host>/lock?ds=<datasetID>&ttl=<ttl>&token=<token>. Response will be 200 with the token on success, 409 if the lock is held.
+	params := url.Values{}
+	params.Add("ds", datasetID)
+	params.Add("ttl", ttl.String())
+	if token != "" {
+		params.Add("token", token)
+	}
+	// Renewing a lock is idempotent, but taking one isn't: a retry would conflict with the lock taken by an earlier attempt.
+	res := bhcs.requestLock(constants.LockPath, params, token != "")
+	defer closeResponse(res.Body)
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		data, err := ioutil.ReadAll(res.Body)
+		d.PanicIfError(err)
+		return string(data), true
+	case http.StatusConflict:
+		return "", false
+	default:
+		d.Panic("Unexpected response: %s", formatErrorResponse(res))
+		return "", false
+	}
+}
+
+// unlockDataset releases the advisory write lock on datasetID held with token. Returns false if the lock is held with a different token.
+func (bhcs *httpBatchStore) unlockDataset(datasetID, token string) bool {
+	// POST http://<host>/unlock?ds=<datasetID>&token=<token>. Response will be 200 on success, 409 if the lock is held by someone else.
+	params := url.Values{}
+	params.Add("ds", datasetID)
+	params.Add("token", token)
+	res := bhcs.requestLock(constants.UnlockPath, params, false)
+	defer closeResponse(res.Body)
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true
+	case http.StatusConflict:
+		return false
+	default:
+		d.Panic("Unexpected response: %s", formatErrorResponse(res))
+		return false
+	}
+}
+
+// datasetStats gets the server's DatasetStats.
+func (bhcs *httpBatchStore) datasetStats() (DatasetStats, error) {
+	// GET http://<host>/stats. Response will be 200 with the DatasetStats as JSON.
+	u := *bhcs.host
+	u.Path = httprouter.CleanPath(bhcs.host.Path + constants.StatsPath)
+	res, _, err := bhcs.do(func() *http.Request {
+		return newRequest("GET", bhcs.auth, u.String(), nil, nil)
+	}, true)
+	if err != nil {
+		return DatasetStats{}, err
+	}
+	expectVersion(res)
+	defer closeResponse(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return DatasetStats{}, fmt.Errorf("Unexpected response: %s", formatErrorResponse(res))
+	}
+	stats := DatasetStats{}
+	err = json.NewDecoder(res.Body).Decode(&stats)
+	return stats, err
+}
+
+func (bhcs *httpBatchStore) requestLock(path string, params url.Values, idempotent bool) *http.Response {
+	u := *bhcs.host
+	u.Path = httprouter.CleanPath(bhcs.host.Path + path)
+	u.RawQuery = params.Encode()
+
+	res, _, err := bhcs.do(func() *http.Request {
+		return newRequest("POST", bhcs.auth, u.String(), nil, nil)
+	}, idempotent)
+	d.PanicIfError(err)
+	expectVersion(res)
 	return res
 }
 
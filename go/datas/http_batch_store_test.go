@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/attic-labs/noms/go/chunks"
 	"github.com/attic-labs/noms/go/constants"
@@ -84,6 +85,20 @@ func NewHTTPBatchStoreForTest(cs chunks.ChunkStore) *httpBatchStore {
 			HandleRootGet(w, req, ps, cs)
 		},
 	)
+	locks := newDatasetLocks()
+	handleLock, handleUnlock := createHandler(locks.handleLock, true), createHandler(locks.handleUnlock, true)
+	serv.POST(
+		constants.LockPath,
+		func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+			handleLock(w, req, ps, cs)
+		},
+	)
+	serv.POST(
+		constants.UnlockPath,
+		func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+			handleUnlock(w, req, ps, cs)
+		},
+	)
 	hcs := NewHTTPBatchStore("http://localhost:9000", "")
 	hcs.httpClient = serv
 	return hcs
@@ -271,3 +286,38 @@ func (suite *HTTPBatchStoreSuite) TestHas() {
 	suite.True(suite.store.Has(chnx[0].Hash()))
 	suite.True(suite.store.Has(chnx[1].Hash()))
 }
+
+func (suite *HTTPBatchStoreSuite) TestHasMany() {
+	chnx := []chunks.Chunk{
+		chunks.NewChunk([]byte("abc")),
+		chunks.NewChunk([]byte("def")),
+	}
+	suite.cs.PutMany(chnx)
+	unwritten := chunks.NewChunk([]byte("ghi"))
+	suite.store.SchedulePut(unwritten)
+	notPresent := chunks.NewChunk([]byte("xyz")).Hash()
+
+	hashes := hash.NewHashSet(chnx[0].Hash(), chnx[1].Hash(), unwritten.Hash(), notPresent)
+	present := suite.store.HasMany(hashes)
+	suite.Equal(hash.NewHashSet(chnx[0].Hash(), chnx[1].Hash(), unwritten.Hash()), present)
+}
+
+func (suite *HTTPBatchStoreSuite) TestLockDataset() {
+	token, ok := suite.store.lockDataset("ds", "", time.Minute)
+	suite.True(ok)
+	suite.NotEmpty(token)
+
+	_, ok = suite.store.lockDataset("ds", "", time.Minute)
+	suite.False(ok)
+	_, ok = suite.store.lockDataset("other", "", time.Minute)
+	suite.True(ok)
+
+	renewed, ok := suite.store.lockDataset("ds", token, time.Minute)
+	suite.True(ok)
+	suite.Equal(token, renewed)
+
+	suite.False(suite.store.unlockDataset("ds", "bogus"))
+	suite.True(suite.store.unlockDataset("ds", token))
+	_, ok = suite.store.lockDataset("ds", "", time.Minute)
+	suite.True(ok)
+}
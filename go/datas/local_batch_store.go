@@ -16,15 +16,19 @@ import (
 )
 
 type localBatchStore struct {
-	cs            chunks.ChunkStore
+	cs   chunks.ChunkStore
+	once sync.Once
+
+	// cacheMu is held for reading to use unwrittenPuts, and for writing to flush it, so that a Flush doesn't lose puts made concurrently.
+	cacheMu       *sync.RWMutex
 	unwrittenPuts *nbs.NomsBlockCache
 	vbs           *types.ValidatingBatchingSink
-	once          sync.Once
 }
 
 func newLocalBatchStore(cs chunks.ChunkStore) *localBatchStore {
 	return &localBatchStore{
 		cs:            cs,
+		cacheMu:       &sync.RWMutex{},
 		unwrittenPuts: nbs.NewCache(),
 		vbs:           types.NewCompletenessCheckingBatchingSink(cs),
 	}
@@ -34,7 +38,12 @@ func newLocalBatchStore(cs chunks.ChunkStore) *localBatchStore {
 // not present.
 func (lbs *localBatchStore) Get(h hash.Hash) chunks.Chunk {
 	lbs.once.Do(lbs.expectVersion)
-	if pending := lbs.unwrittenPuts.Get(h); !pending.IsEmpty() {
+	pending := func() chunks.Chunk {
+		lbs.cacheMu.RLock()
+		defer lbs.cacheMu.RUnlock()
+		return lbs.unwrittenPuts.Get(h)
+	}()
+	if !pending.IsEmpty() {
 		return pending
 	}
 	return lbs.cs.Get(h)
@@ -46,7 +55,12 @@ func (lbs *localBatchStore) GetMany(hashes hash.HashSet, foundChunks chan *chunk
 		remaining.Insert(h)
 	}
 	localChunks := make(chan *chunks.Chunk)
-	go func() { defer close(localChunks); lbs.unwrittenPuts.GetMany(hashes, localChunks) }()
+	go func() {
+		defer close(localChunks)
+		lbs.cacheMu.RLock()
+		defer lbs.cacheMu.RUnlock()
+		lbs.unwrittenPuts.GetMany(hashes, localChunks)
+	}()
 	for c := range localChunks {
 		remaining.Remove(c.Hash())
 		foundChunks <- c
@@ -57,6 +71,8 @@ func (lbs *localBatchStore) GetMany(hashes hash.HashSet, foundChunks chan *chunk
 // SchedulePut simply calls Put on the underlying ChunkStore.
 func (lbs *localBatchStore) SchedulePut(c chunks.Chunk) {
 	lbs.once.Do(lbs.expectVersion)
+	lbs.cacheMu.RLock()
+	defer lbs.cacheMu.RUnlock()
 	lbs.unwrittenPuts.Insert(c)
 }
 
@@ -83,6 +99,8 @@ func (lbs *localBatchStore) UpdateRoot(current, last hash.Hash) bool {
 
 func (lbs *localBatchStore) Flush() {
 	lbs.once.Do(lbs.expectVersion)
+	lbs.cacheMu.Lock()
+	defer lbs.cacheMu.Unlock()
 
 	chunkChan := make(chan *chunks.Chunk, 128)
 	go func() {
@@ -105,6 +123,8 @@ func (lbs *localBatchStore) Flush() {
 // when the owning Database is closing and it isn't semantically correct to
 // flush.
 func (lbs *localBatchStore) Destroy() {
+	lbs.cacheMu.Lock()
+	defer lbs.cacheMu.Unlock()
 	lbs.unwrittenPuts.Destroy()
 }
 
@@ -36,6 +36,11 @@ func (ldb *LocalDatabase) CommitValue(ds Dataset, v types.Value) (Dataset, error
 	return ldb.Commit(ds, v, CommitOptions{})
 }
 
+func (ldb *LocalDatabase) CommitMany(commits []DatasetCommit) ([]Dataset, error) {
+	err := ldb.doCommitDatasets(commits)
+	return getDatasets(ldb, commits), err
+}
+
 func (ldb *LocalDatabase) Delete(ds Dataset) (Dataset, error) {
 	return ldb.doHeadUpdate(ds, func(ds Dataset) error { return ldb.doDelete(ds.ID()) })
 }
@@ -0,0 +1,114 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/hash"
+	"github.com/attic-labs/noms/go/types"
+)
+
+// ErrMigrateDestNotEmpty is returned by Migrate if the root of its destination is neither empty nor the root of its source.
+var ErrMigrateDestNotEmpty = errors.New("The destination's root isn't empty: migrate into an empty store")
+
+const (
+	// migrateFlushBytes is how much Migrate writes between flushes of its destination, which is how much a resumed Migrate may have to write again.
+	migrateFlushBytes = 1 << 26
+	// migrateProgressChunks is how many chunks Migrate reads between sends of its progress.
+	migrateProgressChunks = 1 << 10
+	// migrateHasChunks is how many chunks Migrate reads before checking which of them its destination already has, with a single HasMany.
+	migrateHasChunks = 1 << 10
+)
+
+// MigrateProgress is the progress of Migrate.
+type MigrateProgress struct {
+	// Chunks and Bytes are of the chunks which have been read from the source.
+	Chunks, Bytes uint64
+	// Written is how many of them have been written to the destination, rather than found there, having been written by an earlier Migrate which was interrupted.
+	Written uint64
+}
+
+// Migrate copies every chunk reachable from the root of src to cs, byte for byte, then sets the root of cs to the root of src. It's for moving a database to a store with different Features, which a store must have before any data is written to it, so cs should be empty. The chunks aren't re-encoded, so the Features of cs must be able to hold values in their current encoding.
+//
+// Every chunk's encoding is checked with types.VerifyEncoding before it's written, so that a chunk which wouldn't be encoded the same way now, and so wouldn't have the same hash, isn't copied. If ctx is done, Migrate stops, flushing what it's written to cs, but leaving its root unchanged. It can then be resumed by running it again, which reads all the chunks again, but doesn't write those that were flushed. The progress is sent to progress, if it isn't nil, every so often and when Migrate is done.
+func Migrate(ctx context.Context, src Database, cs chunks.ChunkStore, progress chan<- MigrateProgress) (root hash.Hash, err error) {
+	root = src.validatingBatchStore().Root()
+	current := cs.Root()
+	if current == root {
+		return root, nil
+	} else if !current.IsEmpty() {
+		return root, ErrMigrateDestNotEmpty
+	}
+
+	p := MigrateProgress{}
+	sendProgress := func() {
+		if progress != nil {
+			progress <- p
+		}
+	}
+	unflushed := 0
+	pending := []chunks.Chunk{}
+	// writePending writes those of the pending chunks which cs doesn't have.
+	writePending := func() {
+		hashes := hash.HashSet{}
+		for _, c := range pending {
+			hashes.Insert(c.Hash())
+		}
+		present := cs.HasMany(hashes)
+		for _, c := range pending {
+			if present.Has(c.Hash()) {
+				continue
+			}
+			d.PanicIfError(types.VerifyEncoding(c))
+			cs.Put(c)
+			p.Written++
+			if unflushed += len(c.Data()); unflushed >= migrateFlushBytes {
+				cs.Flush()
+				unflushed = 0
+			}
+		}
+		pending = pending[:0]
+	}
+	err = d.Try(func() {
+		missing := walkChunks(src, []types.Value{src.Datasets()}, func(c chunks.Chunk, v types.Value) bool {
+			if ctx.Err() != nil {
+				return false
+			}
+			if p.Chunks++; p.Chunks%migrateProgressChunks == 0 {
+				sendProgress()
+			}
+			p.Bytes += uint64(len(c.Data()))
+			if pending = append(pending, c); len(pending) >= migrateHasChunks {
+				writePending()
+			}
+			return true
+		})
+		writePending()
+		if len(missing) > 0 {
+			d.Panic("Chunks reachable from root %s are missing, e.g. %s", root, anyHash(missing))
+		}
+	})
+	cs.Flush()
+	sendProgress()
+	if err != nil {
+		return root, d.Unwrap(err)
+	}
+	if err = ctx.Err(); err != nil {
+		return root, err
+	}
+
+	if !cs.Has(root) {
+		return root, fmt.Errorf("Root %s wasn't migrated", root)
+	}
+	if !cs.UpdateRoot(root, current) {
+		return root, ErrMigrateDestNotEmpty
+	}
+	return root, nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestMigrate(t *testing.T) {
+	assert := assert.New(t)
+	src := NewDatabase(chunks.NewMemoryStore())
+	defer src.Close()
+
+	values := make(types.ValueSlice, 10000)
+	for i := range values {
+		values[i] = types.Number(i)
+	}
+	ds, err := src.CommitValue(src.GetDataset("ds"), types.NewList(values...))
+	assert.NoError(err)
+	_, err = src.CommitValue(src.GetDataset("other"), types.String("other"))
+	assert.NoError(err)
+
+	// A Migrate which is cancelled doesn't change the root.
+	cs := chunks.NewMemoryStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Migrate(ctx, src, cs, nil)
+	assert.Equal(context.Canceled, err)
+	assert.True(cs.Root().IsEmpty())
+
+	progress := make(chan MigrateProgress, 1024)
+	root, err := Migrate(context.Background(), src, cs, progress)
+	close(progress)
+	assert.NoError(err)
+	assert.Equal(src.Datasets().Hash(), root)
+	assert.Equal(root, cs.Root())
+	var last MigrateProgress
+	for p := range progress {
+		last = p
+	}
+	assert.True(last.Chunks > 2)
+	assert.Equal(last.Chunks, last.Written)
+
+	migrated := NewDatabase(cs)
+	assert.True(migrated.GetDataset("ds").HeadRef().Equals(ds.HeadRef()))
+	assert.Empty(Fsck(migrated, 4, nil))
+
+	// Migrating again does nothing.
+	root2, err := Migrate(context.Background(), src, cs, nil)
+	assert.NoError(err)
+	assert.Equal(root, root2)
+
+	// Nor can another database be migrated into the same store.
+	other := NewDatabase(chunks.NewMemoryStore())
+	defer other.Close()
+	_, err = other.CommitValue(other.GetDataset("ds"), types.Bool(true))
+	assert.NoError(err)
+	_, err = Migrate(context.Background(), other, cs, nil)
+	assert.Equal(ErrMigrateDestNotEmpty, err)
+}
+
+func TestMigrateResume(t *testing.T) {
+	assert := assert.New(t)
+	src := NewDatabase(chunks.NewMemoryStore())
+	defer src.Close()
+
+	values := make(types.ValueSlice, 10000)
+	for i := range values {
+		values[i] = types.Number(i)
+	}
+	_, err := src.CommitValue(src.GetDataset("ds"), types.NewList(values...))
+	assert.NoError(err)
+
+	// Simulate an interrupted Migrate, which wrote the root chunk and its children.
+	cs := chunks.NewMemoryStore()
+	written := 0
+	walkChunks(src, []types.Value{src.Datasets()}, func(c chunks.Chunk, v types.Value) bool {
+		cs.Put(c)
+		written++
+		return written < 3
+	})
+	cs.Flush()
+
+	progress := make(chan MigrateProgress, 1024)
+	_, err = Migrate(context.Background(), src, cs, progress)
+	close(progress)
+	assert.NoError(err)
+	var last MigrateProgress
+	for p := range progress {
+		last = p
+	}
+	assert.Equal(last.Chunks-uint64(written), last.Written)
+	assert.Empty(Fsck(NewDatabase(cs), 4, nil))
+}
+
+func TestMigrateNonCanonical(t *testing.T) {
+	assert := assert.New(t)
+	src := NewDatabase(chunks.NewMemoryStore())
+	defer src.Close()
+
+	// A struct whose fields aren't in order of their names.
+	data := []byte{byte(types.StructKind), 1, 'S', 2, 1, 'b', 1, 'a'}
+	data = append(data, types.EncodeValue(types.Number(2), nil).Data()...)
+	data = append(data, types.EncodeValue(types.Number(1), nil).Data()...)
+	s := types.DecodeValue(chunks.NewChunk(data), nil)
+	_, err := src.CommitValue(src.GetDataset("ds"), types.NewList(s))
+	assert.NoError(err)
+
+	cs := chunks.NewMemoryStore()
+	_, err = Migrate(context.Background(), src, cs, nil)
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "aren't in order")
+	}
+	assert.True(cs.Root().IsEmpty())
+}
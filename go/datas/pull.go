@@ -5,6 +5,7 @@
 package datas
 
 import (
+	"context"
 	"math"
 	"math/rand"
 	"sort"
@@ -30,8 +31,27 @@ const bytesWrittenSampleRate = .10
 // longer tell which reads were caused by Pull() and which by Flush().
 // TODO: Get rid of this (BUG 2982)
 func PullWithFlush(srcDB, sinkDB Database, sourceRef, sinkHeadRef types.Ref, concurrency int, progressCh chan PullProgress) {
-	Pull(srcDB, sinkDB, sourceRef, sinkHeadRef, concurrency, progressCh)
+	PullWithFlushContext(context.Background(), srcDB, sinkDB, sourceRef, sinkHeadRef, concurrency, progressCh)
+}
+
+// PullWithFlushContext is like PullWithFlush, but stops early if ctx is
+// cancelled. In that case nothing is flushed and ctx.Err() is returned.
+func PullWithFlushContext(ctx context.Context, srcDB, sinkDB Database, sourceRef, sinkHeadRef types.Ref, concurrency int, progressCh chan PullProgress) error {
+	if err := PullContext(ctx, srcDB, sinkDB, sourceRef, sinkHeadRef, concurrency, progressCh); err != nil {
+		return err
+	}
+	sinkDB.validatingBatchStore().Flush()
+	return nil
+}
+
+// PullManyWithFlushContext calls PullManyContext and then flushes data to
+// sinkDB, like PullWithFlushContext.
+func PullManyWithFlushContext(ctx context.Context, srcDB, sinkDB Database, sourceRefs, sinkHeadRefs types.RefSlice, concurrency int, progressCh chan PullProgress) error {
+	if err := PullManyContext(ctx, srcDB, sinkDB, sourceRefs, sinkHeadRefs, concurrency, progressCh); err != nil {
+		return err
+	}
 	sinkDB.validatingBatchStore().Flush()
+	return nil
 }
 
 // Pull objects that descend from sourceRef from srcDB to sinkDB. sinkHeadRef
@@ -39,19 +59,53 @@ func PullWithFlush(srcDB, sinkDB Database, sourceRef, sinkHeadRef types.Ref, con
 // allows the algorithm to figure out which portions of data are already
 // present in sinkDB and skip copying them.
 func Pull(srcDB, sinkDB Database, sourceRef, sinkHeadRef types.Ref, concurrency int, progressCh chan PullProgress) {
-	srcQ, sinkQ := &types.RefByHeight{sourceRef}, &types.RefByHeight{sinkHeadRef}
+	PullContext(context.Background(), srcDB, sinkDB, sourceRef, sinkHeadRef, concurrency, progressCh)
+}
 
-	// If the sourceRef points to an object already in sinkDB, there's nothing to do.
-	if sinkDB.has(sourceRef.TargetHash()) {
-		return
+// PullContext is like Pull, but stops early if ctx is cancelled or its
+// deadline passes, returning ctx.Err(). It returns as soon as ctx is done,
+// even if reads from srcDB or sinkDB are blocked, though those reads, which
+// don't take a ctx, carry on in the background until they return. The chunks
+// that were copied before cancellation are left in sinkDB but aren't
+// reachable from any Dataset.
+func PullContext(ctx context.Context, srcDB, sinkDB Database, sourceRef, sinkHeadRef types.Ref, concurrency int, progressCh chan PullProgress) error {
+	return PullManyContext(ctx, srcDB, sinkDB, types.RefSlice{sourceRef}, types.RefSlice{sinkHeadRef}, concurrency, progressCh)
+}
+
+// PullManyContext is like PullContext, but pulls everything reachable from
+// any of sourceRefs in a single pass, using all of sinkHeadRefs as hints for
+// what's already present in sinkDB. Chunks shared between several of
+// sourceRefs, e.g. the common history of related Datasets, are only examined
+// once. Empty Refs in sinkHeadRefs are ignored.
+func PullManyContext(ctx context.Context, srcDB, sinkDB Database, sourceRefs, sinkHeadRefs types.RefSlice, concurrency int, progressCh chan PullProgress) error {
+	srcQ, sinkQ := &types.RefByHeight{}, &types.RefByHeight{}
+
+	// If a sourceRef points to an object already in sinkDB, there's nothing to do for it.
+	present := sinkDB.HasMany(refHashes(sourceRefs))
+	for _, r := range sourceRefs {
+		if !present.Has(r.TargetHash()) {
+			srcQ.PushBack(r)
+		}
+	}
+	if srcQ.Empty() {
+		return nil
 	}
 
-	// We generally expect that sourceRef descends from sinkHeadRef, so that walking down from sinkHeadRef yields useful hints. If it's not even in the srcDB, then just clear out sinkQ right now and don't bother.
-	if !srcDB.has(sinkHeadRef.TargetHash()) {
-		sinkQ.PopBack()
+	// We generally expect that sourceRefs descend from sinkHeadRefs, so that walking down from sinkHeadRefs yields useful hints. If one isn't even in the srcDB, then don't bother with it.
+	sinkHeads := hash.HashSet{}
+	present = srcDB.HasMany(refHashes(sinkHeadRefs))
+	for _, r := range sinkHeadRefs {
+		if h := r.TargetHash(); !h.IsEmpty() && present.Has(h) {
+			sinkQ.PushBack(r)
+			sinkHeads.Insert(h)
+		}
 	}
+	sort.Sort(srcQ)
+	sort.Sort(sinkQ)
+	srcQ.Unique()
+	sinkQ.Unique()
 
-	// Since we expect sinkHeadRef to descend from sourceRef, we assume srcDB has a superset of the data in sinkDB. There are some cases where, logically, the code wants to read data it knows to be in sinkDB. In this case, it doesn't actually matter which Database the data comes from, so as an optimization we use whichever is a LocalDatabase -- if either is.
+	// Since we expect sinkHeadRefs to descend from sourceRefs, we assume srcDB has a superset of the data in sinkDB. There are some cases where, logically, the code wants to read data it knows to be in sinkDB. In this case, it doesn't actually matter which Database the data comes from, so as an optimization we use whichever is a LocalDatabase -- if either is.
 	mostLocalDB := srcDB
 	if _, ok := sinkDB.(*LocalDatabase); ok {
 		mostLocalDB = sinkDB
@@ -66,21 +120,19 @@ func Pull(srcDB, sinkDB Database, sourceRef, sinkHeadRef types.Ref, concurrency
 	comResChan := make(chan traverseResult)
 	done := make(chan struct{})
 
+	// The channels aren't closed, since after a cancellation workers may still be blocked reading from the stores. They exit, and the channels are collected, once those reads return.
 	workerWg := &sync.WaitGroup{}
+	cancelled := false
 	defer func() {
 		close(done)
-		workerWg.Wait()
-
-		close(srcChan)
-		close(sinkChan)
-		close(comChan)
-		close(srcResChan)
-		close(sinkResChan)
-		close(comResChan)
+		if !cancelled {
+			workerWg.Wait()
+		}
 	}()
 	traverseWorker := func() {
 		workerWg.Add(1)
 		go func() {
+			defer workerWg.Done()
 			for {
 				select {
 				case srcRef := <-srcChan:
@@ -90,13 +142,24 @@ func Pull(srcDB, sinkDB Database, sourceRef, sinkHeadRef types.Ref, concurrency
 					// There's no immediately observable performance benefit to sampling here, but there's
 					// also no appreciable loss in accuracy, so we'll keep it around.
 					takeSample := rand.Float64() < bytesWrittenSampleRate
-					srcResChan <- traverseSource(srcRef, srcDB, sinkDB, takeSample)
+					select {
+					case srcResChan <- traverseSource(srcRef, srcDB, sinkDB, takeSample):
+					case <-done:
+						return
+					}
 				case sinkRef := <-sinkChan:
-					sinkResChan <- traverseSink(sinkRef, mostLocalDB)
+					select {
+					case sinkResChan <- traverseSink(sinkRef, mostLocalDB):
+					case <-done:
+						return
+					}
 				case comRef := <-comChan:
-					comResChan <- traverseCommon(comRef, sinkHeadRef, mostLocalDB)
+					select {
+					case comResChan <- traverseCommon(comRef, sinkHeads, mostLocalDB):
+					case <-done:
+						return
+					}
 				case <-done:
-					workerWg.Done()
 					return
 				}
 			}
@@ -118,6 +181,9 @@ func Pull(srcDB, sinkDB Database, sourceRef, sinkHeadRef types.Ref, concurrency
 	sampleSize := uint64(0)
 	sampleCount := uint64(0)
 	for !srcQ.Empty() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		srcRefs, sinkRefs, comRefs := planWork(srcQ, sinkQ)
 		srcWork, sinkWork, comWork := len(srcRefs), len(sinkRefs), len(comRefs)
 		if srcWork+comWork > 0 {
@@ -125,13 +191,16 @@ func Pull(srcDB, sinkDB Database, sourceRef, sinkHeadRef types.Ref, concurrency
 		}
 
 		// These goroutines send work to traverseWorkers, blocking when all are busy. They self-terminate when they've sent all they have.
-		go sendWork(srcChan, srcRefs)
-		go sendWork(sinkChan, sinkRefs)
-		go sendWork(comChan, comRefs)
+		go sendWork(srcChan, srcRefs, done)
+		go sendWork(sinkChan, sinkRefs, done)
+		go sendWork(comChan, comRefs, done)
 		//  Don't use srcRefs, sinkRefs, or comRefs after this point. The goroutines above own them.
 
 		for srcWork+sinkWork+comWork > 0 {
 			select {
+			case <-ctx.Done():
+				cancelled = true
+				return ctx.Err()
 			case res := <-srcResChan:
 				for _, reachable := range res.reachables {
 					srcQ.PushBack(reachable)
@@ -149,7 +218,7 @@ func Pull(srcDB, sinkDB Database, sourceRef, sinkHeadRef types.Ref, concurrency
 				}
 				sinkWork--
 			case res := <-comResChan:
-				isHeadOfSink := res.readHash == sinkHeadRef.TargetHash()
+				isHeadOfSink := sinkHeads.Has(res.readHash)
 				for _, reachable := range res.reachables {
 					sinkQ.PushBack(reachable)
 					if !isHeadOfSink {
@@ -165,6 +234,7 @@ func Pull(srcDB, sinkDB Database, sourceRef, sinkHeadRef types.Ref, concurrency
 		sinkQ.Unique()
 		srcQ.Unique()
 	}
+	return nil
 }
 
 type traverseResult struct {
@@ -224,10 +294,22 @@ func findCommon(taller, shorter *types.RefByHeight, height uint64) (tallRefs, co
 	return
 }
 
-func sendWork(ch chan<- types.Ref, refs types.RefSlice) {
+func sendWork(ch chan<- types.Ref, refs types.RefSlice, done <-chan struct{}) {
+	for _, r := range refs {
+		select {
+		case ch <- r:
+		case <-done:
+			return
+		}
+	}
+}
+
+func refHashes(refs types.RefSlice) hash.HashSet {
+	hashes := hash.HashSet{}
 	for _, r := range refs {
-		ch <- r
+		hashes.Insert(r.TargetHash())
 	}
+	return hashes
 }
 
 type hintCache map[hash.Hash]hash.Hash
@@ -268,13 +350,13 @@ func traverseSink(sinkRef types.Ref, db Database) traverseResult {
 	return traverseResult{}
 }
 
-func traverseCommon(comRef, sinkHead types.Ref, db Database) traverseResult {
+func traverseCommon(comRef types.Ref, sinkHeads hash.HashSet, db Database) traverseResult {
 	// TODO: Add IsRefOfCommit?
 	if comRef.Height() > 1 && IsRefOfCommitType(types.TypeOf(comRef)) {
 		commit := comRef.TargetValue(db).(types.Struct)
 		// We don't want to traverse the parents of sinkHead, but we still want to traverse its Value on the sinkDB side. We also still want to traverse all children, in both the srcDB and sinkDB, of any common Commit that is not at the Head of sinkDB.
 		exclusionSet := types.NewSet()
-		if comRef.Equals(sinkHead) {
+		if sinkHeads.Has(comRef.TargetHash()) {
 			exclusionSet = commit.Get(ParentsField).(types.Set)
 		}
 		chunks := types.RefSlice(getChunks(commit))
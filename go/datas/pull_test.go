@@ -5,10 +5,13 @@
 package datas
 
 import (
+	"context"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/hash"
 	"github.com/attic-labs/noms/go/types"
 	"github.com/attic-labs/testify/assert"
 	"github.com/attic-labs/testify/suite"
@@ -86,7 +89,7 @@ func (suite *RemoteToRemoteSuite) SetupTest() {
 
 func makeRemoteDb(cs chunks.ChunkStore) Database {
 	hbs := NewHTTPBatchStoreForTest(cs)
-	return &RemoteDatabaseClient{newDatabaseCommon(newCachingChunkHaver(hbs), types.NewValueStore(hbs), hbs)}
+	return &RemoteDatabaseClient{newDatabaseCommon(newCachingChunkHaver(hbs), types.NewValueStore(hbs), hbs), hbs}
 }
 
 func (suite *PullSuite) sinkIsLocal() bool {
@@ -164,6 +167,17 @@ func (suite *PullSuite) TestPullEverything() {
 	suite.True(l.Equals(v.Get(ValueField)))
 }
 
+func (suite *PullSuite) TestPullCancelled() {
+	l := buildListOfHeight(2, suite.source)
+	sourceRef := suite.commitToSource(l, types.NewSet())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := PullWithFlushContext(ctx, suite.source, suite.sink, sourceRef, types.Ref{}, 2, nil)
+	suite.Equal(context.Canceled, err)
+	suite.Nil(suite.sink.ReadValue(sourceRef.TargetHash()))
+}
+
 // Source: -6-> C3(L5) -1-> N
 //               .  \  -5-> L4 -1-> N
 //                .          \ -4-> L3 -1-> N
@@ -303,6 +317,37 @@ func (suite *PullSuite) TestPullUpdates() {
 	suite.True(srcL.Equals(v.Get(ValueField)))
 }
 
+// Source: ds1: C2(L4) -> C1(L2), ds2: C3(L3) -> C1(L2)
+// Sink:   ds1: C1(L2)
+func (suite *PullSuite) TestPullMany() {
+	sinkL := buildListOfHeight(2, suite.sink)
+	sinkRef := suite.commitToSink(sinkL, types.NewSet())
+	expectedReads := suite.sinkCS.Reads
+
+	srcL := buildListOfHeight(2, suite.source)
+	baseRef := suite.commitToSource(srcL, types.NewSet())
+	srcL1 := buildListOfHeight(4, suite.source)
+	sourceRef1 := suite.commitToSource(srcL1, types.NewSet(baseRef))
+
+	ds2, err := suite.source.Commit(suite.source.GetDataset("ds2"), buildListOfHeight(3, suite.source), CommitOptions{Parents: types.NewSet(baseRef)})
+	suite.NoError(err)
+	sourceRef2 := ds2.HeadRef()
+
+	pt := startProgressTracker()
+
+	err = PullManyContext(context.Background(), suite.source, suite.sink, types.RefSlice{sourceRef1, sourceRef2}, types.RefSlice{sinkRef, types.Ref{}}, 2, pt.Ch)
+	suite.NoError(err)
+
+	suite.Equal(expectedReads, suite.sinkCS.Reads)
+	pt.Validate(suite)
+
+	suite.sink.validatingBatchStore().Flush()
+	v := suite.sink.ReadValue(sourceRef1.TargetHash()).(types.Struct)
+	suite.True(srcL1.Equals(v.Get(ValueField)))
+	v = suite.sink.ReadValue(sourceRef2.TargetHash()).(types.Struct)
+	suite.True(ds2.HeadValue().Equals(v.Get(ValueField)))
+}
+
 func (suite *PullSuite) commitToSource(v types.Value, p types.Set) types.Ref {
 	ds := suite.source.GetDataset(datasetID)
 	ds, err := suite.source.Commit(ds, v, CommitOptions{Parents: p})
@@ -357,3 +402,47 @@ func TestFindCommon(t *testing.T) {
 	assert.Equal(t, 0, len(*taller))
 	assert.Equal(t, 50, len(*shorter))
 }
+
+// blockingStore is a TestStore whose Get, once blocking is closed, signals blocked and waits until release is closed.
+type blockingStore struct {
+	*chunks.TestStore
+	blocking, blocked, release chan struct{}
+}
+
+func (bs *blockingStore) Get(h hash.Hash) chunks.Chunk {
+	select {
+	case <-bs.blocking:
+		select {
+		case bs.blocked <- struct{}{}:
+		default:
+		}
+		<-bs.release
+	default:
+	}
+	return bs.TestStore.Get(h)
+}
+
+func TestPullCancelledWhileBlocked(t *testing.T) {
+	assert := assert.New(t)
+
+	cs := &blockingStore{chunks.NewTestStore(), make(chan struct{}), make(chan struct{}, 1), make(chan struct{})}
+	source, sink := NewDatabase(cs), NewDatabase(chunks.NewTestStore())
+	ds, err := source.CommitValue(source.GetDataset("ds"), buildListOfHeight(2, source))
+	assert.NoError(err)
+	source = ds.Database()
+	sourceRef := ds.HeadRef()
+
+	close(cs.blocking)
+	defer close(cs.release)
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error)
+	go func() { result <- PullContext(ctx, source, sink, sourceRef, types.Ref{}, 2, nil) }()
+	<-cs.blocked
+	cancel()
+	select {
+	case err := <-result:
+		assert.Equal(context.Canceled, err)
+	case <-time.After(10 * time.Second):
+		assert.Fail("Pull wasn't cancelled")
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/hash"
+)
+
+// readThroughStore is a ChunkStore which reads chunks from a local cache,
+// falling back to an upstream Database on a miss, and adding what it reads
+// from upstream to the cache. The root, and all writes, belong to upstream.
+type readThroughStore struct {
+	upstream Database
+	cache    chunks.ChunkStore
+}
+
+// NewReadThroughStore returns a ChunkStore for upstream which keeps a copy of
+// every chunk it reads in cache. Since chunks never change, the copies never
+// need to be invalidated. Serving it with a RemoteDatabaseServer makes a
+// caching proxy of upstream, so that clients near the proxy pull each chunk
+// from a distant upstream at most once. The root is always read from
+// upstream, and writes are passed through to it, also being kept in cache.
+// What's added to cache is only flushed to it by Flush and Close.
+func NewReadThroughStore(upstream Database, cache chunks.ChunkStore) chunks.ChunkStore {
+	return &readThroughStore{upstream, cache}
+}
+
+func (rts *readThroughStore) Get(h hash.Hash) chunks.Chunk {
+	if c := rts.cache.Get(h); !c.IsEmpty() {
+		return c
+	}
+	c := rts.upstream.validatingBatchStore().Get(h)
+	if !c.IsEmpty() {
+		rts.cache.Put(c)
+	}
+	return c
+}
+
+func (rts *readThroughStore) GetMany(hashes hash.HashSet, foundChunks chan *chunks.Chunk) {
+	missing := hash.HashSet{}
+	for h := range hashes {
+		missing.Insert(h)
+	}
+
+	cached := make(chan *chunks.Chunk, 16)
+	go func() {
+		rts.cache.GetMany(hashes, cached)
+		close(cached)
+	}()
+	for c := range cached {
+		missing.Remove(c.Hash())
+		foundChunks <- c
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	fetched := make(chan *chunks.Chunk, 16)
+	go func() {
+		rts.upstream.validatingBatchStore().GetMany(missing, fetched)
+		close(fetched)
+	}()
+	for c := range fetched {
+		rts.cache.Put(*c)
+		foundChunks <- c
+	}
+}
+
+func (rts *readThroughStore) Has(h hash.Hash) bool {
+	return rts.cache.Has(h) || rts.upstream.has(h)
+}
+
+func (rts *readThroughStore) HasMany(hashes hash.HashSet) (present hash.HashSet) {
+	present = rts.cache.HasMany(hashes)
+	remaining := hash.HashSet{}
+	for h := range hashes {
+		if !present.Has(h) {
+			remaining.Insert(h)
+		}
+	}
+	if len(remaining) > 0 {
+		for h := range rts.upstream.HasMany(remaining) {
+			present.Insert(h)
+		}
+	}
+	return
+}
+
+func (rts *readThroughStore) Version() string {
+	return rts.cache.Version()
+}
+
+func (rts *readThroughStore) Put(c chunks.Chunk) {
+	rts.upstream.validatingBatchStore().SchedulePut(c)
+	rts.cache.Put(c)
+}
+
+func (rts *readThroughStore) PutMany(chunks []chunks.Chunk) {
+	for _, c := range chunks {
+		rts.Put(c)
+	}
+}
+
+func (rts *readThroughStore) Flush() {
+	rts.upstream.validatingBatchStore().Flush()
+	rts.cache.Flush()
+}
+
+func (rts *readThroughStore) Root() hash.Hash {
+	return rts.upstream.validatingBatchStore().Root()
+}
+
+func (rts *readThroughStore) UpdateRoot(current, last hash.Hash) bool {
+	return rts.upstream.validatingBatchStore().UpdateRoot(current, last)
+}
+
+func (rts *readThroughStore) Close() error {
+	rts.cache.Flush()
+	err := rts.cache.Close()
+	if uerr := rts.upstream.Close(); err == nil {
+		err = uerr
+	}
+	return err
+}
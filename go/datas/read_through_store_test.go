@@ -0,0 +1,67 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"testing"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestReadThroughStore(t *testing.T) {
+	assert := assert.New(t)
+	upstreamStore := chunks.NewTestStore()
+	upstream := NewDatabase(upstreamStore)
+
+	values := make(types.ValueSlice, 10000)
+	for i := range values {
+		values[i] = types.Number(i)
+	}
+	l := types.NewList(values...)
+	_, err := upstream.CommitValue(upstream.GetDataset("ds"), l)
+	assert.NoError(err)
+
+	cache := &flushCountingStore{ChunkStore: chunks.NewMemoryStore()}
+	rts := NewReadThroughStore(upstream, cache)
+	readAll := func() {
+		// A new Database each time, so that nothing is cached in its ValueStore.
+		db := NewDatabase(rts)
+		assert.True(db.GetDataset("ds").HeadValue().Equals(l))
+		db.GetDataset("ds").HeadValue().(types.List).IterAll(func(v types.Value, i uint64) {})
+	}
+
+	upstreamStore.Reads = 0
+	readAll()
+	assert.True(upstreamStore.Reads > 0)
+	assert.True(cache.Has(upstream.GetDataset("ds").HeadRef().TargetHash()))
+	// Reads don't flush the cache.
+	assert.Equal(0, cache.flushes)
+
+	// Everything but the root is now read from the cache.
+	upstreamStore.Reads = 0
+	readAll()
+	assert.Equal(0, upstreamStore.Reads)
+	assert.True(rts.Has(upstream.GetDataset("ds").HeadRef().TargetHash()))
+
+	// Commits through the store go to upstream.
+	db := NewDatabase(rts)
+	_, err = db.CommitValue(db.GetDataset("ds"), types.String("hi"))
+	assert.NoError(err)
+	assert.Equal(rts.Root(), upstreamStore.Root())
+	upstream = NewDatabase(upstreamStore)
+	assert.True(upstream.GetDataset("ds").HeadValue().Equals(types.String("hi")))
+}
+
+type flushCountingStore struct {
+	chunks.ChunkStore
+	flushes int
+}
+
+func (s *flushCountingStore) Flush() {
+	s.flushes++
+	s.ChunkStore.Flush()
+}
@@ -5,6 +5,9 @@
 package datas
 
 import (
+	"errors"
+	"time"
+
 	"github.com/attic-labs/noms/go/types"
 	"github.com/julienschmidt/httprouter"
 )
@@ -12,11 +15,37 @@ import (
 // Database provides versioned storage for noms values. Each Database instance represents one moment in history. Heads() returns the Commit from each active fork at that moment. The Commit() method returns a new Database, representing a new moment in history.
 type RemoteDatabaseClient struct {
 	databaseCommon
+	httpBS *httpBatchStore
 }
 
+// ErrDatasetLocked is returned by LockDataset if another client holds the lock.
+var ErrDatasetLocked = errors.New("Dataset is locked by another client")
+
 func NewRemoteDatabase(baseURL, auth string) *RemoteDatabaseClient {
 	httpBS := NewHTTPBatchStore(baseURL, auth)
-	return &RemoteDatabaseClient{newDatabaseCommon(newCachingChunkHaver(httpBS), types.NewValueStore(httpBS), httpBS)}
+	return &RemoteDatabaseClient{newDatabaseCommon(newCachingChunkHaver(httpBS), types.NewValueStore(httpBS), httpBS), httpBS}
+}
+
+// LockDataset acquires the server's advisory write lock on ds for ttl, returning a token to pass to UnlockDataset. Passing the token from a previous call renews the lock. The lock doesn't prevent other clients from committing to ds; it is up to cooperating writers to take it before they commit. If another client holds the lock, ErrDatasetLocked is returned.
+func (rdb *RemoteDatabaseClient) LockDataset(ds Dataset, token string, ttl time.Duration) (string, error) {
+	token, ok := rdb.httpBS.lockDataset(ds.ID(), token, ttl)
+	if !ok {
+		return "", ErrDatasetLocked
+	}
+	return token, nil
+}
+
+// UnlockDataset releases the lock on ds previously acquired with LockDataset. If the lock is held by another client, ErrDatasetLocked is returned.
+func (rdb *RemoteDatabaseClient) UnlockDataset(ds Dataset, token string) error {
+	if !rdb.httpBS.unlockDataset(ds.ID(), token) {
+		return ErrDatasetLocked
+	}
+	return nil
+}
+
+// DatasetStats returns the reads and writes of each dataset counted by the server since it started, as described by DatasetStats.
+func (rdb *RemoteDatabaseClient) DatasetStats() (DatasetStats, error) {
+	return rdb.httpBS.datasetStats()
 }
 
 func (rdb *RemoteDatabaseClient) GetDataset(datasetID string) Dataset {
@@ -32,6 +61,11 @@ func (rdb *RemoteDatabaseClient) CommitValue(ds Dataset, v types.Value) (Dataset
 	return rdb.Commit(ds, v, CommitOptions{})
 }
 
+func (rdb *RemoteDatabaseClient) CommitMany(commits []DatasetCommit) ([]Dataset, error) {
+	err := rdb.doCommitDatasets(commits)
+	return getDatasets(rdb, commits), err
+}
+
 func (rdb *RemoteDatabaseClient) Delete(ds Dataset) (Dataset, error) {
 	err := rdb.doDelete(ds.ID())
 	return rdb.GetDataset(ds.ID()), err
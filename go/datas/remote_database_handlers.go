@@ -89,6 +89,11 @@ var (
 
 	HandleGraphQL = createHandler(handleGraphQL, false)
 
+	// HandleReadOnly is used in place of the handlers for endpoints that
+	// would write to the database when a server is run in read-only mode. It
+	// always responds with 403.
+	HandleReadOnly = createHandler(handleReadOnly, false)
+
 	writeValueConcurrency = runtime.NumCPU()
 )
 
@@ -191,6 +196,14 @@ func buildWriteValueRequest(chunkChan chan *chunks.Chunk) io.Reader {
 	body, pw := io.Pipe()
 
 	go func() {
+		// If the request fails, and body is closed, writing panics. Report that to the reader instead, and drain chunkChan so that its sender isn't left blocked.
+		defer func() {
+			if r := recover(); r != nil {
+				pw.CloseWithError(fmt.Errorf("%v", r))
+				for range chunkChan {
+				}
+			}
+		}()
 		gw := snappy.NewBufferedWriter(pw)
 		for c := range chunkChan {
 			chunks.Serialize(*c, gw)
@@ -450,6 +463,10 @@ func handleBaseGet(w http.ResponseWriter, req *http.Request, ps URLParams, rt ch
 	fmt.Fprintf(w, nomsBaseHTML)
 }
 
+func handleReadOnly(w http.ResponseWriter, req *http.Request, ps URLParams, cs chunks.ChunkStore) {
+	http.Error(w, "Error: database is read-only", http.StatusForbidden)
+}
+
 func assertMapOfStringToRefOfCommit(proposed, datasets types.Map, vr types.ValueReader) {
 	stopChan := make(chan struct{})
 	defer close(stopChan)
@@ -393,3 +393,12 @@ type params map[string]string
 func (p params) ByName(k string) string {
 	return p[k]
 }
+
+func TestHandleReadOnly(t *testing.T) {
+	assert := assert.New(t)
+	cs := chunks.NewTestStore()
+
+	w := httptest.NewRecorder()
+	HandleReadOnly(w, newRequest("POST", "", "", nil, nil), params{}, cs)
+	assert.Equal(http.StatusForbidden, w.Code)
+}
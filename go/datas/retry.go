@@ -0,0 +1,110 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/attic-labs/noms/go/util/verbose"
+	flag "github.com/juju/gnuflag"
+)
+
+// RetryPolicy describes how requests to a remote database which fail
+// transiently, because the network or server is briefly unavailable, are
+// retried. The wait before each retry doubles, from InitialBackoff up to
+// MaxBackoff, with some jitter so that clients don't retry in lockstep.
+type RetryPolicy struct {
+	// Retries is the most times a failed request is sent again. 0 turns
+	// retries off.
+	Retries        int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy is the RetryPolicy of remote databases when they're
+// created. Commands can let it be set with RegisterRetryFlags.
+var DefaultRetryPolicy = RetryPolicy{
+	Retries:        5,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     30 * time.Second,
+}
+
+// RegisterRetryFlags registers --retries and --retry-backoff, which set
+// DefaultRetryPolicy, with flags.
+func RegisterRetryFlags(flags *flag.FlagSet) {
+	flags.IntVar(&DefaultRetryPolicy.Retries, "retries", DefaultRetryPolicy.Retries, "number of times to retry a request to a remote database that fails because the network or server is briefly unavailable")
+	flags.DurationVar(&DefaultRetryPolicy.InitialBackoff, "retry-backoff", DefaultRetryPolicy.InitialBackoff, "time to wait before the first retry of a request to a remote database; the wait doubles with each retry")
+}
+
+// backoff returns how long to wait before the attempt'th retry, the first
+// being 1.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	b := p.InitialBackoff
+	for i := 1; i < attempt && b < p.MaxBackoff; i++ {
+		b *= 2
+	}
+	if p.MaxBackoff > 0 && b > p.MaxBackoff {
+		b = p.MaxBackoff
+	}
+	if b <= 0 {
+		return 0
+	}
+	return b/2 + time.Duration(rand.Int63n(int64(b/2)+1))
+}
+
+// retryable says whether a request which got res or err can be sent again.
+// Only a failure to connect, or a response saying the server is too busy,
+// guarantees that the server didn't act on the request; other failures,
+// such as a connection dropped while waiting for the response, are only
+// retried if the request is idempotent.
+func retryable(res *http.Response, err error, idempotent bool) bool {
+	if err != nil {
+		if ue, ok := err.(*url.Error); ok {
+			err = ue.Err
+		}
+		if oe, ok := err.(*net.OpError); ok && oe.Op == "dial" {
+			return true
+		}
+		return idempotent
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent
+	}
+	return false
+}
+
+// do sends the request made by newReq, retrying it as described by
+// bhcs.retry while it fails transiently. newReq is called for each attempt,
+// so that the request's body can be sent again. retried is true if more than
+// one attempt was made. The result of the last attempt is returned.
+func (bhcs *httpBatchStore) do(newReq func() *http.Request, idempotent bool) (res *http.Response, retried bool, err error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			time.Sleep(bhcs.retry.backoff(attempt))
+			if bhcs.metrics != nil {
+				bhcs.metrics.Retried()
+			}
+			retried = true
+		}
+		req := newReq()
+		res, err = bhcs.httpClient.Do(req)
+		if attempt >= bhcs.retry.Retries || !retryable(res, err, idempotent) {
+			return
+		}
+		if err != nil {
+			verbose.Log("Retrying %s %s: %s", req.Method, req.URL.Path, err)
+		} else {
+			verbose.Log("Retrying %s %s: %s", req.Method, req.URL.Path, res.Status)
+			closeResponse(res.Body)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/hash"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+type failure int
+
+const (
+	// refused fails without sending the request.
+	refused failure = iota
+	// unavailable responds 503 without handling the request.
+	unavailable
+	// lostResponse handles the request, but fails to return the response.
+	lostResponse
+)
+
+// flakyDoer fails the next failures requests it's given, as described by
+// mode, and sends the rest to httpDoer.
+type flakyDoer struct {
+	httpDoer
+	mode failure
+
+	mu       sync.Mutex
+	failures int
+}
+
+func (f *flakyDoer) fail(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = n
+}
+
+func (f *flakyDoer) Do(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	fail := f.failures > 0
+	f.failures--
+	f.mu.Unlock()
+	if !fail {
+		return f.httpDoer.Do(req)
+	}
+
+	switch f.mode {
+	case refused:
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, &url.Error{Op: req.Method, URL: req.URL.String(), Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}
+	case unavailable:
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Status:     http.StatusText(http.StatusServiceUnavailable),
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(&bytes.Buffer{}),
+		}, nil
+	default:
+		res, _ := f.httpDoer.Do(req)
+		res.Body.Close()
+		return nil, &url.Error{Op: req.Method, URL: req.URL.String(), Err: errors.New("connection reset by peer")}
+	}
+}
+
+func (suite *HTTPBatchStoreSuite) flaky(mode failure) (*flakyDoer, *types.ExpvarMetrics) {
+	f := &flakyDoer{httpDoer: suite.store.httpClient, mode: mode}
+	suite.store.httpClient = f
+	suite.store.retry = RetryPolicy{Retries: 3, InitialBackoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond}
+	m := types.NewExpvarMetrics("")
+	types.NewValueStore(suite.store).SetMetrics(m)
+	return f, m
+}
+
+func (suite *HTTPBatchStoreSuite) TestRetryReadsAndWrites() {
+	for i, mode := range []failure{refused, unavailable, lostResponse} {
+		if i > 0 {
+			suite.TearDownTest()
+			suite.SetupTest()
+		}
+		f, m := suite.flaky(mode)
+
+		c := types.EncodeValue(types.NewMap(), nil)
+		suite.store.SchedulePut(c)
+		f.fail(2)
+		suite.store.Flush()
+		suite.True(suite.cs.Has(c.Hash()))
+
+		f.fail(3)
+		suite.True(suite.store.UpdateRoot(c.Hash(), hash.Hash{}))
+		suite.Equal(c.Hash(), suite.cs.Root())
+
+		f.fail(1)
+		suite.Equal(c.Hash(), suite.store.Root())
+
+		other := chunks.NewChunk([]byte("def"))
+		suite.cs.Put(other)
+		f.fail(2)
+		suite.Equal(other.Hash(), suite.store.Get(other.Hash()).Hash())
+		f.fail(2)
+		suite.True(suite.store.Has(other.Hash()))
+
+		suite.Equal(int64(10), m.Retries.Value())
+	}
+}
+
+func (suite *HTTPBatchStoreSuite) TestRetryGivesUp() {
+	f, m := suite.flaky(unavailable)
+	f.fail(4)
+	suite.Panics(func() { suite.store.Root() })
+	suite.Equal(int64(3), m.Retries.Value())
+}
+
+func (suite *HTTPBatchStoreSuite) TestRetryLockOnlyIfIdempotent() {
+	f, m := suite.flaky(lostResponse)
+
+	// Taking the lock may have succeeded, so it isn't retried.
+	f.fail(1)
+	suite.Panics(func() { suite.store.lockDataset("ds", "", time.Minute) })
+	suite.Equal(int64(0), m.Retries.Value())
+
+	// But a request which was refused is.
+	f.mode = refused
+	f.fail(1)
+	_, ok := suite.store.lockDataset("other", "", time.Minute)
+	suite.True(ok)
+	suite.Equal(int64(1), m.Retries.Value())
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	assert := assert.New(t)
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	for attempt, max := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		max *= time.Millisecond
+		b := p.backoff(attempt + 1)
+		assert.True(b >= max/2 && b <= max, "attempt %d: %s", attempt+1, b)
+	}
+	assert.Equal(time.Duration(0), RetryPolicy{}.backoff(1))
+}
@@ -0,0 +1,78 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"context"
+	"time"
+
+	"github.com/attic-labs/noms/go/types"
+)
+
+// PathChange is a change of the value at a Path in the head value of a Dataset, as reported by WatchPath.
+type PathChange struct {
+	// Head is the head of the Dataset with the New value, or the zero Ref if the Dataset was deleted.
+	Head types.Ref
+	// Old and New are the values at the Path before and after the change. Either is nil if the Path didn't resolve.
+	Old, New types.Value
+}
+
+// rootRefresher is a Database which can be made to see changes made to its root by other Databases, e.g. other clients of the same server.
+type rootRefresher interface {
+	rootChanged()
+}
+
+// WatchPath sends a PathChange to changes whenever the value at path in the head value of the Dataset datasetID of db changes, until ctx is done, when it returns ctx.Err(). It's for apps which keep a view of part of a Dataset, e.g. of the entry for one key of a Map which is imported anew every day, up to date.
+//
+// The first PathChange, sent straight away, is from nil to the value at path in the current head, if it resolves. After that, db is checked for a new head every interval, which is only sent if the value at path has changed. Since values are compared by hash, and resolving path only reads the chunks along it, a check costs about the same however large the head value is. There's no notification of new commits, so a change which is undone within an interval may not be seen, and changes are only seen if db sees them: a Database served by noms serve sees the commits of every client, but a local one may only see the commits made through it.
+func WatchPath(ctx context.Context, db Database, datasetID string, path types.Path, interval time.Duration, changes chan<- PathChange) error {
+	var head types.Ref
+	var hasHead bool
+	var value, resolved types.Value
+	check := func() bool {
+		if r, ok := db.(rootRefresher); ok {
+			r.rootChanged()
+		}
+		ds := db.GetDataset(datasetID)
+		newHead, ok := ds.MaybeHeadRef()
+		if ok == hasHead && (!ok || newHead.Equals(head)) {
+			return true
+		}
+		head, hasHead = newHead, ok
+
+		newValue, _ := ds.MaybeHeadValue()
+		if newValue != nil && value != nil && newValue.Equals(value) {
+			return true
+		}
+		value = newValue
+
+		var newResolved types.Value
+		if newValue != nil {
+			newResolved = path.Resolve(newValue, db)
+		}
+		if newResolved == nil && resolved == nil || newResolved != nil && resolved != nil && newResolved.Equals(resolved) {
+			return true
+		}
+		change := PathChange{Head: head, Old: resolved, New: newResolved}
+		resolved = newResolved
+		select {
+		case changes <- change:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for check() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,80 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package datas
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestWatchPath(t *testing.T) {
+	assert := assert.New(t)
+	cs := chunks.NewMemoryStore()
+	// The watcher sees the commits of another Database of the same store.
+	writer, watched := NewDatabase(cs), NewDatabase(cs)
+	defer writer.Close()
+	defer watched.Close()
+
+	commit := func(m types.Map) {
+		_, err := writer.CommitValue(writer.GetDataset("ds"), m)
+		assert.NoError(err)
+	}
+	commit(types.NewMap(types.String("a"), types.Number(1), types.String("b"), types.Number(2)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan PathChange)
+	done := make(chan error)
+	go func() {
+		done <- WatchPath(ctx, watched, "ds", types.MustParsePath(`["a"]`), time.Millisecond, changes)
+	}()
+
+	next := func() PathChange {
+		select {
+		case c := <-changes:
+			return c
+		case <-time.After(10 * time.Second):
+			assert.Fail("timed out waiting for a change")
+			return PathChange{}
+		}
+	}
+	assertChange := func(old, new types.Value, c PathChange) {
+		assert.Equal(old == nil, c.Old == nil)
+		if old != nil && c.Old != nil {
+			assert.True(old.Equals(c.Old))
+		}
+		assert.Equal(new == nil, c.New == nil)
+		if new != nil && c.New != nil {
+			assert.True(new.Equals(c.New))
+		}
+	}
+
+	assertChange(nil, types.Number(1), next())
+
+	// A change to another key isn't reported.
+	commit(types.NewMap(types.String("a"), types.Number(1), types.String("b"), types.Number(3)))
+	commit(types.NewMap(types.String("a"), types.Number(4), types.String("b"), types.Number(3)))
+	c := next()
+	assertChange(types.Number(1), types.Number(4), c)
+	assert.True(writer.GetDataset("ds").HeadRef().Equals(c.Head))
+
+	commit(types.NewMap(types.String("b"), types.Number(3)))
+	assertChange(types.Number(4), nil, next())
+
+	commit(types.NewMap(types.String("a"), types.Number(5)))
+	assertChange(nil, types.Number(5), next())
+	_, err := writer.Delete(writer.GetDataset("ds"))
+	assert.NoError(err)
+	c = next()
+	assertChange(types.Number(5), nil, c)
+	assert.Equal(types.Ref{}, c.Head)
+
+	cancel()
+	assert.Equal(context.Canceled, <-done)
+}
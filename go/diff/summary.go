@@ -6,6 +6,7 @@ package diff
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/attic-labs/noms/go/datas"
 	"github.com/attic-labs/noms/go/types"
@@ -13,7 +14,9 @@ import (
 	humanize "github.com/dustin/go-humanize"
 )
 
-// Summary prints a summary of the diff between two values to stdout.
+// Summary prints a summary of the diff between two values to stdout. If the
+// values are structs, the changes to each of their fields which are
+// collections or structs are summarized too.
 func Summary(value1, value2 types.Value) {
 	if datas.IsCommit(value1) && datas.IsCommit(value2) {
 		fmt.Println("Comparing commit values")
@@ -21,137 +24,38 @@ func Summary(value1, value2 types.Value) {
 		value2 = value2.(types.Struct).Get(datas.ValueField)
 	}
 
-	var singular, plural string
-	if value1.Kind() == value2.Kind() {
-		switch value1.Kind() {
-		case types.StructKind:
-			singular = "field"
-			plural = "fields"
-		case types.MapKind:
-			singular = "entry"
-			plural = "entries"
-		default:
-			singular = "value"
-			plural = "values"
+	summary := types.DiffSummary(value1, value2)
+	root, ok := summary[""]
+	if !ok {
+		root = types.DiffCounts{Kind: value1.Kind(), OldSize: 1, NewSize: 1}
+		if c, ok := value1.(types.Collection); ok {
+			root.OldSize, root.NewSize = c.Len(), c.Len()
 		}
 	}
-
-	ch := make(chan diffSummaryProgress)
-	go func() {
-		diffSummary(ch, value1, value2)
-		close(ch)
-	}()
-
-	acc := diffSummaryProgress{}
-	for p := range ch {
-		acc.Adds += p.Adds
-		acc.Removes += p.Removes
-		acc.Changes += p.Changes
-		acc.NewSize += p.NewSize
-		acc.OldSize += p.OldSize
-		if status.WillPrint() {
-			formatStatus(acc, singular, plural)
-		}
-	}
-	formatStatus(acc, singular, plural)
+	status.Printf("%s", formatCounts(root))
 	status.Done()
-}
-
-type diffSummaryProgress struct {
-	Adds, Removes, Changes, NewSize, OldSize uint64
-}
 
-func diffSummary(ch chan diffSummaryProgress, v1, v2 types.Value) {
-	if !v1.Equals(v2) {
-		if shouldDescend(v1, v2) {
-			switch v1.Kind() {
-			case types.ListKind:
-				diffSummaryList(ch, v1.(types.List), v2.(types.List))
-			case types.MapKind:
-				diffSummaryMap(ch, v1.(types.Map), v2.(types.Map))
-			case types.SetKind:
-				diffSummarySet(ch, v1.(types.Set), v2.(types.Set))
-			case types.StructKind:
-				diffSummaryStructs(ch, v1.(types.Struct), v2.(types.Struct))
-			default:
-				panic("Unrecognized type in diff function: " + types.TypeOf(v1).Describe() + " and " + types.TypeOf(v2).Describe())
-			}
-		} else {
-			ch <- diffSummaryProgress{Adds: 1, Removes: 1, NewSize: 1, OldSize: 1}
+	paths := []string{}
+	for p := range summary {
+		if p != "" {
+			paths = append(paths, p)
 		}
 	}
-}
-
-func diffSummaryList(ch chan<- diffSummaryProgress, v1, v2 types.List) {
-	ch <- diffSummaryProgress{OldSize: v1.Len(), NewSize: v2.Len()}
-
-	spliceChan := make(chan types.Splice)
-	stopChan := make(chan struct{}, 1) // buffer size of 1, so this won't block if diff already finished
-
-	go func() {
-		v2.Diff(v1, spliceChan, stopChan)
-		close(spliceChan)
-	}()
-
-	for splice := range spliceChan {
-		if splice.SpRemoved == splice.SpAdded {
-			ch <- diffSummaryProgress{Changes: splice.SpRemoved}
-		} else {
-			ch <- diffSummaryProgress{Adds: splice.SpAdded, Removes: splice.SpRemoved}
-		}
+	sort.Strings(paths)
+	for _, p := range paths {
+		fmt.Printf("  %s: %s\n", p, formatCounts(summary[p]))
 	}
 }
 
-func diffSummaryMap(ch chan<- diffSummaryProgress, v1, v2 types.Map) {
-	diffSummaryValueChanged(ch, v1.Len(), v2.Len(), func(changeChan chan<- types.ValueChanged, stopChan <-chan struct{}) {
-		v2.Diff(v1, changeChan, stopChan)
-	})
-}
-
-func diffSummarySet(ch chan<- diffSummaryProgress, v1, v2 types.Set) {
-	diffSummaryValueChanged(ch, v1.Len(), v2.Len(), func(changeChan chan<- types.ValueChanged, stopChan <-chan struct{}) {
-		v2.Diff(v1, changeChan, stopChan)
-	})
-}
-
-func diffSummaryStructs(ch chan<- diffSummaryProgress, v1, v2 types.Struct) {
-	// TODO: Operate on values directly
-	size1 := uint64(types.TypeOf(v1).Desc.(types.StructDesc).Len())
-	size2 := uint64(types.TypeOf(v2).Desc.(types.StructDesc).Len())
-	diffSummaryValueChanged(ch, size1, size2, func(changeChan chan<- types.ValueChanged, stopChan <-chan struct{}) {
-		v2.Diff(v1, changeChan, stopChan)
-	})
-}
-
-func diffSummaryValueChanged(ch chan<- diffSummaryProgress, oldSize, newSize uint64, f diffFunc) {
-	ch <- diffSummaryProgress{OldSize: oldSize, NewSize: newSize}
-
-	changeChan := make(chan types.ValueChanged)
-	stopChan := make(chan struct{}, 1) // buffer size of 1, so this won't block if diff already finished
-
-	go func() {
-		f(changeChan, stopChan)
-		close(changeChan)
-	}()
-	reportChanges(ch, changeChan)
-}
-
-func reportChanges(ch chan<- diffSummaryProgress, changeChan chan types.ValueChanged) {
-	for change := range changeChan {
-		switch change.ChangeType {
-		case types.DiffChangeAdded:
-			ch <- diffSummaryProgress{Adds: 1}
-		case types.DiffChangeRemoved:
-			ch <- diffSummaryProgress{Removes: 1}
-		case types.DiffChangeModified:
-			ch <- diffSummaryProgress{Changes: 1}
-		default:
-			panic("unknown change type")
-		}
+func formatCounts(dc types.DiffCounts) string {
+	singular, plural := "value", "values"
+	switch dc.Kind {
+	case types.StructKind:
+		singular, plural = "field", "fields"
+	case types.MapKind:
+		singular, plural = "entry", "entries"
 	}
-}
 
-func formatStatus(acc diffSummaryProgress, singular, plural string) {
 	pluralize := func(singular, plural string, n uint64) string {
 		var noun string
 		if n != 1 {
@@ -162,12 +66,12 @@ func formatStatus(acc diffSummaryProgress, singular, plural string) {
 		return fmt.Sprintf("%s %s", humanize.Comma(int64(n)), noun)
 	}
 
-	insertions := pluralize("insertion", "insertions", acc.Adds)
-	deletions := pluralize("deletion", "deletions", acc.Removes)
-	changes := pluralize("change", "changes", acc.Changes)
+	insertions := pluralize("insertion", "insertions", dc.Adds)
+	deletions := pluralize("deletion", "deletions", dc.Removes)
+	changes := pluralize("change", "changes", dc.Modifies)
 
-	oldValues := pluralize(singular, plural, acc.OldSize)
-	newValues := pluralize(singular, plural, acc.NewSize)
+	oldValues := pluralize(singular, plural, dc.OldSize)
+	newValues := pluralize(singular, plural, dc.NewSize)
 
-	status.Printf("%s (%.2f%%), %s (%.2f%%), %s (%.2f%%), (%s vs %s)", insertions, (float64(100*acc.Adds) / float64(acc.OldSize)), deletions, (float64(100*acc.Removes) / float64(acc.OldSize)), changes, (float64(100*acc.Changes) / float64(acc.OldSize)), oldValues, newValues)
+	return fmt.Sprintf("%s (%.2f%%), %s (%.2f%%), %s (%.2f%%), (%s vs %s)", insertions, (float64(100*dc.Adds) / float64(dc.OldSize)), deletions, (float64(100*dc.Removes) / float64(dc.OldSize)), changes, (float64(100*dc.Modifies) / float64(dc.OldSize)), oldValues, newValues)
 }
@@ -0,0 +1,171 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package merge
+
+import (
+	"time"
+
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/types"
+)
+
+// This file has values which converge: any two of them can be merged, with
+// the same result whichever order they're merged in, so apps which commit them
+// from many writers with a ThreeWay Policy never fail to merge them. They're
+// Structs, which can be anywhere in a committed value, and are merged by the
+// StructMergeFuncs registered for their names.
+
+const (
+	// CounterName is the name of counter Structs, see NewCounter.
+	CounterName = "PNCounter"
+	// RegisterName is the name of register Structs, see NewRegister.
+	RegisterName = "LWWRegister"
+)
+
+func init() {
+	RegisterStructMerge(CounterName, mergeCounters)
+	RegisterStructMerge(RegisterName, mergeRegisters)
+}
+
+// NewCounter returns a counter with the value 0. A counter is a Struct with a
+// total of increments and a total of decrements for each writer, which only
+// that writer changes, so two counters are merged by taking the greater of
+// each writer's totals.
+func NewCounter() types.Struct {
+	return newCounter(types.NewMap(), types.NewMap())
+}
+
+func newCounter(inc, dec types.Map) types.Struct {
+	return types.NewStruct(CounterName, types.StructData{"inc": inc, "dec": dec})
+}
+
+// IncrementCounter returns c with delta, which may be negative, added by
+// writer. Every writer of a counter must use a different writer, e.g. a
+// client ID, or merges may lose its changes.
+func IncrementCounter(c types.Struct, writer string, delta float64) types.Struct {
+	inc, dec, ok := counterTotals(c)
+	d.PanicIfFalse(ok)
+	add := func(totals types.Map, delta float64) types.Map {
+		total := types.Number(0)
+		if v, ok := totals.MaybeGet(types.String(writer)); ok {
+			total = v.(types.Number)
+		}
+		return totals.Set(types.String(writer), total+types.Number(delta))
+	}
+	if delta > 0 {
+		inc = add(inc, delta)
+	} else if delta < 0 {
+		dec = add(dec, -delta)
+	}
+	return newCounter(inc, dec)
+}
+
+// CounterValue returns the value of c, the sum of its increments less the sum
+// of its decrements.
+func CounterValue(c types.Struct) float64 {
+	inc, dec, ok := counterTotals(c)
+	d.PanicIfFalse(ok)
+	sum := func(totals types.Map) (s float64) {
+		totals.IterAll(func(k, v types.Value) {
+			s += float64(v.(types.Number))
+		})
+		return
+	}
+	return sum(inc) - sum(dec)
+}
+
+// counterTotals returns the totals of increments and decrements of c, and
+// whether c is a counter.
+func counterTotals(c types.Struct) (inc, dec types.Map, ok bool) {
+	if c.Name() != CounterName {
+		return
+	}
+	incVal, incOk := c.MaybeGet("inc")
+	decVal, decOk := c.MaybeGet("dec")
+	if !incOk || !decOk {
+		return
+	}
+	inc, incOk = incVal.(types.Map)
+	dec, decOk = decVal.(types.Map)
+	return inc, dec, incOk && decOk
+}
+
+func mergeCounters(a, b types.Struct, parent types.Value, vrw types.ValueReadWriter) (types.Value, error) {
+	aInc, aDec, aOk := counterTotals(a)
+	bInc, bDec, bOk := counterTotals(b)
+	if !aOk || !bOk {
+		return parent, newMergeConflict("Cannot merge malformed %s structs.", CounterName)
+	}
+	// The totals of a writer only grow, so the greater is the later.
+	max := func(a, b types.Map) types.Map {
+		merged := a
+		b.IterAll(func(k, v types.Value) {
+			if total, ok := merged.MaybeGet(k); !ok || total.Less(v) {
+				merged = merged.Set(k, v)
+			}
+		})
+		return merged
+	}
+	return newCounter(max(aInc, bInc), max(aDec, bDec)), nil
+}
+
+// NewRegister returns a register holding v, as set by writer at t. A register
+// is a Struct holding a single value, and two registers are merged by taking
+// the one set last. If they were set at the same time, the one with the
+// greater writer is taken. The times are stored to the millisecond.
+func NewRegister(v types.Value, writer string, t time.Time) types.Struct {
+	return types.NewStruct(RegisterName, types.StructData{
+		"value":  v,
+		"writer": types.String(writer),
+		"time":   types.Number(t.UnixNano() / int64(time.Millisecond)),
+	})
+}
+
+// RegisterValue returns the value held by r.
+func RegisterValue(r types.Struct) types.Value {
+	_, _, ok := registerSet(r)
+	d.PanicIfFalse(ok)
+	return r.Get("value")
+}
+
+// registerSet returns when and by whom r was set, and whether r is a register.
+func registerSet(r types.Struct) (t types.Number, writer types.String, ok bool) {
+	if r.Name() != RegisterName {
+		return
+	}
+	if _, ok = r.MaybeGet("value"); !ok {
+		return
+	}
+	tVal, tOk := r.MaybeGet("time")
+	wVal, wOk := r.MaybeGet("writer")
+	if !tOk || !wOk {
+		return t, writer, false
+	}
+	t, tOk = tVal.(types.Number)
+	writer, wOk = wVal.(types.String)
+	return t, writer, tOk && wOk
+}
+
+func mergeRegisters(a, b types.Struct, parent types.Value, vrw types.ValueReadWriter) (types.Value, error) {
+	aTime, aWriter, aOk := registerSet(a)
+	bTime, bWriter, bOk := registerSet(b)
+	if !aOk || !bOk {
+		return parent, newMergeConflict("Cannot merge malformed %s structs.", RegisterName)
+	}
+	switch {
+	case aTime != bTime:
+		if aTime < bTime {
+			return b, nil
+		}
+	case aWriter != bWriter:
+		if aWriter < bWriter {
+			return b, nil
+		}
+	case a.Get("value").Less(b.Get("value")):
+		// The same writer set different values at the same time. Any choice will do, as long as it's the same for every merge.
+		return b, nil
+	}
+	return a, nil
+}
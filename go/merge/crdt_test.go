@@ -0,0 +1,84 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package merge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestCounter(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCounter()
+	assert.Equal(0.0, CounterValue(c))
+	c = IncrementCounter(c, "a", 3)
+	c = IncrementCounter(c, "a", -1)
+	c = IncrementCounter(c, "b", 2)
+	assert.Equal(4.0, CounterValue(c))
+}
+
+func TestCounterMerge(t *testing.T) {
+	assert := assert.New(t)
+	vs := types.NewTestValueStore()
+	defer vs.Close()
+
+	parent := IncrementCounter(NewCounter(), "a", 1)
+	a := IncrementCounter(IncrementCounter(parent, "a", 2), "a", -1)
+	b := IncrementCounter(IncrementCounter(parent, "b", 5), "a", 0)
+
+	merged, err := ThreeWay(types.NewMap(types.String("c"), a), types.NewMap(types.String("c"), b), types.NewMap(types.String("c"), parent), vs, nil, nil)
+	assert.NoError(err)
+	assert.Equal(7.0, CounterValue(merged.(types.Map).Get(types.String("c")).(types.Struct)))
+
+	ab, err := ThreeWay(a, b, parent, vs, nil, nil)
+	assert.NoError(err)
+	ba, err := ThreeWay(b, a, parent, vs, nil, nil)
+	assert.NoError(err)
+	assert.True(ab.Equals(ba))
+	assert.Equal(7.0, CounterValue(ab.(types.Struct)))
+
+	// Merging again changes nothing.
+	again, err := ThreeWay(ab, a, parent, vs, nil, nil)
+	assert.NoError(err)
+	assert.True(ab.Equals(again))
+
+	// Counters added in both candidates are merged too.
+	merged, err = ThreeWay(types.NewMap(types.String("c"), a), types.NewMap(types.String("c"), b), types.NewMap(), vs, nil, nil)
+	assert.NoError(err)
+	assert.Equal(7.0, CounterValue(merged.(types.Map).Get(types.String("c")).(types.Struct)))
+
+	_, err = ThreeWay(a, types.NewStruct(CounterName, types.StructData{"inc": types.Number(1)}), parent, vs, nil, nil)
+	assert.Error(err)
+}
+
+func TestRegisterMerge(t *testing.T) {
+	assert := assert.New(t)
+	vs := types.NewTestValueStore()
+	defer vs.Close()
+
+	now := time.Now()
+	parent := NewRegister(types.String("p"), "a", now)
+	a := NewRegister(types.String("a"), "a", now.Add(time.Second))
+	b := NewRegister(types.String("b"), "b", now.Add(2*time.Second))
+
+	test := func(a, b types.Struct, expected types.Value) {
+		for _, m := range [][2]types.Struct{{a, b}, {b, a}} {
+			merged, err := ThreeWay(types.NewStruct("S", types.StructData{"r": m[0]}), types.NewStruct("S", types.StructData{"r": m[1]}), types.NewStruct("S", types.StructData{"r": parent}), vs, nil, nil)
+			if assert.NoError(err) {
+				assert.True(expected.Equals(RegisterValue(merged.(types.Struct).Get("r").(types.Struct))))
+			}
+		}
+	}
+	test(a, b, types.String("b"))
+
+	// Ties are broken by writer, then by value.
+	c := NewRegister(types.String("c"), "c", now.Add(2*time.Second))
+	test(b, c, types.String("c"))
+	d := NewRegister(types.String("d"), "c", now.Add(2*time.Second))
+	test(c, d, types.String("d"))
+}
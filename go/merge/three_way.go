@@ -43,6 +43,23 @@ func Theirs(aChange, bChange types.DiffChangeType, a, b types.Value, path types.
 	return bChange, b, true
 }
 
+// StructMergeFunc merges two Structs of the same name, a and b, against their
+// common ancestor, parent, which is nil if there isn't one. See
+// RegisterStructMerge.
+type StructMergeFunc func(a, b types.Struct, parent types.Value, vrw types.ValueReadWriter) (merged types.Value, err error)
+
+var structMerges = map[string]StructMergeFunc{}
+
+// RegisterStructMerge registers merge as the way ThreeWay merges Structs
+// named name, instead of field by field. It's for values which can always be
+// merged, such as counters and registers (see NewCounter and NewRegister), so
+// that apps which commit them from many writers don't fail to commit. It
+// should only be called from init().
+func RegisterStructMerge(name string, merge StructMergeFunc) {
+	d.PanicIfTrue(name == "")
+	structMerges[name] = merge
+}
+
 // ErrMergeConflict indicates that a merge attempt failed and must be resolved
 // manually for the provided reason.
 type ErrMergeConflict struct {
@@ -80,7 +97,8 @@ func NewThreeWay(resolve ResolveFunc) Policy {
 //     - if the two merged values are still different: conflict
 //   - if a key was inserted in one candidate and removed in the other: conflict
 // - If the values are structs:
-//   - Same as map, except using field names instead of map keys
+//   - If a StructMergeFunc is registered for their name: the result is that of the StructMergeFunc
+//   - Otherwise, same as map, except using field names instead of map keys
 // - If the values are sets:
 //   - Apply the changes from both candidates to the parent to get the result. No conflicts are possible.
 // - If the values are list:
@@ -208,6 +226,11 @@ func (m *merger) threeWay(a, b, parent types.Value, path types.Path) (merged typ
 		}
 
 	case types.StructKind:
+		if aStruct, bStruct, ok := namedStructAssert(a, b); ok {
+			if merge, ok := structMerges[aStruct.Name()]; ok {
+				return merge(aStruct, bStruct, parent, m.vrw)
+			}
+		}
 		if aStruct, bStruct, pStruct, ok := structAssert(a, b, parent); ok {
 			return m.threeWayStructMerge(aStruct, bStruct, pStruct, path)
 		}
@@ -330,6 +353,13 @@ func setAssert(a, b, parent types.Value) (aSet, bSet, pSet types.Set, ok bool) {
 	return aSet, bSet, pSet, aOk && bOk && pOk
 }
 
+func namedStructAssert(a, b types.Value) (aStruct, bStruct types.Struct, ok bool) {
+	var aOk, bOk bool
+	aStruct, aOk = a.(types.Struct)
+	bStruct, bOk = b.(types.Struct)
+	return aStruct, bStruct, aOk && bOk && aStruct.Name() == bStruct.Name()
+}
+
 func structAssert(a, b, parent types.Value) (aStruct, bStruct, pStruct types.Struct, ok bool) {
 	var aOk, bOk, pOk bool
 	aStruct, aOk = a.(types.Struct)
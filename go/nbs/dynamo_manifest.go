@@ -27,7 +27,8 @@ const (
 )
 
 var (
-	valueEqualsExpression            = fmt.Sprintf("(%s = :prev) and (%s = :vers)", lockAttr, versAttr)
+	// The version of the manifest is constants.NomsVersion, possibly followed by the store's Features, see isNomsVersion().
+	valueEqualsExpression            = fmt.Sprintf("(%s = :prev) and (%s = :vers or begins_with(%s, :versFeatures))", lockAttr, versAttr, versAttr)
 	valueNotExistsOrEqualsExpression = fmt.Sprintf("attribute_not_exists("+lockAttr+") or %s", valueEqualsExpression)
 )
 
@@ -88,13 +89,13 @@ func validateManifest(item map[string]*dynamodb.AttributeValue) (valid, hasSpecs
 	return false, false
 }
 
-func (dm dynamoManifest) Update(lastLock, newLock addr, specs []tableSpec, newRoot hash.Hash, writeHook func()) (lock addr, actual hash.Hash, tableSpecs []tableSpec) {
+func (dm dynamoManifest) Update(lastLock, newLock addr, specs []tableSpec, newRoot hash.Hash, newVers string, writeHook func()) (lock addr, actual hash.Hash, tableSpecs []tableSpec, vers string) {
 	putArgs := dynamodb.PutItemInput{
 		TableName: aws.String(dm.table),
 		Item: map[string]*dynamodb.AttributeValue{
 			dbAttr:      {S: aws.String(dm.db)},
 			nbsVersAttr: {S: aws.String(StorageVersion)},
-			versAttr:    {S: aws.String(constants.NomsVersion)},
+			versAttr:    {S: aws.String(newVers)},
 			rootAttr:    {B: newRoot[:]},
 			lockAttr:    {B: newLock[:]},
 		},
@@ -112,8 +113,9 @@ func (dm dynamoManifest) Update(lastLock, newLock addr, specs []tableSpec, newRo
 
 	putArgs.ConditionExpression = aws.String(expr)
 	putArgs.ExpressionAttributeValues = map[string]*dynamodb.AttributeValue{
-		":prev": {B: lastLock[:]},
-		":vers": {S: aws.String(constants.NomsVersion)},
+		":prev":         {B: lastLock[:]},
+		":vers":         {S: aws.String(constants.NomsVersion)},
+		":versFeatures": {S: aws.String(constants.NomsVersion + "+")},
 	}
 
 	_, ddberr := dm.ddbsvc.PutItem(&putArgs)
@@ -122,12 +124,12 @@ func (dm dynamoManifest) Update(lastLock, newLock addr, specs []tableSpec, newRo
 			if awsErr.Code() == "ConditionalCheckFailedException" {
 				exists, vers, lock, actual, tableSpecs := dm.ParseIfExists(nil)
 				d.Chk.True(exists)
-				d.Chk.True(vers == constants.NomsVersion)
-				return lock, actual, tableSpecs
+				d.Chk.True(isNomsVersion(vers))
+				return lock, actual, tableSpecs, vers
 			} // TODO handle other aws errors?
 		}
 		d.Chk.NoError(ddberr)
 	}
 
-	return newLock, newRoot, specs
+	return newLock, newRoot, specs, newVers
 }
@@ -6,6 +6,7 @@ package nbs
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/attic-labs/noms/go/constants"
@@ -60,7 +61,7 @@ func TestDynamoManifestUpdateWontClobberOldVersion(t *testing.T) {
 	badRoot := hash.Of([]byte("bad root"))
 	ddb.put(db, lock[:], badRoot[:], "0", "")
 
-	assert.Panics(func() { mm.Update(lock, addr{}, nil, hash.Hash{}, nil) })
+	assert.Panics(func() { mm.Update(lock, addr{}, nil, hash.Hash{}, constants.NomsVersion, nil) })
 }
 
 func TestDynamoManifestUpdate(t *testing.T) {
@@ -70,7 +71,7 @@ func TestDynamoManifestUpdate(t *testing.T) {
 	// First, test winning the race against another process.
 	newLock, newRoot := computeAddr([]byte("locker")), hash.Of([]byte("new root"))
 	specs := []tableSpec{{computeAddr([]byte("a")), 3}}
-	lock, actual, tableSpecs := mm.Update(addr{}, newLock, specs, newRoot, func() {
+	lock, actual, tableSpecs, _ := mm.Update(addr{}, newLock, specs, newRoot, constants.NomsVersion, func() {
 		// This should fail to get the lock, and therefore _not_ clobber the manifest. So the Update should succeed.
 		lock := computeAddr([]byte("nolock"))
 		newRoot2 := hash.Of([]byte("noroot"))
@@ -82,11 +83,11 @@ func TestDynamoManifestUpdate(t *testing.T) {
 
 	// Now, test the case where the optimistic lock fails, and someone else updated the root since last we checked.
 	newLock2, newRoot2 := computeAddr([]byte("locker 2")), hash.Of([]byte("new root 2"))
-	lock, actual, tableSpecs = mm.Update(addr{}, newLock2, nil, newRoot2, nil)
+	lock, actual, tableSpecs, _ = mm.Update(addr{}, newLock2, nil, newRoot2, constants.NomsVersion, nil)
 	assert.Equal(newLock, lock)
 	assert.Equal(newRoot, actual)
 	assert.Equal(specs, tableSpecs)
-	lock, actual, tableSpecs = mm.Update(lock, newLock2, nil, newRoot2, nil)
+	lock, actual, tableSpecs, _ = mm.Update(lock, newLock2, nil, newRoot2, constants.NomsVersion, nil)
 	assert.Equal(newLock2, lock)
 	assert.Equal(newRoot2, actual)
 	assert.Empty(tableSpecs)
@@ -97,7 +98,7 @@ func TestDynamoManifestUpdate(t *testing.T) {
 	ddb.put(db, jerkLock[:], newRoot2[:], constants.NomsVersion, tableName.String()+":1")
 
 	newLock3, newRoot3 := computeAddr([]byte("locker 3")), hash.Of([]byte("new root 3"))
-	lock, actual, tableSpecs = mm.Update(lock, newLock3, nil, newRoot3, nil)
+	lock, actual, tableSpecs, _ = mm.Update(lock, newLock3, nil, newRoot3, constants.NomsVersion, nil)
 	assert.Equal(jerkLock, lock)
 	assert.Equal(newRoot2, actual)
 	assert.Equal([]tableSpec{{tableName, 1}}, tableSpecs)
@@ -108,7 +109,7 @@ func TestDynamoManifestUpdateEmpty(t *testing.T) {
 	mm, _ := makeDynamoManifestFake(t)
 
 	l := computeAddr([]byte{0x01})
-	lock, actual, tableSpecs := mm.Update(addr{}, l, nil, hash.Hash{}, nil)
+	lock, actual, tableSpecs, _ := mm.Update(addr{}, l, nil, hash.Hash{}, constants.NomsVersion, nil)
 	assert.Equal(l, lock)
 	assert.True(actual.IsEmpty())
 	assert.Empty(tableSpecs)
@@ -170,7 +171,8 @@ func (m *fakeDDB) PutItem(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput
 
 	m.assert.NotNil(input.Item[versAttr], "%s should have been present", versAttr)
 	m.assert.NotNil(input.Item[versAttr].S, "nbsVers should have been a String: %+v", input.Item[versAttr])
-	m.assert.Equal(constants.NomsVersion, *input.Item[versAttr].S)
+	m.assert.True(isNomsVersion(*input.Item[versAttr].S))
+	vers := *input.Item[versAttr].S
 
 	m.assert.NotNil(input.Item[lockAttr], "%s should have been present", lockAttr)
 	m.assert.NotNil(input.Item[lockAttr].B, "lock should have been a blob: %+v", input.Item[lockAttr])
@@ -195,12 +197,13 @@ func (m *fakeDDB) PutItem(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput
 		return nil, mockAWSError("ConditionalCheckFailedException")
 	}
 
-	m.put(key, lock, root, constants.NomsVersion, specs)
+	m.put(key, lock, root, vers, specs)
 	m.numPuts++
 
 	return &dynamodb.PutItemOutput{}, nil
 }
 
 func checkCondition(current record, expressionAttrVals map[string]*dynamodb.AttributeValue) bool {
-	return current.vers == *expressionAttrVals[":vers"].S && bytes.Equal(current.lock, expressionAttrVals[":prev"].B)
+	versOk := current.vers == *expressionAttrVals[":vers"].S || strings.HasPrefix(current.vers, *expressionAttrVals[":versFeatures"].S)
+	return versOk && bytes.Equal(current.lock, expressionAttrVals[":prev"].B)
 }
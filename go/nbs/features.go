@@ -0,0 +1,128 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package nbs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/attic-labs/noms/go/constants"
+)
+
+// Feature is a capability of the data in a store beyond what its Noms version
+// implies, such as a new encoding of values. A store records the Features it
+// has in its manifest, so that a new encoding can be rolled out without
+// binaries which can't read it corrupting stores which use it.
+//
+// The Features are recorded in the Noms version of the manifest, which is the
+// version followed by a "+", a Feature and its Compatibility for each
+// Feature, e.g. "7.8+rle/ro". Binaries from before Features see a version
+// other than theirs, and so refuse to write to the store or serve it, but they
+// can still open it and read from it directly, whatever its Features.
+type Feature string
+
+// The Features which new encodings are expected to need. None of them are
+// known to this binary yet.
+const (
+	FeatureRLE         Feature = "rle"
+	FeatureDictionary  Feature = "dict"
+	FeatureCompression Feature = "compress"
+	FeatureColumnar    Feature = "columnar"
+)
+
+// Compatibility says what a binary which doesn't know of a Feature can do
+// with a store which has it. It's recorded with the Feature, since such a
+// binary can't know it otherwise.
+type Compatibility uint8
+
+const (
+	// Compatible Features can be ignored, e.g. an index which is kept up to
+	// date by readers which know of it.
+	Compatible Compatibility = iota
+	// ReadCompatible Features can be ignored by readers, but not by writers,
+	// e.g. an encoding of values which is only used when it's read, so stores
+	// which have them are opened read-only.
+	ReadCompatible
+	// Incompatible Features can't be ignored, e.g. an encoding of values which
+	// can't be decoded otherwise, so stores which have them can't be opened.
+	Incompatible
+)
+
+var compatibilityNames = map[Compatibility]string{
+	Compatible:     "compat",
+	ReadCompatible: "ro",
+	Incompatible:   "incompat",
+}
+
+// knownFeatures are the Features this binary can read and write, with the
+// Compatibility which is recorded when it enables them.
+var knownFeatures = map[Feature]Compatibility{}
+
+// features are the Features of a store.
+type features map[Feature]Compatibility
+
+// parseVersion splits vers, the Noms version of a manifest, into the Noms
+// version of the data in the store and the store's Features.
+func parseVersion(vers string) (nomsVersion string, fs features, err error) {
+	parts := strings.Split(vers, "+")
+	fs = features{}
+	for _, part := range parts[1:] {
+		idx := strings.LastIndex(part, "/")
+		if idx <= 0 {
+			return "", nil, fmt.Errorf("Malformed feature %s in version %s", part, vers)
+		}
+		f, name := Feature(part[:idx]), part[idx+1:]
+		found := false
+		for c, n := range compatibilityNames {
+			if n == name {
+				fs[f], found = c, true
+			}
+		}
+		if !found {
+			return "", nil, fmt.Errorf("Unknown compatibility %s of feature %s in version %s", name, f, vers)
+		}
+	}
+	return parts[0], fs, nil
+}
+
+// isNomsVersion returns whether vers, the Noms version of a manifest, is
+// constants.NomsVersion, with or without Features.
+func isNomsVersion(vers string) bool {
+	return vers == constants.NomsVersion || strings.HasPrefix(vers, constants.NomsVersion+"+")
+}
+
+// formatVersion is the inverse of parseVersion. The Features are in order of
+// name, so a store's version only changes when its Features do.
+func formatVersion(nomsVersion string, fs features) string {
+	strs := make([]string, 0, len(fs))
+	for f, c := range fs {
+		strs = append(strs, fmt.Sprintf("%s/%s", f, compatibilityNames[c]))
+	}
+	sort.Strings(strs)
+	return strings.Join(append([]string{nomsVersion}, strs...), "+")
+}
+
+// readOnly returns whether a store with fs must only be read by this binary,
+// and an error if it can't be opened at all.
+func (fs features) readOnly() (bool, error) {
+	ro, unknown := false, []string{}
+	for f, c := range fs {
+		if _, ok := knownFeatures[f]; ok {
+			continue
+		}
+		switch c {
+		case ReadCompatible:
+			ro = true
+		case Incompatible:
+			unknown = append(unknown, string(f))
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return false, fmt.Errorf("Store has features %s, which this version of Noms (%s) doesn't support", strings.Join(unknown, ", "), constants.NomsVersion)
+	}
+	return ro, nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package nbs
+
+import (
+	"testing"
+
+	"github.com/attic-labs/noms/go/constants"
+	"github.com/attic-labs/noms/go/hash"
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestParseVersion(t *testing.T) {
+	assert := assert.New(t)
+
+	vers, fs, err := parseVersion("7.8")
+	assert.NoError(err)
+	assert.Equal("7.8", vers)
+	assert.Empty(fs)
+	assert.Equal("7.8", formatVersion(vers, fs))
+
+	vers, fs, err = parseVersion("7.8+rle/ro+dict/incompat+idx/compat")
+	assert.NoError(err)
+	assert.Equal("7.8", vers)
+	assert.Equal(features{FeatureRLE: ReadCompatible, FeatureDictionary: Incompatible, "idx": Compatible}, fs)
+	assert.Equal("7.8+dict/incompat+idx/compat+rle/ro", formatVersion(vers, fs))
+
+	for _, bad := range []string{"7.8+rle", "7.8+/ro", "7.8+rle/never"} {
+		_, _, err = parseVersion(bad)
+		assert.Error(err, bad)
+	}
+
+	assert.True(isNomsVersion(constants.NomsVersion))
+	assert.True(isNomsVersion(constants.NomsVersion + "+rle/ro"))
+	assert.False(isNomsVersion(constants.NomsVersion + "0"))
+}
+
+// withKnownFeatures runs f as if this binary knew of fs.
+func withKnownFeatures(fs features, f func()) {
+	old := knownFeatures
+	defer func() { knownFeatures = old }()
+	knownFeatures = fs
+	f()
+}
+
+func TestStoreFeatures(t *testing.T) {
+	assert := assert.New(t)
+	root := hash.Of([]byte("root"))
+
+	openWith := func(vers string) (*fakeManifest, *NomsBlockStore) {
+		fm := &fakeManifest{}
+		fm.set(vers, computeAddr([]byte("lock")), root, nil)
+		return fm, newNomsBlockStore(fm, newFakeTableSet(), 0, defaultMaxTables)
+	}
+
+	// Compatible features are kept, but otherwise ignored.
+	fm, store := openWith(constants.NomsVersion + "+idx/compat")
+	assert.Equal(constants.NomsVersion, store.Version())
+	assert.False(store.ReadOnly())
+	newRoot := hash.Of([]byte("new root"))
+	assert.True(store.UpdateRoot(newRoot, root))
+	assert.Equal(constants.NomsVersion+"+idx/compat", fm.version)
+
+	// Stores with unknown ReadCompatible features can be read, but not written.
+	_, store = openWith(constants.NomsVersion + "+rle/ro")
+	assert.True(store.ReadOnly())
+	assert.Equal(root, store.Root())
+	assert.False(store.UpdateRoot(newRoot, root))
+	assert.Equal(root, store.Root())
+	assert.NotPanics(store.Flush)
+
+	// Stores with unknown Incompatible features can't be opened.
+	assert.Panics(func() { openWith(constants.NomsVersion + "+rle/incompat") })
+
+	// Unless they're known.
+	withKnownFeatures(features{FeatureRLE: Incompatible}, func() {
+		fm, store = openWith(constants.NomsVersion + "+rle/incompat")
+		assert.False(store.ReadOnly())
+		assert.True(store.UpdateRoot(newRoot, root))
+		assert.Equal(constants.NomsVersion+"+rle/incompat", fm.version)
+	})
+}
+
+func TestStoreEnableFeature(t *testing.T) {
+	assert := assert.New(t)
+
+	fm, _, store := makeStoreWithFakes(t)
+	defer store.Close()
+	assert.Error(store.EnableFeature(FeatureRLE))
+
+	withKnownFeatures(features{FeatureRLE: ReadCompatible}, func() {
+		assert.NoError(store.EnableFeature(FeatureRLE))
+		assert.Equal(map[Feature]Compatibility{FeatureRLE: ReadCompatible}, store.Features())
+		assert.True(store.UpdateRoot(hash.Of([]byte("root")), hash.Hash{}))
+		assert.Equal(constants.NomsVersion+"+rle/ro", fm.version)
+	})
+
+	// Features enabled by another writer are kept when the root is updated.
+	other := newNomsBlockStore(fm, newFakeTableSet(), 0, defaultMaxTables)
+	assert.True(other.ReadOnly())
+	withKnownFeatures(features{FeatureRLE: ReadCompatible, FeatureDictionary: Compatible}, func() {
+		other := newNomsBlockStore(fm, newFakeTableSet(), 0, defaultMaxTables)
+		assert.False(other.ReadOnly())
+		assert.NoError(other.EnableFeature(FeatureDictionary))
+		assert.True(other.UpdateRoot(hash.Of([]byte("other root")), hash.Of([]byte("root"))))
+
+		assert.False(store.UpdateRoot(hash.Of([]byte("newer root")), hash.Of([]byte("root"))))
+		assert.Equal(map[Feature]Compatibility{FeatureRLE: ReadCompatible, FeatureDictionary: Compatible}, store.Features())
+		assert.True(store.UpdateRoot(hash.Of([]byte("newer root")), hash.Of([]byte("other root"))))
+		assert.Equal(constants.NomsVersion+"+dict/compat+rle/ro", fm.version)
+	})
+}
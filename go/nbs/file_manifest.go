@@ -13,7 +13,6 @@ import (
 
 	"golang.org/x/sys/unix"
 
-	"github.com/attic-labs/noms/go/constants"
 	"github.com/attic-labs/noms/go/d"
 	"github.com/attic-labs/noms/go/hash"
 )
@@ -84,14 +83,14 @@ func parseManifest(r io.Reader) (nomsVersion string, lock addr, root hash.Hash,
 	return slices[1], ParseAddr([]byte(slices[2])), hash.Parse(slices[3]), parseSpecs(slices[4:])
 }
 
-func (fm fileManifest) Update(lastLock, newLock addr, specs []tableSpec, newRoot hash.Hash, writeHook func()) (lock addr, actual hash.Hash, tableSpecs []tableSpec) {
+func (fm fileManifest) Update(lastLock, newLock addr, specs []tableSpec, newRoot hash.Hash, newVers string, writeHook func()) (lock addr, actual hash.Hash, tableSpecs []tableSpec, vers string) {
 	// Write a temporary manifest file, to be renamed over manifestFileName upon success.
 	// The closure here ensures this file is closed before moving on.
 	tempManifestPath := func() string {
 		temp, err := ioutil.TempFile(fm.dir, "nbs_manifest_")
 		d.PanicIfError(err)
 		defer checkClose(temp)
-		writeManifest(temp, newLock, newRoot, specs)
+		writeManifest(temp, newVers, newLock, newRoot, specs)
 		return temp.Name()
 	}()
 	defer os.Remove(tempManifestPath) // If we rename below, this will be a no-op
@@ -110,25 +109,24 @@ func (fm fileManifest) Update(lastLock, newLock addr, specs []tableSpec, newRoot
 		if f := openIfExists(manifestPath); f != nil {
 			defer checkClose(f)
 
-			var mVers string
-			mVers, lock, actual, tableSpecs = parseManifest(f)
-			d.PanicIfFalse(constants.NomsVersion == mVers)
+			vers, lock, actual, tableSpecs = parseManifest(f)
+			d.PanicIfFalse(isNomsVersion(vers))
 		} else {
 			d.Chk.True(lastLock == addr{})
 		}
 	}()
 
 	if lastLock != lock {
-		return lock, actual, tableSpecs
+		return lock, actual, tableSpecs, vers
 	}
 	rerr := os.Rename(tempManifestPath, manifestPath)
 	d.PanicIfError(rerr)
-	return newLock, newRoot, specs
+	return newLock, newRoot, specs, newVers
 }
 
-func writeManifest(temp io.Writer, lock addr, root hash.Hash, specs []tableSpec) {
+func writeManifest(temp io.Writer, vers string, lock addr, root hash.Hash, specs []tableSpec) {
 	strs := make([]string, 2*len(specs)+4)
-	strs[0], strs[1], strs[2], strs[3] = StorageVersion, constants.NomsVersion, lock.String(), root.String()
+	strs[0], strs[1], strs[2], strs[3] = StorageVersion, vers, lock.String(), root.String()
 	tableInfo := strs[4:]
 	formatSpecs(specs, tableInfo)
 	_, err := io.WriteString(temp, strings.Join(strs, ":"))
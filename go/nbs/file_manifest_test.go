@@ -90,7 +90,7 @@ func TestFileManifestUpdateWontClobberOldVersion(t *testing.T) {
 	err := clobberManifest(fm.dir, strings.Join([]string{StorageVersion, "0", addr{}.String(), hash.Hash{}.String()}, ":"))
 	assert.NoError(err)
 
-	assert.Panics(func() { fm.Update(addr{}, addr{}, nil, hash.Hash{}, nil) })
+	assert.Panics(func() { fm.Update(addr{}, addr{}, nil, hash.Hash{}, constants.NomsVersion, nil) })
 }
 
 func TestFileManifestUpdateEmpty(t *testing.T) {
@@ -99,7 +99,7 @@ func TestFileManifestUpdateEmpty(t *testing.T) {
 	defer os.RemoveAll(fm.dir)
 
 	l := computeAddr([]byte{0x01})
-	lock, actual, tableSpecs := fm.Update(addr{}, l, nil, hash.Hash{}, nil)
+	lock, actual, tableSpecs, _ := fm.Update(addr{}, l, nil, hash.Hash{}, constants.NomsVersion, nil)
 	assert.Equal(l, lock)
 	assert.True(actual.IsEmpty())
 	assert.Empty(tableSpecs)
@@ -112,7 +112,7 @@ func TestFileManifestUpdateEmpty(t *testing.T) {
 	assert.Empty(tableSpecs)
 
 	l2 := computeAddr([]byte{0x02})
-	lock, actual, tableSpecs = fm2.Update(l, l2, nil, hash.Hash{}, nil)
+	lock, actual, tableSpecs, _ = fm2.Update(l, l2, nil, hash.Hash{}, constants.NomsVersion, nil)
 	assert.Equal(l2, lock)
 	assert.True(actual.IsEmpty())
 	assert.Empty(tableSpecs)
@@ -126,7 +126,7 @@ func TestFileManifestUpdate(t *testing.T) {
 	// First, test winning the race against another process.
 	newLock, newRoot := computeAddr([]byte("locker")), hash.Of([]byte("new root"))
 	specs := []tableSpec{{computeAddr([]byte("a")), 3}}
-	lock, actual, tableSpecs := fm.Update(addr{}, newLock, specs, newRoot, func() {
+	lock, actual, tableSpecs, _ := fm.Update(addr{}, newLock, specs, newRoot, constants.NomsVersion, func() {
 		// This should fail to get the lock, and therefore _not_ clobber the manifest. So the Update should succeed.
 		lock := computeAddr([]byte("nolock"))
 		newRoot2 := hash.Of([]byte("noroot"))
@@ -139,11 +139,11 @@ func TestFileManifestUpdate(t *testing.T) {
 
 	// Now, test the case where the optimistic lock fails, and someone else updated the root since last we checked.
 	newLock2, newRoot2 := computeAddr([]byte("locker 2")), hash.Of([]byte("new root 2"))
-	lock, actual, tableSpecs = fm.Update(addr{}, newLock2, nil, newRoot2, nil)
+	lock, actual, tableSpecs, _ = fm.Update(addr{}, newLock2, nil, newRoot2, constants.NomsVersion, nil)
 	assert.Equal(newLock, lock)
 	assert.Equal(newRoot, actual)
 	assert.Equal(specs, tableSpecs)
-	lock, actual, tableSpecs = fm.Update(newLock, newLock2, nil, newRoot2, nil)
+	lock, actual, tableSpecs, _ = fm.Update(newLock, newLock2, nil, newRoot2, constants.NomsVersion, nil)
 	assert.Equal(newLock2, lock)
 	assert.Equal(newRoot2, actual)
 	assert.Empty(tableSpecs)
@@ -155,7 +155,7 @@ func TestFileManifestUpdate(t *testing.T) {
 	assert.NoError(err)
 
 	newLock3, newRoot3 := computeAddr([]byte("locker 3")), hash.Of([]byte("new root 3"))
-	lock, actual, tableSpecs = fm.Update(lock, newLock3, nil, newRoot3, nil)
+	lock, actual, tableSpecs, _ = fm.Update(lock, newLock3, nil, newRoot3, constants.NomsVersion, nil)
 	assert.Equal(jerkLock, lock)
 	assert.Equal(newRoot2, actual)
 	assert.Equal([]tableSpec{{tableName, 1}}, tableSpecs)
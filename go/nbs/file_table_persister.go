@@ -34,7 +34,7 @@ func (ftp fsTablePersister) persistTable(name addr, data []byte, chunkCount uint
 		io.Copy(temp, bytes.NewReader(data))
 		index := parseTableIndex(data)
 		if ftp.indexCache != nil {
-			ftp.indexCache.put(name, index)
+			ftp.indexCache.put(tableFileKey{ftp.dir, name}, index)
 		}
 		return temp.Name()
 	}()
@@ -100,3 +100,35 @@ func TestFSTablePersisterCompactAllDups(t *testing.T) {
 		assert.EqualValues(len(testChunks), tr.count())
 	}
 }
+
+func TestFSTablePersisterSharedIndexCache(t *testing.T) {
+	assert := assert.New(t)
+	cache := newIndexCache(1024)
+	dirs := []string{}
+	defer func() {
+		for _, dir := range dirs {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	// Tables of the same chunks, written in different orders to different directories, have the same name.
+	persist := func(chunks [][]byte) chunkSource {
+		mt := newMemTable(testMemTableSize)
+		for _, c := range chunks {
+			assert.True(mt.addChunk(computeAddr(c), c))
+		}
+		dir := makeTempDir(assert)
+		dirs = append(dirs, dir)
+		fts := fsTablePersister{dir: dir, indexCache: cache}
+		return fts.Compact(mt, nil)
+	}
+	reversed := make([][]byte, len(testChunks))
+	for i, c := range testChunks {
+		reversed[len(testChunks)-1-i] = c
+	}
+
+	src, rev := persist(testChunks), persist(reversed)
+	assert.Equal(src.hash(), rev.hash())
+	assertChunksInReader(testChunks, src, assert)
+	assertChunksInReader(testChunks, rev, assert)
+}
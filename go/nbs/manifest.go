@@ -30,21 +30,23 @@ type manifest interface {
 	ParseIfExists(readHook func()) (exists bool, vers string, lock addr, root hash.Hash, tableSpecs []tableSpec)
 
 	// Update optimistically tries to write a new manifest containing
-	// |newRoot| and the tables referenced by |specs|. If |lastLock| matches
-	// the lock hash in the currently persisted manifest (logically, the lock
-	// that would be returned by ParseIfExists), then Update succeeds and
-	// subsequent calls to both Update and ParseIfExists will reflect a
-	// manifest containing |newLock|, |newRoot| and |tables|. If not, Update
-	// fails. Regardless, |lock|, |actual| and |tableSpecs| will reflect the
-	// current state of the world upon return. Callers should check that
-	// |actual| == |newRoot| and, if not, merge any desired new table
-	// information with the contents of |tableSpecs| before trying again.
+	// |newRoot|, the tables referenced by |specs| and the version |newVers|,
+	// which is constants.NomsVersion, followed by the store's Features, if
+	// any. If |lastLock| matches the lock hash in the currently persisted
+	// manifest (logically, the lock that would be returned by ParseIfExists),
+	// then Update succeeds and subsequent calls to both Update and
+	// ParseIfExists will reflect a manifest containing |newLock|, |newRoot|,
+	// |tables| and |newVers|. If not, Update fails. Regardless, |lock|,
+	// |actual|, |tableSpecs| and |vers| will reflect the current state of the
+	// world upon return. Callers should check that |actual| == |newRoot| and,
+	// if not, merge any desired new table information with the contents of
+	// |tableSpecs| before trying again.
 	// Concrete implementations are responsible for ensuring that concurrent
 	// Update calls (and ParseIfExists calls) are correct.
 	// If writeHook is non-nil, it will be invoked while the implementation is
 	// guaranteeing exclusive access to the manifest. This allows for testing
 	// of race conditions.
-	Update(lastLock, newLock addr, specs []tableSpec, newRoot hash.Hash, writeHook func()) (lock addr, actual hash.Hash, tableSpecs []tableSpec)
+	Update(lastLock, newLock addr, specs []tableSpec, newRoot hash.Hash, newVers string, writeHook func()) (lock addr, actual hash.Hash, tableSpecs []tableSpec, vers string)
 }
 
 type tableSpec struct {
@@ -37,6 +37,12 @@ func init() {
 	}
 }
 
+// tableFileKey is the key of the index of a table in dir in an indexCache. A table's name is the hash of its chunks' addresses, so tables of the same chunks, written in different orders by stores in different directories, have the same name but different indices.
+type tableFileKey struct {
+	dir  string
+	name addr
+}
+
 func newMmapTableReader(dir string, h addr, chunkCount uint32, indexCache *indexCache) chunkSource {
 	success := false
 	f, err := os.Open(filepath.Join(dir, h.String()))
@@ -54,7 +60,7 @@ func newMmapTableReader(dir string, h addr, chunkCount uint32, indexCache *index
 	var index tableIndex
 	found := false
 	if indexCache != nil {
-		index, found = indexCache.get(h)
+		index, found = indexCache.get(tableFileKey{dir, h})
 	}
 
 	var buff []byte
@@ -69,7 +75,7 @@ func newMmapTableReader(dir string, h addr, chunkCount uint32, indexCache *index
 		index = parseTableIndex(buff[indexOffset-aligned:])
 
 		if indexCache != nil {
-			indexCache.put(h, index)
+			indexCache.put(tableFileKey{dir, h}, index)
 		}
 	}
 	success = true
@@ -160,14 +160,15 @@ func (fm *fakeManifest) ParseIfExists(readHook func()) (exists bool, vers string
 
 // Update checks whether |lastLock| == |fm.lock| and, if so, updates internal
 // fake manifest state as per the manifest.Update() contract: |fm.lock| is set
-// to |newLock|, |fm.root| is set to |newRoot|, and the contents of |specs|
+// to |newLock|, |fm.root| is set to |newRoot|, |fm.version| is set to
+// |newVers|, and the contents of |specs|
 // are merged into |fm.tableSpecs|. If |lastLock| != |fm.lock|, then the update
 // fails. Regardless of success or failure, the current state is returned.
-func (fm *fakeManifest) Update(lastLock, newLock addr, specs []tableSpec, newRoot hash.Hash, writeHook func()) (lock addr, actual hash.Hash, tableSpecs []tableSpec) {
+func (fm *fakeManifest) Update(lastLock, newLock addr, specs []tableSpec, newRoot hash.Hash, newVers string, writeHook func()) (lock addr, actual hash.Hash, tableSpecs []tableSpec, vers string) {
 	fm.mu.Lock()
 	defer fm.mu.Unlock()
 	if fm.lock == lastLock {
-		fm.version = constants.NomsVersion
+		fm.version = newVers
 		fm.lock = newLock
 		fm.root = newRoot
 
@@ -182,7 +183,7 @@ func (fm *fakeManifest) Update(lastLock, newLock addr, specs []tableSpec, newRoo
 			}
 		}
 	}
-	return fm.lock, fm.root, fm.tableSpecs
+	return fm.lock, fm.root, fm.tableSpecs, fm.version
 }
 
 func (fm *fakeManifest) set(version string, lock addr, root hash.Hash, specs []tableSpec) {
@@ -53,6 +53,9 @@ type NomsBlockStore struct {
 	mt     *memTable
 	tables tableSet
 	root   hash.Hash
+	// features are the Features of the store, which are written to its manifest with the root. If readOnly, one of them is ReadCompatible and unknown to this binary, so the root can't be updated.
+	features features
+	readOnly bool
 
 	mtSize    uint64
 	maxTables int
@@ -151,18 +154,69 @@ func newNomsBlockStore(mm manifest, ts tableSet, memTableSize uint64, maxTables
 		mm:          mm,
 		tables:      ts,
 		nomsVersion: constants.NomsVersion,
+		features:    features{},
 		mtSize:      memTableSize,
 		maxTables:   maxTables,
 	}
 
 	if exists, vers, lock, root, tableSpecs := nbs.mm.ParseIfExists(nil); exists {
-		nbs.nomsVersion, nbs.manifestLock, nbs.root = vers, lock, root
+		d.PanicIfError(nbs.setVersion(vers))
+		nbs.manifestLock, nbs.root = lock, root
 		nbs.tables, _ = nbs.tables.Rebase(tableSpecs)
 	}
 
 	return nbs
 }
 
+// setVersion sets the Noms version and Features of nbs from vers, the version
+// of its manifest. It returns an error if nbs has Features which this binary
+// can't ignore.
+func (nbs *NomsBlockStore) setVersion(vers string) error {
+	nomsVersion, fs, err := parseVersion(vers)
+	if err != nil {
+		return err
+	}
+	readOnly, err := fs.readOnly()
+	if err != nil {
+		return err
+	}
+	nbs.nomsVersion, nbs.features, nbs.readOnly = nomsVersion, fs, readOnly
+	return nil
+}
+
+// Features returns the Features of nbs, with their Compatibility.
+func (nbs *NomsBlockStore) Features() map[Feature]Compatibility {
+	nbs.mu.RLock()
+	defer nbs.mu.RUnlock()
+	fs := map[Feature]Compatibility{}
+	for f, c := range nbs.features {
+		fs[f] = c
+	}
+	return fs
+}
+
+// EnableFeature adds f to the Features of nbs, which is recorded in its
+// manifest when its root is next updated. Only Features known to this binary
+// can be enabled.
+func (nbs *NomsBlockStore) EnableFeature(f Feature) error {
+	c, ok := knownFeatures[f]
+	if !ok {
+		return fmt.Errorf("This version of Noms (%s) doesn't support feature %s", constants.NomsVersion, f)
+	}
+	nbs.mu.Lock()
+	defer nbs.mu.Unlock()
+	nbs.features[f] = c
+	return nil
+}
+
+// ReadOnly returns whether nbs has a Feature which this binary can read but
+// not write, so that its root can't be updated.
+func (nbs *NomsBlockStore) ReadOnly() bool {
+	nbs.mu.RLock()
+	defer nbs.mu.RUnlock()
+	return nbs.readOnly
+}
+
 func (nbs *NomsBlockStore) Put(c chunks.Chunk) {
 	a := addr(c.Hash())
 	d.PanicIfFalse(nbs.addChunk(a, c.Data()))
@@ -362,6 +416,7 @@ func (nbs *NomsBlockStore) Root() hash.Hash {
 	return nbs.root
 }
 
+// UpdateRoot returns false, leaving the root as it is, if last isn't the root or if nbs is ReadOnly().
 func (nbs *NomsBlockStore) UpdateRoot(current, last hash.Hash) bool {
 	b := &backoff.Backoff{
 		Min:    128 * time.Microsecond,
@@ -372,7 +427,7 @@ func (nbs *NomsBlockStore) UpdateRoot(current, last hash.Hash) bool {
 	for {
 		if err := nbs.updateManifest(current, last); err == nil {
 			return true
-		} else if err == errOptimisticLockFailedRoot || err == errLastRootMismatch {
+		} else if err == errOptimisticLockFailedRoot || err == errLastRootMismatch || err == errReadOnly {
 			return false
 		}
 		time.Sleep(b.Duration())
@@ -383,11 +438,15 @@ var (
 	errLastRootMismatch           = fmt.Errorf("last does not match nbs.Root()")
 	errOptimisticLockFailedRoot   = fmt.Errorf("Root moved")
 	errOptimisticLockFailedTables = fmt.Errorf("Tables changed")
+	errReadOnly                   = fmt.Errorf("Store has features which this version of Noms (%s) can read but not write", constants.NomsVersion)
 )
 
 func (nbs *NomsBlockStore) updateManifest(current, last hash.Hash) error {
 	nbs.mu.Lock()
 	defer nbs.mu.Unlock()
+	if nbs.readOnly {
+		return errReadOnly
+	}
 	if nbs.root != last {
 		return errLastRootMismatch
 	}
@@ -405,11 +464,13 @@ func (nbs *NomsBlockStore) updateManifest(current, last hash.Hash) error {
 
 	specs := candidate.ToSpecs()
 	nl := generateLockHash(current, specs)
-	lock, actual, tableNames := nbs.mm.Update(nbs.manifestLock, nl, specs, current, nil)
+	lock, actual, tableNames, vers := nbs.mm.Update(nbs.manifestLock, nl, specs, current, formatVersion(constants.NomsVersion, nbs.features), nil)
 	if nl != lock {
 		// Optimistic lock failure. Someone else moved to the root, the set of tables, or both out from under us.
 		// Regardless of what happened, we're going to start fresh by re-opening all the new tables from upstream, and re-calculating which tables to compact, so close all the compactees as well as any chunkSources that are dropped during Rebase().
 		compactees.close()
+		// Someone else may also have enabled features, which must be kept, and which this binary may not be able to ignore.
+		d.PanicIfError(nbs.setVersion(vers))
 		var dropped chunkSources
 		nbs.manifestLock = lock
 		nbs.root = actual
@@ -455,7 +516,12 @@ func (nbs *NomsBlockStore) Flush() {
 		Factor: 2,
 		Jitter: true,
 	}
-	for nbs.updateManifest(nbs.root, nbs.root) != nil {
+	for {
+		err := nbs.updateManifest(nbs.root, nbs.root)
+		if err == nil || err == errReadOnly {
+			// If nbs is read-only, what's been Put stays in memory, since it can't be added to the manifest.
+			return
+		}
 		time.Sleep(b.Duration())
 	}
 }
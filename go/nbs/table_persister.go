@@ -28,8 +28,9 @@ func newIndexCache(size uint64) *indexCache {
 	return &indexCache{sizecache.New(size)}
 }
 
-func (sic indexCache) get(name addr) (tableIndex, bool) {
-	idx, found := sic.cache.Get(name)
+// get returns the index cached under key, which is the name of a table, or, for a table in a local directory, its tableFileKey.
+func (sic indexCache) get(key interface{}) (tableIndex, bool) {
+	idx, found := sic.cache.Get(key)
 	if found {
 		return idx.(tableIndex), true
 	}
@@ -37,9 +38,9 @@ func (sic indexCache) get(name addr) (tableIndex, bool) {
 	return tableIndex{}, false
 }
 
-func (sic indexCache) put(name addr, idx tableIndex) {
+func (sic indexCache) put(key interface{}, idx tableIndex) {
 	indexSize := uint64(idx.chunkCount) * (addrSize + ordinalSize + lengthSize + uint64Size)
-	sic.cache.Add(name, indexSize, idx)
+	sic.cache.Add(key, indexSize, idx)
 }
 
 type chunkSourcesByDescendingCount chunkSources
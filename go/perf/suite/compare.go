@@ -0,0 +1,99 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package suite
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/attic-labs/noms/go/types"
+)
+
+// regression is a test whose mean elapsed time got worse between a baseline run and the current run.
+type regression struct {
+	name              string
+	baseline, current time.Duration
+}
+
+// change is the fractional increase in elapsed time, e.g. 0.5 means 50% slower.
+func (r regression) change() float64 {
+	return float64(r.current-r.baseline) / float64(r.baseline)
+}
+
+// repsFromRecord reads the reps of a results record previously written by Run. Tests that are missing timing info are skipped.
+func repsFromRecord(record types.Value) []testRep {
+	s, ok := record.(types.Struct)
+	if !ok {
+		return nil
+	}
+	repsVal, ok := s.MaybeGet("reps")
+	if !ok {
+		return nil
+	}
+	repsList, ok := repsVal.(types.List)
+	if !ok {
+		return nil
+	}
+
+	duration := func(s types.Struct, f string) time.Duration {
+		if v, ok := s.MaybeGet(f); ok {
+			if n, ok := v.(types.Number); ok {
+				return time.Duration(n)
+			}
+		}
+		return 0
+	}
+
+	reps := []testRep{}
+	repsList.IterAll(func(v types.Value, _ uint64) {
+		m, ok := v.(types.Map)
+		if !ok {
+			return
+		}
+		rep := testRep{}
+		m.IterAll(func(k, v types.Value) {
+			name, ok := k.(types.String)
+			times, ok2 := v.(types.Struct)
+			if !ok || !ok2 {
+				return
+			}
+			rep[string(name)] = timeInfo{duration(times, "elapsed"), duration(times, "paused"), duration(times, "total")}
+		})
+		reps = append(reps, rep)
+	})
+	return reps
+}
+
+// findRegressions compares the mean elapsed time of each test in current against baseline, and returns those which are slower by more than threshold (e.g. 0.1 for 10%), sorted by name. Tests which aren't in both runs are ignored.
+func findRegressions(baseline, current []testRep, threshold float64) []regression {
+	baselineStats, currentStats := elapsedStats(baseline), elapsedStats(current)
+	regressions := []regression{}
+	for name, cur := range currentStats {
+		base, ok := baselineStats[name]
+		if !ok || base.mean <= 0 {
+			continue
+		}
+		if r := (regression{name, base.mean, cur.mean}); r.change() > threshold {
+			regressions = append(regressions, r)
+		}
+	}
+	sort.Slice(regressions, func(i, j int) bool {
+		return regressions[i].name < regressions[j].name
+	})
+	return regressions
+}
+
+func printRegressions(w io.Writer, datasetID string, regressions []regression, threshold float64) {
+	if len(regressions) == 0 {
+		fmt.Fprintf(w, "(perf) %s: no regressions beyond %.0f%%\n", datasetID, threshold*100)
+		return
+	}
+	fmt.Fprintf(w, "(perf) %s: %d regression(s) beyond %.0f%%\n", datasetID, len(regressions), threshold*100)
+	for _, r := range regressions {
+		fmt.Fprintf(w, "(perf) REGRESSED: %s (%s -> %s, +%.1f%%)\n", r.name, r.baseline, r.current, r.change()*100)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package suite
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/attic-labs/noms/go/spec"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestRepsFromRecord(t *testing.T) {
+	assert := assert.New(t)
+
+	reps := []testRep{
+		{"Foo": {time.Second, 0, time.Second}, "Bar": {2 * time.Second, time.Second, 3 * time.Second}},
+		{"Foo": {3 * time.Second, 0, 3 * time.Second}},
+	}
+	record := types.NewStruct("", types.StructData{"reps": repsToList(reps)})
+	assert.Equal(reps, repsFromRecord(record))
+
+	assert.Nil(repsFromRecord(types.String("nope")))
+	assert.Nil(repsFromRecord(types.NewStruct("", types.StructData{})))
+}
+
+func TestFindRegressions(t *testing.T) {
+	assert := assert.New(t)
+
+	elapsed := func(d time.Duration) timeInfo {
+		return timeInfo{d, 0, d}
+	}
+	baseline := []testRep{
+		{"Fast": elapsed(10 * time.Second), "Same": elapsed(time.Second), "Slow": elapsed(time.Second), "Gone": elapsed(time.Second)},
+		{"Fast": elapsed(10 * time.Second), "Same": elapsed(time.Second), "Slow": elapsed(3 * time.Second), "Gone": elapsed(time.Second)},
+	}
+	current := []testRep{
+		{"Fast": elapsed(time.Second), "Same": elapsed(1050 * time.Millisecond), "Slow": elapsed(3 * time.Second), "New": elapsed(time.Second)},
+		{"Fast": elapsed(time.Second), "Same": elapsed(1050 * time.Millisecond), "Slow": elapsed(3 * time.Second), "New": elapsed(time.Second)},
+	}
+
+	assert.Equal([]regression{{"Slow", 2 * time.Second, 3 * time.Second}}, findRegressions(baseline, current, 0.1))
+	assert.Equal([]regression{{"Same", time.Second, 1050 * time.Millisecond}, {"Slow", 2 * time.Second, 3 * time.Second}}, findRegressions(baseline, current, 0.01))
+	assert.Empty(findRegressions(baseline, current, 0.5))
+
+	buf := &bytes.Buffer{}
+	printRegressions(buf, "ds", findRegressions(baseline, current, 0.1), 0.1)
+	assert.Equal("(perf) ds: 1 regression(s) beyond 10%\n(perf) REGRESSED: Slow (2s -> 3s, +50.0%)\n", buf.String())
+}
+
+func TestRegressionReportFlag(t *testing.T) {
+	assert := assert.New(t)
+
+	ldbDir, err := ioutil.TempDir("", "suite.TestRegressionReportFlag")
+	assert.NoError(err)
+	defer os.RemoveAll(ldbDir)
+
+	flagVal, regressionFlagVal, reportFlagVal := *perfFlag, *perfRegressionFlag, *perfRegressionReportFlag
+	*perfFlag, *perfRegressionFlag, *perfRegressionReportFlag = ldbDir, 0.01, true
+	defer func() {
+		*perfFlag, *perfRegressionFlag, *perfRegressionReportFlag = flagVal, regressionFlagVal, reportFlagVal
+	}()
+
+	// Timings are too noisy to assert on regressions here, but the comparison against the first run must not fail the test in report mode.
+	Run("ds", t, &testSuite{})
+	Run("ds", t, &testSuite{})
+
+	sp, err := spec.ForDataset(ldbDir + "::ds")
+	assert.NoError(err)
+	defer sp.Close()
+	assert.Len(repsFromRecord(sp.GetDataset().HeadValue()), 1)
+}
@@ -0,0 +1,117 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package suite
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/rand"
+	"path"
+	"path/filepath"
+	"strconv"
+)
+
+// CSVSpec describes a synthetic CSV file for GenerateCSV.
+type CSVSpec struct {
+	// Rows is the number of rows, not including the header.
+	Rows int
+
+	// Columns is the number of columns. If Header is set, it must have this many entries.
+	Columns int
+
+	// Header names the columns. If empty, columns are named "Col0", "Col1", etc.
+	Header []string
+
+	// Cardinality is the number of distinct values in each column. If 0, every value is distinct.
+	Cardinality int
+
+	// RunLength is how many consecutive rows share the same value in each column, like the sorted or repetitive columns of real data, which chunk and compress differently from random values. Values of 0 and 1 both mean no runs.
+	RunLength int
+
+	// Seed seeds the random values, so that the same spec always generates the same data.
+	Seed int64
+}
+
+// GenerateCSV writes a CSV file described by spec to w. Even columns hold numbers and odd columns hold strings, so that imports see a mix of types.
+func GenerateCSV(w io.Writer, spec CSVSpec) error {
+	if spec.Header != nil && len(spec.Header) != spec.Columns {
+		return fmt.Errorf("CSVSpec has %d columns but %d header entries", spec.Columns, len(spec.Header))
+	}
+
+	header := spec.Header
+	if header == nil {
+		header = make([]string, spec.Columns)
+		for i := range header {
+			header[i] = fmt.Sprintf("Col%d", i)
+		}
+	}
+
+	runLength := spec.RunLength
+	if runLength < 1 {
+		runLength = 1
+	}
+
+	bw := bufio.NewWriter(w)
+	cw := csv.NewWriter(bw)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	r := rand.New(rand.NewSource(spec.Seed))
+	row := make([]string, spec.Columns)
+	for i := 0; i < spec.Rows; i++ {
+		if i%runLength == 0 {
+			for c := range row {
+				row[c] = generateValue(r, c, i/runLength, spec.Cardinality)
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func generateValue(r *rand.Rand, col, run, cardinality int) string {
+	n := run
+	if cardinality > 0 {
+		n = r.Intn(cardinality)
+	}
+	if col%2 == 0 {
+		return strconv.Itoa(n)
+	}
+	return fmt.Sprintf("value %d of column %d", n, col)
+}
+
+// OpenGlobOrGenerate is like OpenGlob, but if nothing matches pattern (e.g. because the testdata directory isn't checked out), or if the -perf.generate flag is set, it instead generates a CSV file described by spec and opens that. Either way, the result should be closed with CloseGlob.
+func (suite *PerfSuite) OpenGlobOrGenerate(spec CSVSpec, pattern ...string) []io.Reader {
+	assert := suite.NewAssert()
+
+	if !*perfGenerateFlag {
+		glob, err := filepath.Glob(path.Join(pattern...))
+		assert.NoError(err)
+		if len(glob) > 0 {
+			return suite.OpenGlob(pattern...)
+		}
+	}
+
+	fmt.Fprintf(suite.W, "\tgenerating %d rows for %s\n", spec.Rows, path.Join(pattern...))
+	f := suite.TempFile()
+
+	// Generating isn't what's being measured.
+	suite.Pause(func() {
+		assert.NoError(GenerateCSV(f, spec))
+		_, err := f.Seek(0, 0)
+		assert.NoError(err)
+	})
+	return []io.Reader{f}
+}
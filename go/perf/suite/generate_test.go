@@ -0,0 +1,106 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package suite
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/attic-labs/testify/assert"
+)
+
+func readGenerated(t *testing.T, spec CSVSpec) [][]string {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, GenerateCSV(buf, spec))
+	rows, err := csv.NewReader(buf).ReadAll()
+	assert.NoError(t, err)
+	return rows
+}
+
+func TestGenerateCSV(t *testing.T) {
+	assert := assert.New(t)
+
+	rows := readGenerated(t, CSVSpec{Rows: 10, Columns: 3})
+	assert.Len(rows, 11)
+	assert.Equal([]string{"Col0", "Col1", "Col2"}, rows[0])
+	assert.Equal([]string{"0", "value 0 of column 1", "0"}, rows[1])
+	assert.Equal([]string{"9", "value 9 of column 1", "9"}, rows[10])
+
+	rows = readGenerated(t, CSVSpec{Rows: 2, Columns: 2, Header: []string{"a", "b"}})
+	assert.Equal([]string{"a", "b"}, rows[0])
+
+	assert.Error(GenerateCSV(&bytes.Buffer{}, CSVSpec{Columns: 2, Header: []string{"a"}}))
+}
+
+func TestGenerateCSVCardinalityAndRuns(t *testing.T) {
+	assert := assert.New(t)
+
+	spec := CSVSpec{Rows: 1000, Columns: 2, Cardinality: 7, RunLength: 10, Seed: 42}
+	rows := readGenerated(t, spec)[1:]
+	assert.Len(rows, 1000)
+
+	distinct := map[string]bool{}
+	for i, row := range rows {
+		distinct[row[0]] = true
+		if i%10 != 0 {
+			assert.Equal(rows[i-1], row)
+		}
+	}
+	assert.True(len(distinct) <= 7)
+
+	// The same spec generates the same data.
+	assert.Equal(rows, readGenerated(t, spec)[1:])
+}
+
+type generateSuite struct {
+	PerfSuite
+	generated, testdata [][]string
+}
+
+func (s *generateSuite) TestGenerate() {
+	spec := CSVSpec{Rows: 5, Columns: 2}
+	readAll := func(pattern ...string) [][]string {
+		files := s.OpenGlobOrGenerate(spec, pattern...)
+		defer s.CloseGlob(files)
+		rows, err := csv.NewReader(io.MultiReader(files...)).ReadAll()
+		assert.NoError(s.T, err)
+		return rows
+	}
+	s.generated = readAll(s.Testdata, "does-not-exist", "*.csv")
+	s.testdata = readAll(s.Testdata, "data.csv")
+}
+
+func TestOpenGlobOrGenerate(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "suite.TestOpenGlobOrGenerate")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	assert.NoError(ioutil.WriteFile(path.Join(dir, "data.csv"), []byte("x\n1\n"), 0644))
+
+	run := func(generate bool) *generateSuite {
+		flagVal, testdataFlagVal, generateFlagVal := *perfFlag, *perfTestdataFlag, *perfGenerateFlag
+		*perfFlag, *perfTestdataFlag, *perfGenerateFlag = "mem", dir, generate
+		defer func() {
+			*perfFlag, *perfTestdataFlag, *perfGenerateFlag = flagVal, testdataFlagVal, generateFlagVal
+		}()
+		s := &generateSuite{}
+		Run("generate", t, s)
+		return s
+	}
+
+	s := run(false)
+	assert.Len(s.generated, 6)
+	assert.Equal([][]string{{"x"}, {"1"}}, s.testdata)
+
+	s = run(true)
+	assert.Len(s.generated, 6)
+	assert.Len(s.testdata, 6)
+}
@@ -0,0 +1,96 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package suite
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+type jsonTimeInfo struct {
+	Elapsed int64 `json:"elapsed"`
+	Paused  int64 `json:"paused"`
+	Total   int64 `json:"total"`
+}
+
+type jsonStats struct {
+	Min    int64 `json:"min"`
+	Median int64 `json:"median"`
+	Mean   int64 `json:"mean"`
+	Stddev int64 `json:"stddev"`
+}
+
+type jsonRecord struct {
+	Dataset          string                    `json:"dataset"`
+	NomsRevision     string                    `json:"nomsRevision"`
+	TestdataRevision string                    `json:"testdataRevision"`
+	Environment      environment               `json:"environment"`
+	Reps             []map[string]jsonTimeInfo `json:"reps"`
+	Stats            map[string]jsonStats      `json:"stats"`
+}
+
+// writeJSON writes testReps to w as a single JSON object, mirroring the struct written to the results dataset, environment and memory stats included. Times are in nanoseconds.
+func writeJSON(w io.Writer, datasetID, nomsRevision, testdataRevision string, env environment, testReps []testRep) error {
+	record := jsonRecord{datasetID, nomsRevision, testdataRevision, env, make([]map[string]jsonTimeInfo, len(testReps)), map[string]jsonStats{}}
+	for i, rep := range testReps {
+		record.Reps[i] = map[string]jsonTimeInfo{}
+		for name, info := range rep {
+			record.Reps[i][name] = jsonTimeInfo{info.elapsed.Nanoseconds(), info.paused.Nanoseconds(), info.total.Nanoseconds()}
+		}
+	}
+	for name, s := range elapsedStats(testReps) {
+		record.Stats[name] = jsonStats{s.min.Nanoseconds(), s.median.Nanoseconds(), s.mean.Nanoseconds(), s.stddev.Nanoseconds()}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(record)
+}
+
+// writeCSV writes testReps to w with one row per test per rep. Times are in nanoseconds.
+func writeCSV(w io.Writer, datasetID string, testReps []testRep) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"dataset", "rep", "test", "elapsed", "paused", "total"})
+	for i, rep := range testReps {
+		for _, name := range rep.names() {
+			info := rep[name]
+			cw.Write([]string{
+				datasetID,
+				fmt.Sprintf("%d", i),
+				name,
+				fmt.Sprintf("%d", info.elapsed.Nanoseconds()),
+				fmt.Sprintf("%d", info.paused.Nanoseconds()),
+				fmt.Sprintf("%d", info.total.Nanoseconds()),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// names returns the names of the tests in rep, sorted.
+func (rep testRep) names() []string {
+	names := make([]string, 0, len(rep))
+	for name := range rep {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,68 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package suite
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/attic-labs/noms/go/types"
+)
+
+// stats summarizes the elapsed times of a single test across reps.
+type stats struct {
+	min, median, mean, stddev time.Duration
+}
+
+// elapsedStats returns the stats of each test's elapsed time across reps. Tests which didn't run in every rep are summarized over the reps they ran in. The stddev is the population standard deviation, so it's 0 for a single rep.
+func elapsedStats(reps []testRep) map[string]stats {
+	samples := map[string][]time.Duration{}
+	for _, rep := range reps {
+		for name, info := range rep {
+			samples[name] = append(samples[name], info.elapsed)
+		}
+	}
+
+	result := make(map[string]stats, len(samples))
+	for name, s := range samples {
+		sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+
+		var sum time.Duration
+		for _, d := range s {
+			sum += d
+		}
+		mean := sum / time.Duration(len(s))
+
+		var variance float64
+		for _, d := range s {
+			diff := float64(d - mean)
+			variance += diff * diff
+		}
+		variance /= float64(len(s))
+
+		median := s[len(s)/2]
+		if len(s)%2 == 0 {
+			median = (s[len(s)/2-1] + s[len(s)/2]) / 2
+		}
+
+		result[name] = stats{s[0], median, mean, time.Duration(math.Sqrt(variance))}
+	}
+	return result
+}
+
+// statsToMap converts the result of elapsedStats to the Map<String, Struct{min, median, mean, stddev}> written to the results dataset.
+func statsToMap(testStats map[string]stats) types.Map {
+	kvs := types.ValueSlice{}
+	for name, s := range testStats {
+		kvs = append(kvs, types.String(name), types.NewStruct("", types.StructData{
+			"min":    types.Number(s.min.Nanoseconds()),
+			"median": types.Number(s.median.Nanoseconds()),
+			"mean":   types.Number(s.mean.Nanoseconds()),
+			"stddev": types.Number(s.stddev.Nanoseconds()),
+		}))
+	}
+	return types.NewMap(kvs...)
+}
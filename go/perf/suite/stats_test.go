@@ -0,0 +1,41 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package suite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestElapsedStats(t *testing.T) {
+	assert := assert.New(t)
+
+	elapsed := func(d time.Duration) timeInfo {
+		return timeInfo{d, 0, d}
+	}
+	reps := []testRep{
+		{"Odd": elapsed(4 * time.Second), "Even": elapsed(2 * time.Second), "Once": elapsed(time.Second)},
+		{"Odd": elapsed(2 * time.Second), "Even": elapsed(4 * time.Second)},
+		{"Odd": elapsed(6 * time.Second), "Even": elapsed(6 * time.Second)},
+		{"Even": elapsed(8 * time.Second)},
+	}
+
+	s := elapsedStats(reps)
+	assert.Len(s, 3)
+	assert.Equal(stats{2 * time.Second, 4 * time.Second, 4 * time.Second, 1632993161 * time.Nanosecond}, s["Odd"])
+	assert.Equal(stats{2 * time.Second, 5 * time.Second, 5 * time.Second, 2236067977 * time.Nanosecond}, s["Even"])
+	assert.Equal(stats{time.Second, time.Second, time.Second, 0}, s["Once"])
+
+	m := statsToMap(s)
+	assert.Equal(uint64(3), m.Len())
+	odd := m.Get(types.String("Odd")).(types.Struct)
+	assert.Equal(types.Number(2e9), odd.Get("min"))
+	assert.Equal(types.Number(4e9), odd.Get("median"))
+	assert.Equal(types.Number(4e9), odd.Get("mean"))
+	assert.Equal(types.Number(1632993161), odd.Get("stddev"))
+}
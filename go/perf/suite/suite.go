@@ -13,18 +13,33 @@
 //  4. Run go test with the -perf <path to noms db> flag.
 //
 // Flags:
-//  -perf.mem      Backs the database by a memory store, instead of nbs.
-//  -perf.prefix   Gives the dataset IDs for test results a prefix.
-//  -perf.repeat   Sets how many times tests are repeated ("reps").
-//  -perf.run      Only run tests that match a regex (case insensitive).
-//  -perf.testdata Sets a custom path to the Noms testdata directory.
+//  -perf.csv                Also writes results to a CSV file at this path.
+//  -perf.generate           Uses generated data instead of testdata, for tests that support it.
+//  -perf.json               Also writes results to a JSON file at this path.
+//  -perf.list               Lists the tests that would run, in order, without running them.
+//  -perf.mem                Backs the database by a memory store, instead of nbs.
+//  -perf.prefix             Gives the dataset IDs for test results a prefix.
+//  -perf.regression         Compares against the previous run and fails on regressions beyond this fraction.
+//  -perf.regression.report  Only reports regressions, instead of failing.
+//  -perf.repeat             Sets how many times tests are repeated ("reps").
+//  -perf.run                Only run tests whose method or recorded name matches a regex (case insensitive).
+//  -perf.testdata           Sets a custom path to the Noms testdata directory.
+//  -perf.warmup             Sets how many extra reps to run first, whose results are discarded.
 //
 // PerfSuite also supports testify/suite style Setup/TearDown methods:
 //  Setup/TearDownSuite is called exactly once.
 //  Setup/TearDownRep   is called for each repetition of the test runs, i.e. -perf.repeat times.
 //  Setup/TearDownTest  is called for every test.
 //
-// Test results are written to Noms, along with a dump of the environment they were recorded in.
+// Tests are always run in order of their method names, compared byte-wise. Digits sort before letters, so numbered tests like Test01Qux run before unnumbered ones like TestFoo.
+//
+// Tests which read from the testdata directory can use OpenGlobOrGenerate, which falls back to synthetic CSV data (see GenerateCSV) when testdata isn't checked out, so that they can be run by anyone.
+//
+// Test results are written to Noms, along with a dump of the environment they were recorded in. They can also be written to JSON and CSV files, with -perf.json and -perf.csv, for consumption by other tools.
+//
+// Alongside the results of each rep, the min, median, mean and standard deviation of each test's elapsed time across reps are recorded as "stats". Use -perf.warmup to run some reps first which aren't recorded at all, e.g. to warm up disk caches.
+//
+// With -perf.regression, the mean elapsed time of each test is compared against the previous results in the dataset, and the run fails if any test got slower by more than the given fraction (e.g. 0.1 for 10%). Add -perf.regression.report to print the comparison without failing.
 //
 // Test names are derived from that "non-empty capitalized string": "Test" is omitted because it's
 // redundant, and leading digits are omitted to allow for manual test ordering. For example:
@@ -80,6 +95,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -100,13 +116,20 @@ import (
 )
 
 var (
-	perfFlag         = flag.String("perf", "", "The database to write perf tests to. If this isn't specified, perf tests are skipped. If you want a dry run, use \"mem\" as a database")
-	perfMemFlag      = flag.Bool("perf.mem", false, "Back the test database by a memory store, not nbs. This will affect test timing, but it's provided in case you're low on disk space")
-	perfPrefixFlag   = flag.String("perf.prefix", "", `Prefix for the dataset IDs where results are written. For example, a prefix of "foo/" will write test datasets like "foo/csv-import" instead of just "csv-import"`)
-	perfRepeatFlag   = flag.Int("perf.repeat", 1, "The number of times to repeat each perf test")
-	perfRunFlag      = flag.String("perf.run", "", "Only run perf tests that match a regular expression")
-	perfTestdataFlag = flag.String("perf.testdata", "", "Path to the noms testdata directory. By default this is ../testdata relative to the noms directory")
-	testNamePattern  = regexp.MustCompile("^Test[0-9]*([A-Z].*$)")
+	perfFlag                 = flag.String("perf", "", "The database to write perf tests to. If this isn't specified, perf tests are skipped. If you want a dry run, use \"mem\" as a database")
+	perfCSVFlag              = flag.String("perf.csv", "", "Path to a file to also write test results to as CSV, one row per test per repetition")
+	perfGenerateFlag         = flag.Bool("perf.generate", false, "Always use generated data in tests that support it (see OpenGlobOrGenerate), even if the testdata directory is present")
+	perfJSONFlag             = flag.String("perf.json", "", "Path to a file to also write test results to as JSON")
+	perfListFlag             = flag.Bool("perf.list", false, "List the perf tests that would run, in order, without running them")
+	perfMemFlag              = flag.Bool("perf.mem", false, "Back the test database by a memory store, not nbs. This will affect test timing, but it's provided in case you're low on disk space")
+	perfPrefixFlag           = flag.String("perf.prefix", "", `Prefix for the dataset IDs where results are written. For example, a prefix of "foo/" will write test datasets like "foo/csv-import" instead of just "csv-import"`)
+	perfRegressionFlag       = flag.Float64("perf.regression", 0, "If non-zero, compare each test's mean elapsed time against the previous run in the results dataset, and fail if it regressed by more than this fraction (e.g. 0.1 for 10%)")
+	perfRegressionReportFlag = flag.Bool("perf.regression.report", false, "With -perf.regression, only print a report of regressions instead of failing")
+	perfRepeatFlag           = flag.Int("perf.repeat", 1, "The number of times to repeat each perf test")
+	perfRunFlag              = flag.String("perf.run", "", "Only run perf tests that match a regular expression")
+	perfTestdataFlag         = flag.String("perf.testdata", "", "Path to the noms testdata directory. By default this is ../testdata relative to the noms directory")
+	perfWarmupFlag           = flag.Int("perf.warmup", 0, "The number of times to run each perf test before the recorded reps. Results from warm-up reps are discarded")
+	testNamePattern          = regexp.MustCompile("^Test[0-9]*([A-Z].*$)")
 )
 
 // PerfSuite is the core of the perf testing suite. See package documentation for details.
@@ -150,11 +173,11 @@ type perfSuiteT interface {
 }
 
 type environment struct {
-	DiskUsages map[string]disk.UsageStat
-	Cpus       map[int]cpu.InfoStat
-	Mem        mem.VirtualMemoryStat
-	Host       host.InfoStat
-	Partitions map[string]disk.PartitionStat
+	DiskUsages map[string]disk.UsageStat     `json:"diskUsages"`
+	Cpus       map[int]cpu.InfoStat          `json:"cpus"`
+	Mem        mem.VirtualMemoryStat         `json:"mem"`
+	Host       host.InfoStat                 `json:"host"`
+	Partitions map[string]disk.PartitionStat `json:"partitions"`
 }
 
 type timeInfo struct {
@@ -182,6 +205,21 @@ func Run(datasetID string, t *testing.T, suiteT perfSuiteT) {
 	assert.NotNil(verboseFlag)
 	verbose := verboseFlag.Value.(flag.Getter).Get().(bool)
 
+	// Note: the default value of perfRunFlag is "", which is actually a valid
+	// regular expression that matches everything.
+	perfRunRe, err := regexp.Compile("(?i)" + *perfRunFlag)
+	if !assert.NoError(err, `Invalid regular expression "%s"`, *perfRunFlag) {
+		return
+	}
+	tests := findTests(suiteT, perfRunRe)
+
+	if *perfListFlag {
+		for _, pt := range tests {
+			fmt.Printf("(perf) %s: %s (as \"%s\")\n", datasetID, pt.method.Name, pt.recordName)
+		}
+		return
+	}
+
 	if *perfFlag == "" {
 		if verbose {
 			fmt.Printf("(perf) Skipping %s, -perf flag not set\n", datasetID)
@@ -229,47 +267,60 @@ func Run(datasetID string, t *testing.T, suiteT perfSuiteT) {
 	// List of test runs, each a map of test name => timing info.
 	testReps := make([]testRep, *perfRepeatFlag)
 
-	// Note: the default value of perfRunFlag is "", which is actually a valid
-	// regular expression that matches everything.
-	perfRunRe, err := regexp.Compile("(?i)" + *perfRunFlag)
-	if !assert.NoError(err, `Invalid regular expression "%s"`, *perfRunFlag) {
-		return
-	}
-
 	defer func() {
-		reps := make([]types.Value, *perfRepeatFlag)
-		for i, rep := range testReps {
-			timesSlice := types.ValueSlice{}
-			for name, info := range rep {
-				timesSlice = append(timesSlice, types.String(name), types.NewStruct("", types.StructData{
-					"elapsed": types.Number(info.elapsed.Nanoseconds()),
-					"paused":  types.Number(info.paused.Nanoseconds()),
-					"total":   types.Number(info.total.Nanoseconds()),
-				}))
-			}
-			reps[i] = types.NewMap(timesSlice...)
-		}
-
+		nomsRevision := suite.getGitHead(path.Join(suite.AtticLabs, "noms"))
+		testdataRevision := suite.getGitHead(suite.Testdata)
+		env := suite.getEnvironment()
+		envStruct, err := marshal.Marshal(env)
+		assert.NoError(err)
 		record := types.NewStruct("", map[string]types.Value{
-			"environment":      suite.getEnvironment(),
-			"nomsRevision":     types.String(suite.getGitHead(path.Join(suite.AtticLabs, "noms"))),
-			"testdataRevision": types.String(suite.getGitHead(suite.Testdata)),
-			"reps":             types.NewList(reps...),
+			"environment":      envStruct,
+			"nomsRevision":     types.String(nomsRevision),
+			"testdataRevision": types.String(testdataRevision),
+			"reps":             repsToList(testReps),
+			"stats":            statsToMap(elapsedStats(testReps)),
 		})
 
 		db := sp.GetDatabase()
 		ds := db.GetDataset(*perfPrefixFlag + datasetID)
-		var err error
+		baseline, hasBaseline := ds.MaybeHeadValue()
 		ds, err = db.CommitValue(ds, record)
 		assert.NoError(err)
+
+		if *perfRegressionFlag > 0 {
+			if !hasBaseline {
+				fmt.Printf("(perf) %s: no previous results to compare against\n", ds.ID())
+			} else {
+				regressions := findRegressions(repsFromRecord(baseline), testReps, *perfRegressionFlag)
+				printRegressions(os.Stdout, ds.ID(), regressions, *perfRegressionFlag)
+				if !*perfRegressionReportFlag {
+					assert.Empty(regressions, "Perf regressions in %s", ds.ID())
+				}
+			}
+		}
+
+		if *perfJSONFlag != "" {
+			assert.NoError(writeFile(*perfJSONFlag, func(w io.Writer) error {
+				return writeJSON(w, *perfPrefixFlag+datasetID, nomsRevision, testdataRevision, env, testReps)
+			}))
+		}
+		if *perfCSVFlag != "" {
+			assert.NoError(writeFile(*perfCSVFlag, func(w io.Writer) error {
+				return writeCSV(w, *perfPrefixFlag+datasetID, testReps)
+			}))
+		}
 	}()
 
 	if t, ok := suiteT.(testifySuite.SetupAllSuite); ok {
 		t.SetupSuite()
 	}
 
-	for repIdx := 0; repIdx < *perfRepeatFlag; repIdx++ {
-		testReps[repIdx] = testRep{}
+	for repIdx := -*perfWarmupFlag; repIdx < *perfRepeatFlag; repIdx++ {
+		rep := testRep{}
+		runLabel := fmt.Sprintf("RUN(%d/%d)", repIdx+1, *perfRepeatFlag)
+		if repIdx < 0 {
+			runLabel = fmt.Sprintf("WARMUP(%d/%d)", repIdx+*perfWarmupFlag+1, *perfWarmupFlag)
+		}
 
 		serverHost, stopServerFn := suite.StartRemoteDatabase()
 		suite.DatabaseSpec = serverHost
@@ -279,26 +330,16 @@ func Run(datasetID string, t *testing.T, suiteT perfSuiteT) {
 			t.SetupRep()
 		}
 
-		for t, mIdx := reflect.TypeOf(suiteT), 0; mIdx < t.NumMethod(); mIdx++ {
-			m := t.Method(mIdx)
-
-			parts := testNamePattern.FindStringSubmatch(m.Name)
-			if parts == nil {
-				continue
-			}
+		for _, pt := range tests {
+			m, recordName := pt.method, pt.recordName
 
-			recordName := parts[1]
-			if !perfRunRe.MatchString(recordName) && !perfRunRe.MatchString(m.Name) {
-				continue
-			}
-
-			if _, ok := testReps[repIdx][recordName]; ok {
+			if _, ok := rep[recordName]; ok {
 				assert.Fail(`Multiple tests are named "%s"`, recordName)
 				continue
 			}
 
 			if verbose {
-				fmt.Printf("(perf) RUN(%d/%d) %s (as \"%s\")\n", repIdx+1, *perfRepeatFlag, m.Name, recordName)
+				fmt.Printf("(perf) %s %s (as \"%s\")\n", runLabel, m.Name, recordName)
 			}
 
 			if t, ok := suiteT.(testifySuite.SetupTestSuite); ok {
@@ -320,7 +361,7 @@ func Run(datasetID string, t *testing.T, suiteT perfSuiteT) {
 				fmt.Println(err)
 			}
 
-			testReps[repIdx][recordName] = timeInfo{elapsed, suite.paused, total}
+			rep[recordName] = timeInfo{elapsed, suite.paused, total}
 
 			if t, ok := suiteT.(testifySuite.TearDownTestSuite); ok {
 				t.TearDownTest()
@@ -331,6 +372,10 @@ func Run(datasetID string, t *testing.T, suiteT perfSuiteT) {
 			t.TearDownRep()
 		}
 
+		if repIdx >= 0 {
+			testReps[repIdx] = rep
+		}
+
 		stopServerFn()
 	}
 
@@ -339,6 +384,54 @@ func Run(datasetID string, t *testing.T, suiteT perfSuiteT) {
 	}
 }
 
+// repsToList converts testReps to the List of Map<String, Struct{elapsed, paused, total}> written to the results dataset.
+func repsToList(testReps []testRep) types.List {
+	reps := make([]types.Value, len(testReps))
+	for i, rep := range testReps {
+		timesSlice := types.ValueSlice{}
+		for name, info := range rep {
+			timesSlice = append(timesSlice, types.String(name), types.NewStruct("", types.StructData{
+				"elapsed": types.Number(info.elapsed.Nanoseconds()),
+				"paused":  types.Number(info.paused.Nanoseconds()),
+				"total":   types.Number(info.total.Nanoseconds()),
+			}))
+		}
+		reps[i] = types.NewMap(timesSlice...)
+	}
+	return types.NewList(reps...)
+}
+
+type perfTest struct {
+	method     reflect.Method
+	recordName string
+}
+
+// findTests returns the test methods of suiteT whose method name or record name matches re, in the order they should be run: sorted by method name.
+func findTests(suiteT perfSuiteT, re *regexp.Regexp) []perfTest {
+	tests := []perfTest{}
+	for t, mIdx := reflect.TypeOf(suiteT), 0; mIdx < t.NumMethod(); mIdx++ {
+		m := t.Method(mIdx)
+
+		parts := testNamePattern.FindStringSubmatch(m.Name)
+		if parts == nil {
+			continue
+		}
+
+		recordName := parts[1]
+		if !re.MatchString(recordName) && !re.MatchString(m.Name) {
+			continue
+		}
+
+		tests = append(tests, perfTest{m, recordName})
+	}
+
+	// reflect happens to return methods sorted by name, but don't rely on it.
+	sort.Slice(tests, func(i, j int) bool {
+		return tests[i].method.Name < tests[j].method.Name
+	})
+	return tests
+}
+
 func (suite *PerfSuite) Suite() *PerfSuite {
 	return suite
 }
@@ -418,7 +511,7 @@ func callSafe(name string, fun reflect.Value, args ...interface{}) error {
 	})
 }
 
-func (suite *PerfSuite) getEnvironment() types.Value {
+func (suite *PerfSuite) getEnvironment() environment {
 	assert := suite.NewAssert()
 
 	env := environment{
@@ -449,10 +542,7 @@ func (suite *PerfSuite) getEnvironment() types.Value {
 	hostInfo, err := host.Info()
 	assert.NoError(err)
 	env.Host = *hostInfo
-
-	envStruct, err := marshal.Marshal(env)
-	assert.NoError(err)
-	return envStruct
+	return env
 }
 
 func (suite *PerfSuite) getGitHead(dir string) string {
@@ -5,8 +5,12 @@
 package suite
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"io/ioutil"
 	"os"
+	"path"
+	"regexp"
 	"testing"
 	"time"
 
@@ -246,6 +250,50 @@ func runTestSuite(t *testing.T, mem bool) {
 
 		assert.Equal(i, len(expectedTests))
 	})
+
+	testStats, ok := getOrFail(head, "stats").(types.Map)
+	assert.True(ok)
+	assert.Equal(uint64(len(expectedTests)), testStats.Len())
+	testStats.IterAll(func(k, v types.Value) {
+		st := v.(types.Struct)
+		min, median := getOrFail(st, "min").(types.Number), getOrFail(st, "median").(types.Number)
+		mean, max := getOrFail(st, "mean").(types.Number), types.Number(0)
+		getOrFail(st, "stddev")
+		reps.IterAll(func(rep types.Value, _ uint64) {
+			if elapsed := rep.(types.Map).Get(k).(types.Struct).Get("elapsed").(types.Number); elapsed > max {
+				max = elapsed
+			}
+		})
+		assert.True(min > 0)
+		assert.True(min <= median && median <= max)
+		assert.True(min <= mean && mean <= max)
+	})
+}
+
+func TestWarmupFlag(t *testing.T) {
+	assert := assert.New(t)
+
+	ldbDir, err := ioutil.TempDir("", "suite.TestWarmupFlag")
+	assert.NoError(err)
+	defer os.RemoveAll(ldbDir)
+
+	flagVal, repeatFlagVal, warmupFlagVal := *perfFlag, *perfRepeatFlag, *perfWarmupFlag
+	*perfFlag, *perfRepeatFlag, *perfWarmupFlag = ldbDir, 2, 3
+	defer func() {
+		*perfFlag, *perfRepeatFlag, *perfWarmupFlag = flagVal, repeatFlagVal, warmupFlagVal
+	}()
+
+	s := &testSuite{}
+	Run("ds", t, s)
+
+	// Warm-up reps run, but aren't recorded.
+	assert.Equal(5, s.setupRep)
+	assert.Equal(5, s.foo)
+
+	sp, err := spec.ForDataset(ldbDir + "::ds")
+	assert.NoError(err)
+	defer sp.Close()
+	assert.Len(repsFromRecord(sp.GetDataset().HeadValue()), 2)
 }
 
 func TestPrefixFlag(t *testing.T) {
@@ -278,6 +326,48 @@ func TestPrefixFlag(t *testing.T) {
 	assert.True(ok)
 }
 
+func TestOutputFlags(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "suite.TestOutputFlags")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	jsonPath, csvPath := path.Join(dir, "results.json"), path.Join(dir, "results.csv")
+	flagVal, memFlagVal, repeatFlagVal, jsonFlagVal, csvFlagVal := *perfFlag, *perfMemFlag, *perfRepeatFlag, *perfJSONFlag, *perfCSVFlag
+	*perfFlag, *perfMemFlag, *perfRepeatFlag, *perfJSONFlag, *perfCSVFlag = "mem", true, 2, jsonPath, csvPath
+	defer func() {
+		*perfFlag, *perfMemFlag, *perfRepeatFlag, *perfJSONFlag, *perfCSVFlag = flagVal, memFlagVal, repeatFlagVal, jsonFlagVal, csvFlagVal
+	}()
+
+	Run("test", t, &testSuite{})
+
+	f, err := os.Open(jsonPath)
+	assert.NoError(err)
+	defer f.Close()
+	var record jsonRecord
+	assert.NoError(json.NewDecoder(f).Decode(&record))
+	assert.Equal("test", record.Dataset)
+	assert.NotEmpty(record.Environment.Cpus)
+	assert.True(record.Environment.Mem.Total > 0)
+	assert.NotEmpty(record.Environment.Host.Hostname)
+	assert.Len(record.Reps, 2)
+	for _, rep := range record.Reps {
+		assert.True(rep["Foo"].Elapsed > 0)
+		assert.True(rep["Pause"].Paused > 0)
+		assert.Equal(int64(0), rep["Foo"].Paused)
+	}
+
+	f, err = os.Open(csvPath)
+	assert.NoError(err)
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	assert.NoError(err)
+	assert.Equal([]string{"dataset", "rep", "test", "elapsed", "paused", "total"}, rows[0])
+	assert.Len(rows, 1+2*len(record.Reps[0]))
+	assert.Equal([]string{"test", "0", "Abc"}, rows[1][:3])
+}
+
 func TestRunFlag(t *testing.T) {
 	assert := assert.New(t)
 
@@ -352,3 +442,38 @@ func TestRunFlag(t *testing.T) {
 	run("footest", expect{})
 	run("nothing", expect{})
 }
+
+func TestFindTestsOrder(t *testing.T) {
+	assert := assert.New(t)
+
+	names := func(re string) (methods, recordNames []string) {
+		for _, pt := range findTests(&testSuite{}, regexp.MustCompile("(?i)"+re)) {
+			methods = append(methods, pt.method.Name)
+			recordNames = append(recordNames, pt.recordName)
+		}
+		return
+	}
+
+	methods, recordNames := names("")
+	assert.Equal([]string{"Test01Abc", "Test02Def", "TestBar", "TestDatabase", "TestFoo", "TestGlob", "TestNonEmptyPaths", "TestPause", "TestTempFile"}, methods)
+	assert.Equal([]string{"Abc", "Def", "Bar", "Database", "Foo", "Glob", "NonEmptyPaths", "Pause", "TempFile"}, recordNames)
+
+	methods, _ = names("def|foo|abc")
+	assert.Equal([]string{"Test01Abc", "Test02Def", "TestFoo"}, methods)
+}
+
+func TestListFlag(t *testing.T) {
+	assert := assert.New(t)
+
+	flagVal, listFlagVal := *perfFlag, *perfListFlag
+	*perfFlag, *perfListFlag = "mem", true
+	defer func() {
+		*perfFlag, *perfListFlag = flagVal, listFlagVal
+	}()
+
+	// Listing must not run anything.
+	s := testSuite{}
+	Run("test", t, &s)
+	assert.Equal(0, s.setupSuite)
+	assert.Equal(0, s.foo)
+}
@@ -0,0 +1,50 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package spec
+
+import (
+	"sync"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/d"
+)
+
+// ProtocolImpl creates the ChunkStore for a database spec whose protocol has
+// been claimed with RegisterProtocol. sp.DatabaseName is everything after
+// "protocol:", e.g. "//bucket/path" for the spec "s3://bucket/path::ds".
+type ProtocolImpl func(sp Spec) chunks.ChunkStore
+
+var (
+	customProtocolsMu = &sync.RWMutex{}
+	customProtocols   = map[string]ProtocolImpl{}
+)
+
+// RegisterProtocol makes specs with the given protocol, e.g. "s3" for
+// "s3://bucket/path::ds", resolve to ChunkStores created by impl. This lets
+// ChunkStore implementations outside of Noms be used anywhere a spec is
+// accepted, including via .nomsconfig aliases. It's typically called from an
+// init() function. Registering a protocol which is built in or already
+// registered panics.
+func RegisterProtocol(protocol string, impl ProtocolImpl) {
+	d.PanicIfTrue(impl == nil)
+	switch protocol {
+	case "", "nbs", "mem", "http", "https", "aws":
+		d.Panic("Cannot register built-in protocol %s", protocol)
+	}
+
+	customProtocolsMu.Lock()
+	defer customProtocolsMu.Unlock()
+	if _, ok := customProtocols[protocol]; ok {
+		d.Panic("Protocol %s is already registered", protocol)
+	}
+	customProtocols[protocol] = impl
+}
+
+func getCustomProtocol(protocol string) (impl ProtocolImpl, ok bool) {
+	customProtocolsMu.RLock()
+	defer customProtocolsMu.RUnlock()
+	impl, ok = customProtocols[protocol]
+	return
+}
@@ -38,11 +38,13 @@ type SpecOptions struct {
 
 // Spec locates a Noms database, dataset, or value globally.
 type Spec struct {
-	// Protocol is one of "mem", "ldb", "http", or "https".
+	// Protocol is one of "mem", "nbs", "aws", "http", "https", or a protocol
+	// added with RegisterProtocol.
 	Protocol string
 
 	// DatabaseName is the name of the Spec's database, which is the string after
-	// "protocol:". http/https specs include their leading "//" characters.
+	// "protocol:". http/https specs include their leading "//" characters. For
+	// mem specs it is the path of the snapshot file, if any.
 	DatabaseName string
 
 	// Options are the SpecOptions that the Spec was constructed with.
@@ -141,7 +143,7 @@ func ForPathOpts(spec string, opts SpecOptions) (Spec, error) {
 
 func (sp Spec) String() string {
 	s := sp.Protocol
-	if s != "mem" {
+	if s != "mem" || sp.DatabaseName != "" {
 		s += ":" + sp.DatabaseName
 	}
 	p := sp.Path.String()
@@ -174,7 +176,10 @@ func (sp Spec) NewChunkStore() chunks.ChunkStore {
 	case "nbs":
 		return nbs.NewLocalStore(sp.DatabaseName, 1<<28)
 	case "mem":
-		return chunks.NewMemoryStore()
+		return newMemStore(sp.DatabaseName)
+	}
+	if impl, ok := getCustomProtocol(sp.Protocol); ok {
+		return impl(sp)
 	}
 	panic("unreachable")
 }
@@ -270,11 +275,22 @@ func (sp Spec) createDatabase() datas.Database {
 		os.Mkdir(sp.DatabaseName, 0777)
 		return datas.NewDatabase(nbs.NewLocalStore(sp.DatabaseName, 1<<28))
 	case "mem":
-		return datas.NewDatabase(chunks.NewMemoryStore())
+		return datas.NewDatabase(newMemStore(sp.DatabaseName))
+	}
+	if impl, ok := getCustomProtocol(sp.Protocol); ok {
+		return datas.NewDatabase(impl(sp))
 	}
 	panic("unreachable")
 }
 
+// newMemStore returns a MemoryStore, or if snapshot isn't empty, a SnapshotStore which is loaded from and saved to that file.
+func newMemStore(snapshot string) chunks.ChunkStore {
+	if snapshot == "" {
+		return chunks.NewMemoryStore()
+	}
+	return chunks.NewSnapshotStore(snapshot)
+}
+
 func parseDatabaseSpec(spec string) (protocol, name string, err error) {
 	if len(spec) == 0 {
 		err = fmt.Errorf("Empty spec")
@@ -312,10 +328,18 @@ func parseDatabaseSpec(spec string) (protocol, name string, err error) {
 		}
 
 	case "mem":
-		err = fmt.Errorf(`In-memory database must be specified as "mem", not "mem:"`)
+		if parts[1] == "" {
+			err = fmt.Errorf(`In-memory database must be specified as "mem" or "mem:<snapshot file>", not "mem:"`)
+		} else {
+			protocol, name = parts[0], parts[1]
+		}
 
 	default:
-		err = fmt.Errorf("Invalid database protocol %s in %s", protocol, spec)
+		if _, ok := getCustomProtocol(parts[0]); ok {
+			protocol, name = parts[0], parts[1]
+		} else {
+			err = fmt.Errorf("Invalid database protocol %s in %s", parts[0], spec)
+		}
 	}
 	return
 }
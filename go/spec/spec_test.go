@@ -11,6 +11,7 @@ import (
 	"path"
 	"testing"
 
+	"github.com/attic-labs/noms/go/chunks"
 	"github.com/attic-labs/noms/go/datas"
 	"github.com/attic-labs/noms/go/nbs"
 	"github.com/attic-labs/noms/go/types"
@@ -191,7 +192,6 @@ func TestForDatabase(t *testing.T) {
 	assert := assert.New(t)
 
 	badSpecs := []string{
-		"mem:stuff",
 		"mem::",
 		"mem:",
 		"http:",
@@ -506,3 +506,68 @@ func TestAcccessingInvalidSpec(t *testing.T) {
 	test("http:")
 	test("http:💩:")
 }
+
+func TestRegisterProtocol(t *testing.T) {
+	assert := assert.New(t)
+
+	stores := map[string]*chunks.MemoryStore{}
+	RegisterProtocol("test", func(sp Spec) chunks.ChunkStore {
+		if stores[sp.DatabaseName] == nil {
+			stores[sp.DatabaseName] = chunks.NewMemoryStore()
+		}
+		return stores[sp.DatabaseName]
+	})
+	assert.Panics(func() { RegisterProtocol("test", func(sp Spec) chunks.ChunkStore { return nil }) })
+	assert.Panics(func() { RegisterProtocol("nbs", func(sp Spec) chunks.ChunkStore { return nil }) })
+
+	sp, err := ForDataset("test://bucket/db::ds")
+	assert.NoError(err)
+	assert.Equal("test", sp.Protocol)
+	assert.Equal("//bucket/db", sp.DatabaseName)
+	assert.Equal("test://bucket/db::ds", sp.String())
+
+	s := types.String("hello")
+	_, err = sp.GetDatabase().CommitValue(sp.GetDataset(), s)
+	assert.NoError(err)
+	sp.Close()
+
+	sp, err = ForPath("test://bucket/db::ds.value")
+	assert.NoError(err)
+	defer sp.Close()
+	assert.Equal(s, sp.GetValue())
+	assert.NotNil(stores["//bucket/db"])
+
+	_, err = ForDatabase("unregistered://bucket/db")
+	assert.Error(err)
+}
+
+func TestMemSnapshotSpec(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpDir, err := ioutil.TempDir("", "spec_test")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpDir)
+	snapshot := path.Join(tmpDir, "snapshot")
+
+	sp, err := ForDataset("mem:" + snapshot + "::ds")
+	assert.NoError(err)
+	assert.Equal("mem", sp.Protocol)
+	assert.Equal(snapshot, sp.DatabaseName)
+	assert.Equal("mem:"+snapshot+"::ds", sp.String())
+
+	s := types.String("hello")
+	_, err = sp.GetDatabase().CommitValue(sp.GetDataset(), s)
+	assert.NoError(err)
+	assert.NoError(sp.Close())
+
+	sp, err = ForPath("mem:" + snapshot + "::ds.value")
+	assert.NoError(err)
+	defer sp.Close()
+	assert.Equal(s, sp.GetValue())
+
+	// Plain mem databases are still empty every time.
+	sp2, err := ForPath("mem::ds.value")
+	assert.NoError(err)
+	defer sp2.Close()
+	assert.Nil(sp2.GetValue())
+}
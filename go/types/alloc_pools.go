@@ -0,0 +1,79 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Writers whose buffers have grown beyond this are left for the GC rather
+// than pooled, so that one huge value doesn't pin a huge buffer.
+const maxPooledWriterSize = 1 << 20
+
+var (
+	writerPool = sync.Pool{}
+	hasherPool = sync.Pool{}
+
+	allocStats = &AllocStats{}
+)
+
+// AllocStats counts how often the short-lived objects used when encoding and
+// chunking values were newly allocated vs reused from a pool. A high ratio of
+// reuse means less garbage, which matters for long running imports.
+type AllocStats struct {
+	WritersAllocated, WritersReused uint64
+	HashersAllocated, HashersReused uint64
+}
+
+// GetAllocStats returns a snapshot of the allocation counts since the process
+// started.
+func GetAllocStats() AllocStats {
+	return AllocStats{
+		WritersAllocated: atomic.LoadUint64(&allocStats.WritersAllocated),
+		WritersReused:    atomic.LoadUint64(&allocStats.WritersReused),
+		HashersAllocated: atomic.LoadUint64(&allocStats.HashersAllocated),
+		HashersReused:    atomic.LoadUint64(&allocStats.HashersReused),
+	}
+}
+
+func getBinaryNomsWriter() *binaryNomsWriter {
+	if w, ok := writerPool.Get().(*binaryNomsWriter); ok {
+		atomic.AddUint64(&allocStats.WritersReused, 1)
+		w.reset()
+		return w
+	}
+	atomic.AddUint64(&allocStats.WritersAllocated, 1)
+	return newBinaryNomsWriter()
+}
+
+// putBinaryNomsWriter returns w to the pool. Nothing may hold on to w.data()
+// afterwards.
+func putBinaryNomsWriter(w *binaryNomsWriter) {
+	if len(w.buff) <= maxPooledWriterSize {
+		writerPool.Put(w)
+	}
+}
+
+// getRollingValueHasher is like newRollingValueHasher, but reuses a hasher
+// released by putRollingValueHasher if there's one with the right window.
+func getRollingValueHasher(kind NomsKind) *rollingValueHasher {
+	pattern, window := chunkingConfig(kind)
+	return getRollingValueHasherWithConfig(pattern, window)
+}
+
+func getRollingValueHasherWithConfig(pattern, window uint32) *rollingValueHasher {
+	if rv, ok := hasherPool.Get().(*rollingValueHasher); ok && rv.window == window {
+		atomic.AddUint64(&allocStats.HashersReused, 1)
+		rv.reset(pattern)
+		return rv
+	}
+	atomic.AddUint64(&allocStats.HashersAllocated, 1)
+	return newRollingValueHasherWithConfig(pattern, window)
+}
+
+func putRollingValueHasher(rv *rollingValueHasher) {
+	hasherPool.Put(rv)
+}
@@ -0,0 +1,74 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"testing"
+
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestAllocStats(t *testing.T) {
+	assert := assert.New(t)
+
+	before := GetAllocStats()
+	c1 := EncodeValue(String("hello"), nil)
+	c2 := EncodeValue(String("world"), nil)
+	after := GetAllocStats()
+
+	// The pool may drop writers at any time, so only the total is predictable.
+	assert.Equal(before.WritersAllocated+before.WritersReused+2, after.WritersAllocated+after.WritersReused)
+	assert.True(String("hello").Equals(DecodeValue(c1, nil)))
+	assert.True(String("world").Equals(DecodeValue(c2, nil)))
+
+	before = GetAllocStats()
+	NewList(Number(1), Number(2))
+	NewList(Number(3))
+	after = GetAllocStats()
+	assert.Equal(before.HashersAllocated+before.HashersReused+2, after.HashersAllocated+after.HashersReused)
+}
+
+func TestReusedHasherMatchesNew(t *testing.T) {
+	assert := assert.New(t)
+
+	rv := getRollingValueHasher(ListKind)
+	for i := 0; i < 1000; i++ {
+		rv.HashValue(Number(i))
+	}
+	putRollingValueHasher(rv)
+
+	rv1, rv2 := getRollingValueHasher(ListKind), newRollingValueHasher(ListKind)
+	for i := 0; i < 1000; i++ {
+		rv1.HashValue(String("x"))
+		rv2.HashValue(String("x"))
+		assert.Equal(rv2.bz.sum32(), rv1.bz.sum32())
+		assert.Equal(rv2.crossedBoundary, rv1.crossedBoundary)
+	}
+}
+
+func TestChunkerReusesWriter(t *testing.T) {
+	assert := assert.New(t)
+
+	// Each level of the chunker hashes all of its sequences with the same writer, rather than encoding each into a new chunk.
+	before := GetAllocStats()
+	l := NewList(generateNumbersAsValues(10000)...)
+	after := GetAllocStats()
+	height := uint64(1)
+	for seq := l.sequence(); ; height++ {
+		ms, ok := seq.(metaSequence)
+		if !ok {
+			break
+		}
+		seq = ms.getChildSequence(0)
+	}
+	assert.True(height > 1)
+	gets := (after.WritersAllocated + after.WritersReused) - (before.WritersAllocated + before.WritersReused)
+	assert.True(gets <= height, "%d writers for a tree of height %d", gets, height)
+
+	// The hashes are the same as those of the encoded chunks.
+	for _, mt := range l.sequence().(metaSequence).tuples {
+		assert.Equal(EncodeValue(mt.child, nil).Hash(), mt.ref.TargetHash())
+	}
+}
@@ -36,6 +36,32 @@ func (b Blob) Reader() *BlobReader {
 	return &BlobReader{b.seq, cursor, nil, 0}
 }
 
+// ReadAt implements io.ReaderAt. Unlike a BlobReader, ReadAt keeps no state
+// between calls, so it's safe to call concurrently, e.g. to read different
+// ranges of a large Blob in parallel.
+func (b Blob) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, errors.New("Blob.ReadAt: negative offset")
+	}
+	if uint64(off) >= b.Len() {
+		return 0, io.EOF
+	}
+
+	cur := newCursorAtIndex(b.seq, uint64(off), false)
+	for n < len(p) && cur.valid() {
+		data := cur.seq.(blobLeafSequence).data
+		copied := copy(p[n:], data[cur.idx:])
+		n += copied
+		// Move to the last byte copied, so that advancing moves on to the next leaf if this one is exhausted.
+		cur.idx += copied - 1
+		cur.advance()
+	}
+	if n < len(p) {
+		err = io.EOF
+	}
+	return
+}
+
 func (b Blob) Splice(idx uint64, deleteCount uint64, data []byte) Blob {
 	if deleteCount == 0 && len(data) == 0 {
 		return b
@@ -67,7 +93,7 @@ func (b Blob) Concat(other Blob) Blob {
 }
 
 func (b Blob) newChunker(cur *sequenceCursor, vr ValueReader) *sequenceChunker {
-	return newSequenceChunker(cur, vr, nil, makeBlobLeafChunkFn(vr), newIndexedMetaSequenceChunkFn(BlobKind, vr), hashValueByte)
+	return newSequenceChunker(cur, BlobKind, vr, nil, makeBlobLeafChunkFn(vr), newIndexedMetaSequenceChunkFn(BlobKind, vr), hashValueByte)
 }
 
 // Collection interface
@@ -144,6 +170,43 @@ func (cbr *BlobReader) Read(p []byte) (n int, err error) {
 	return
 }
 
+// ReadSlice returns the unread bytes of the leaf chunk the reader is
+// positioned in, and advances past them. The bytes aren't copied: they're
+// shared with the Blob, so must not be modified. ReadSlice returns io.EOF at
+// the end of the Blob.
+func (cbr *BlobReader) ReadSlice() ([]byte, error) {
+	if !cbr.cursor.valid() {
+		return nil, io.EOF
+	}
+
+	data := cbr.cursor.seq.(blobLeafSequence).data
+	p := data[cbr.cursor.idx:]
+	cbr.pos += uint64(len(p))
+	// Move to the last byte of the leaf, so that advancing moves on to the next leaf.
+	cbr.cursor.idx = len(data) - 1
+	cbr.cursor.advance()
+	cbr.currentReader = nil
+	return p, nil
+}
+
+// WriteTo implements io.WriterTo, writing the rest of the Blob to w directly
+// from its leaf chunks. io.Copy uses WriteTo in preference to Read.
+func (cbr *BlobReader) WriteTo(w io.Writer) (n int64, err error) {
+	for {
+		p, err := cbr.ReadSlice()
+		if err == io.EOF {
+			return n, nil
+		}
+		written, err := w.Write(p)
+		n += int64(written)
+		if err != nil {
+			// Leave the reader positioned after the bytes which were written.
+			cbr.Seek(int64(cbr.pos)-int64(len(p)-written), 0)
+			return n, err
+		}
+	}
+}
+
 func (cbr BlobReader) Copy(w io.Writer) (n int64) {
 	if cbr.cursor.parent == nil {
 		data := cbr.cursor.seq.(blobLeafSequence).data
@@ -245,7 +308,7 @@ func readBlobsP(vrw ValueReadWriter, rs ...io.Reader) Blob {
 	case 0:
 		return NewEmptyBlob()
 	case 1:
-		return readBlob(rs[0], vrw)
+		return readBlob(rs[0], vrw, nil, nil)
 	}
 
 	blobs := make([]Blob, len(rs))
@@ -256,7 +319,7 @@ func readBlobsP(vrw ValueReadWriter, rs ...io.Reader) Blob {
 	for i, r := range rs {
 		i2, r2 := i, r
 		go func() {
-			blobs[i2] = readBlob(r2, vrw)
+			blobs[i2] = readBlob(r2, vrw, nil, nil)
 			wg.Done()
 		}()
 	}
@@ -270,26 +333,53 @@ func readBlobsP(vrw ValueReadWriter, rs ...io.Reader) Blob {
 	return b
 }
 
-func readBlob(r io.Reader, vrw ValueReadWriter) Blob {
-	sc := newEmptySequenceChunker(vrw, vrw, makeBlobLeafChunkFn(vrw), newIndexedMetaSequenceChunkFn(BlobKind, vrw), func(item sequenceItem, rv *rollingValueHasher) {
+// NewCSVBlob creates a Blob by reading CSV content from r, with its leaf
+// chunks ending at the ends of records, as described by csv. If vrw is not
+// nil, chunks are written to vrw instead of kept in memory.
+//
+// Since chunk boundaries determine a Blob's hash, the Blob isn't Equal to
+// one created by NewBlob from the same bytes. Splice and Concat chunk the
+// parts of the Blob they change as NewBlob would.
+func NewCSVBlob(vrw ValueReadWriter, csv CSVChunking, r io.Reader) Blob {
+	return readBlob(r, vrw, &csv, nil)
+}
+
+// readBlob chunks r into a Blob, at the boundaries found by the rolling hash
+// or, if csv isn't nil, at the ends of the records following them. If config
+// isn't nil, the rolling hash is configured by it rather than by BlobKind's
+// chunking config.
+func readBlob(r io.Reader, vrw ValueReadWriter, csv *CSVChunking, config *ChunkConfig) Blob {
+	sc := newEmptySequenceChunker(BlobKind, vrw, vrw, makeBlobLeafChunkFn(vrw), newIndexedMetaSequenceChunkFn(BlobKind, vrw), func(item sequenceItem, rv *rollingValueHasher) {
 		rv.HashByte(item.(byte))
 	})
 
 	// TODO: The code below is temporary. It's basically a custom leaf-level chunker for blobs. There are substational perf gains by doing it this way as it avoids the cost of boxing every single byte which is chunked.
 	chunkBuff := [8192]byte{}
 	chunkBytes := chunkBuff[:]
-	rv := newRollingValueHasher()
+	rv := newRollingValueHasher(BlobKind)
+	if config != nil {
+		sc.withConfig(*config)
+		rv = newRollingValueHasherWithConfig(config.pattern(), config.Window)
+	}
+	nextBoundary := func(bs []byte) (int, bool) {
+		hashed := rv.HashBytesToBoundary(bs)
+		if !rv.crossedBoundary {
+			return hashed, false
+		}
+		rv.ClearLastBoundary()
+		return hashed, true
+	}
+	if csv != nil {
+		nextBoundary = newCSVBoundaries(rv, *csv).next
+	}
 	offset := 0
-	addByte := func(b byte) bool {
-		if offset >= len(chunkBytes) {
+	addBytes := func(bs []byte) {
+		for offset+len(bs) > len(chunkBytes) {
 			tmp := make([]byte, len(chunkBytes)*2)
 			copy(tmp, chunkBytes)
 			chunkBytes = tmp
 		}
-		chunkBytes[offset] = b
-		offset++
-		rv.HashByte(b)
-		return rv.crossedBoundary
+		offset += copy(chunkBytes[offset:], bs)
 	}
 
 	mtChan := make(chan chan metaTuple, runtime.NumCPU())
@@ -320,9 +410,11 @@ func readBlob(r io.Reader, vrw ValueReadWriter) Blob {
 		readBuff := [8192]byte{}
 		for {
 			n, err := r.Read(readBuff[:])
-			for i := 0; i < n; i++ {
-				if addByte(readBuff[i]) {
-					rv.ClearLastBoundary()
+			for buff := readBuff[:n]; len(buff) > 0; {
+				hashed, boundary := nextBoundary(buff)
+				addBytes(buff[:hashed])
+				buff = buff[hashed:]
+				if boundary {
 					makeChunk()
 				}
 			}
@@ -0,0 +1,88 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+// maxCSVRecordWait is how many bytes past a chunk boundary NewCSVBlob looks
+// for the end of a record before ending the chunk anyway, e.g. because a
+// quote was never closed.
+const maxCSVRecordWait = 1 << 20
+
+// CSVChunking describes how records of CSV content end, for NewCSVBlob to
+// align chunk boundaries with them. The field delimiter doesn't affect where
+// records end, so isn't needed.
+type CSVChunking struct {
+	// Quote is the byte which quotes fields, usually '"'. A RecordSeparator
+	// inside a quoted field doesn't end the record. 0 means fields aren't
+	// quoted.
+	Quote byte
+	// RecordSeparator ends each record, usually '\n'.
+	RecordSeparator byte
+}
+
+// DefaultCSVChunking describes CSV as RFC 4180 does.
+var DefaultCSVChunking = CSVChunking{Quote: '"', RecordSeparator: '\n'}
+
+// csvBoundaries moves the chunk boundaries found by a rolling hash to the end
+// of the record each falls in. It tracks whether it's inside a quoted field
+// from the start of the input, so boundaries only depend on the content
+// before them, and appending records to CSV content leaves all but its last
+// chunk unchanged.
+type csvBoundaries struct {
+	rv       *rollingValueHasher
+	csv      CSVChunking
+	inQuotes bool
+	// waited is the number of bytes since a boundary was found, while
+	// looking for the end of its record, or -1.
+	waited int
+}
+
+func newCSVBoundaries(rv *rollingValueHasher, csv CSVChunking) *csvBoundaries {
+	return &csvBoundaries{rv: rv, csv: csv, waited: -1}
+}
+
+// next consumes bytes from bs until a chunk boundary, and returns the number
+// consumed and whether the boundary was reached.
+func (cb *csvBoundaries) next(bs []byte) (int, bool) {
+	if cb.waited < 0 {
+		n := cb.rv.HashBytesToBoundary(bs)
+		recordEnded := cb.scan(bs[:n])
+		if !cb.rv.crossedBoundary {
+			return n, false
+		}
+		cb.rv.ClearLastBoundary()
+		if recordEnded {
+			return n, true
+		}
+		cb.waited = 0
+		return n, false
+	}
+
+	for i := range bs {
+		if cb.scan(bs[i:i+1]) || cb.waited+i+1 >= maxCSVRecordWait {
+			// The rest of the record is hashed too, so that the rolling hash's
+			// window is the same as if the boundary had been here.
+			cb.waited = -1
+			cb.rv.HashBytes(bs[:i+1])
+			cb.rv.ClearLastBoundary()
+			return i + 1, true
+		}
+	}
+	cb.waited += len(bs)
+	cb.rv.HashBytes(bs)
+	return len(bs), false
+}
+
+// scan updates whether bs ends inside a quoted field, and returns whether
+// its last byte ends a record.
+func (cb *csvBoundaries) scan(bs []byte) (recordEnded bool) {
+	for _, b := range bs {
+		if cb.csv.Quote != 0 && b == cb.csv.Quote {
+			// An escaped quote is two quotes, so it leaves the field quoted.
+			cb.inQuotes = !cb.inQuotes
+		}
+		recordEnded = b == cb.csv.RecordSeparator && !cb.inQuotes
+	}
+	return
+}
@@ -0,0 +1,71 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// +build gofuzz
+
+package types
+
+import "fmt"
+
+// Fuzz is a go-fuzz target for the chunk boundaries NewCSVBlob finds. It
+// checks that they're strictly increasing, that each ends a record, and that
+// they don't depend on how the input is split into reads. Chunks are made
+// small, so that short inputs have several.
+func Fuzz(data []byte) int {
+	whole := fuzzCSVBoundaries(data, len(data))
+	if bytewise := fuzzCSVBoundaries(data, 1); fmt.Sprint(whole) != fmt.Sprint(bytewise) {
+		panic(fmt.Sprintf("boundaries depend on reads: %v vs %v", whole, bytewise))
+	}
+
+	last := 0
+	for _, b := range whole {
+		if b <= last {
+			panic(fmt.Sprintf("boundaries aren't increasing: %v", whole))
+		}
+		last = b
+	}
+
+	inQuotes := false
+	for i, c := range data {
+		if c == DefaultCSVChunking.Quote {
+			inQuotes = !inQuotes
+		}
+		if len(whole) > 0 && whole[0] == i+1 {
+			if c != DefaultCSVChunking.RecordSeparator || inQuotes {
+				panic(fmt.Sprintf("boundary at %d doesn't end a record", i+1))
+			}
+			whole = whole[1:]
+		}
+	}
+	if len(whole) > 0 {
+		panic(fmt.Sprintf("boundaries past the end: %v", whole))
+	}
+	return 0
+}
+
+// fuzzCSVBoundaries returns the offsets of the chunk boundaries in data,
+// reading it readSize bytes at a time.
+func fuzzCSVBoundaries(data []byte, readSize int) []int {
+	if readSize == 0 {
+		return nil
+	}
+	cb := newCSVBoundaries(newRollingValueHasherWithConfig(1<<4-1, 8), DefaultCSVChunking)
+	boundaries := []int{}
+	offset := 0
+	for start := 0; start < len(data); start += readSize {
+		end := start + readSize
+		if end > len(data) {
+			end = len(data)
+		}
+		for bs := data[start:end]; len(bs) > 0; {
+			n, boundary := cb.next(bs)
+			offset += n
+			bs = bs[n:]
+			if boundary {
+				boundaries = append(boundaries, offset)
+			}
+		}
+	}
+	return boundaries
+}
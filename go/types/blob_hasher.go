@@ -0,0 +1,105 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"io"
+	"sync"
+
+	"github.com/attic-labs/noms/go/hash"
+)
+
+// BlobHasher computes the hash of the Blob which NewBlob would create from
+// the bytes written to it, without creating the Blob: its chunks are hashed
+// as they're made, then dropped. So an importer can find whether a database
+// already has the Blob of a large file before writing it, keeping only a few
+// chunks of each level of the Blob's prolly tree in memory.
+type BlobHasher struct {
+	pw     *io.PipeWriter
+	done   chan struct{}
+	h      hash.Hash
+	length uint64
+}
+
+// NewBlobHasher returns a BlobHasher, which chunks the bytes written to it on
+// its own goroutine until Sum is called.
+func NewBlobHasher() *BlobHasher {
+	return newBlobHasher(nil)
+}
+
+// NewCSVBlobHasher returns a BlobHasher which computes the hash of the Blob
+// which NewCSVBlob would create with csv, rather than NewBlob.
+func NewCSVBlobHasher(csv CSVChunking) *BlobHasher {
+	return newBlobHasher(&csv)
+}
+
+func newBlobHasher(csv *CSVChunking) *BlobHasher {
+	pr, pw := io.Pipe()
+	bh := &BlobHasher{pw: pw, done: make(chan struct{})}
+	go func() {
+		defer close(bh.done)
+		b := readBlob(pr, newHashingValueWriter(), csv, nil)
+		bh.h, bh.length = b.Hash(), b.Len()
+	}()
+	return bh
+}
+
+// Write implements io.Writer. It returns an error if Sum has been called.
+func (bh *BlobHasher) Write(p []byte) (int, error) {
+	return bh.pw.Write(p)
+}
+
+// Sum returns the hash of the Blob of every byte written, and how many there
+// were. No more can be written after Sum is called.
+func (bh *BlobHasher) Sum() (hash.Hash, uint64) {
+	bh.pw.Close()
+	<-bh.done
+	return bh.h, bh.length
+}
+
+// hashingValueWriter is the ValueReadWriter a BlobHasher chunks with. It
+// doesn't write values, it only returns Refs to them. But it has to keep the
+// last value written at each height, since when a sequenceChunker is done it
+// may read back the only chunk written at a level to find the canonical root
+// of the tree.
+type hashingValueWriter struct {
+	mu   *sync.Mutex
+	last map[uint64]Value // ref height -> last value written of that height
+}
+
+func newHashingValueWriter() *hashingValueWriter {
+	return &hashingValueWriter{mu: &sync.Mutex{}, last: map[uint64]Value{}}
+}
+
+func (hvw *hashingValueWriter) WriteValue(v Value) Ref {
+	r := NewRef(v)
+	hvw.mu.Lock()
+	defer hvw.mu.Unlock()
+	hvw.last[r.Height()] = v
+	return r
+}
+
+func (hvw *hashingValueWriter) ReadValue(h hash.Hash) Value {
+	hvw.mu.Lock()
+	defer hvw.mu.Unlock()
+	for _, v := range hvw.last {
+		if v.Hash() == h {
+			return v
+		}
+	}
+	return nil
+}
+
+func (hvw *hashingValueWriter) ReadManyValues(hashes hash.HashSet, foundValues chan<- Value) {
+	for h := range hashes {
+		if v := hvw.ReadValue(h); v != nil {
+			foundValues <- v
+		}
+	}
+}
+
+func (hvw *hashingValueWriter) opCache() opCache {
+	panic("unreachable")
+}
@@ -6,6 +6,8 @@ package types
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"math/rand"
@@ -165,6 +167,34 @@ func (suite *blobTestSuite) TestRandomRead() {
 	}
 }
 
+func (suite *blobTestSuite) TestReadAt() {
+	blob := suite.col.(Blob)
+	length := int64(len(suite.buff))
+
+	checkReadAt := func(off, count int64) {
+		p := make([]byte, count)
+		n, err := blob.ReadAt(p, off)
+		suite.NoError(err)
+		suite.Equal(int(count), n)
+		suite.Equal(suite.buff[off:off+count], p)
+	}
+
+	checkReadAt(0, length)
+	checkReadAt(length/3, length/3)
+	checkReadAt(length-1, 1)
+
+	p := make([]byte, 10)
+	n, err := blob.ReadAt(p, length-5)
+	suite.Equal(io.EOF, err)
+	suite.Equal(5, n)
+	suite.Equal(suite.buff[length-5:], p[:5])
+
+	_, err = blob.ReadAt(p, length)
+	suite.Equal(io.EOF, err)
+	_, err = blob.ReadAt(p, -1)
+	suite.Error(err)
+}
+
 type testReader struct {
 	readCount int
 	buf       *bytes.Buffer
@@ -323,3 +353,201 @@ func TestStreamingParallelBlob(t *testing.T) {
 	blob.Reader().Copy(outBuff)
 	assert.True(bytes.Compare(buff, outBuff.Bytes()) == 0)
 }
+
+func TestBlobKindChunkConfig(t *testing.T) {
+	assert := assert.New(t)
+	defer normalProductionChunks()
+
+	buff := randomBuff(16)
+	nums := generateNumbersAsValues(10000)
+	b1, l1 := NewBlob(bytes.NewReader(buff)), NewList(nums...)
+
+	SetKindChunkConfig(BlobKind, ChunkConfig{AverageSize: 1 << 8, Window: 64})
+	assert.Equal(ChunkConfig{AverageSize: 1 << 8, Window: 64}, KindChunkConfig(BlobKind))
+	assert.Equal(DefaultChunkConfig, KindChunkConfig(ListKind))
+	assert.Panics(func() { SetKindChunkConfig(ListKind, ChunkConfig{AverageSize: 1000, Window: 64}) })
+	b2, l2 := NewBlob(bytes.NewReader(buff)), NewList(nums...)
+
+	// Only blobs are chunked differently.
+	vs := NewTestValueStore()
+	vs.WriteValue(b1)
+	vs.WriteValue(b2)
+	numChunks := func(b Blob) uint64 {
+		return CompareChunks(NewEmptyBlob(), vs, b, vs).Total()
+	}
+	assert.True(numChunks(b2) > 4*numChunks(b1))
+	assert.False(b1.Equals(b2))
+	assert.True(l1.Equals(l2))
+
+	out := &bytes.Buffer{}
+	out.ReadFrom(b2.Reader())
+	assert.Equal(buff, out.Bytes())
+
+	// Building the blob by splicing goes through sequenceChunker rather than readBlob, and must agree.
+	assert.True(b2.Equals(NewEmptyBlob().Splice(0, 0, buff)))
+}
+
+type failingWriter struct {
+	n int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		written := w.n
+		w.n = 0
+		return written, errors.New("failingWriter")
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestBlobReaderReadSliceAndWriteTo(t *testing.T) {
+	assert := assert.New(t)
+
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	vs := NewTestValueStore()
+	r := rand.New(rand.NewSource(0))
+	data := make([]byte, 1e6)
+	r.Read(data)
+	b := NewBlob(bytes.NewReader(data))
+	b = vs.ReadValue(vs.WriteValue(b).TargetHash()).(Blob)
+
+	// ReadSlice returns each leaf in turn.
+	br := b.Reader()
+	actual := []byte{}
+	slices := 0
+	for {
+		p, err := br.ReadSlice()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(err)
+		actual = append(actual, p...)
+		slices++
+	}
+	assert.Equal(data, actual)
+	assert.True(slices > 1)
+
+	// ReadSlice, Read and WriteTo each continue from where the others left off.
+	br = b.Reader()
+	br.Seek(1000, 0)
+	p, err := br.ReadSlice()
+	assert.NoError(err)
+	assert.Equal(data[1000:1000+len(p)], p)
+	pos := 1000 + len(p)
+	buf := make([]byte, 10)
+	n, err := br.Read(buf)
+	assert.NoError(err)
+	assert.Equal(data[pos:pos+n], buf[:n])
+	pos += n
+
+	out := &bytes.Buffer{}
+	copied, err := io.Copy(out, br)
+	assert.NoError(err)
+	assert.Equal(int64(len(data)-pos), copied)
+	assert.Equal(data[pos:], out.Bytes())
+
+	p, err = br.ReadSlice()
+	assert.Nil(p)
+	assert.Equal(io.EOF, err)
+
+	// A failed write leaves the reader after the bytes written.
+	br = b.Reader()
+	copied, err = br.WriteTo(&failingWriter{n: 123456})
+	assert.Error(err)
+	assert.Equal(int64(123456), copied)
+	out.Reset()
+	out.ReadFrom(br)
+	assert.Equal(data[123456:], out.Bytes())
+
+	out.Reset()
+	copied, err = NewEmptyBlob().Reader().WriteTo(out)
+	assert.NoError(err)
+	assert.Equal(int64(0), copied)
+}
+
+func TestBlobHasher(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, data := range [][]byte{{}, []byte("hello"), randomBuff(16), randomBuff(20)} {
+		expected := NewBlob(bytes.NewReader(data))
+
+		bh := NewBlobHasher()
+		// Write in uneven pieces, to hash across writes.
+		for rest := data; len(rest) > 0; {
+			n := 1000
+			if n > len(rest) {
+				n = len(rest)
+			}
+			written, err := bh.Write(rest[:n])
+			assert.NoError(err)
+			assert.Equal(n, written)
+			rest = rest[n:]
+		}
+		h, length := bh.Sum()
+		assert.Equal(expected.Hash(), h)
+		assert.Equal(uint64(len(data)), length)
+
+		_, err := bh.Write([]byte{1})
+		assert.Error(err)
+	}
+}
+
+func TestCSVBlob(t *testing.T) {
+	assert := assert.New(t)
+
+	records := func(from, to int) []byte {
+		buf := &bytes.Buffer{}
+		for i := from; i < to; i++ {
+			// Every tenth record has a quoted field with a newline and an escaped quote in it.
+			if i%10 == 0 {
+				fmt.Fprintf(buf, "%d,\"a \"\"quoted\"\"\nfield %d\",x\n", i, i*7)
+			} else {
+				fmt.Fprintf(buf, "%d,%d,%s\n", i, i*i, strings.Repeat("y", i%13))
+			}
+		}
+		return buf.Bytes()
+	}
+	leaves := func(b Blob) (leaves []string) {
+		br := b.Reader()
+		for p, err := br.ReadSlice(); err == nil; p, err = br.ReadSlice() {
+			leaves = append(leaves, string(p))
+		}
+		return
+	}
+
+	data := records(0, 20000)
+	b := NewCSVBlob(nil, DefaultCSVChunking, bytes.NewReader(data))
+	out := &bytes.Buffer{}
+	io.Copy(out, b.Reader())
+	assert.Equal(data, out.Bytes())
+	assert.False(b.Equals(NewBlob(bytes.NewReader(data))))
+
+	// Every leaf is a whole number of records.
+	ls := leaves(b)
+	assert.True(len(ls) > 10)
+	for _, l := range ls {
+		assert.True(strings.HasSuffix(l, "\n"))
+		assert.Equal(0, strings.Count(l, `"`)%2)
+	}
+
+	// Appending records leaves all but the last leaf alone.
+	appended := leaves(NewCSVBlob(nil, DefaultCSVChunking, bytes.NewReader(append(data, records(20000, 21000)...))))
+	assert.Equal(ls[:len(ls)-1], appended[:len(ls)-1])
+
+	// A BlobHasher with the same CSVChunking finds the same boundaries.
+	bh := NewCSVBlobHasher(DefaultCSVChunking)
+	_, err := io.Copy(bh, bytes.NewReader(data))
+	assert.NoError(err)
+	h, length := bh.Sum()
+	assert.Equal(b.Hash(), h)
+	assert.Equal(b.Len(), length)
+
+	// A record which never ends doesn't stop the Blob being chunked.
+	unterminated := append([]byte(`1,"`), randomBuff(22)...)
+	b = NewCSVBlob(nil, CSVChunking{Quote: '"', RecordSeparator: '\n'}, bytes.NewReader(unterminated))
+	assert.Equal(uint64(len(unterminated)), b.Len())
+	assert.True(len(leaves(b)) > 1)
+}
@@ -0,0 +1,99 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import "github.com/kch42/buzhash"
+
+// buzTable is the byte -> hash table used by github.com/kch42/buzhash. It
+// isn't exported, but hashing a single byte into a fresh hash yields exactly
+// the table entry for that byte.
+var buzTable = func() (t [256]uint32) {
+	for i := range t {
+		t[i] = buzhash.NewBuzHash(1).HashByte(byte(i))
+	}
+	return
+}()
+
+// buzHash computes exactly the same rolling hash as buzhash.BuzHash, but has
+// its state accessible so that rollingValueHasher can hash whole slices in a
+// tight loop, rather than making a method call per byte.
+type buzHash struct {
+	state          uint32
+	buf            []byte
+	n, pos         uint32
+	shiftn, shiftm uint
+	overflow       bool
+}
+
+func newBuzHash(n uint32) *buzHash {
+	return &buzHash{
+		buf:    make([]byte, n),
+		n:      n,
+		shiftn: uint(n % 32),
+		shiftm: 32 - uint(n%32),
+	}
+}
+
+func (bh *buzHash) reset() {
+	bh.state = 0
+	bh.pos = 0
+	bh.overflow = false
+}
+
+func (bh *buzHash) sum32() uint32 {
+	return bh.state
+}
+
+func (bh *buzHash) hashByte(b byte) uint32 {
+	if bh.pos == bh.n {
+		bh.overflow = true
+		bh.pos = 0
+	}
+
+	state := bh.state<<1 | bh.state>>31
+	if bh.overflow {
+		out := buzTable[bh.buf[bh.pos]]
+		state ^= out<<bh.shiftn | out>>bh.shiftm
+	}
+	bh.buf[bh.pos] = b
+	bh.pos++
+
+	bh.state = state ^ buzTable[b]
+	return bh.state
+}
+
+// hashBytesToBoundary hashes bytes from bs until the hash matches pattern,
+// and returns the number of bytes hashed, which is len(bs) if it never does.
+func (bh *buzHash) hashBytesToBoundary(bs []byte, pattern uint32) (n int, found bool) {
+	state, buf, pos, overflow := bh.state, bh.buf, bh.pos, bh.overflow
+	size, shiftn, shiftm := bh.n, bh.shiftn, bh.shiftm
+
+	for n < len(bs) {
+		b := bs[n]
+		n++
+
+		if pos == size {
+			overflow = true
+			pos = 0
+		}
+
+		state = state<<1 | state>>31
+		if overflow {
+			out := buzTable[buf[pos]]
+			state ^= out<<shiftn | out>>shiftm
+		}
+		buf[pos] = b
+		pos++
+		state ^= buzTable[b]
+
+		if state&pattern == pattern {
+			found = true
+			break
+		}
+	}
+
+	bh.state, bh.pos, bh.overflow = state, pos, overflow
+	return
+}
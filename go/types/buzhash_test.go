@@ -0,0 +1,106 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/attic-labs/testify/assert"
+	"github.com/kch42/buzhash"
+)
+
+func TestBuzHashMatchesBuzhashPackage(t *testing.T) {
+	assert := assert.New(t)
+
+	data := make([]byte, 10000)
+	rand.New(rand.NewSource(42)).Read(data)
+
+	for _, window := range []uint32{1, 31, 32, 33, 64, 67} {
+		expected := buzhash.NewBuzHash(window)
+		actual := newBuzHash(window)
+		for _, b := range data {
+			assert.Equal(expected.HashByte(b), actual.hashByte(b))
+		}
+	}
+}
+
+func TestBuzHashBytesToBoundary(t *testing.T) {
+	assert := assert.New(t)
+
+	data := make([]byte, 100000)
+	rand.New(rand.NewSource(42)).Read(data)
+	pattern := uint32(1<<8 - 1)
+
+	expected := []int{}
+	bh := newBuzHash(64)
+	for i, b := range data {
+		if bh.hashByte(b)&pattern == pattern {
+			expected = append(expected, i)
+		}
+	}
+	assert.NotEmpty(expected)
+
+	actual := []int{}
+	bh = newBuzHash(64)
+	for offset := 0; offset < len(data); {
+		n, found := bh.hashBytesToBoundary(data[offset:], pattern)
+		offset += n
+		if found {
+			actual = append(actual, offset-1)
+		}
+	}
+	assert.Equal(expected, actual)
+}
+
+func TestRollingValueHasherHashBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	data := make([]byte, 10000)
+	rand.New(rand.NewSource(42)).Read(data)
+
+	rv1, rv2 := newRollingValueHasher(BlobKind), newRollingValueHasher(BlobKind)
+	for _, b := range data {
+		rv1.HashByte(b)
+	}
+	rv2.HashBytes(data)
+	assert.Equal(rv1.bz.sum32(), rv2.bz.sum32())
+	assert.Equal(rv1.bytesHashed, rv2.bytesHashed)
+	assert.Equal(rv1.crossedBoundary, rv2.crossedBoundary)
+}
+
+func benchmarkData(b *testing.B) []byte {
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(42)).Read(data)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	return data
+}
+
+func BenchmarkRollingValueHasherHashByte(b *testing.B) {
+	data := benchmarkData(b)
+	rv := newRollingValueHasher(BlobKind)
+	for i := 0; i < b.N; i++ {
+		for _, c := range data {
+			rv.HashByte(c)
+		}
+	}
+}
+
+func BenchmarkRollingValueHasherHashBytes(b *testing.B) {
+	data := benchmarkData(b)
+	rv := newRollingValueHasher(BlobKind)
+	for i := 0; i < b.N; i++ {
+		rv.HashBytes(data)
+	}
+}
+
+func BenchmarkNewBlob(b *testing.B) {
+	data := benchmarkData(b)
+	for i := 0; i < b.N; i++ {
+		NewBlob(bytes.NewReader(data))
+	}
+}
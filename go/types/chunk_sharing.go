@@ -0,0 +1,119 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import "github.com/attic-labs/noms/go/hash"
+
+// ChunkSharing describes how many of the chunks that make up a value are shared with another value. A chunk is the value itself plus the target of every Ref reachable from it, including the Refs which link the chunks of a prolly tree together.
+type ChunkSharing struct {
+	// Shared is the number of chunks which are also reachable from the other value.
+	Shared uint64
+	// New is the number of chunks which are not.
+	New uint64
+	// Old is the number of chunks which are only reachable from the other value.
+	Old uint64
+	// SharedBytes, NewBytes and OldBytes are the total encoded sizes of those chunks.
+	SharedBytes, NewBytes, OldBytes uint64
+}
+
+// Total is the number of distinct chunks in the value.
+func (cs ChunkSharing) Total() uint64 {
+	return cs.Shared + cs.New
+}
+
+// SharedFraction is the fraction of chunks which are shared, or 0 if there are no chunks.
+func (cs ChunkSharing) SharedFraction() float64 {
+	if cs.Total() == 0 {
+		return 0
+	}
+	return float64(cs.Shared) / float64(cs.Total())
+}
+
+// CompareChunks reports how many of the chunks reachable from v are also reachable from base, by hash. This quantifies how well an incremental change (e.g. re-importing a slightly different CSV file) deduplicates against the previous version. base is read from baseVR and v from vr, which may be the same.
+//
+// Every chunk reachable from base is loaded, but subtrees of v which are shared with base are not. Chunks which can't be read (e.g. because they haven't been written yet) aren't counted. The roots are counted as they're encoded, whether or not they're stored in chunks of their own.
+func CompareChunks(base Value, baseVR ValueReader, v Value, vr ValueReader) ChunkSharing {
+	// children maps each chunk reachable from base to the chunks it references directly, and sizes to its size.
+	children := map[hash.Hash]hash.HashSlice{}
+	sizes := map[hash.Hash]uint64{}
+	walkChunks(base, baseVR, func(h hash.Hash, v Value) bool {
+		if _, ok := children[h]; ok {
+			return false
+		}
+		refs := hash.HashSlice{}
+		v.WalkRefs(func(r Ref) {
+			refs = append(refs, r.TargetHash())
+		})
+		children[h] = refs
+		sizes[h] = encodedSize(v)
+		return true
+	})
+
+	cs := ChunkSharing{}
+	visited := hash.HashSet{}
+
+	var countShared func(h hash.Hash)
+	countShared = func(h hash.Hash) {
+		if _, ok := children[h]; !ok || visited.Has(h) {
+			return
+		}
+		visited.Insert(h)
+		cs.Shared++
+		cs.SharedBytes += sizes[h]
+		for _, c := range children[h] {
+			countShared(c)
+		}
+	}
+
+	walkChunks(v, vr, func(h hash.Hash, v Value) bool {
+		if visited.Has(h) {
+			return false
+		}
+		if _, ok := children[h]; ok {
+			countShared(h)
+			return false
+		}
+		visited.Insert(h)
+		cs.New++
+		cs.NewBytes += encodedSize(v)
+		return true
+	})
+
+	cs.Old = uint64(len(children)) - cs.Shared
+	for _, size := range sizes {
+		cs.OldBytes += size
+	}
+	cs.OldBytes -= cs.SharedBytes
+	return cs
+}
+
+func encodedSize(v Value) uint64 {
+	return uint64(len(EncodeValue(v, nil).Data()))
+}
+
+// walkChunks calls cb with the hash of v and every chunk reachable from it, loading chunks from vr. Chunks referenced by a chunk are only visited if cb returns true for it.
+func walkChunks(v Value, vr ValueReader, cb func(h hash.Hash, v Value) bool) {
+	if !cb(v.Hash(), v) {
+		return
+	}
+	refs := []Ref{}
+	v.WalkRefs(func(r Ref) {
+		refs = append(refs, r)
+	})
+	for len(refs) > 0 {
+		r := refs[len(refs)-1]
+		refs = refs[:len(refs)-1]
+
+		target := r.TargetValue(vr)
+		if target == nil {
+			continue
+		}
+		if cb(r.TargetHash(), target) {
+			target.WalkRefs(func(r Ref) {
+				refs = append(refs, r)
+			})
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"testing"
+
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestCompareChunksIdentical(t *testing.T) {
+	assert := assert.New(t)
+
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	vs := NewTestValueStore()
+	l := vs.ReadValue(vs.WriteValue(newTestList(1000).toList()).TargetHash())
+
+	cs := CompareChunks(l, vs, l, vs)
+	assert.Equal(uint64(0), cs.New)
+	assert.True(cs.Shared > 1)
+	assert.Equal(1.0, cs.SharedFraction())
+}
+
+func TestCompareChunksIncrementalChange(t *testing.T) {
+	assert := assert.New(t)
+
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	vs := NewTestValueStore()
+	write := func(v Value) Value {
+		return vs.ReadValue(vs.WriteValue(v).TargetHash())
+	}
+
+	tl := newTestList(5000)
+	base := write(tl.toList())
+	changed := write(base.(List).Set(2500, String("changed")))
+
+	all := CompareChunks(NewList(), vs, changed, vs)
+	assert.Equal(uint64(0), all.Shared)
+	assert.True(all.New > 10)
+
+	// Changing a single element only creates new chunks along one path from the root.
+	cs := CompareChunks(base, vs, changed, vs)
+	assert.Equal(all.Total(), cs.Total())
+	assert.True(cs.New > 0)
+	assert.True(cs.New < 10, "%d new chunks", cs.New)
+	assert.True(cs.Shared > cs.New)
+
+	cs = CompareChunks(base, vs, Number(1), vs)
+	assert.Equal(uint64(0), cs.Shared)
+	assert.Equal(uint64(1), cs.New)
+	assert.Equal(CompareChunks(NewList(), vs, base, vs).Total(), cs.Old)
+}
+
+func TestCompareChunksNested(t *testing.T) {
+	assert := assert.New(t)
+
+	vs := NewTestValueStore()
+	r1 := vs.WriteValue(String("one"))
+	r2 := vs.WriteValue(String("two"))
+	r3 := vs.WriteValue(String("three"))
+	base := vs.ReadValue(vs.WriteValue(NewList(r1, r2)).TargetHash())
+	v := vs.ReadValue(vs.WriteValue(NewList(r1, r3)).TargetHash())
+
+	size := func(v Value) uint64 {
+		return uint64(len(EncodeValue(v, nil).Data()))
+	}
+
+	// The list chunks differ, r1 is shared, r3 is new and r2 is old.
+	assert.Equal(ChunkSharing{
+		Shared: 1, SharedBytes: size(String("one")),
+		New: 2, NewBytes: size(v) + size(String("three")),
+		Old: 2, OldBytes: size(base) + size(String("two")),
+	}, CompareChunks(base, vs, v, vs))
+	assert.Equal(ChunkSharing{
+		Shared: 3, SharedBytes: size(base) + size(String("one")) + size(String("two")),
+	}, CompareChunks(base, vs, base, vs))
+}
@@ -0,0 +1,179 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/attic-labs/testify/assert"
+)
+
+// These are property tests of the chunker: however a collection is arrived at, through any sequence of edits, and whether or not its chunks have been written and reloaded in between, it must be chunked identically to the same collection built from scratch. This exercises sequenceChunker.resume() and finalizeCursor(), which are subtle.
+
+const (
+	listOpAppend = iota
+	listOpInsert
+	listOpRemove
+	listOpSet
+	listOpReload
+	numListOps
+)
+
+// listOp is a random edit. Positions are fractions of the list's length at the time the edit is applied.
+type listOp struct {
+	kind     int
+	pos, end float64
+	values   []Value
+}
+
+func (op listOp) String() string {
+	names := []string{"append", "insert", "remove", "set", "reload"}
+	return fmt.Sprintf("%s(%.3f, %.3f, %d values)", names[op.kind], op.pos, op.end, len(op.values))
+}
+
+type listOps []listOp
+
+// randomValues returns up to 64 values. Sometimes they're all the same, because long runs of identical values are a hard case for the rolling hash.
+func randomValues(r *rand.Rand) []Value {
+	n := r.Intn(64) + 1
+	vs := make([]Value, n)
+	same := r.Intn(4) == 0
+	for i := range vs {
+		if same && i > 0 {
+			vs[i] = vs[0]
+		} else {
+			vs[i] = Number(r.Intn(1000))
+		}
+	}
+	return vs
+}
+
+func (listOps) Generate(r *rand.Rand, size int) reflect.Value {
+	ops := make(listOps, r.Intn(size)+1)
+	for i := range ops {
+		op := listOp{kind: r.Intn(numListOps), pos: r.Float64(), end: r.Float64()}
+		if op.kind == listOpAppend || op.kind == listOpInsert || op.kind == listOpSet {
+			op.values = randomValues(r)
+		}
+		ops[i] = op
+	}
+	return reflect.ValueOf(ops)
+}
+
+func (ops listOps) apply(assert *assert.Assertions, initial testList) bool {
+	vs := NewTestValueStore()
+	tl, l := initial, initial.toList()
+	at := func(f float64) int {
+		return int(f * float64(len(tl)))
+	}
+
+	for _, op := range ops {
+		switch op.kind {
+		case listOpAppend:
+			tl, l = append(tl, op.values...), l.Append(op.values...)
+		case listOpInsert:
+			idx := at(op.pos)
+			tl, l = tl.Insert(idx, op.values...), l.Insert(uint64(idx), op.values...)
+		case listOpRemove:
+			start, end := at(op.pos), at(op.end)
+			if start > end {
+				start, end = end, start
+			}
+			tl, l = tl.Remove(start, end), l.Remove(uint64(start), uint64(end))
+		case listOpSet:
+			if len(tl) > 0 {
+				idx := at(op.pos)
+				tl, l = tl.Set(idx, op.values[0]), l.Set(uint64(idx), op.values[0])
+			}
+		case listOpReload:
+			l = vs.ReadValue(vs.WriteValue(l).TargetHash()).(List)
+		}
+	}
+
+	expected := tl.toList()
+	return assert.Equal(expected.Len(), l.Len()) &&
+		assert.Equal(expected.Hash(), l.Hash(), "after %v", ops)
+}
+
+func TestListChunkingIsIndependentOfEdits(t *testing.T) {
+	assert := assert.New(t)
+
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	r := rand.New(rand.NewSource(0))
+	initial := newTestList(1000)
+	err := quick.Check(func(ops listOps) bool {
+		return ops.apply(assert, initial)
+	}, &quick.Config{MaxCount: 100, Rand: r})
+	assert.NoError(err)
+}
+
+// mapOps is a random sequence of Set (value non-nil) and Remove (value nil) edits to a map.
+type mapOps []mapEdit
+
+func (mapOps) Generate(r *rand.Rand, size int) reflect.Value {
+	ops := make(mapOps, r.Intn(size*10)+1)
+	for i := range ops {
+		ops[i].key = Number(r.Intn(2000))
+		if r.Intn(4) != 0 {
+			// Mostly the same value, so that there are long runs.
+			ops[i].value = Number(r.Intn(3))
+		}
+	}
+	return reflect.ValueOf(ops)
+}
+
+func TestMapChunkingIsIndependentOfEdits(t *testing.T) {
+	assert := assert.New(t)
+
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	kvs := []Value{}
+	for i := 0; i < 2000; i += 3 {
+		kvs = append(kvs, Number(i), Number(0))
+	}
+	initial := NewMap(kvs...)
+
+	r := rand.New(rand.NewSource(0))
+	err := quick.Check(func(ops mapOps) bool {
+		vs := NewTestValueStore()
+		model := map[Number]Value{}
+		initial.IterAll(func(k, v Value) {
+			model[k.(Number)] = v
+		})
+
+		m, me := initial, NewMapEditor(initial)
+		for i, op := range ops {
+			if op.value == nil {
+				delete(model, op.key.(Number))
+				m = m.Remove(op.key)
+				me.Remove(op.key)
+			} else {
+				model[op.key.(Number)] = op.value
+				m = m.Set(op.key, op.value)
+				me.Set(op.key, op.value)
+			}
+			if i%50 == 49 {
+				m = vs.ReadValue(vs.WriteValue(m).TargetHash()).(Map)
+			}
+		}
+
+		kvs := []Value{}
+		for k, v := range model {
+			kvs = append(kvs, k, v)
+		}
+		expected := NewMap(kvs...)
+		return assert.Equal(expected.Len(), m.Len()) &&
+			assert.Equal(expected.Hash(), m.Hash()) &&
+			assert.Equal(expected.Hash(), me.Map().Hash())
+	}, &quick.Config{MaxCount: 50, Rand: r})
+	assert.NoError(err)
+}
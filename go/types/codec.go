@@ -5,7 +5,9 @@
 package types
 
 import (
+	"bytes"
 	"encoding/binary"
+	"fmt"
 
 	"github.com/attic-labs/noms/go/chunks"
 	"github.com/attic-labs/noms/go/d"
@@ -15,11 +17,16 @@ import (
 const initialBufferSize = 2048
 
 func EncodeValue(v Value, vw ValueWriter) chunks.Chunk {
-	w := newBinaryNomsWriter()
+	w := getBinaryNomsWriter()
 	enc := newValueEncoder(w, vw, false)
 	enc.writeValue(v)
 
-	c := chunks.NewChunk(w.data())
+	// The Chunk outlives w, so it needs its own copy of the data.
+	data := make([]byte, w.offset)
+	copy(data, w.data())
+	putBinaryNomsWriter(w)
+
+	c := chunks.NewChunk(data)
 	if cacher, ok := v.(hashCacher); ok {
 		assignHash(cacher, c.Hash())
 	}
@@ -43,6 +50,25 @@ func decodeFromBytesWithValidation(data []byte, vr ValueReader) Value {
 	return v
 }
 
+// VerifyEncoding checks that c is the canonical encoding of the value it
+// holds: that it decodes, with the fields of each struct encoded inline in
+// the order NewStruct gives them, and that encoding that value again gives
+// exactly the bytes of c. The hash of a chunk which isn't canonical may not
+// survive the value being written again, e.g. by a later version of this
+// package, so hashes which are stored elsewhere would no longer be found.
+func VerifyEncoding(c chunks.Chunk) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("Chunk %s doesn't decode: %v", c.Hash(), r)
+		}
+	}()
+	v := decodeFromBytesWithValidation(c.Data(), nil)
+	if !bytes.Equal(EncodeValue(v, nil).Data(), c.Data()) {
+		return fmt.Errorf("Chunk %s isn't encoded the way its %s value is now", c.Hash(), v.Kind())
+	}
+	return nil
+}
+
 // DecodeValue decodes a value from a chunk source. It is an error to provide an empty chunk.
 func DecodeValue(c chunks.Chunk, vr ValueReader) Value {
 	d.PanicIfTrue(c.IsEmpty())
@@ -62,6 +88,7 @@ type nomsReader interface {
 	readNumber() Number
 	readBool() bool
 	readString() string
+	readStringBytes() []byte
 	readHash() hash.Hash
 }
 
@@ -119,9 +146,15 @@ func (b *binaryNomsReader) readBool() bool {
 }
 
 func (b *binaryNomsReader) readString() string {
+	return string(b.readStringBytes())
+}
+
+// readStringBytes is like readString, but returns a slice of the underlying
+// buffer rather than copying it.
+func (b *binaryNomsReader) readStringBytes() []byte {
 	size := uint32(b.readCount())
 
-	v := string(b.buff[b.offset : b.offset+size])
+	v := b.buff[b.offset : b.offset+size]
 	b.offset += size
 	return v
 }
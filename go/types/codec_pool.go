@@ -0,0 +1,76 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"runtime"
+	"sync"
+)
+
+// codecWorkers returns how many goroutines a ValueStore uses to decode a
+// batch of chunks, and a sequenceChunker uses to encode the chunks it writes.
+// Encoding and decoding are CPU bound, so there's no point in having more
+// than can run at once.
+func codecWorkers() int {
+	return runtime.GOMAXPROCS(0)
+}
+
+// codecPool runs the work of encoding or decoding a batch of chunks on up to
+// codecWorkers() goroutines. A panic in any of them, e.g. because a chunk
+// can't be decoded, is re-raised by wait, in the caller's goroutine, so that
+// it can be recovered there as it would be if the work were done serially.
+type codecPool struct {
+	work  chan func()
+	wg    sync.WaitGroup
+	mu    sync.Mutex
+	panic interface{}
+}
+
+func newCodecPool(n int) *codecPool {
+	if workers := codecWorkers(); n > workers || n <= 0 {
+		n = workers
+	}
+	p := &codecPool{work: make(chan func(), n)}
+	p.wg.Add(n)
+	for i := 0; i < n; i++ {
+		go p.run()
+	}
+	return p
+}
+
+func (p *codecPool) run() {
+	defer p.wg.Done()
+	for f := range p.work {
+		p.do(f)
+	}
+}
+
+func (p *codecPool) do(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if p.panic == nil {
+				p.panic = r
+			}
+		}
+	}()
+	f()
+}
+
+// submit schedules f, blocking while all the workers are busy.
+func (p *codecPool) submit(f func()) {
+	p.work <- f
+}
+
+// wait returns once everything submitted has been done, panicking with the
+// first panic of any of it. Nothing may be submitted afterwards.
+func (p *codecPool) wait() {
+	close(p.work)
+	p.wg.Wait()
+	if p.panic != nil {
+		panic(p.panic)
+	}
+}
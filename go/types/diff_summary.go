@@ -0,0 +1,242 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+// DiffCounts are the numbers of elements added, removed and modified between
+// two values of the same Kind: the entries of a Map, the values of a Set or
+// List, or the fields of a Struct. Any other pair of differing values counts
+// as one removal and one addition. Kind is the Kind of the values compared, or
+// UnionKind if they're of different kinds.
+type DiffCounts struct {
+	Kind                    NomsKind
+	Adds, Removes, Modifies uint64
+	OldSize, NewSize        uint64
+}
+
+func (dc *DiffCounts) add(other DiffCounts) {
+	dc.Adds += other.Adds
+	dc.Removes += other.Removes
+	dc.Modifies += other.Modifies
+}
+
+// DiffSummary returns the DiffCounts between last and current, keyed by the
+// path of the values they were computed for, where "" is the root. Structs are
+// descended into: each field that's in both last and current, differs, and is
+// a collection or struct in both has its own DiffCounts, keyed by e.g.
+// ".rows". Unlike Diff, individual changes are never materialized. Chunks with
+// the same hash are skipped, and chunks only in last or only in current are
+// counted by the number of leaves recorded in their parents, without being
+// read. The time taken is therefore proportional to the size of the change,
+// not of the values.
+func DiffSummary(last, current Value) map[string]DiffCounts {
+	summary := map[string]DiffCounts{}
+	diffSummary(summary, "", last, current)
+	return summary
+}
+
+func diffSummary(summary map[string]DiffCounts, path string, last, current Value) {
+	if last.Equals(current) {
+		return
+	}
+
+	dc := DiffCounts{Kind: last.Kind()}
+	switch {
+	case last.Kind() != current.Kind():
+		dc = DiffCounts{Kind: UnionKind, Adds: 1, Removes: 1, OldSize: 1, NewSize: 1}
+	case last.Kind() == MapKind:
+		l, c := last.(Map), current.(Map)
+		dc.OldSize, dc.NewSize = l.Len(), c.Len()
+		dc.add(orderedSequenceDiffCounts(l.seq, c.seq))
+	case last.Kind() == SetKind:
+		l, c := last.(Set), current.(Set)
+		dc.OldSize, dc.NewSize = l.Len(), c.Len()
+		dc.add(orderedSequenceDiffCounts(l.seq, c.seq))
+	case last.Kind() == ListKind:
+		l, c := last.(List), current.(List)
+		dc.OldSize, dc.NewSize = l.Len(), c.Len()
+		dc.add(listDiffCounts(l, c))
+	case last.Kind() == StructKind:
+		l, c := last.(Struct), current.(Struct)
+		dc.OldSize, dc.NewSize = uint64(len(l.fieldNames)), uint64(len(c.fieldNames))
+		dc.add(structDiffCounts(summary, path, l, c))
+	default:
+		dc = DiffCounts{Kind: last.Kind(), Adds: 1, Removes: 1, OldSize: 1, NewSize: 1}
+	}
+	summary[path] = dc
+}
+
+func structDiffCounts(summary map[string]DiffCounts, path string, last, current Struct) (dc DiffCounts) {
+	current.IterFields(func(name string, cv Value) {
+		lv, ok := last.MaybeGet(name)
+		if !ok {
+			dc.Adds++
+			return
+		}
+		if lv.Equals(cv) {
+			return
+		}
+		dc.Modifies++
+		if lv.Kind() == cv.Kind() {
+			switch lv.Kind() {
+			case MapKind, SetKind, ListKind, StructKind:
+				diffSummary(summary, path+"."+name, lv, cv)
+			}
+		}
+	})
+	last.IterFields(func(name string, _ Value) {
+		if _, ok := current.MaybeGet(name); !ok {
+			dc.Removes++
+		}
+	})
+	return
+}
+
+func listDiffCounts(last, current List) (dc DiffCounts) {
+	splices := make(chan Splice)
+	go func() {
+		current.Diff(last, splices, nil)
+		close(splices)
+	}()
+	for sp := range splices {
+		if sp.SpRemoved == sp.SpAdded {
+			dc.Modifies += sp.SpRemoved
+		} else {
+			dc.Adds += sp.SpAdded
+			dc.Removes += sp.SpRemoved
+		}
+	}
+	return
+}
+
+// orderedSequenceDiffCounts counts the differences between last and current,
+// descending top-down into only the chunks that differ.
+func orderedSequenceDiffCounts(last, current orderedSequence) DiffCounts {
+	if last.numLeaves() == 0 || current.numLeaves() == 0 {
+		return DiffCounts{Adds: current.numLeaves(), Removes: last.numLeaves()}
+	}
+	lastHeight := newCursorAt(last, emptyKey, false, false, false).depth()
+	currentHeight := newCursorAt(current, emptyKey, false, false, false).depth()
+	return orderedSequenceDiffCountsInternal(last, current, lastHeight, currentHeight)
+}
+
+func orderedSequenceDiffCountsInternal(last, current orderedSequence, lastHeight, currentHeight int) DiffCounts {
+	if lastHeight > currentHeight {
+		lastChild := last.(metaSequence).getCompositeChildSequence(0, uint64(last.seqLen())).(orderedSequence)
+		return orderedSequenceDiffCountsInternal(lastChild, current, lastHeight-1, currentHeight)
+	}
+	if currentHeight > lastHeight {
+		currentChild := current.(metaSequence).getCompositeChildSequence(0, uint64(current.seqLen())).(orderedSequence)
+		return orderedSequenceDiffCountsInternal(last, currentChild, lastHeight, currentHeight-1)
+	}
+	if !isMetaSequence(last) {
+		return orderedLeafDiffCounts(last, current)
+	}
+
+	lastMeta, currentMeta := last.(metaSequence), current.(metaSequence)
+	lastLen, currentLen := lastMeta.seqLen(), currentMeta.seqLen()
+	isEqual := lastMeta.getCompareFn(currentMeta)
+
+	dc := DiffCounts{}
+	for i, j := 0, 0; i < lastLen || j < currentLen; {
+		if i < lastLen && j < currentLen && isEqual(i, j) {
+			i++
+			j++
+			continue
+		}
+
+		// Find the extent of the differing chunks. Each tuple's key is the
+		// greatest key in its chunk, so advancing whichever side has the lesser
+		// key keeps the two extents covering the same range of keys, up to the
+		// next pair of equal chunks.
+		i0, j0 := i, j
+		for i < lastLen || j < currentLen {
+			if i < lastLen && j < currentLen {
+				if isEqual(i, j) {
+					break
+				}
+				lastKey, currentKey := lastMeta.getKey(i), currentMeta.getKey(j)
+				if lastKey.Less(currentKey) {
+					i++
+				} else if currentKey.Less(lastKey) {
+					j++
+				} else {
+					i++
+					j++
+				}
+			} else if i < lastLen {
+				i++
+			} else {
+				j++
+			}
+		}
+
+		switch {
+		case i0 == i:
+			dc.Adds += numLeavesInRange(currentMeta, j0, j)
+		case j0 == j:
+			dc.Removes += numLeavesInRange(lastMeta, i0, i)
+		default:
+			// Chunks on one side which only have keys greater than any on the
+			// other, as when entries are appended, are added or removed whole.
+			lastEnd := trimChunksAfter(lastMeta, i0, i, currentMeta.getKey(j-1))
+			currentEnd := trimChunksAfter(currentMeta, j0, j, lastMeta.getKey(i-1))
+			dc.Removes += numLeavesInRange(lastMeta, lastEnd, i)
+			dc.Adds += numLeavesInRange(currentMeta, currentEnd, j)
+
+			lastChild := lastMeta.getCompositeChildSequence(uint64(i0), uint64(lastEnd-i0)).(orderedSequence)
+			currentChild := currentMeta.getCompositeChildSequence(uint64(j0), uint64(currentEnd-j0)).(orderedSequence)
+			dc.add(orderedSequenceDiffCountsInternal(lastChild, currentChild, lastHeight-1, currentHeight-1))
+		}
+	}
+	return dc
+}
+
+// trimChunksAfter returns the end of the chunks in ms[start:end] which may
+// have keys less than or equal to key. The first chunk is always included.
+func trimChunksAfter(ms metaSequence, start, end int, key orderedKey) int {
+	for i := start + 1; i < end; i++ {
+		if !ms.getKey(i - 1).Less(key) {
+			// Every key in chunk i is greater than the greatest in chunk i-1.
+			return i
+		}
+	}
+	return end
+}
+
+func numLeavesInRange(ms metaSequence, start, end int) (n uint64) {
+	for _, mt := range ms.tuples[start:end] {
+		n += mt.numLeaves
+	}
+	return
+}
+
+func orderedLeafDiffCounts(last, current orderedSequence) (dc DiffCounts) {
+	lastLen, currentLen := last.seqLen(), current.seqLen()
+	if lastLen == 0 || currentLen == 0 {
+		return DiffCounts{Adds: uint64(currentLen), Removes: uint64(lastLen)}
+	}
+
+	isEqual := last.getCompareFn(current)
+	i, j := 0, 0
+	for i < lastLen && j < currentLen {
+		lastKey, currentKey := last.getKey(i), current.getKey(j)
+		if lastKey.Less(currentKey) {
+			dc.Removes++
+			i++
+		} else if currentKey.Less(lastKey) {
+			dc.Adds++
+			j++
+		} else {
+			if !isEqual(i, j) {
+				dc.Modifies++
+			}
+			i++
+			j++
+		}
+	}
+	dc.Removes += uint64(lastLen - i)
+	dc.Adds += uint64(currentLen - j)
+	return
+}
@@ -0,0 +1,210 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/testify/assert"
+)
+
+// streamedDiffCounts counts the changes from Map.Diff, which DiffSummary must agree with.
+func streamedDiffCounts(last, current Map) (dc DiffCounts) {
+	changes := make(chan ValueChanged)
+	go func() {
+		current.Diff(last, changes, nil)
+		close(changes)
+	}()
+	for c := range changes {
+		switch c.ChangeType {
+		case DiffChangeAdded:
+			dc.Adds++
+		case DiffChangeRemoved:
+			dc.Removes++
+		case DiffChangeModified:
+			dc.Modifies++
+		}
+	}
+	return
+}
+
+func newNumberMap(entries map[int]Value) Map {
+	kvs := ValueSlice{}
+	for k, v := range entries {
+		kvs = append(kvs, Number(k), v)
+	}
+	return NewMap(kvs...)
+}
+
+func TestDiffSummaryMap(t *testing.T) {
+	assert := assert.New(t)
+
+	entries := map[int]Value{}
+	for i := 0; i < 20000; i++ {
+		entries[i*2] = Number(i)
+	}
+	last := newNumberMap(entries)
+
+	// A block of new keys beyond the end, scattered new keys, removals and modifications.
+	for i := 50000; i < 55000; i++ {
+		entries[i] = Number(i)
+	}
+	for i := 1; i < 4000; i += 400 {
+		entries[i] = Number(i)
+	}
+	for i := 10000; i < 12000; i += 2 {
+		delete(entries, i)
+	}
+	for i := 30000; i < 30100; i += 2 {
+		entries[i] = String("changed")
+	}
+	m := newNumberMap(entries)
+
+	summary := DiffSummary(last, m)
+	assert.Len(summary, 1)
+	dc := summary[""]
+	assert.Equal(MapKind, dc.Kind)
+	assert.Equal(uint64(5010), dc.Adds)
+	assert.Equal(uint64(1000), dc.Removes)
+	assert.Equal(uint64(50), dc.Modifies)
+	assert.Equal(last.Len(), dc.OldSize)
+	assert.Equal(m.Len(), dc.NewSize)
+
+	expected := streamedDiffCounts(last, m)
+	assert.Equal(expected.Adds, dc.Adds)
+	assert.Equal(expected.Removes, dc.Removes)
+	assert.Equal(expected.Modifies, dc.Modifies)
+
+	reverse := DiffSummary(m, last)[""]
+	assert.Equal(dc.Adds, reverse.Removes)
+	assert.Equal(dc.Removes, reverse.Adds)
+
+	assert.Empty(DiffSummary(m, m))
+	assert.Equal(m.Len(), DiffSummary(NewMap(), m)[""].Adds)
+}
+
+func TestDiffSummaryMapRandomEdits(t *testing.T) {
+	assert := assert.New(t)
+	r := rand.New(rand.NewSource(0))
+
+	for n := 0; n < 10; n++ {
+		entries := map[int]Value{}
+		for i := 0; i < 10000; i++ {
+			entries[r.Intn(40000)] = Number(i)
+		}
+		last := newNumberMap(entries)
+
+		// Edits of random keys, and runs of keys, which may add or remove whole chunks.
+		for i := 0; i < r.Intn(200); i++ {
+			start := r.Intn(40000)
+			run := 1
+			if r.Intn(10) == 0 {
+				run = r.Intn(3000)
+			}
+			op := r.Intn(3)
+			for k := start; k < start+run; k++ {
+				switch op {
+				case 0:
+					delete(entries, k)
+				case 1:
+					entries[k] = Number(k)
+				case 2:
+					if _, ok := entries[k]; ok {
+						entries[k] = String("changed")
+					}
+				}
+			}
+		}
+		current := newNumberMap(entries)
+
+		expected := streamedDiffCounts(last, current)
+		dc := DiffSummary(last, current)[""]
+		assert.Equal(expected.Adds, dc.Adds)
+		assert.Equal(expected.Removes, dc.Removes)
+		assert.Equal(expected.Modifies, dc.Modifies)
+	}
+}
+
+func TestDiffSummaryReadsOnlyChangedChunks(t *testing.T) {
+	assert := assert.New(t)
+	cs := chunks.NewTestStore()
+	vs := newLocalValueStore(cs)
+
+	entries := map[int]Value{}
+	for i := 0; i < 20000; i++ {
+		entries[i] = Number(i)
+	}
+	last := newNumberMap(entries)
+	// Appending a large block of entries adds whole chunks, which are counted without being read.
+	for i := 20000; i < 40000; i++ {
+		entries[i] = Number(i)
+	}
+	current := newNumberMap(entries)
+	lastHash, currentHash := vs.WriteValue(last).TargetHash(), vs.WriteValue(current).TargetHash()
+	vs.Flush(lastHash)
+	vs.Flush(currentHash)
+
+	read := func() (Map, Map) {
+		vs := newLocalValueStore(cs)
+		lastMap, currentMap := vs.ReadValue(lastHash).(Map), vs.ReadValue(currentHash).(Map)
+		cs.Reads = 0
+		return lastMap, currentMap
+	}
+
+	dc := DiffSummary(read())[""]
+	assert.Equal(uint64(20000), dc.Adds)
+	assert.Equal(uint64(0), dc.Removes+dc.Modifies)
+	summaryReads := cs.Reads
+
+	streamedDiffCounts(read())
+	assert.True(summaryReads < cs.Reads/2, "DiffSummary read %d chunks, Diff read %d", summaryReads, cs.Reads)
+}
+
+func TestDiffSummarySetAndList(t *testing.T) {
+	assert := assert.New(t)
+
+	dc := DiffSummary(NewSet(Number(1), Number(2), Number(3)), NewSet(Number(2), Number(3), Number(4), Number(5)))[""]
+	assert.Equal(DiffCounts{Kind: SetKind, Adds: 2, Removes: 1, OldSize: 3, NewSize: 4}, dc)
+
+	dc = DiffSummary(NewList(Number(1), Number(2), Number(3), Number(4)), NewList(Number(1), Number(222), Number(4)))[""]
+	assert.Equal(DiffCounts{Kind: ListKind, Adds: 1, Removes: 2, OldSize: 4, NewSize: 3}, dc)
+
+	dc = DiffSummary(NewList(Number(1), Number(2)), NewList(Number(1), Number(3)))[""]
+	assert.Equal(DiffCounts{Kind: ListKind, Modifies: 1, OldSize: 2, NewSize: 2}, dc)
+}
+
+func TestDiffSummaryStruct(t *testing.T) {
+	assert := assert.New(t)
+
+	last := NewStruct("S", StructData{
+		"rows":  NewMap(Number(1), String("a"), Number(2), String("b")),
+		"inner": NewStruct("", StructData{"l": NewList(Number(1))}),
+		"name":  String("old"),
+		"gone":  Bool(true),
+	})
+	current := NewStruct("S", StructData{
+		"rows":  NewMap(Number(1), String("a"), Number(2), String("B"), Number(3), String("c")),
+		"inner": NewStruct("", StructData{"l": NewList(Number(1), Number(2))}),
+		"name":  String("new"),
+		"new":   Bool(true),
+	})
+
+	summary := DiffSummary(last, current)
+	assert.Equal(map[string]DiffCounts{
+		"":         {Kind: StructKind, Adds: 1, Removes: 1, Modifies: 3, OldSize: 4, NewSize: 4},
+		".rows":    {Kind: MapKind, Adds: 1, Modifies: 1, OldSize: 2, NewSize: 3},
+		".inner":   {Kind: StructKind, Modifies: 1, OldSize: 1, NewSize: 1},
+		".inner.l": {Kind: ListKind, Adds: 1, OldSize: 1, NewSize: 2},
+	}, summary)
+
+	assert.Equal(map[string]DiffCounts{
+		"": {Kind: UnionKind, Adds: 1, Removes: 1, OldSize: 1, NewSize: 1},
+	}, DiffSummary(String("a"), Number(1)))
+	assert.Equal(map[string]DiffCounts{
+		"": {Kind: StringKind, Adds: 1, Removes: 1, OldSize: 1, NewSize: 1},
+	}, DiffSummary(String("a"), String("b")))
+}
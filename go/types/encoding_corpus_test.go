@@ -0,0 +1,154 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"sort"
+	"testing"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/hash"
+	"github.com/attic-labs/testify/assert"
+)
+
+var updateCorpus = flag.Bool("corpus.update", false, "rewrite testdata/encoding_corpus.json with the encodings the corpus test gets, rather than checking them")
+
+const corpusFile = "testdata/encoding_corpus.json"
+
+// corpusValues are values of each kind, whose encodings are expected to be
+// those in corpusFile. Of a chunked collection, it's the encoding of the root
+// chunk.
+func corpusValues() map[string]Value {
+	numbers := make([]Value, 10000)
+	for i := range numbers {
+		numbers[i] = Number(i)
+	}
+	person := NewStruct("Person", StructData{
+		"name": String("Ann"),
+		"age":  Number(42),
+		"tags": NewSet(String("a"), String("b")),
+	})
+	return map[string]Value{
+		"bool":          Bool(true),
+		"number":        Number(42),
+		"number-frac":   Number(-1.5),
+		"number-large":  Number(1e300),
+		"string":        String("hello, 世界"),
+		"struct-empty":  NewStruct("", nil),
+		"struct":        person,
+		"struct-nested": NewStruct("Team", StructData{"lead": person, "size": Number(3)}),
+		"list":          NewList(Number(1), String("two"), Bool(false)),
+		"list-chunked":  NewList(numbers...),
+		"set":           NewSet(Number(1), String("two"), person),
+		"map":           NewMap(String("a"), Number(1), Number(2), NewList(Bool(true))),
+		"blob":          NewBlob(bytes.NewBufferString("blob")),
+		"ref":           NewRef(person),
+		"type": MakeStructType("T",
+			StructField{"a", NumberType, false},
+			StructField{"b", MakeUnionType(StringType, MakeCycleType("T")), true},
+		),
+	}
+}
+
+// TestEncodingCorpus checks that each of corpusValues is encoded as it is in
+// corpusFile, that its encoding there decodes to the same value, and that the
+// encoding is canonical. A change to the encoding which changes them would
+// change the hashes of stored values, so it fails this test, and must be made
+// deliberately, by running it with -corpus.update and committing the new
+// corpusFile.
+func TestEncodingCorpus(t *testing.T) {
+	assert := assert.New(t)
+
+	values := corpusValues()
+	got := map[string]string{}
+	for name, v := range values {
+		got[name] = hex.EncodeToString(EncodeValue(v, nil).Data())
+	}
+
+	if *updateCorpus {
+		data, err := json.MarshalIndent(got, "", "  ")
+		assert.NoError(err)
+		assert.NoError(ioutil.WriteFile(corpusFile, append(data, '\n'), 0644))
+		return
+	}
+
+	data, err := ioutil.ReadFile(corpusFile)
+	if !assert.NoError(err) {
+		return
+	}
+	expected := map[string]string{}
+	assert.NoError(json.Unmarshal(data, &expected))
+
+	names := []string{}
+	for name := range expected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		assert.Equal(expected[name], got[name], "the encoding of %s changed; if that's intended, run go test -corpus.update", name)
+
+		b, err := hex.DecodeString(expected[name])
+		if !assert.NoError(err, name) {
+			continue
+		}
+		c := chunks.NewChunk(b)
+		assert.NoError(VerifyEncoding(c), name)
+		if v, ok := values[name]; ok {
+			assert.True(v.Equals(DecodeValue(c, nil)), "%s decodes to a different value", name)
+		}
+	}
+	assert.Equal(len(expected), len(got), "%s has the encodings of values which no longer exist", corpusFile)
+}
+
+// encodeUnsortedStruct encodes a struct named name with fields in the order given.
+func encodeUnsortedStruct(name string, fields ...interface{}) chunks.Chunk {
+	s := Struct{name: name, h: &hash.Hash{}}
+	for i := 0; i < len(fields); i += 2 {
+		s.fieldNames = append(s.fieldNames, fields[i].(string))
+		s.values = append(s.values, fields[i+1].(Value))
+	}
+	return EncodeValue(s, nil)
+}
+
+func TestVerifyEncoding(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.NoError(VerifyEncoding(EncodeValue(NewStruct("S", StructData{"a": Number(1), "b": Number(2)}), nil)))
+
+	unsorted := encodeUnsortedStruct("S", "b", Number(2), "a", Number(1))
+	assert.Error(VerifyEncoding(unsorted))
+	dup := encodeUnsortedStruct("S", "a", Number(2), "a", Number(1))
+	assert.Error(VerifyEncoding(dup))
+	nested := EncodeValue(NewList(DecodeValue(unsorted, nil)), nil)
+	assert.Error(VerifyEncoding(nested))
+
+	// 4 * 2^-1 isn't how 2 is encoded, so it isn't encoded the same again.
+	assert.Error(VerifyEncoding(chunks.NewChunk([]byte{uint8(NumberKind), 8, 1})))
+
+	assert.Error(VerifyEncoding(chunks.NewChunk([]byte{uint8(StringKind), 10, 'a'})))
+}
+
+func TestNormalizeStruct(t *testing.T) {
+	assert := assert.New(t)
+
+	expected := NewStruct("S", StructData{"a": Number(1), "b": Number(2)})
+	s := DecodeValue(encodeUnsortedStruct("S", "b", Number(2), "a", Number(1)), nil).(Struct)
+	assert.False(expected.Equals(s))
+
+	n := NormalizeStruct(s)
+	assert.True(expected.Equals(n))
+	assert.Equal(Number(1), n.Get("a"))
+	assert.NoError(VerifyEncoding(EncodeValue(n, nil)))
+	assert.True(expected.Equals(NormalizeStruct(expected)))
+
+	assert.Panics(func() {
+		NormalizeStruct(DecodeValue(encodeUnsortedStruct("S", "a", Number(2), "a", Number(1)), nil).(Struct))
+	})
+}
@@ -0,0 +1,67 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import "sync"
+
+// flushGroup coalesces concurrent flushes of a BatchStore. A goroutine which
+// calls do while a flush is running waits for it to finish, then runs one
+// more flush on behalf of every goroutine which arrived in the meantime,
+// since the running flush may have started before their chunks were put.
+// So however many goroutines flush at once, each waits for at most two
+// flushes, and the BatchStore is flushed by one goroutine at a time.
+type flushGroup struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	running bool
+	next    *flushCall
+}
+
+// flushCall is a flush which one or more goroutines are waiting for.
+type flushCall struct {
+	done bool
+	// panicked is what the flush panicked with, if it did. It's re-panicked
+	// in each waiting goroutine.
+	panicked interface{}
+}
+
+func newFlushGroup() *flushGroup {
+	g := &flushGroup{}
+	g.cond = sync.NewCond(&g.mu)
+	return g
+}
+
+// do returns once flush has run to completion, having started after do was
+// called.
+func (g *flushGroup) do(flush func()) {
+	g.mu.Lock()
+	if g.next == nil {
+		g.next = &flushCall{}
+	}
+	call := g.next
+	for g.running && !call.done {
+		g.cond.Wait()
+	}
+	if !call.done {
+		g.next, g.running = nil, true
+		g.mu.Unlock()
+		panicked := runFlush(flush)
+		g.mu.Lock()
+		call.done, call.panicked, g.running = true, panicked, false
+		g.cond.Broadcast()
+	}
+	g.mu.Unlock()
+	if call.panicked != nil {
+		panic(call.panicked)
+	}
+}
+
+func runFlush(flush func()) (panicked interface{}) {
+	defer func() {
+		panicked = recover()
+	}()
+	flush()
+	return
+}
@@ -0,0 +1,45 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestFlushGroup(t *testing.T) {
+	assert := assert.New(t)
+	g := newFlushGroup()
+
+	var flushes, running int32
+	flush := func() {
+		assert.Equal(int32(1), atomic.AddInt32(&running, 1), "flushes shouldn't overlap")
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&flushes, 1)
+		atomic.AddInt32(&running, -1)
+	}
+
+	const callers = 50
+	wg := sync.WaitGroup{}
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			before := atomic.LoadInt32(&flushes)
+			g.do(flush)
+			// A whole flush must have run since do was called.
+			assert.True(atomic.LoadInt32(&flushes) > before)
+		}()
+	}
+	wg.Wait()
+	assert.True(flushes < callers/2, "%d flushes", flushes)
+
+	assert.Panics(func() { g.do(func() { panic("failed") }) })
+	g.do(flush)
+}
@@ -16,7 +16,16 @@ func getHash(v Value) hash.Hash {
 }
 
 func getHashNoOverride(v Value) hash.Hash {
-	return EncodeValue(v, nil).Hash()
+	w := getBinaryNomsWriter()
+	defer putBinaryNomsWriter(w)
+	return encodedHash(v, w)
+}
+
+// encodedHash returns the hash of v's encoding, which it writes to w, after resetting it. Unlike EncodeValue, it doesn't copy the encoding out of w.
+func encodedHash(v Value, w *binaryNomsWriter) hash.Hash {
+	w.reset()
+	newValueEncoder(w, nil, false).writeValue(v)
+	return hash.Of(w.data())
 }
 
 func EnsureHash(h *hash.Hash, v Value) hash.Hash {
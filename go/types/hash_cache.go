@@ -0,0 +1,41 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"github.com/attic-labs/noms/go/hash"
+	"github.com/attic-labs/noms/go/util/sizecache"
+)
+
+const hashCacheShards = 16
+
+// hashCache is a SizeCache keyed by hash, split into shards by the first
+// byte of the hash, so that goroutines sharing a ValueStore seldom contend
+// for the same lock. Each shard holds up to an equal part of the total size.
+type hashCache [hashCacheShards]*sizecache.SizeCache
+
+func newHashCache(maxSize uint64) *hashCache {
+	hc := &hashCache{}
+	for i := range hc {
+		hc[i] = sizecache.New(maxSize / hashCacheShards)
+	}
+	return hc
+}
+
+func (hc *hashCache) shard(h hash.Hash) *sizecache.SizeCache {
+	return hc[h[0]%hashCacheShards]
+}
+
+func (hc *hashCache) Get(h hash.Hash) (interface{}, bool) {
+	return hc.shard(h).Get(h)
+}
+
+func (hc *hashCache) Add(h hash.Hash, size uint64, value interface{}) {
+	hc.shard(h).Add(h, size, value)
+}
+
+func (hc *hashCache) Drop(h hash.Hash) {
+	hc.shard(h).Drop(h)
+}
@@ -0,0 +1,80 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/attic-labs/testify/assert"
+)
+
+// assertIndexedInvariants checks that every metaTuple reachable from seq has a key equal to its numLeaves, and that numLeaves is the number of leaves of its child. Returns the number of leaves in seq.
+func assertIndexedInvariants(assert *assert.Assertions, seq sequence) uint64 {
+	ms, ok := seq.(metaSequence)
+	if !ok {
+		return uint64(seq.seqLen())
+	}
+	sum := uint64(0)
+	for i, mt := range ms.tuples {
+		assert.True(mt.key.isOrderedByValue)
+		assert.True(Number(mt.numLeaves).Equals(mt.key.v))
+		assert.Equal(mt.numLeaves, assertIndexedInvariants(assert, ms.getChildSequence(i)))
+		sum += mt.numLeaves
+	}
+	assert.Equal(sum, ms.numLeaves())
+	return sum
+}
+
+func TestIndexedSequenceNumLeavesRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	vs := NewTestValueStore()
+	reload := func(v Value) Value {
+		return vs.ReadValue(vs.WriteValue(v).TargetHash())
+	}
+
+	tl := newTestList(5000)
+	l := tl.toList()
+	assert.True(isMetaSequence(l.seq))
+	assert.Equal(uint64(5000), assertIndexedInvariants(assert, l.seq))
+
+	l = reload(l).(List)
+	assert.Equal(uint64(5000), l.Len())
+	assert.Equal(l.Len(), assertIndexedInvariants(assert, l.seq))
+
+	l = reload(l.Splice(1000, 2500, tl[:10]...)).(List)
+	assert.Equal(uint64(2510), l.Len())
+	assert.Equal(l.Len(), assertIndexedInvariants(assert, l.seq))
+	assert.True(tl[999].Equals(l.Get(999)))
+	assert.True(tl[0].Equals(l.Get(1000)))
+	assert.True(tl[3500].Equals(l.Get(1010)))
+
+	buff := make([]byte, 100000)
+	rand.New(rand.NewSource(0)).Read(buff)
+	b := NewBlob(bytes.NewReader(buff))
+	assert.True(isMetaSequence(b.seq))
+	b = reload(b).(Blob)
+	assert.Equal(uint64(100000), b.Len())
+	assert.Equal(b.Len(), assertIndexedInvariants(assert, b.seq))
+}
+
+func TestIndexedSequenceDecodeRejectsBadNumLeaves(t *testing.T) {
+	assert := assert.New(t)
+
+	leaf := NewList(Number(1), Number(2), Number(3))
+	bad := newList(newListMetaSequence([]metaTuple{
+		newMetaTuple(NewRef(leaf), orderedKeyFromUint64(3), 4, nil),
+	}, nil))
+
+	c := EncodeValue(bad, nil)
+	assert.Panics(func() {
+		DecodeValue(c, nil)
+	})
+}
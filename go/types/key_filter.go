@@ -0,0 +1,145 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"math"
+	"sync"
+
+	"github.com/attic-labs/noms/go/d"
+)
+
+var keyFilterMagic = []byte("NOMSKF1")
+
+// ErrNotKeyFilter is returned by KeyFilterFromBlob if the Blob isn't an encoded KeyFilter.
+var ErrNotKeyFilter = errors.New("Blob isn't a KeyFilter")
+
+// KeyFilter is a Bloom filter of the keys of a Map, or the values of a Set.
+// MaybeHas is false for a key which was never added, and true for one which
+// was, and sometimes also for one which wasn't, at about the false positive
+// rate the filter was made with. A Map or Set with a KeyFilter, from
+// WithKeyFilter, answers Has for most absent keys without reading a chunk,
+// which saves a round trip per lookup on a remote database.
+//
+// Filters aren't part of the collections they describe, so they don't change
+// their encoding or hashes. Store one as a Blob alongside its collection. A
+// KeyFilter is safe for concurrent use.
+type KeyFilter struct {
+	mu   sync.RWMutex
+	bits []uint64
+	k    uint32
+}
+
+// NewKeyFilter makes a KeyFilter of the keys of c, a Map or Set, reading all
+// of them once, sized for falsePositiveRate and twice as many keys as c has,
+// to leave room for keys added later.
+func NewKeyFilter(c Collection, falsePositiveRate float64) *KeyFilter {
+	d.PanicIfFalse(falsePositiveRate > 0 && falsePositiveRate < 1)
+	f := newKeyFilter(2*c.Len(), falsePositiveRate)
+	switch c := c.(type) {
+	case Map:
+		c.IterAll(func(k, v Value) {
+			f.Add(k)
+		})
+	case Set:
+		c.IterAll(func(v Value) {
+			f.Add(v)
+		})
+	default:
+		d.Panic("Expected a Map or Set, found a %s", c.Kind())
+	}
+	return f
+}
+
+func newKeyFilter(n uint64, falsePositiveRate float64) *KeyFilter {
+	if n == 0 {
+		n = 1
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint32(math.Ceil(float64(m) / float64(n) * math.Ln2))
+	return &KeyFilter{bits: make([]uint64, (m+63)/64), k: k}
+}
+
+// positions calls cb with the k bits of key v, by double hashing its hash.
+func (f *KeyFilter) positions(v Value, cb func(word int, bit uint64)) {
+	h := v.Hash()
+	h1 := binary.BigEndian.Uint64(h[0:8])
+	h2 := binary.BigEndian.Uint64(h[8:16]) | 1
+	m := uint64(len(f.bits)) * 64
+	for i := uint32(0); i < f.k; i++ {
+		p := (h1 + uint64(i)*h2) % m
+		cb(int(p/64), 1<<(p%64))
+	}
+}
+
+// Add adds v to f. Since filters only give false positives, never false
+// negatives, adding to a filter that's shared by older versions of a
+// collection keeps it valid for all of them.
+func (f *KeyFilter) Add(v Value) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.positions(v, func(word int, bit uint64) {
+		f.bits[word] |= bit
+	})
+}
+
+// MaybeHas returns false if v was never added to f.
+func (f *KeyFilter) MaybeHas(v Value) (has bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	has = true
+	f.positions(v, func(word int, bit uint64) {
+		has = has && f.bits[word]&bit != 0
+	})
+	return
+}
+
+/*
+  Blob encoding:
+    Magic // keyFilterMagic
+    K     // uint32: the number of bits per key
+    Bits  // uint64s, each big-endian
+*/
+
+// Blob encodes f as a Blob.
+func (f *KeyFilter) Blob() Blob {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	buf := &bytes.Buffer{}
+	buf.Write(keyFilterMagic)
+	binary.Write(buf, binary.BigEndian, f.k)
+	binary.Write(buf, binary.BigEndian, f.bits)
+	return NewBlob(buf)
+}
+
+// KeyFilterFromBlob decodes a KeyFilter encoded by KeyFilter.Blob.
+func KeyFilterFromBlob(b Blob) (*KeyFilter, error) {
+	data, err := ioutil.ReadAll(b.Reader())
+	if err != nil {
+		return nil, err
+	}
+	header := len(keyFilterMagic) + 4
+	if len(data) < header || !bytes.Equal(data[:len(keyFilterMagic)], keyFilterMagic) || (len(data)-header)%8 != 0 || len(data) == header {
+		return nil, ErrNotKeyFilter
+	}
+	f := &KeyFilter{k: binary.BigEndian.Uint32(data[len(keyFilterMagic):header])}
+	f.bits = make([]uint64, (len(data)-header)/8)
+	for i := range f.bits {
+		f.bits[i] = binary.BigEndian.Uint64(data[header+8*i:])
+	}
+	return f, nil
+}
+
+// keyFilterHas is false if f isn't nil and says v isn't in it.
+func keyFilterHas(f *KeyFilter, v Value) bool {
+	return f == nil || f.MaybeHas(v)
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestKeyFilter(t *testing.T) {
+	assert := assert.New(t)
+
+	kvs := ValueSlice{}
+	for i := 0; i < 1000; i++ {
+		kvs = append(kvs, Number(2*i), String("v"))
+	}
+	m := NewMap(kvs...)
+	f := NewKeyFilter(m, 0.01)
+
+	for i := 0; i < 1000; i++ {
+		assert.True(f.MaybeHas(Number(2 * i)))
+	}
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if f.MaybeHas(Number(2*i + 1)) {
+			falsePositives++
+		}
+	}
+	assert.True(falsePositives < 200, "%d false positives", falsePositives)
+
+	f2, err := KeyFilterFromBlob(f.Blob())
+	assert.NoError(err)
+	for i := 0; i < 1000; i++ {
+		assert.Equal(f.MaybeHas(Number(i)), f2.MaybeHas(Number(i)))
+	}
+
+	_, err = KeyFilterFromBlob(NewBlob(bytes.NewBufferString("not a filter")))
+	assert.Equal(ErrNotKeyFilter, err)
+}
+
+func TestMapWithKeyFilter(t *testing.T) {
+	assert := assert.New(t)
+
+	m := NewMap(Number(1), String("a"), Number(2), String("b"))
+	m = m.WithKeyFilter(NewKeyFilter(m, 0.01))
+	assert.True(m.Has(Number(1)))
+	assert.Equal(String("b"), m.Get(Number(2)))
+	assert.False(m.Has(Number(3)))
+
+	// Keys added by Set are added to the filter, and removed keys stay out of the Map.
+	m2 := m.Set(Number(3), String("c")).Remove(Number(1))
+	assert.True(m2.Has(Number(3)))
+	assert.Equal(String("c"), m2.Get(Number(3)))
+	assert.False(m2.Has(Number(1)))
+	assert.True(m2.Equals(NewMap(Number(2), String("b"), Number(3), String("c"))))
+
+	// So are keys set by a MapEditor.
+	m3 := NewMapEditor(m2).Set(Number(4), String("d")).Remove(Number(2)).Map()
+	assert.True(m3.Has(Number(4)))
+	assert.Equal(String("d"), m3.Get(Number(4)))
+	assert.False(m3.Has(Number(2)))
+	assert.True(m3.Equals(NewMap(Number(3), String("c"), Number(4), String("d"))))
+
+	s := NewSet(Number(1), Number(2))
+	s = s.WithKeyFilter(NewKeyFilter(s, 0.01)).Insert(Number(3)).Remove(Number(1))
+	assert.True(s.Has(Number(3)))
+	assert.False(s.Has(Number(1)))
+}
+
+func TestMapWithKeyFilterSkipsReads(t *testing.T) {
+	assert := assert.New(t)
+	cs := chunks.NewTestStore()
+	vs := newLocalValueStore(cs)
+
+	entries := map[int]Value{}
+	for i := 0; i < 20000; i++ {
+		entries[2*i] = Number(i)
+	}
+	h := vs.WriteValue(newNumberMap(entries)).TargetHash()
+	vs.Flush(h)
+
+	vs = newLocalValueStore(cs)
+	m := vs.ReadValue(h).(Map)
+	f := NewKeyFilter(m, 0.001)
+
+	read := func(m Map) int {
+		vs := newLocalValueStore(cs)
+		m = vs.ReadValue(h).(Map).WithKeyFilter(m.filter)
+		cs.Reads = 0
+		for i := 0; i < 100; i++ {
+			m.Has(Number(2*i*97 + 1))
+		}
+		return cs.Reads
+	}
+	withoutFilter := read(m)
+	withFilter := read(m.WithKeyFilter(f))
+	assert.True(withoutFilter > 0)
+	assert.True(withFilter < withoutFilter/10, "with filter: %d reads, without: %d", withFilter, withoutFilter)
+}
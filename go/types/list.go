@@ -5,6 +5,8 @@
 package types
 
 import (
+	"sync"
+
 	"github.com/attic-labs/noms/go/d"
 	"github.com/attic-labs/noms/go/hash"
 )
@@ -21,10 +23,21 @@ import (
 type List struct {
 	seq sequence
 	h   *hash.Hash
+	cur *listCursorCache
 }
 
 func newList(seq sequence) List {
-	return List{seq, &hash.Hash{}}
+	return List{seq, &hash.Hash{}, nil}
+}
+
+// WithCursorCache returns a copy of l whose Get remembers the position it
+// last descended to, so that Gets of indexes in the same or an adjacent chunk,
+// as when iterating by index, don't descend from the root again. The cache is
+// shared by copies of the returned List, and is safe for concurrent use,
+// though concurrent Gets far apart will keep replacing it. Lists derived from
+// the returned List, e.g. by Append, don't have a cache.
+func (l List) WithCursorCache() List {
+	return List{l.seq, l.h, &listCursorCache{}}
 }
 
 // NewList creates a new List where the type is computed from the elements in the list, populated
@@ -113,10 +126,52 @@ func (l List) Kind() NomsKind {
 // descend into the prolly-tree which leads to Get being O(depth).
 func (l List) Get(idx uint64) Value {
 	d.PanicIfFalse(idx < l.Len())
+	if l.cur != nil {
+		return l.cur.get(l.seq, idx)
+	}
 	cur := newCursorAtIndex(l.seq, idx, false)
 	return cur.current().(Value)
 }
 
+// listCursorCache holds the cursor left by the last List.Get, see
+// List.WithCursorCache.
+type listCursorCache struct {
+	mu sync.Mutex
+	// cur is at index start+cur.idx of the list, or nil before the first Get.
+	cur   *sequenceCursor
+	start uint64
+}
+
+func (c *listCursorCache) get(seq sequence, idx uint64) Value {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cur != nil {
+		end := c.start + uint64(c.cur.length())
+		switch {
+		case idx >= c.start && idx < end:
+			c.cur.idx = int(idx - c.start)
+			return c.cur.current().(Value)
+		case idx == end:
+			// The first value of the next chunk.
+			c.cur.idx = c.cur.length() - 1
+			c.cur.advance()
+			c.start = end
+			return c.cur.current().(Value)
+		case idx+1 == c.start:
+			// The last value of the previous chunk.
+			c.cur.idx = 0
+			c.cur.retreat()
+			c.start -= uint64(c.cur.length())
+			return c.cur.current().(Value)
+		}
+	}
+
+	c.cur = newCursorAtIndex(seq, idx, false)
+	c.start = idx - uint64(c.cur.idx)
+	return c.cur.current().(Value)
+}
+
 type MapFunc func(v Value, index uint64) interface{}
 
 // Deprecated: This API may change in the future. Use IterAll or Iterator instead.
@@ -148,6 +203,15 @@ func (l List) Append(vs ...Value) List {
 	return l.Splice(l.Len(), 0, vs...)
 }
 
+// Prepend returns a new list where vs have been inserted at the start of the list. Only the first
+// chunk of each level of the existing list is rechunked: chunking resynchronizes with the
+// existing chunk boundaries shortly after the inserted values, and every chunk to the right of
+// that is reused. This makes Prepend as cheap as Append, e.g. for log-style lists with the most
+// recent entries first.
+func (l List) Prepend(vs ...Value) List {
+	return l.Splice(0, 0, vs...)
+}
+
 // Splice returns a new list where deleteCount values have been removed at idx and vs have been
 // inserted instead.
 // This function panics if idx or deleteCount is out of bounds.
@@ -274,7 +338,7 @@ func (l List) DiffWithLimit(last List, changes chan<- Splice, closeChan <-chan s
 }
 
 func (l List) newChunker(cur *sequenceCursor, vr ValueReader) *sequenceChunker {
-	return newSequenceChunker(cur, vr, nil, makeListLeafChunkFn(vr), newIndexedMetaSequenceChunkFn(ListKind, vr), hashValueBytes)
+	return newSequenceChunker(cur, ListKind, vr, nil, makeListLeafChunkFn(vr), newIndexedMetaSequenceChunkFn(ListKind, vr), hashValueBytes)
 }
 
 // If |sink| is not nil, chunks will be eagerly written as they're created. Otherwise they are
@@ -293,5 +357,5 @@ func makeListLeafChunkFn(vr ValueReader) makeChunkFn {
 }
 
 func newEmptyListSequenceChunker(vr ValueReader, vw ValueWriter) *sequenceChunker {
-	return newEmptySequenceChunker(vr, vw, makeListLeafChunkFn(vr), newIndexedMetaSequenceChunkFn(ListKind, vr), hashValueBytes)
+	return newEmptySequenceChunker(ListKind, vr, vw, makeListLeafChunkFn(vr), newIndexedMetaSequenceChunkFn(ListKind, vr), hashValueBytes)
 }
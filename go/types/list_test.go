@@ -193,6 +193,47 @@ func TestListRemove(t *testing.T) {
 	assert.True(tl.toList().Equals(list))
 }
 
+func TestListWithCursorCache(t *testing.T) {
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	assert := assert.New(t)
+
+	tl := newTestList(5000)
+	l := tl.toList()
+	cl := l.WithCursorCache()
+	assert.True(l.Equals(cl))
+
+	for i := uint64(0); i < l.Len(); i++ {
+		assert.True(l.Get(i).Equals(cl.Get(i)))
+	}
+	for i := l.Len(); i > 0; i-- {
+		assert.True(l.Get(i - 1).Equals(cl.Get(i - 1)))
+	}
+	for i := uint64(0); i < l.Len(); i += 37 {
+		assert.True(l.Get(i).Equals(cl.Get(i)))
+	}
+	for i := 0; i < 1000; i++ {
+		idx := uint64(rand.Intn(int(l.Len())))
+		assert.True(l.Get(idx).Equals(cl.Get(idx)))
+	}
+
+	done := make(chan struct{})
+	for g := 0; g < 4; g++ {
+		go func(g int) {
+			defer func() { done <- struct{}{} }()
+			for i := uint64(g); i < l.Len(); i += 4 {
+				assert.True(tl[i].Equals(cl.Get(i)))
+			}
+		}(g)
+	}
+	for g := 0; g < 4; g++ {
+		<-done
+	}
+
+	assert.Nil(cl.Append(Number(1)).cur)
+}
+
 func TestListRemoveAt(t *testing.T) {
 	assert := assert.New(t)
 
@@ -275,6 +316,36 @@ func TestStreamingListCreation(t *testing.T) {
 	})
 }
 
+func TestListPrepend(t *testing.T) {
+	assert := assert.New(t)
+
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	vs := NewTestValueStore()
+	write := func(l List) List {
+		return vs.ReadValue(vs.WriteValue(l).TargetHash()).(List)
+	}
+
+	// Build a log-style list, newest entries first, one small batch at a time.
+	tl := testList{}
+	l := write(NewList())
+	for i := 0; i < 500; i++ {
+		batch := generateNumbersAsValuesFromToBy(i*20, (i+1)*20, 1)
+		tl = append(append(testList{}, batch...), tl...)
+		l = write(l.Prepend(batch...))
+	}
+	assert.True(tl.toList().Equals(l))
+
+	// Prepending reuses every chunk to the right of the first few.
+	prepended := write(l.Prepend(Number(-1), Number(-2)))
+	assert.True(Number(-1).Equals(prepended.Get(0)))
+	assert.Equal(l.Len()+2, prepended.Len())
+	cs := CompareChunks(l, vs, prepended, vs)
+	assert.True(cs.New < 10, "%d new chunks", cs.New)
+	assert.True(cs.Shared > 5*cs.New, "%d shared chunks", cs.Shared)
+}
+
 func TestListAppend(t *testing.T) {
 	smallTestChunks()
 	defer normalProductionChunks()
@@ -13,12 +13,21 @@ import (
 )
 
 type Map struct {
-	seq orderedSequence
-	h   *hash.Hash
+	seq    orderedSequence
+	h      *hash.Hash
+	filter *KeyFilter
 }
 
 func newMap(seq orderedSequence) Map {
-	return Map{seq, &hash.Hash{}}
+	return Map{seq, &hash.Hash{}, nil}
+}
+
+// WithKeyFilter returns m with f, a KeyFilter of its keys, which Has, Get and
+// MaybeGet check before reading any chunks. f is carried over to the Maps
+// returned by Set, SetM, Remove and a MapEditor made from m, and keys they add
+// are added to it.
+func (m Map) WithKeyFilter(f *KeyFilter) Map {
+	return Map{m.seq, m.h, f}
 }
 
 func mapHashValueBytes(item sequenceItem, rv *rollingValueHasher) {
@@ -59,6 +68,35 @@ func NewStreamingMap(vrw ValueReadWriter, kvs <-chan Value) <-chan Map {
 	return outChan
 }
 
+// NewMapFromSortedEntries creates a Map from kvs, which alternates keys and
+// values as for NewStreamingMap, but must be in strictly increasing key order.
+// The entries are appended to the chunker as they're received, skipping the
+// sort NewStreamingMap does, so it's for sources which are already sorted. It
+// panics if a key isn't greater than the one before it. As chunks are created,
+// they're written to vrw, which may be nil to keep them in memory.
+func NewMapFromSortedEntries(vrw ValueReadWriter, kvs <-chan Value) <-chan Map {
+	outChan := make(chan Map)
+	go func() {
+		defer close(outChan)
+		ch := newEmptyMapSequenceChunker(vrw, vrw)
+		var k, last Value
+		for v := range kvs {
+			if k == nil {
+				k = v
+				continue
+			}
+			if last != nil && !last.Less(k) {
+				d.Panic("Map keys aren't sorted: %s isn't greater than %s", EncodedValue(k), EncodedValue(last))
+			}
+			ch.Append(mapEntry{k, v})
+			last, k = k, nil
+		}
+		d.PanicIfFalse(k == nil)
+		outChan <- newMap(ch.Done().(orderedSequence))
+	}()
+	return outChan
+}
+
 // Diff computes the diff from |last| to |m| using the top-down algorithm,
 // which completes as fast as possible while taking longer to return early
 // results than left-to-right.
@@ -170,6 +208,9 @@ func (m Map) At(idx uint64) (key, value Value) {
 }
 
 func (m Map) MaybeGet(key Value) (v Value, ok bool) {
+	if !keyFilterHas(m.filter, key) {
+		return nil, false
+	}
 	cur := newCursorAtValue(m.seq, key, false, false, false)
 	if !cur.valid() {
 		return nil, false
@@ -198,19 +239,21 @@ func (m Map) SetM(kv ...Value) Map {
 	deleteCount := uint64(0)
 	if found {
 		deleteCount = 1
+	} else if m.filter != nil {
+		m.filter.Add(k)
 	}
-	return m.splice(cur, deleteCount, mapEntry{k, v}).SetM(tail...)
+	return m.splice(cur, deleteCount, mapEntry{k, v}).WithKeyFilter(m.filter).SetM(tail...)
 }
 
 func (m Map) Remove(k Value) Map {
 	if cur, found := m.getCursorAtValue(k, false); found {
-		return m.splice(cur, 1)
+		return m.splice(cur, 1).WithKeyFilter(m.filter)
 	}
 	return m
 }
 
 func (m Map) splice(cur *sequenceCursor, deleteCount uint64, vs ...mapEntry) Map {
-	ch := newSequenceChunker(cur, m.seq.valueReader(), nil, makeMapLeafChunkFn(m.seq.valueReader()), newOrderedMetaSequenceChunkFn(MapKind, m.seq.valueReader()), mapHashValueBytes)
+	ch := m.newChunker(cur, m.seq.valueReader())
 	for deleteCount > 0 {
 		ch.Skip()
 		deleteCount--
@@ -222,6 +265,10 @@ func (m Map) splice(cur *sequenceCursor, deleteCount uint64, vs ...mapEntry) Map
 	return newMap(ch.Done().(orderedSequence))
 }
 
+func (m Map) newChunker(cur *sequenceCursor, vr ValueReader) *sequenceChunker {
+	return newSequenceChunker(cur, MapKind, vr, nil, makeMapLeafChunkFn(vr), newOrderedMetaSequenceChunkFn(MapKind, vr), mapHashValueBytes)
+}
+
 func (m Map) getCursorAtValue(v Value, readAhead bool) (cur *sequenceCursor, found bool) {
 	cur = newCursorAtValue(m.seq, v, true, false, readAhead)
 	found = cur.idx < cur.seq.seqLen() && cur.current().(mapEntry).key.Equals(v)
@@ -229,12 +276,15 @@ func (m Map) getCursorAtValue(v Value, readAhead bool) (cur *sequenceCursor, fou
 }
 
 func (m Map) Has(key Value) bool {
-	cur := newCursorAtValue(m.seq, key, false, false, false)
-	if !cur.valid() {
+	if !keyFilterHas(m.filter, key) {
 		return false
 	}
-	entry := cur.current().(mapEntry)
-	return entry.key.Equals(key)
+	return orderedSequenceHas(m.seq, newOrderedKey(key))
+}
+
+// CountRange returns the number of keys k in m such that from <= k < to. Only the chunks on the paths to from and to are loaded.
+func (m Map) CountRange(from, to Value) uint64 {
+	return orderedSequenceCountRange(m.seq, newOrderedKey(from), newOrderedKey(to))
 }
 
 func (m Map) Get(key Value) Value {
@@ -299,6 +349,41 @@ func (m Map) IterFrom(start Value, cb mapIterCallback) {
 	})
 }
 
+// Range calls cb for the entries in m whose keys k are such that from <= k < to,
+// in order, stopping after limit entries. A nil from or to leaves that end of
+// the range open, and a limit of 0 means no limit. If the limit cut the range
+// short, Range returns the key of the next entry, which is where the next
+// page starts; otherwise it returns nil. Only the chunks holding the entries
+// passed to cb, and those on the path to them, are loaded.
+func (m Map) Range(from, to Value, limit uint64, cb mapIterAllCallback) (next Value) {
+	// Only read ahead if the whole range is wanted, since a page may be much
+	// smaller than a chunk.
+	readAhead := limit == 0
+	var cur *sequenceCursor
+	if from == nil {
+		cur = newCursorAt(m.seq, emptyKey, false, false, readAhead)
+	} else {
+		cur = newCursorAtValue(m.seq, from, false, false, readAhead)
+	}
+
+	var toKey orderedKey
+	if to != nil {
+		toKey = newOrderedKey(to)
+	}
+	for n := uint64(0); cur.valid(); n++ {
+		entry := cur.current().(mapEntry)
+		if to != nil && !newOrderedKey(entry.key).Less(toKey) {
+			return nil
+		}
+		if limit > 0 && n == limit {
+			return entry.key
+		}
+		cb(entry.key, entry.value)
+		cur.advance()
+	}
+	return nil
+}
+
 func buildMapData(values []Value) mapEntrySlice {
 	if len(values) == 0 {
 		return mapEntrySlice{}
@@ -350,5 +435,5 @@ func makeMapLeafChunkFn(vr ValueReader) makeChunkFn {
 }
 
 func newEmptyMapSequenceChunker(vr ValueReader, vw ValueWriter) *sequenceChunker {
-	return newEmptySequenceChunker(vr, vw, makeMapLeafChunkFn(vr), newOrderedMetaSequenceChunkFn(MapKind, vr), mapHashValueBytes)
+	return newEmptySequenceChunker(MapKind, vr, vw, makeMapLeafChunkFn(vr), newOrderedMetaSequenceChunkFn(MapKind, vr), mapHashValueBytes)
 }
@@ -0,0 +1,113 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import "sort"
+
+// MapEditor accumulates edits to a Map and applies them all at once when Map() is called. Edits are applied in key order, and consecutive edits which fall within the same leaf chunk share a single chunker run, so a batch of nearby edits costs one pass over the affected part of the tree rather than one per edit as with repeated calls to Map.Set().
+type MapEditor struct {
+	m     Map
+	edits []mapEdit
+}
+
+type mapEdit struct {
+	key, value Value // value is nil for a removal
+}
+
+func NewMapEditor(m Map) *MapEditor {
+	return &MapEditor{m, nil}
+}
+
+// Set sets key to value in the resulting Map. If key is edited more than once, the last edit wins.
+func (me *MapEditor) Set(key, value Value) *MapEditor {
+	me.edits = append(me.edits, mapEdit{key, value})
+	return me
+}
+
+// Remove removes key from the resulting Map, if it's there. If key is edited more than once, the last edit wins.
+func (me *MapEditor) Remove(key Value) *MapEditor {
+	me.edits = append(me.edits, mapEdit{key, nil})
+	return me
+}
+
+// Map applies all pending edits and returns the result. The editor can continue to be used afterwards, starting from the result. If the Map the editor started from has a KeyFilter, the result has it too, with the keys which were set added to it.
+func (me *MapEditor) Map() Map {
+	edits := me.sortedEdits()
+	me.edits = nil
+
+	filter := me.m.filter
+	if filter != nil {
+		for _, e := range edits {
+			if e.value != nil {
+				filter.Add(e.key)
+			}
+		}
+	}
+
+	m := me.m
+	for i := 0; i < len(edits); {
+		cur, found := m.getCursorAtValue(edits[i].key, false)
+		ch := m.newChunker(cur, m.seq.valueReader())
+		for {
+			if found {
+				ch.Skip()
+			}
+			if edits[i].value != nil {
+				ch.Append(mapEntry{edits[i].key, edits[i].value})
+			}
+
+			i++
+			if i == len(edits) || !inCurrentLeaf(cur, edits[i].key) {
+				break
+			}
+
+			// Copy through existing entries up to the next edit. They're in the current leaf chunk, which has to be re-chunked anyway.
+			nextKey := newOrderedKey(edits[i].key)
+			for cur.valid() && newOrderedKey(cur.current().(mapEntry).key).Less(nextKey) {
+				ch.Append(cur.current())
+				ch.Skip()
+			}
+			found = cur.valid() && cur.current().(mapEntry).key.Equals(edits[i].key)
+		}
+		m = newMap(ch.Done().(orderedSequence)).WithKeyFilter(filter)
+	}
+
+	me.m = m
+	return m
+}
+
+// sortedEdits returns the pending edits sorted by key, keeping only the last edit to each key.
+func (me *MapEditor) sortedEdits() []mapEdit {
+	keys := make([]orderedKey, len(me.edits))
+	for i, e := range me.edits {
+		keys[i] = newOrderedKey(e.key)
+	}
+	idx := make([]int, len(me.edits))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return keys[idx[i]].Less(keys[idx[j]])
+	})
+
+	edits := make([]mapEdit, 0, len(idx))
+	for n, i := range idx {
+		if n+1 < len(idx) && !keys[idx[n+1]].Less(keys[i]) && !keys[i].Less(keys[idx[n+1]]) {
+			// A later edit to the same key follows.
+			continue
+		}
+		edits = append(edits, me.edits[i])
+	}
+	return edits
+}
+
+// inCurrentLeaf returns whether key would be positioned within the leaf chunk that cur is in, or at the end of the sequence if cur is already there.
+func inCurrentLeaf(cur *sequenceCursor, key Value) bool {
+	if !cur.valid() {
+		return true
+	}
+	last := cur.seq.getItem(cur.seq.seqLen() - 1).(mapEntry)
+	return !newOrderedKey(last.key).Less(newOrderedKey(key))
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestMapEditorBasics(t *testing.T) {
+	assert := assert.New(t)
+
+	m := NewMap(Number(1), String("a"), Number(2), String("b"))
+	me := NewMapEditor(m)
+	me.Set(Number(3), String("c")).Remove(Number(1)).Set(Number(2), String("x")).Set(Number(2), String("y"))
+	me.Set(Number(4), String("d")).Remove(Number(4))
+	me.Remove(Number(5))
+
+	assert.True(NewMap(Number(2), String("y"), Number(3), String("c")).Equals(me.Map()))
+	// The original is unchanged.
+	assert.True(NewMap(Number(1), String("a"), Number(2), String("b")).Equals(m))
+
+	// Editing continues from the previous result.
+	assert.True(NewMap(Number(3), String("c")).Equals(me.Remove(Number(2)).Map()))
+	assert.True(NewMap(Number(3), String("c")).Equals(me.Map()))
+
+	assert.True(NewMap().Equals(NewMapEditor(NewMap()).Map()))
+}
+
+func TestMapEditorMatchesSequentialEdits(t *testing.T) {
+	assert := assert.New(t)
+
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	r := rand.New(rand.NewSource(0))
+	kvs := []Value{}
+	for i := 0; i < 2000; i += 2 {
+		kvs = append(kvs, Number(i), Number(i*10))
+	}
+	orig := NewMap(kvs...)
+	assert.True(isMetaSequence(orig.seq))
+
+	for _, numEdits := range []int{1, 10, 100, 1000} {
+		me := NewMapEditor(orig)
+		expected := orig
+		for i := 0; i < numEdits; i++ {
+			// Keys overlap existing ones (odd and even), run past the end, and some are struct keys which are ordered by hash.
+			var k Value = Number(r.Intn(2200))
+			if r.Intn(10) == 0 {
+				k = NewStruct("S", StructData{"n": k})
+			}
+			if r.Intn(3) == 0 {
+				me.Remove(k)
+				expected = expected.Remove(k)
+			} else {
+				v := Number(r.Int63())
+				me.Set(k, v)
+				expected = expected.Set(k, v)
+			}
+		}
+
+		actual := me.Map()
+		assert.Equal(expected.Len(), actual.Len())
+		assert.True(expected.Equals(actual), "%d edits", numEdits)
+	}
+}
+
+func TestMapEditorReloaded(t *testing.T) {
+	assert := assert.New(t)
+
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	vs := NewTestValueStore()
+	kvs := []Value{}
+	for i := 0; i < 1000; i++ {
+		kvs = append(kvs, Number(i), String("v"))
+	}
+	m := vs.ReadValue(vs.WriteValue(NewMap(kvs...)).TargetHash()).(Map)
+
+	me := NewMapEditor(m)
+	expected := m
+	for i := 0; i < 1000; i += 7 {
+		me.Set(Number(i), String("edited"))
+		expected = expected.Set(Number(i), String("edited"))
+	}
+	me.Set(Number(1000), String("new"))
+	expected = expected.Set(Number(1000), String("new"))
+
+	actual := me.Map()
+	assert.True(expected.Equals(actual))
+	assert.True(vs.ReadValue(vs.WriteValue(actual).TargetHash()).Equals(expected))
+}
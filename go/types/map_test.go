@@ -12,6 +12,7 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/attic-labs/noms/go/chunks"
 	"github.com/attic-labs/testify/assert"
 	"github.com/attic-labs/testify/suite"
 )
@@ -266,6 +267,24 @@ func (suite *mapTestSuite) TestStreamingMap2() {
 	wg.Wait()
 }
 
+func (suite *mapTestSuite) TestMapFromSortedEntries() {
+	vs := NewTestValueStore()
+	defer vs.Close()
+
+	sorted := make(mapEntrySlice, len(suite.elems.entries))
+	copy(sorted, suite.elems.entries)
+	sort.Sort(sorted)
+
+	kvChan := make(chan Value)
+	mapChan := NewMapFromSortedEntries(vs, kvChan)
+	for _, entry := range sorted {
+		kvChan <- entry.key
+		kvChan <- entry.value
+	}
+	close(kvChan)
+	suite.True(suite.validate(<-mapChan), "map not valid")
+}
+
 func TestMapSuite4K(t *testing.T) {
 	suite.Run(t, newMapTestSuite(12, 9, 2, 2, newNumber))
 }
@@ -1331,3 +1350,113 @@ func TestMapWithStructShouldHaveOptionalFields(t *testing.T) {
 		).Equals(TypeOf(list)))
 
 }
+
+func TestMapCountRange(t *testing.T) {
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	assert := assert.New(t)
+
+	kvs := []Value{}
+	for i := 0; i < 5000; i += 2 {
+		kvs = append(kvs, Number(i), String(fmt.Sprintf("%d", i)))
+	}
+	m := NewMap(kvs...)
+
+	countLinear := func(from, to Value) (n uint64) {
+		m.IterAll(func(k, v Value) {
+			if !k.Less(from) && k.Less(to) {
+				n++
+			}
+		})
+		return
+	}
+
+	for _, r := range [][2]float64{{0, 5000}, {-10, 10}, {1, 2}, {3, 4000}, {4998, 6000}, {100, 50}, {2500, 2500}} {
+		from, to := Number(r[0]), Number(r[1])
+		assert.Equal(countLinear(from, to), m.CountRange(from, to), "%v", r)
+	}
+	assert.Equal(m.Len(), m.CountRange(Number(-1), String("")))
+	assert.Equal(uint64(0), NewMap().CountRange(Number(0), Number(10)))
+}
+
+func TestMapRange(t *testing.T) {
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	assert := assert.New(t)
+
+	kvs := []Value{}
+	for i := 0; i < 5000; i += 2 {
+		kvs = append(kvs, Number(i), String(fmt.Sprintf("%d", i)))
+	}
+	m := NewMap(kvs...)
+
+	rangeLinear := func(from, to Value) []Value {
+		keys := []Value{}
+		m.IterAll(func(k, v Value) {
+			if (from == nil || !k.Less(from)) && (to == nil || k.Less(to)) {
+				keys = append(keys, k)
+			}
+		})
+		return keys
+	}
+
+	// Paging through a range with each limit finds the same keys as iterating over all of it.
+	for _, r := range [][2]Value{{Number(0), Number(5000)}, {Number(-10), Number(11)}, {Number(3), Number(4000)}, {nil, Number(100)}, {Number(4900), nil}, {Number(100), Number(50)}} {
+		for _, limit := range []uint64{0, 1, 7, 1000} {
+			keys := []Value{}
+			next := r[0]
+			for pages := 0; pages == 0 || next != nil; pages++ {
+				n := uint64(0)
+				next = m.Range(next, r[1], limit, func(k, v Value) {
+					assert.True(v.Equals(m.Get(k)))
+					keys = append(keys, k)
+					n++
+				})
+				if next != nil {
+					assert.Equal(limit, n)
+				}
+			}
+			assert.Equal(rangeLinear(r[0], r[1]), keys, "%v %d", r, limit)
+		}
+	}
+
+	assert.Nil(NewMap().Range(nil, nil, 10, func(k, v Value) { assert.Fail("empty") }))
+}
+
+func TestMapHasAndCountRangeSkipChunks(t *testing.T) {
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	assert := assert.New(t)
+
+	kvs := []Value{}
+	for i := 0; i < 5000; i++ {
+		kvs = append(kvs, Number(i), Number(i))
+	}
+	cs := chunks.NewTestStore()
+	vs := newLocalValueStore(cs)
+	h := vs.WriteValue(NewMap(kvs...)).TargetHash()
+	vs.Flush(h)
+
+	m := newLocalValueStore(cs).ReadValue(h).(Map)
+	depth := newCursorAtIndex(m.sequence(), 0, false).depth()
+	assert.True(depth > 2)
+	reads := cs.Reads
+
+	// The last key is the key of the last meta tuple at every level, and a key past the end is rejected at the root.
+	assert.True(m.Has(Number(4999)))
+	assert.False(m.Has(Number(5000)))
+	assert.Equal(reads, cs.Reads)
+
+	// Counting only loads the chunks on the paths to each end of the range.
+	assert.Equal(uint64(4000), m.CountRange(Number(500), Number(4500)))
+	assert.True(cs.Reads-reads <= 2*(depth-1))
+	reads = cs.Reads
+
+	// A page from the middle of the map only loads the chunks on the path to it.
+	next := m.Range(Number(2500), nil, 3, func(k, v Value) {})
+	assert.True(Number(2503).Equals(next))
+	assert.True(cs.Reads-reads <= depth)
+}
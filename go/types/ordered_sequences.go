@@ -72,6 +72,54 @@ func seekTo(cur *sequenceCursor, key orderedKey, lastPositionIfNotFound bool) bo
 	return cur.idx < seq.seqLen()
 }
 
+// orderedSequenceHas returns whether seq contains key. Meta tuple keys are the largest key in each subtree, so the search stops without loading any more chunks as soon as key is either past the end of a level or equal to one of its meta tuple keys.
+func orderedSequenceHas(seq orderedSequence, key orderedKey) bool {
+	for {
+		idx := sort.Search(seq.seqLen(), func(i int) bool {
+			return !seq.getKey(i).Less(key)
+		})
+		if idx == seq.seqLen() {
+			return false
+		}
+		if !key.Less(seq.getKey(idx)) {
+			return true
+		}
+		if !isMetaSequence(seq) {
+			return false
+		}
+		seq = seq.(metaSequence).getChildSequence(idx).(orderedSequence)
+	}
+}
+
+// orderedSequenceIndexOf returns the number of items in seq whose key is less than key. Only the chunks on the path to key are loaded; the items in the subtrees to its left are counted using the meta tuples' numLeaves.
+func orderedSequenceIndexOf(seq orderedSequence, key orderedKey) uint64 {
+	idx := uint64(0)
+	for {
+		i := sort.Search(seq.seqLen(), func(i int) bool {
+			return !seq.getKey(i).Less(key)
+		})
+		if !isMetaSequence(seq) {
+			return idx + uint64(i)
+		}
+		ms := seq.(metaSequence)
+		if i == ms.seqLen() {
+			return idx + ms.numLeaves()
+		}
+		if i > 0 {
+			idx += ms.cumulativeNumberOfLeaves(i - 1)
+		}
+		seq = ms.getChildSequence(i).(orderedSequence)
+	}
+}
+
+// orderedSequenceCountRange returns the number of items in seq whose key k is in the range from <= k < to.
+func orderedSequenceCountRange(seq orderedSequence, from, to orderedKey) uint64 {
+	if !from.Less(to) {
+		return 0
+	}
+	return orderedSequenceIndexOf(seq, to) - orderedSequenceIndexOf(seq, from)
+}
+
 // Gets the key used for ordering the sequence at current index.
 func getCurrentKey(cur *sequenceCursor) orderedKey {
 	seq, ok := cur.seq.(orderedSequence)
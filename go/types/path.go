@@ -35,6 +35,14 @@ type PathPart interface {
 	String() string
 }
 
+// multiPathPart is a PathPart which can resolve to more than one value. Its
+// Resolve returns the first of them.
+type multiPathPart interface {
+	// iterAll calls cb with each value the part resolves to, in order, until
+	// cb returns true, and returns whether it did.
+	iterAll(v Value, vr ValueReader, cb func(v Value) (stop bool)) (stopped bool)
+}
+
 // ParsePath parses str into a Path, or returns an error if parsing failed.
 func ParsePath(str string) (Path, error) {
 	if str == "" {
@@ -65,6 +73,23 @@ func constructPath(p Path, str string) (Path, error) {
 
 	switch op {
 	case '.':
+		if strings.HasPrefix(tail, ".") {
+			rem := tail[1:]
+			if rem == "" {
+				return Path{}, errors.New("Path ends in ..")
+			}
+			p = append(p, RecursivePath{})
+			if rem[0] == '[' {
+				return constructPath(p, rem)
+			}
+			if rem[0] == '.' || rem[0] == '@' {
+				return Path{}, fmt.Errorf("Invalid field: %s", rem)
+			}
+			return constructPath(p, "."+rem)
+		}
+		if strings.HasPrefix(tail, "*") {
+			return constructPath(append(p, WildcardPath{}), tail[1:])
+		}
 		idx := fieldNameComponentRe.FindIndex([]byte(tail))
 		if idx == nil {
 			return Path{}, errors.New("Invalid field: " + tail)
@@ -76,6 +101,9 @@ func constructPath(p Path, str string) (Path, error) {
 		if len(tail) == 0 {
 			return Path{}, errors.New("Path ends in [")
 		}
+		if strings.HasPrefix(tail, "*]") {
+			return constructPath(append(p, WildcardPath{Index: true}), tail[2:])
+		}
 
 		idx, h, rem, err := ParsePathIndex(tail)
 		if err != nil {
@@ -148,7 +176,17 @@ func constructPath(p Path, str string) (Path, error) {
 
 // Resolves a path relative to some value.
 // A ValueReader is required to resolve paths that contain the @target annotation.
+// If p contains wildcard or recursive parts, the first value ResolveAll would
+// return is, and values are only resolved until it's found.
 func (p Path) Resolve(v Value, vr ValueReader) (resolved Value) {
+	if p.isMulti() {
+		p.iterAll(v, vr, func(v Value) bool {
+			resolved = v
+			return true
+		})
+		return
+	}
+
 	resolved = v
 	for _, part := range p {
 		if resolved == nil {
@@ -160,6 +198,46 @@ func (p Path) Resolve(v Value, vr ValueReader) (resolved Value) {
 	return
 }
 
+// ResolveAll resolves a path relative to some value, like Resolve, but
+// returns every value it resolves to: each part of the path is resolved in
+// each of the values the parts before it resolved to. A path without wildcard
+// or recursive parts resolves to at most one value.
+func (p Path) ResolveAll(v Value, vr ValueReader) []Value {
+	values := []Value{}
+	p.iterAll(v, vr, func(v Value) bool {
+		values = append(values, v)
+		return false
+	})
+	return values
+}
+
+// iterAll calls cb with each value p resolves to relative to v, in the order
+// ResolveAll returns them, until cb returns true, and returns whether it did.
+// Each value is resolved only when the ones before it have been passed to cb.
+func (p Path) iterAll(v Value, vr ValueReader, cb func(v Value) bool) bool {
+	if len(p) == 0 {
+		return cb(v)
+	}
+	if mp, ok := p[0].(multiPathPart); ok {
+		return mp.iterAll(v, vr, func(v Value) bool {
+			return p[1:].iterAll(v, vr, cb)
+		})
+	}
+	if resolved := p[0].Resolve(v, vr); resolved != nil {
+		return p[1:].iterAll(resolved, vr, cb)
+	}
+	return false
+}
+
+func (p Path) isMulti() bool {
+	for _, part := range p {
+		if _, ok := part.(multiPathPart); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (p Path) Equals(o Path) bool {
 	if len(p) != len(o) {
 		return false
@@ -181,8 +259,15 @@ func (p Path) Append(pp PathPart) Path {
 
 func (p Path) String() string {
 	strs := make([]string, 0, len(p))
-	for _, part := range p {
-		strs = append(strs, part.String())
+	for i, part := range p {
+		str := part.String()
+		if _, ok := part.(RecursivePath); !ok && i > 0 {
+			if _, ok := p[i-1].(RecursivePath); ok {
+				// "..foo", not "...foo".
+				str = strings.TrimPrefix(str, ".")
+			}
+		}
+		strs = append(strs, str)
 	}
 	return strings.Join(strs, "")
 }
@@ -215,6 +300,79 @@ func (fp FieldPath) String() string {
 	return fmt.Sprintf(".%s", fp.Name)
 }
 
+// WildcardPath resolves to every child of a value: the fields of a Struct,
+// the items of a List or Set, or the values of a Map, e.g. `.*` or `[*]`.
+type WildcardPath struct {
+	// Whether the wildcard is written as an index, `[*]`, rather than a field,
+	// `.*`. Either resolves to the same values.
+	Index bool
+}
+
+func (wp WildcardPath) Resolve(v Value, vr ValueReader) (resolved Value) {
+	wp.iterAll(v, vr, func(v Value) bool {
+		resolved = v
+		return true
+	})
+	return
+}
+
+func (wp WildcardPath) iterAll(v Value, vr ValueReader, cb func(v Value) bool) (stopped bool) {
+	switch v := v.(type) {
+	case Struct:
+		v.IterFields(func(name string, field Value) {
+			stopped = stopped || cb(field)
+		})
+	case List:
+		v.Iter(func(item Value, idx uint64) bool {
+			stopped = cb(item)
+			return stopped
+		})
+	case Set:
+		v.Iter(func(item Value) bool {
+			stopped = cb(item)
+			return stopped
+		})
+	case Map:
+		v.Iter(func(key, value Value) bool {
+			stopped = cb(value)
+			return stopped
+		})
+	}
+	return
+}
+
+func (wp WildcardPath) String() string {
+	if wp.Index {
+		return "[*]"
+	}
+	return ".*"
+}
+
+// RecursivePath resolves to a value and all the values under it, as Walk
+// visits them without following Refs, so that the part of the path after it
+// is resolved at any depth, e.g. `..Date` resolves to every Date field
+// anywhere under the value.
+type RecursivePath struct {
+}
+
+func (rp RecursivePath) Resolve(v Value, vr ValueReader) Value {
+	return v
+}
+
+func (rp RecursivePath) iterAll(v Value, vr ValueReader, cb func(v Value) bool) (stopped bool) {
+	Walk(v, nil, func(p Path, v Value) WalkAction {
+		if stopped = cb(v); stopped {
+			return StopWalk
+		}
+		return WalkChildren
+	})
+	return
+}
+
+func (rp RecursivePath) String() string {
+	return ".."
+}
+
 // Indexes into Maps and Lists by key or index.
 type IndexPath struct {
 	// The value of the index, e.g. `[42]` or `["value"]`. If Index is a negative
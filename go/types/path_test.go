@@ -201,6 +201,75 @@ func TestPathMulti(t *testing.T) {
 	assertResolvesTo(assert, String("car"), s, `.foo[1]@at(-1)@key@at(-1)`)
 }
 
+func TestPathWildcardAndRecursive(t *testing.T) {
+	assert := assert.New(t)
+
+	date := func(s string) Struct {
+		return NewStruct("Event", StructData{"Date": String(s)})
+	}
+	v := NewStruct("Root", StructData{
+		"Date":   String("root"),
+		"events": NewList(date("a"), date("b")),
+		"byName": NewMap(String("x"), date("c"), String("y"), NewStruct("Other", StructData{"n": Number(1)})),
+		"tags":   NewSet(String("t1"), String("t2")),
+	})
+
+	resolveAll := func(str string) []string {
+		res := []string{}
+		for _, v := range MustParsePath(str).ResolveAll(v, nil) {
+			res = append(res, EncodedValue(v))
+		}
+		return res
+	}
+
+	assert.Equal([]string{`"a"`, `"b"`}, resolveAll(".events[*].Date"))
+	assert.Equal([]string{`"a"`, `"b"`}, resolveAll(".events.*.Date"))
+	assert.Equal([]string{`"c"`}, resolveAll(".byName[*].Date"))
+	assert.Equal([]string{`"t1"`, `"t2"`}, resolveAll(".tags[*]"))
+	assert.Equal(4, len(resolveAll(".*")))
+	assert.Equal([]string{`"root"`, `"c"`, `"a"`, `"b"`}, resolveAll("..Date"))
+	assert.Equal([]string{`"c"`, `"a"`, `"b"`}, resolveAll(".*..Date"))
+	assert.Equal([]string{"1"}, resolveAll("..n"))
+	assert.Empty(resolveAll("..missing"))
+	assert.Empty(resolveAll(".Date[*]"))
+
+	// Resolve returns the first of them.
+	assertResolvesTo(assert, String("a"), v, ".events[*].Date")
+	assertResolvesTo(assert, String("root"), v, "..Date")
+	assertResolvesTo(assert, nil, v, "..missing")
+
+	// A path without wildcards resolves to at most one value.
+	assert.Equal([]string{`"a"`}, resolveAll(".events[0].Date"))
+	assert.Empty(resolveAll(".events[2].Date"))
+}
+
+// readCountingValueReader counts the values read through it.
+type readCountingValueReader struct {
+	ValueReader
+	reads int
+}
+
+func (r *readCountingValueReader) ReadValue(h hash.Hash) Value {
+	r.reads++
+	return r.ValueReader.ReadValue(h)
+}
+
+func TestPathResolveStopsAtFirst(t *testing.T) {
+	assert := assert.New(t)
+
+	vs := NewTestValueStore()
+	l := NewList(vs.WriteValue(String("a")), vs.WriteValue(String("b")), vs.WriteValue(String("c")))
+
+	// Only the target of the first Ref is read.
+	vr := &readCountingValueReader{ValueReader: vs}
+	assert.True(String("a").Equals(MustParsePath("[*]@target").Resolve(l, vr)))
+	assert.Equal(1, vr.reads)
+
+	vr.reads = 0
+	assert.Equal(3, len(MustParsePath("[*]@target").ResolveAll(l, vr)))
+	assert.Equal(3, vr.reads)
+}
+
 func TestPathParseSuccess(t *testing.T) {
 	assert := assert.New(t)
 
@@ -250,6 +319,14 @@ func TestPathParseSuccess(t *testing.T) {
 	test(".foo[0].bar[4.5][false]")
 	test(fmt.Sprintf(".foo[#%s]", h.String()))
 	test(fmt.Sprintf(".bar[#%s]@key", h.String()))
+	test(".*")
+	test("[*]")
+	test(".foo.*.bar[*]")
+	test("..foo")
+	test("..*")
+	test("..[0]")
+	test("..[*]@type")
+	test(".foo..bar..baz")
 }
 
 func TestPathParseErrors(t *testing.T) {
@@ -314,6 +391,12 @@ func TestPathParseErrors(t *testing.T) {
 	test(".foo@at()", "@at annotation requires a position argument")
 	test(".foo@at(", "@at annotation requires a position argument")
 	test(".foo@at(42", "@at annotation requires a position argument")
+	test("..", "Path ends in ..")
+	test(".foo..", "Path ends in ..")
+	test("...foo", "Invalid field: .foo")
+	test("..@type", "Invalid field: @type")
+	test(".*foo", "Invalid operator: f")
+	test("[*", "Invalid index: *")
 	test(fmt.Sprintf(".foo[#%s]@soup", hash.Of([]byte{42}).String()), "Unsupported annotation: @soup")
 }
 
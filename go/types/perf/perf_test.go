@@ -5,12 +5,14 @@
 package perf
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
 	"math/rand"
 	"os"
 	"testing"
 
+	"github.com/attic-labs/noms/go/hash"
 	"github.com/attic-labs/noms/go/perf/suite"
 	"github.com/attic-labs/noms/go/types"
 	"github.com/attic-labs/testify/assert"
@@ -105,6 +107,77 @@ func (s *perfSuite) Test05Concat10mValues2kTimes() {
 	s.Database = ds.Database()
 }
 
+// Test08 and Test09 measure writing values one at a time, and decoding a batch of them, which ValueStore spreads across GOMAXPROCS goroutines. Test10 measures streaming values into a new List, whose chunks are encoded by GOMAXPROCS goroutines.
+
+func (s *perfSuite) Test08WriteValue1mStructs() {
+	assert := s.NewAssert()
+
+	const batch = 1e4
+	values := make([]types.Value, batch)
+	refs := make([]types.Value, 0, 1e6)
+	for len(refs) < cap(refs) {
+		for i := range values {
+			values[i] = types.NewStruct("", types.StructData{
+				"number": types.Number(s.r.Int63()),
+				"string": types.String(fmt.Sprintf("%d", s.r.Int63())),
+			})
+		}
+		for _, v := range values {
+			refs = append(refs, s.Database.WriteValue(v))
+		}
+	}
+
+	ds := s.Database.GetDataset("WriteValue1mStructs")
+	var err error
+	ds, err = s.Database.CommitValue(ds, types.NewList(refs...))
+
+	assert.NoError(err)
+	s.Database = ds.Database()
+}
+
+func (s *perfSuite) Test09ReadManyValues1mStructs() {
+	assert := s.NewAssert()
+
+	hashes := hash.HashSet{}
+	s.headList("WriteValue1mStructs").IterAll(func(v types.Value, index uint64) {
+		hashes.Insert(v.(types.Ref).TargetHash())
+	})
+
+	found := make(chan types.Value, 1024)
+	go func() {
+		s.Database.ReadManyValues(hashes, found)
+		close(found)
+	}()
+	n := 0
+	for range found {
+		n++
+	}
+	assert.Equal(len(hashes), n)
+}
+
+func (s *perfSuite) Test10StreamingList1mStructs() {
+	assert := s.NewAssert()
+
+	valueChan := make(chan types.Value, 1024)
+	listChan := types.NewStreamingList(s.Database, valueChan)
+	for i := 0; i < 1e6; i++ {
+		valueChan <- types.NewStruct("", types.StructData{
+			"number": types.Number(s.r.Int63()),
+			"string": types.String(fmt.Sprintf("%d", s.r.Int63())),
+		})
+	}
+	close(valueChan)
+
+	ds := s.Database.GetDataset("StreamingList1mStructs")
+	var err error
+	ds, err = s.Database.CommitValue(ds, <-listChan)
+
+	assert.NoError(err)
+	s.Database = ds.Database()
+}
+
+// The TestBuild500megBlob tests measure chunking alone, since the blob isn't written anywhere. Test06 and Test07 measure writing and reading a blob through Database, which is served over HTTP by the perf suite.
+
 func (s *perfSuite) TestBuild500megBlobFromFilesP1() {
 	s.testBuild500megBlob(1)
 }
@@ -122,12 +195,50 @@ func (s *perfSuite) TestBuild500megBlobFromFilesP64() {
 	s.testBuild500megBlob(64)
 }
 
+func (s *perfSuite) Test06Write500megBlobToDatabase() {
+	assert := s.NewAssert()
+	size := int(5e8)
+
+	readers, closeFn := s.openRandomFiles(8, size)
+	defer closeFn()
+
+	b := types.NewStreamingBlob(s.Database, readers...)
+	assert.Equal(uint64(size), b.Len())
+
+	ds := s.Database.GetDataset("Blob500meg")
+	var err error
+	ds, err = s.Database.CommitValue(ds, b)
+
+	assert.NoError(err)
+	s.Database = ds.Database()
+}
+
+func (s *perfSuite) Test07Read500megBlobFromDatabase() {
+	assert := s.NewAssert()
+
+	b := s.Database.GetDataset("Blob500meg").HeadValue().(types.Blob)
+	n, err := io.Copy(ioutil.Discard, b.Reader())
+	assert.NoError(err)
+	assert.Equal(b.Len(), uint64(n))
+}
+
 func (s *perfSuite) testBuild500megBlob(p int) {
 	assert := s.NewAssert()
 	size := int(5e8)
 
-	readers := make([]io.Reader, p)
-	defer func() {
+	readers, closeFn := s.openRandomFiles(p, size)
+	defer closeFn()
+
+	b := types.NewBlob(readers...)
+	assert.Equal(uint64(size), b.Len())
+}
+
+// openRandomFiles writes size random bytes split evenly across p temporary files, with the test timer paused, and opens them. Call closeFn to close and remove the files.
+func (s *perfSuite) openRandomFiles(p, size int) (readers []io.Reader, closeFn func()) {
+	assert := s.NewAssert()
+
+	readers = make([]io.Reader, p)
+	closeFn = func() {
 		for _, r := range readers {
 			f := r.(*os.File)
 			err := f.Close()
@@ -135,7 +246,7 @@ func (s *perfSuite) testBuild500megBlob(p int) {
 			err = os.Remove(f.Name())
 			assert.NoError(err)
 		}
-	}()
+	}
 
 	s.Pause(func() {
 		for i := range readers {
@@ -150,9 +261,7 @@ func (s *perfSuite) testBuild500megBlob(p int) {
 			readers[i] = f
 		}
 	})
-
-	b := types.NewBlob(readers...)
-	assert.Equal(uint64(size), b.Len())
+	return
 }
 
 func (s *perfSuite) randomBytes(seed int64, size int) []byte {
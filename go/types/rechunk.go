@@ -0,0 +1,101 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"sort"
+	"time"
+)
+
+// RechunkValue returns v with every collection in it, including those nested
+// in other collections and in structs, rebuilt with chunk boundaries found
+// as configured by config. Since chunk boundaries determine the hash of a
+// collection, the result isn't Equal to v, but it has the same content. If
+// vrw is not nil, chunks are written to vrw instead of kept in memory.
+//
+// The targets of Refs aren't rechunked. Nor are collections which are later
+// changed, e.g. by List.Append, which chunks the parts it changes as
+// configured for their kind.
+//
+// Values in Sets and keys in Maps other than Bools, Numbers and Strings are
+// ordered by hash, which rechunking them changes, so they're held in memory to
+// be sorted again.
+func RechunkValue(vrw ValueReadWriter, v Value, config ChunkConfig) Value {
+	return newRechunker(vrw, config).value(v)
+}
+
+type rechunker struct {
+	vrw    ValueReadWriter
+	config ChunkConfig
+	// tombstoneCutoff, if not zero, drops Map entries whose values are
+	// Tombstones for deletions before it.
+	tombstoneCutoff time.Time
+}
+
+func newRechunker(vrw ValueReadWriter, config ChunkConfig) rechunker {
+	config.check()
+	return rechunker{vrw: vrw, config: config}
+}
+
+func (r rechunker) expired(v Value) bool {
+	if r.tombstoneCutoff.IsZero() {
+		return false
+	}
+	deletedAt, ok := IsTombstone(v)
+	return ok && deletedAt.Before(r.tombstoneCutoff)
+}
+
+func (r rechunker) value(v Value) Value {
+	switch v := v.(type) {
+	case Blob:
+		return readBlob(v.Reader(), r.vrw, nil, &r.config)
+	case List:
+		ch := newEmptyListSequenceChunker(r.vrw, r.vrw).withConfig(r.config)
+		v.IterAll(func(item Value, idx uint64) {
+			ch.Append(r.value(item))
+		})
+		return newList(ch.Done())
+	case Set:
+		ch := newEmptySetSequenceChunker(r.vrw, r.vrw).withConfig(r.config)
+		byHash := ValueSlice{}
+		v.IterAll(func(item Value) {
+			if isKindOrderedByValue(item.Kind()) {
+				ch.Append(item)
+			} else {
+				byHash = append(byHash, r.value(item))
+			}
+		})
+		sort.Sort(byHash)
+		for _, item := range byHash {
+			ch.Append(item)
+		}
+		return newSet(ch.Done().(orderedSequence))
+	case Map:
+		ch := newEmptyMapSequenceChunker(r.vrw, r.vrw).withConfig(r.config)
+		byHash := mapEntrySlice{}
+		v.IterAll(func(key, value Value) {
+			if r.expired(value) {
+				return
+			}
+			if isKindOrderedByValue(key.Kind()) {
+				ch.Append(mapEntry{key, r.value(value)})
+			} else {
+				byHash = append(byHash, mapEntry{r.value(key), r.value(value)})
+			}
+		})
+		sort.Sort(byHash)
+		for _, entry := range byHash {
+			ch.Append(entry)
+		}
+		return newMap(ch.Done().(orderedSequence))
+	case Struct:
+		data := StructData{}
+		v.IterFields(func(name string, field Value) {
+			data[name] = r.value(field)
+		})
+		return NewStruct(v.Name(), data)
+	}
+	return v
+}
@@ -0,0 +1,55 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestRechunkValue(t *testing.T) {
+	assert := assert.New(t)
+
+	nums := generateNumbersAsValues(5000)
+	structs := generateNumbersAsStructs(2000)
+	mapKV := []Value{}
+	for i, s := range structs {
+		// Struct keys are ordered by hash, so are re-sorted once rechunked.
+		mapKV = append(mapKV, s, NewList(nums[:i%50]...), Number(i), String("n"))
+	}
+	v := NewStruct("Data", StructData{
+		"blob": NewBlob(bytes.NewReader(randomBuff(16))),
+		"list": NewList(nums...),
+		"set":  NewSet(append(structs, nums...)...),
+		"map":  NewMap(mapKV...),
+		"num":  Number(42),
+	})
+
+	small := ChunkConfig{AverageSize: 1 << 8, Window: 64}
+	vs := NewTestValueStore()
+	rechunked := RechunkValue(vs, v, small)
+	assert.False(v.Equals(rechunked))
+
+	numChunks := func(v Value) uint64 {
+		vs.WriteValue(v)
+		return CompareChunks(NewList(), vs, v, vs).Total()
+	}
+	for _, field := range []string{"blob", "list", "set", "map"} {
+		before, after := v.Get(field), rechunked.(Struct).Get(field)
+		assert.False(before.Equals(after), field)
+		assert.True(numChunks(after) > 4*numChunks(before), field)
+		assert.Equal(before.(Collection).Len(), after.(Collection).Len(), field)
+	}
+	assert.True(Number(42).Equals(rechunked.(Struct).Get("num")))
+
+	// Rechunking with the default config restores the original value.
+	rechunked = vs.ReadValue(vs.WriteValue(rechunked).TargetHash())
+	restored := RechunkValue(nil, rechunked, DefaultChunkConfig)
+	assert.True(v.Equals(restored))
+
+	assert.Panics(func() { RechunkValue(nil, v, ChunkConfig{AverageSize: 1000, Window: 64}) })
+}
@@ -8,8 +8,8 @@ import (
 	"encoding/binary"
 	"sync"
 
+	"github.com/attic-labs/noms/go/d"
 	"github.com/attic-labs/noms/go/hash"
-	"github.com/kch42/buzhash"
 )
 
 const (
@@ -24,14 +24,57 @@ var (
 	chunkPattern  = defaultChunkPattern
 	chunkWindow   = defaultChunkWindow
 	chunkConfigMu = &sync.Mutex{}
+
+	// kindChunkConfigs overrides chunkPattern and chunkWindow for sequences of a particular kind, e.g. because blobs and lists of wide structs have different optimal chunk sizes. Leaf and meta sequences of a collection are chunked with the collection's kind.
+	//
+	// Note that this is global rather than, say, per ValueStore, because chunk boundaries determine the hash of every collection: the same value chunked with different settings would have different hashes, so every reader and writer must agree on them.
+	kindChunkConfigs = map[NomsKind]ChunkConfig{}
 )
 
-func chunkingConfig() (pattern, window uint32) {
+// ChunkConfig configures the rolling hash which finds the boundaries at which collections are split into chunks.
+type ChunkConfig struct {
+	// AverageSize is the average size in bytes of the encoded items of a chunk. It must be a power of 2.
+	AverageSize uint32
+	// Window is the number of bytes which the rolling hash is taken over.
+	Window uint32
+}
+
+// DefaultChunkConfig is the ChunkConfig collections of every kind are built with unless SetKindChunkConfig says otherwise.
+var DefaultChunkConfig = ChunkConfig{AverageSize: defaultChunkPattern + 1, Window: defaultChunkWindow}
+
+func (c ChunkConfig) check() {
+	d.PanicIfFalse(c.AverageSize > 1 && c.AverageSize&(c.AverageSize-1) == 0)
+	d.PanicIfFalse(c.Window > 0)
+}
+
+// pattern is the mask which the rolling hash must match at a chunk boundary.
+func (c ChunkConfig) pattern() uint32 {
+	return c.AverageSize - 1
+}
+
+func chunkingConfig(kind NomsKind) (pattern, window uint32) {
 	chunkConfigMu.Lock()
 	defer chunkConfigMu.Unlock()
+	if c, ok := kindChunkConfigs[kind]; ok {
+		return c.pattern(), c.Window
+	}
 	return chunkPattern, chunkWindow
 }
 
+// KindChunkConfig returns the ChunkConfig which collections of kind are built with.
+func KindChunkConfig(kind NomsKind) ChunkConfig {
+	pattern, window := chunkingConfig(kind)
+	return ChunkConfig{pattern + 1, window}
+}
+
+// SetKindChunkConfig makes collections of kind, and the parts of them which are changed, be built with config from now on. Since chunk boundaries determine the hash of a collection, it should be called before any collections of kind are built, and every process which writes to a database should agree on it; values built with a different config can be rebuilt with RechunkValue.
+func SetKindChunkConfig(kind NomsKind, config ChunkConfig) {
+	config.check()
+	chunkConfigMu.Lock()
+	defer chunkConfigMu.Unlock()
+	kindChunkConfigs[kind] = config
+}
+
 func smallTestChunks() {
 	chunkConfigMu.Lock()
 	defer chunkConfigMu.Unlock()
@@ -44,10 +87,11 @@ func normalProductionChunks() {
 	defer chunkConfigMu.Unlock()
 	chunkPattern = defaultChunkPattern
 	chunkWindow = defaultChunkWindow
+	kindChunkConfigs = map[NomsKind]ChunkConfig{}
 }
 
 type rollingValueHasher struct {
-	bz                          *buzhash.BuzHash
+	bz                          *buzHash
 	enc                         *valueEncoder
 	bytesHashed                 uint32
 	lengthOnly, crossedBoundary bool
@@ -62,10 +106,14 @@ func hashValueByte(item sequenceItem, rv *rollingValueHasher) {
 	rv.HashByte(item.(byte))
 }
 
-func newRollingValueHasher() *rollingValueHasher {
-	pattern, window := chunkingConfig()
+func newRollingValueHasher(kind NomsKind) *rollingValueHasher {
+	pattern, window := chunkingConfig(kind)
+	return newRollingValueHasherWithConfig(pattern, window)
+}
+
+func newRollingValueHasherWithConfig(pattern, window uint32) *rollingValueHasher {
 	rv := &rollingValueHasher{
-		bz:      buzhash.NewBuzHash(window),
+		bz:      newBuzHash(window),
 		pattern: pattern,
 		window:  window,
 	}
@@ -73,14 +121,43 @@ func newRollingValueHasher() *rollingValueHasher {
 	return rv
 }
 
+// reset returns rv to the state of a newly created hasher with the same window.
+func (rv *rollingValueHasher) reset(pattern uint32) {
+	rv.bz.reset()
+	rv.bytesHashed = 0
+	rv.lengthOnly, rv.crossedBoundary = false, false
+	rv.pattern = pattern
+}
+
 func (rv *rollingValueHasher) HashByte(b byte) {
 	rv.bytesHashed++
 	if rv.lengthOnly {
 		return
 	}
 
-	rv.bz.HashByte(b)
-	rv.crossedBoundary = rv.crossedBoundary || (rv.bz.Sum32()&chunkPattern == chunkPattern)
+	rv.bz.hashByte(b)
+	rv.crossedBoundary = rv.crossedBoundary || (rv.bz.sum32()&rv.pattern == rv.pattern)
+}
+
+// HashBytes is equivalent to calling HashByte for each byte of bs, but much faster.
+func (rv *rollingValueHasher) HashBytes(bs []byte) {
+	for len(bs) > 0 {
+		n := rv.HashBytesToBoundary(bs)
+		bs = bs[n:]
+	}
+}
+
+// HashBytesToBoundary hashes bytes from bs until a chunk boundary is crossed, and returns the number of bytes hashed. The boundary, if any, is after the last byte hashed.
+func (rv *rollingValueHasher) HashBytesToBoundary(bs []byte) int {
+	if rv.lengthOnly {
+		rv.bytesHashed += uint32(len(bs))
+		return len(bs)
+	}
+
+	n, found := rv.bz.hashBytesToBoundary(bs, rv.pattern)
+	rv.bytesHashed += uint32(n)
+	rv.crossedBoundary = rv.crossedBoundary || found
+	return n
 }
 
 func (rv *rollingValueHasher) ClearLastBoundary() {
@@ -94,9 +171,7 @@ func (rv *rollingValueHasher) HashValue(v Value) {
 
 // nomsWriter interface. Note: It's unfortunate to have another implementation of nomsWriter and this one must be kept in sync with binaryNomsWriter, but hashing values is a red-hot code path and it's worth a lot to avoid the allocations for literally encoding values.
 func (rv *rollingValueHasher) writeBytes(v []byte) {
-	for _, b := range v {
-		rv.HashByte(b)
-	}
+	rv.HashBytes(v)
 }
 
 func (rv *rollingValueHasher) writeUint8(v uint8) {
@@ -137,13 +212,15 @@ func (rv *rollingValueHasher) writeString(v string) {
 	size := uint32(len(v))
 	rv.writeCount(uint64(size))
 
-	for i := 0; i < len(v); i++ {
-		rv.HashByte(v[i])
+	// Copy through a buffer on the stack, to avoid allocating a []byte copy of v.
+	buff := [256]byte{}
+	for len(v) > 0 {
+		n := copy(buff[:], v)
+		rv.HashBytes(buff[:n])
+		v = v[n:]
 	}
 }
 
 func (rv *rollingValueHasher) writeHash(h hash.Hash) {
-	for _, b := range h[:] {
-		rv.HashByte(b)
-	}
+	rv.HashBytes(h[:])
 }
@@ -10,6 +10,7 @@ type hashValueBytesFn func(item sequenceItem, rv *rollingValueHasher)
 
 type sequenceChunker struct {
 	cur                        *sequenceCursor
+	kind                       NomsKind
 	vr                         ValueReader
 	vw                         ValueWriter
 	parent                     *sequenceChunker
@@ -18,17 +19,28 @@ type sequenceChunker struct {
 	isLeaf                     bool
 	hashValueBytes             hashValueBytesFn
 	rv                         *rollingValueHasher
+	w                          *binaryNomsWriter // encodes the sequences which aren't written, to hash them; see createSequence
 	done                       bool
+	config                     *ChunkConfig // overrides the kind's chunking config, if not nil
+	pool                       *codecPool   // writes the sequences of a leaf chunker in parallel; see writesConcurrently
+	writes                     []chan metaTuple
+}
+
+// concurrentValueWriter is a ValueWriter whose WriteValue can be called by several goroutines at once, so that a sequenceChunker can encode the sequences it writes in parallel.
+type concurrentValueWriter interface {
+	ValueWriter
+	writesConcurrently()
 }
 
 // makeChunkFn takes a sequence of items to chunk, and returns the result of chunking those items, a tuple of a reference to that chunk which can itself be chunked + its underlying value.
 type makeChunkFn func(values []sequenceItem) (Collection, orderedKey, uint64)
 
-func newEmptySequenceChunker(vr ValueReader, vw ValueWriter, makeChunk, parentMakeChunk makeChunkFn, hashValueBytes hashValueBytesFn) *sequenceChunker {
-	return newSequenceChunker(nil, vr, vw, makeChunk, parentMakeChunk, hashValueBytes)
+func newEmptySequenceChunker(kind NomsKind, vr ValueReader, vw ValueWriter, makeChunk, parentMakeChunk makeChunkFn, hashValueBytes hashValueBytesFn) *sequenceChunker {
+	return newSequenceChunker(nil, kind, vr, vw, makeChunk, parentMakeChunk, hashValueBytes)
 }
 
-func newSequenceChunker(cur *sequenceCursor, vr ValueReader, vw ValueWriter, makeChunk, parentMakeChunk makeChunkFn, hashValueBytes hashValueBytesFn) *sequenceChunker {
+// newSequenceChunker returns a chunker for a sequence of kind, which determines the chunking config (see chunkingConfig). For meta sequences, kind is the kind of the collection.
+func newSequenceChunker(cur *sequenceCursor, kind NomsKind, vr ValueReader, vw ValueWriter, makeChunk, parentMakeChunk makeChunkFn, hashValueBytes hashValueBytesFn) *sequenceChunker {
 	d.PanicIfFalse(makeChunk != nil)
 	d.PanicIfFalse(parentMakeChunk != nil)
 	d.PanicIfFalse(hashValueBytes != nil)
@@ -37,6 +49,7 @@ func newSequenceChunker(cur *sequenceCursor, vr ValueReader, vw ValueWriter, mak
 
 	sc := &sequenceChunker{
 		cur,
+		kind,
 		vr,
 		vw,
 		nil,
@@ -44,8 +57,12 @@ func newSequenceChunker(cur *sequenceCursor, vr ValueReader, vw ValueWriter, mak
 		makeChunk, parentMakeChunk,
 		true,
 		hashValueBytes,
-		newRollingValueHasher(),
+		getRollingValueHasher(kind),
+		nil,
 		false,
+		nil,
+		nil,
+		nil,
 	}
 
 	if cur != nil {
@@ -55,6 +72,16 @@ func newSequenceChunker(cur *sequenceCursor, vr ValueReader, vw ValueWriter, mak
 	return sc
 }
 
+// withConfig makes sc, which must be new and empty, chunk with config rather
+// than with its kind's chunking config, as must its parents.
+func (sc *sequenceChunker) withConfig(config ChunkConfig) *sequenceChunker {
+	d.PanicIfFalse(sc.cur == nil && len(sc.current) == 0 && sc.parent == nil)
+	putRollingValueHasher(sc.rv)
+	sc.rv = getRollingValueHasherWithConfig(config.pattern(), config.Window)
+	sc.config = &config
+	return sc
+}
+
 func (sc *sequenceChunker) resume() {
 	if sc.cur.parent != nil {
 		sc.createParent()
@@ -158,7 +185,10 @@ func (sc *sequenceChunker) createParent() {
 		// Clone the parent cursor because otherwise calling cur.advance() will affect our parent - and vice versa - in surprising ways. Instead, Skip moves forward our parent's cursor if we advance across a boundary.
 		parent = sc.cur.parent.clone()
 	}
-	sc.parent = newSequenceChunker(parent, sc.vr, sc.vw, sc.parentMakeChunk, sc.parentMakeChunk, metaHashValueBytes)
+	sc.parent = newSequenceChunker(parent, sc.kind, sc.vr, sc.vw, sc.parentMakeChunk, sc.parentMakeChunk, metaHashValueBytes)
+	if sc.config != nil {
+		sc.parent.withConfig(*sc.config)
+	}
 	sc.parent.isLeaf = false
 }
 
@@ -171,17 +201,35 @@ func (sc *sequenceChunker) createSequence() (sequence, metaTuple) {
 		ref = sc.vw.WriteValue(col)
 		col = nil
 	} else {
+		// Hash col with a writer which is reused for every sequence this chunker creates, rather than encoding it into a new chunk.
+		if sc.w == nil {
+			sc.w = getBinaryNomsWriter()
+		}
+		if getHashOverride == nil {
+			assignHash(col.(hashCacher), encodedHash(col, sc.w))
+		}
 		ref = NewRef(col)
 	}
 	mt := newMetaTuple(ref, key, numLeaves, col)
-
-	sc.current = []sequenceItem{}
+	sc.clearCurrent()
 	return seq, mt
 }
 
+// clearCurrent empties sc.current. makeChunk copies what it needs out of sc.current, so the slice can be reused for the next chunk. Clear it so as not to keep the old items alive.
+func (sc *sequenceChunker) clearCurrent() {
+	for i := range sc.current {
+		sc.current[i] = nil
+	}
+	sc.current = sc.current[:0]
+}
+
 func (sc *sequenceChunker) handleChunkBoundary() {
 	d.Chk.NotEmpty(sc.current)
 
+	if sc.writesConcurrently() {
+		sc.writeConcurrently()
+		return
+	}
 	_, mt := sc.createSequence()
 	if sc.parent == nil {
 		sc.createParent()
@@ -189,6 +237,71 @@ func (sc *sequenceChunker) handleChunkBoundary() {
 	sc.parent.Append(mt)
 }
 
+// writesConcurrently returns whether sc writes the sequences it makes on a codecPool, appending their metaTuples to its parent in order as the writes finish, as readBlob does for the chunks of a Blob. This is only done by the leaf chunker of a new sequence, which never touches its parent otherwise, and when sc.vw can be written to concurrently.
+func (sc *sequenceChunker) writesConcurrently() bool {
+	if !sc.isLeaf || sc.cur != nil || codecWorkers() == 1 {
+		return false
+	}
+	_, ok := sc.vw.(concurrentValueWriter)
+	return ok
+}
+
+func (sc *sequenceChunker) writeConcurrently() {
+	col, key, numLeaves := sc.makeChunk(sc.current)
+	sc.clearCurrent()
+	if sc.pool == nil {
+		sc.pool = newCodecPool(0)
+	}
+	ch := make(chan metaTuple, 1)
+	sc.writes = append(sc.writes, ch)
+	sc.pool.submit(func() {
+		defer close(ch)
+		ch <- newMetaTuple(sc.vw.WriteValue(col), key, numLeaves, nil)
+	})
+	sc.appendWritten(len(sc.writes) > 2*codecWorkers())
+}
+
+// appendWritten appends the metaTuples of the writes which have finished to sc.parent, stopping at the first which hasn't. If wait, it waits for the first.
+func (sc *sequenceChunker) appendWritten(wait bool) {
+	for len(sc.writes) > 0 {
+		var mt metaTuple
+		var ok bool
+		if wait {
+			mt, ok = <-sc.writes[0]
+			wait = false
+		} else {
+			select {
+			case mt, ok = <-sc.writes[0]:
+			default:
+				return
+			}
+		}
+		if !ok {
+			// The write panicked. Re-raise it here.
+			pool := sc.pool
+			sc.pool, sc.writes = nil, nil
+			pool.wait()
+		}
+		sc.writes[0] = nil
+		sc.writes = sc.writes[1:]
+		if sc.parent == nil {
+			sc.createParent()
+		}
+		sc.parent.Append(mt)
+	}
+}
+
+// finishWrites waits for every write started by writeConcurrently, and appends them to sc.parent.
+func (sc *sequenceChunker) finishWrites() {
+	for len(sc.writes) > 0 {
+		sc.appendWritten(true)
+	}
+	if sc.pool != nil {
+		sc.pool.wait()
+		sc.pool = nil
+	}
+}
+
 // Returns true if this chunker or any of its parents have any pending items in their |current| slice.
 func (sc *sequenceChunker) anyPending() bool {
 	if len(sc.current) > 0 {
@@ -206,16 +319,24 @@ func (sc *sequenceChunker) anyPending() bool {
 func (sc *sequenceChunker) Done() sequence {
 	d.PanicIfTrue(sc.done)
 	sc.done = true
+	defer putRollingValueHasher(sc.rv)
+	defer func() {
+		if sc.w != nil {
+			putBinaryNomsWriter(sc.w)
+		}
+	}()
 
 	if sc.cur != nil {
 		sc.finalizeCursor()
 	}
+	sc.finishWrites()
 
 	// There is pending content above us, so we must push any remaining items from this level up and allow some parent to find the root of the resulting tree.
 	if sc.parent != nil && sc.parent.anyPending() {
 		if len(sc.current) > 0 {
 			// If there are items in |current| at this point, they represent the final items of the sequence which occurred beyond the previous *explicit* chunk boundary. The end of input of a sequence is considered an *implicit* boundary.
 			sc.handleChunkBoundary()
+			sc.finishWrites()
 		}
 
 		return sc.parent.Done()
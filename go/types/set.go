@@ -8,16 +8,25 @@ import (
 	"fmt"
 	"sort"
 
+	"github.com/attic-labs/noms/go/d"
 	"github.com/attic-labs/noms/go/hash"
 )
 
 type Set struct {
-	seq orderedSequence
-	h   *hash.Hash
+	seq    orderedSequence
+	h      *hash.Hash
+	filter *KeyFilter
 }
 
 func newSet(seq orderedSequence) Set {
-	return Set{seq, &hash.Hash{}}
+	return Set{seq, &hash.Hash{}, nil}
+}
+
+// WithKeyFilter returns s with f, a KeyFilter of its values, which Has checks
+// before reading any chunks. f is carried over to the Sets returned by Insert
+// and Remove, and values Insert adds are added to it.
+func (s Set) WithKeyFilter(f *KeyFilter) Set {
+	return Set{s.seq, s.h, f}
 }
 
 func NewSet(v ...Value) Set {
@@ -44,6 +53,30 @@ func NewStreamingSet(vrw ValueReadWriter, vals <-chan Value) <-chan Set {
 	return outChan
 }
 
+// NewSetFromSortedValues creates a Set from vals, which must be in strictly
+// increasing order. The values are appended to the chunker as they're
+// received, skipping the sort NewStreamingSet does, so it's for sources which
+// are already sorted. It panics if a value isn't greater than the one before
+// it. As chunks are created, they're written to vrw, which may be nil to keep
+// them in memory.
+func NewSetFromSortedValues(vrw ValueReadWriter, vals <-chan Value) <-chan Set {
+	outChan := make(chan Set)
+	go func() {
+		defer close(outChan)
+		ch := newEmptySetSequenceChunker(vrw, vrw)
+		var last Value
+		for v := range vals {
+			if last != nil && !last.Less(v) {
+				d.Panic("Set values aren't sorted: %s isn't greater than %s", EncodedValue(v), EncodedValue(last))
+			}
+			ch.Append(v)
+			last = v
+		}
+		outChan <- newSet(ch.Done().(orderedSequence))
+	}()
+	return outChan
+}
+
 // Diff computes the diff from |last| to |m| using the top-down algorithm,
 // which completes as fast as possible while taking longer to return early
 // results than left-to-right.
@@ -151,7 +184,10 @@ func (s Set) Insert(values ...Value) Set {
 
 	var res Set
 	if cur, found := s.getCursorAtValue(head, false); !found {
-		res = s.splice(cur, 0, head)
+		if s.filter != nil {
+			s.filter.Add(head)
+		}
+		res = s.splice(cur, 0, head).WithKeyFilter(s.filter)
 	} else {
 		res = s
 	}
@@ -168,7 +204,7 @@ func (s Set) Remove(values ...Value) Set {
 
 	var res Set
 	if cur, found := s.getCursorAtValue(head, false); found {
-		res = s.splice(cur, 1)
+		res = s.splice(cur, 1).WithKeyFilter(s.filter)
 	} else {
 		res = s
 	}
@@ -177,7 +213,7 @@ func (s Set) Remove(values ...Value) Set {
 }
 
 func (s Set) splice(cur *sequenceCursor, deleteCount uint64, vs ...Value) Set {
-	ch := newSequenceChunker(cur, s.seq.valueReader(), nil, makeSetLeafChunkFn(s.seq.valueReader()), newOrderedMetaSequenceChunkFn(SetKind, s.seq.valueReader()), hashValueBytes)
+	ch := newSequenceChunker(cur, SetKind, s.seq.valueReader(), nil, makeSetLeafChunkFn(s.seq.valueReader()), newOrderedMetaSequenceChunkFn(SetKind, s.seq.valueReader()), hashValueBytes)
 	for deleteCount > 0 {
 		ch.Skip()
 		deleteCount--
@@ -198,8 +234,15 @@ func (s Set) getCursorAtValue(v Value, readAhead bool) (cur *sequenceCursor, fou
 }
 
 func (s Set) Has(v Value) bool {
-	cur := newCursorAtValue(s.seq, v, false, false, false)
-	return cur.valid() && cur.current().(Value).Equals(v)
+	if !keyFilterHas(s.filter, v) {
+		return false
+	}
+	return orderedSequenceHas(s.seq, newOrderedKey(v))
+}
+
+// CountRange returns the number of values v in s such that from <= v < to. Only the chunks on the paths to from and to are loaded.
+func (s Set) CountRange(from, to Value) uint64 {
+	return orderedSequenceCountRange(s.seq, newOrderedKey(from), newOrderedKey(to))
 }
 
 type setIterCallback func(v Value) bool
@@ -277,5 +320,5 @@ func makeSetLeafChunkFn(vr ValueReader) makeChunkFn {
 }
 
 func newEmptySetSequenceChunker(vr ValueReader, vw ValueWriter) *sequenceChunker {
-	return newEmptySequenceChunker(vr, vw, makeSetLeafChunkFn(vr), newOrderedMetaSequenceChunkFn(SetKind, vr), hashValueBytes)
+	return newEmptySequenceChunker(SetKind, vr, vw, makeSetLeafChunkFn(vr), newOrderedMetaSequenceChunkFn(SetKind, vr), hashValueBytes)
 }
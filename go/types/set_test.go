@@ -171,6 +171,23 @@ func (suite *setTestSuite) createStreamingSet(vs *ValueStore) {
 	suite.True(suite.validate(<-setChan))
 }
 
+func (suite *setTestSuite) TestSetFromSortedValues() {
+	vs := NewTestValueStore()
+	defer vs.Close()
+
+	sorted := make(ValueSlice, len(suite.elems))
+	copy(sorted, suite.elems)
+	sort.Sort(sorted)
+
+	vChan := make(chan Value)
+	setChan := NewSetFromSortedValues(vs, vChan)
+	for _, v := range sorted {
+		vChan <- v
+	}
+	close(vChan)
+	suite.True(suite.validate(<-setChan))
+}
+
 func (suite *setTestSuite) TestStreamingSet() {
 	vs := NewTestValueStore()
 	defer vs.Close()
@@ -1007,3 +1024,17 @@ func TestSetWithStructShouldHaveOptionalFields(t *testing.T) {
 		),
 		).Equals(TypeOf(list)))
 }
+
+func TestSetCountRange(t *testing.T) {
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	assert := assert.New(t)
+
+	s := NewSet(generateNumbersAsValues(5000)...)
+	assert.Equal(uint64(5000), s.CountRange(Number(-1), Number(5000)))
+	assert.Equal(uint64(1), s.CountRange(Number(1), Number(2)))
+	assert.Equal(uint64(2100), s.CountRange(Number(2900), Number(6000)))
+	assert.Equal(uint64(0), s.CountRange(Number(10), Number(10)))
+	assert.Equal(uint64(0), s.CountRange(String("a"), String("z")))
+}
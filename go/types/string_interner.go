@@ -0,0 +1,57 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import "sync"
+
+const (
+	// Longer strings are unlikely to repeat often enough to be worth the lookup.
+	maxInternedStringLen = 64
+	// Once this many strings have been interned, no new ones are added. The
+	// common repeated strings, like struct names and field names, tend to
+	// show up early.
+	maxInternedStrings = 1 << 16
+)
+
+// stringInterner hands out a single shared copy of each distinct short
+// string it sees, so that values decoded from many chunks don't each hold
+// their own copy of the same struct names, field names and small Strings.
+type stringInterner struct {
+	mu      sync.RWMutex
+	strings map[string]string
+}
+
+func newStringInterner() *stringInterner {
+	return &stringInterner{strings: map[string]string{}}
+}
+
+// intern returns a string equal to b, sharing its backing data with previous
+// results where possible. The caller may reuse b afterwards.
+func (si *stringInterner) intern(b []byte) string {
+	if len(b) > maxInternedStringLen {
+		return string(b)
+	}
+
+	si.mu.RLock()
+	s, ok := si.strings[string(b)] // Doesn't allocate.
+	full := len(si.strings) >= maxInternedStrings
+	si.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	s = string(b)
+	if full {
+		return s
+	}
+
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	if existing, ok := si.strings[s]; ok {
+		return existing
+	}
+	si.strings[s] = s
+	return s
+}
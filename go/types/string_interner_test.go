@@ -0,0 +1,55 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/testify/assert"
+)
+
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+func TestStringInterner(t *testing.T) {
+	assert := assert.New(t)
+	si := newStringInterner()
+
+	b := []byte("hello")
+	s1 := si.intern(b)
+	b[0] = 'j'
+	s2 := si.intern([]byte("hello"))
+	assert.Equal("hello", s1)
+	assert.Equal(stringData(s1), stringData(s2))
+	assert.Equal("jello", si.intern(b))
+
+	long := strings.Repeat("x", maxInternedStringLen+1)
+	l1, l2 := si.intern([]byte(long)), si.intern([]byte(long))
+	assert.Equal(long, l1)
+	assert.NotEqual(stringData(l1), stringData(l2))
+}
+
+func TestValueStoreInternsDecodedStrings(t *testing.T) {
+	assert := assert.New(t)
+	cs := chunks.NewTestStore()
+
+	c1 := EncodeValue(NewStruct("Row", StructData{"category": String("fruit"), "n": Number(1)}), nil)
+	c2 := EncodeValue(NewStruct("Row", StructData{"category": String("fruit"), "n": Number(2)}), nil)
+	cs.Put(c1)
+	cs.Put(c2)
+
+	vs := newLocalValueStore(cs)
+	s1 := vs.ReadValue(c1.Hash()).(Struct)
+	s2 := vs.ReadValue(c2.Hash()).(Struct)
+
+	assert.Equal(stringData(s1.name), stringData(s2.name))
+	assert.Equal(stringData(s1.fieldNames[0]), stringData(s2.fieldNames[0]))
+	assert.Equal(stringData(string(s1.Get("category").(String))), stringData(string(s2.Get("category").(String))))
+}
@@ -66,6 +66,25 @@ func NewStruct(name string, data StructData) Struct {
 	return validateStruct(newStruct(name, fieldNames, values))
 }
 
+// NormalizeStruct returns s with its fields in canonical order, sorted by
+// name, which is the order NewStruct gives them. A Struct decoded from an
+// encoding which wasn't written by this package may have them in another
+// order, which changes its hash, and stops Get() finding its fields.
+// NormalizeStruct doesn't change the structs which are the values of s's
+// fields. It panics if s has two fields with the same name.
+func NormalizeStruct(s Struct) Struct {
+	if sort.StringsAreSorted(s.fieldNames) {
+		return validateStruct(s)
+	}
+	data := make(StructData, len(s.fieldNames))
+	for i, name := range s.fieldNames {
+		_, dup := data[name]
+		d.PanicIfTrue(dup)
+		data[name] = s.values[i]
+	}
+	return NewStruct(s.name, data)
+}
+
 func (s Struct) hashPointer() *hash.Hash {
 	return s.h
 }
@@ -122,7 +141,8 @@ func (s Struct) Name() string {
 }
 
 // IterFields iterates over the fields, calling cb for every field in the
-// struct.
+// struct. The fields are always iterated in order of their names, which is
+// the order they're encoded in, so the order is stable across versions.
 func (s Struct) IterFields(cb func(name string, value Value)) {
 	for i := 0; i < len(s.fieldNames); i++ {
 		cb(s.fieldNames[i], s.values[i])
@@ -0,0 +1,73 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import "time"
+
+// A Tombstone is a Map value which marks its key as deleted, instead of
+// removing the entry. Replicas which sync a Map then see deletions as changed
+// values, e.g. in a Diff since the last sync, rather than having to compare
+// the full set of keys to find which are missing. Tombstones are dropped once
+// every replica has seen them, by CompactTombstones.
+//
+// A Tombstone is a Struct named TombstoneName with a single field,
+// deletedAt, the time of the deletion in milliseconds since the Unix epoch.
+const (
+	TombstoneName         = "Tombstone"
+	tombstoneDeletedField = "deletedAt"
+)
+
+// NewTombstone returns a Tombstone for an entry deleted at t.
+func NewTombstone(t time.Time) Struct {
+	return NewStruct(TombstoneName, StructData{
+		tombstoneDeletedField: Number(t.UnixNano() / int64(time.Millisecond)),
+	})
+}
+
+// IsTombstone returns whether v is a Tombstone, and if so, when its entry was
+// deleted.
+func IsTombstone(v Value) (deletedAt time.Time, ok bool) {
+	s, ok := v.(Struct)
+	if !ok || s.Name() != TombstoneName || s.Len() != 1 {
+		return time.Time{}, false
+	}
+	ms, ok := s.MaybeGet(tombstoneDeletedField)
+	if !ok || ms.Kind() != NumberKind {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(ms.(Number))*int64(time.Millisecond)), true
+}
+
+// Tombstone returns m with the value of key replaced by a Tombstone for a
+// deletion at t.
+func (m Map) Tombstone(key Value, t time.Time) Map {
+	return m.Set(key, NewTombstone(t))
+}
+
+// MaybeGetLive is like MaybeGet, but treats a key whose value is a Tombstone
+// as absent.
+func (m Map) MaybeGetLive(key Value) (v Value, ok bool) {
+	v, ok = m.MaybeGet(key)
+	if !ok {
+		return nil, false
+	}
+	if _, dead := IsTombstone(v); dead {
+		return nil, false
+	}
+	return v, true
+}
+
+// CompactTombstones rechunks v as RechunkValue does, and drops the entries of
+// Maps in it, however deeply nested, whose values are Tombstones for
+// deletions before cutoff. Typically cutoff is time.Now() less a TTL which
+// is longer than any replica goes without syncing, since a replica which
+// hasn't seen a Tombstone before it's dropped won't learn of the deletion.
+// Rechunking builds every collection anew, so dropping Tombstones costs
+// nothing extra.
+func CompactTombstones(vrw ValueReadWriter, v Value, config ChunkConfig, cutoff time.Time) Value {
+	r := newRechunker(vrw, config)
+	r.tombstoneCutoff = cutoff
+	return r.value(v)
+}
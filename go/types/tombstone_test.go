@@ -0,0 +1,84 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestTombstone(t *testing.T) {
+	assert := assert.New(t)
+
+	at := time.Unix(1000, int64(5*time.Millisecond))
+	deletedAt, ok := IsTombstone(NewTombstone(at))
+	assert.True(ok)
+	assert.True(at.Equal(deletedAt))
+	for _, v := range []Value{
+		Number(1),
+		NewStruct(TombstoneName, StructData{}),
+		NewStruct(TombstoneName, StructData{"deletedAt": String("x")}),
+		NewStruct("Other", StructData{"deletedAt": Number(1)}),
+	} {
+		_, ok := IsTombstone(v)
+		assert.False(ok)
+	}
+
+	m := NewMap(String("a"), Number(1), String("b"), Number(2))
+	m = m.Tombstone(String("a"), at)
+	assert.Equal(uint64(2), m.Len())
+	_, ok = m.MaybeGetLive(String("a"))
+	assert.False(ok)
+	_, ok = m.MaybeGetLive(String("c"))
+	assert.False(ok)
+	v, ok := m.MaybeGetLive(String("b"))
+	assert.True(ok)
+	assert.True(Number(2).Equals(v))
+}
+
+func TestCompactTombstones(t *testing.T) {
+	assert := assert.New(t)
+
+	start := time.Unix(1000, 0)
+	kv := []Value{}
+	for i := 0; i < 1000; i++ {
+		var v Value = Number(i)
+		if i%2 == 0 {
+			// Entries are deleted a second apart, in key order.
+			v = NewTombstone(start.Add(time.Duration(i) * time.Second))
+		}
+		kv = append(kv, Number(i), v)
+	}
+	m := NewMap(kv...)
+	nested := NewStruct("S", StructData{"m": NewList(m)})
+
+	cutoff := start.Add(500 * time.Second)
+	compact := func(v Value) Map {
+		return CompactTombstones(nil, v, DefaultChunkConfig, cutoff).(Struct).Get("m").(List).Get(0).(Map)
+	}
+	compacted := compact(nested)
+	assert.Equal(uint64(750), compacted.Len())
+	compacted.IterAll(func(k, v Value) {
+		i := int(k.(Number))
+		if deletedAt, ok := IsTombstone(v); ok {
+			assert.True(i >= 500)
+			assert.False(deletedAt.Before(cutoff))
+		} else {
+			assert.True(Number(i).Equals(v))
+		}
+	})
+
+	// Compacting is the same as removing the expired entries.
+	expected := NewMapEditor(m)
+	for i := 0; i < 500; i += 2 {
+		expected.Remove(Number(i))
+	}
+	assert.True(expected.Map().Equals(compacted))
+
+	// A zero cutoff drops nothing.
+	assert.True(nested.Equals(CompactTombstones(nil, nested, DefaultChunkConfig, time.Time{})))
+}
@@ -6,6 +6,7 @@ package types
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/attic-labs/noms/go/d"
 	"github.com/attic-labs/noms/go/hash"
@@ -15,15 +16,32 @@ type valueDecoder struct {
 	nomsReader
 	vr         ValueReader
 	validating bool
+	strings    *stringInterner
 }
 
 // |tc| must be locked as long as the valueDecoder is being used
 func newValueDecoder(nr nomsReader, vr ValueReader) *valueDecoder {
-	return &valueDecoder{nr, vr, false}
+	return &valueDecoder{nr, vr, false, internerFor(vr)}
 }
 
 func newValueDecoderWithValidation(nr nomsReader, vr ValueReader) *valueDecoder {
-	return &valueDecoder{nr, vr, true}
+	return &valueDecoder{nr, vr, true, internerFor(vr)}
+}
+
+// internerFor returns the stringInterner shared by everything decoded on
+// behalf of vr, if there is one.
+func internerFor(vr ValueReader) *stringInterner {
+	if vs, ok := vr.(*ValueStore); ok && vs != nil {
+		return vs.strings
+	}
+	return nil
+}
+
+func (r *valueDecoder) readString() string {
+	if r.strings == nil {
+		return r.nomsReader.readString()
+	}
+	return r.strings.intern(r.readStringBytes())
 }
 
 func (r *valueDecoder) readKind() NomsKind {
@@ -126,6 +144,10 @@ func (r *valueDecoder) readMetaSequence(k NomsKind) metaSequence {
 			key = newOrderedKey(v)
 		}
 		numLeaves := r.readCount()
+		if k == ListKind || k == BlobKind {
+			// The key of a tuple in an indexed sequence is its number of leaves. Len() and index math depend on numLeaves, so a mismatch means the chunk is corrupt.
+			d.PanicIfFalse(key.isOrderedByValue && key.v.Equals(Number(numLeaves)))
+		}
 		data = append(data, newMetaTuple(ref, key, numLeaves, nil))
 	}
 
@@ -196,7 +218,14 @@ func (r *valueDecoder) readStruct() Value {
 		values[i] = r.readValue()
 	}
 
-	return Struct{name, fieldNames, values, &hash.Hash{}}
+	s := Struct{name, fieldNames, values, &hash.Hash{}}
+	if r.validating {
+		if !sort.StringsAreSorted(fieldNames) {
+			d.Panic("The fields of struct %s aren't in order of their names", name)
+		}
+		validateStruct(s)
+	}
+	return s
 }
 
 func boolToUint32(b bool) uint32 {
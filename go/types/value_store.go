@@ -6,11 +6,11 @@ package types
 
 import (
 	"sync"
+	"time"
 
 	"github.com/attic-labs/noms/go/chunks"
 	"github.com/attic-labs/noms/go/d"
 	"github.com/attic-labs/noms/go/hash"
-	"github.com/attic-labs/noms/go/util/sizecache"
 )
 
 // ValueReader is an interface that knows how to read Noms Values, e.g.
@@ -30,7 +30,9 @@ type ValueWriter interface {
 
 // ValueReadWriter is an interface that knows how to read and write Noms
 // Values, e.g. datas/Database. Required to avoid import cycle between this
-// package and the package that implements Value read/writing.
+// package and the package that implements Value read/writing. Like
+// chunks.ChunkStore, it doesn't take a context.Context, so a single read or
+// write can't be cancelled once it has started.
 type ValueReadWriter interface {
 	ValueReader
 	ValueWriter
@@ -43,6 +45,12 @@ type ValueReadWriter interface {
 // Flush.
 // Currently, WriteValue validates the following properties of a Value v:
 // - v can be correctly serialized and its Ref taken
+//
+// A ValueStore is safe for concurrent use by multiple goroutines, other than
+// Close and SetMetrics, so a single one can be shared by the workers of a
+// parallel import. Its caches are sharded to keep them from contending, and
+// concurrent Flushes are coalesced into as few flushes of the BatchStore as
+// possible.
 type ValueStore struct {
 	bs                   BatchStore
 	bufferMu             sync.RWMutex
@@ -50,14 +58,19 @@ type ValueStore struct {
 	bufferedChunksMax    uint64
 	bufferedChunkSize    uint64
 	withBufferedChildren map[hash.Hash]uint64 // chunk Hash -> ref height
-	valueCache           *sizecache.SizeCache
+	valueCache           *hashCache
+	writtenHashes        *hashCache      // hashes of chunks written by this ValueStore, most recent first
+	strings              *stringInterner // shares repeated strings among decoded values
 	opcStore             opCacheStore
 	once                 sync.Once
+	metrics              ValueStoreMetrics
+	flushes              *flushGroup
 }
 
 const (
 	defaultValueCacheSize = 1 << 25 // 32MB
 	defaultPendingPutMax  = 1 << 28 // 256MB
+	writtenHashesSize     = 1 << 24 // 16MB
 )
 
 // NewTestValueStore creates a simple struct that satisfies ValueReadWriter
@@ -90,8 +103,12 @@ func newValueStoreWithCacheAndPending(bs BatchStore, cacheSize, pendingMax uint6
 		bufferedChunksMax:    pendingMax,
 		withBufferedChildren: map[hash.Hash]uint64{},
 
-		valueCache: sizecache.New(cacheSize),
-		once:       sync.Once{},
+		valueCache:    newHashCache(cacheSize),
+		writtenHashes: newHashCache(writtenHashesSize),
+		strings:       newStringInterner(),
+		once:          sync.Once{},
+		metrics:       noopMetrics{},
+		flushes:       newFlushGroup(),
 	}
 }
 
@@ -99,16 +116,41 @@ func (lvs *ValueStore) BatchStore() BatchStore {
 	return lvs.bs
 }
 
+// SetMetrics installs m to be notified of the reads, writes, cache hits and
+// decodes performed by lvs, and of requests retried by its BatchStore, if
+// that has a SetMetrics method too. Passing nil turns instrumentation off.
+// It should be called before lvs is used.
+func (lvs *ValueStore) SetMetrics(m ValueStoreMetrics) {
+	if m == nil {
+		m = noopMetrics{}
+	}
+	lvs.metrics = m
+	if ms, ok := lvs.bs.(interface {
+		SetMetrics(m ValueStoreMetrics)
+	}); ok {
+		ms.SetMetrics(m)
+	}
+}
+
+func (lvs *ValueStore) decode(c chunks.Chunk) Value {
+	start := time.Now()
+	v := DecodeValue(c, lvs)
+	lvs.metrics.Decoded(time.Since(start))
+	return v
+}
+
 // ReadValue reads and decodes a value from lvs. It is not considered an error
 // for the requested chunk to be empty; in this case, the function simply
 // returns nil.
 func (lvs *ValueStore) ReadValue(h hash.Hash) Value {
 	if v, ok := lvs.valueCache.Get(h); ok {
+		lvs.metrics.CacheHit()
 		if v == nil {
 			return nil
 		}
 		return v.(Value)
 	}
+	lvs.metrics.CacheMiss()
 
 	chunk := func() chunks.Chunk {
 		lvs.bufferMu.RLock()
@@ -119,24 +161,28 @@ func (lvs *ValueStore) ReadValue(h hash.Hash) Value {
 		return chunks.EmptyChunk
 	}()
 	if chunk.IsEmpty() {
+		start := time.Now()
 		chunk = lvs.bs.Get(h)
+		lvs.metrics.ChunkRead(uint64(len(chunk.Data())), time.Since(start))
 	}
 	if chunk.IsEmpty() {
 		lvs.valueCache.Add(h, 0, nil)
 		return nil
 	}
 
-	v := DecodeValue(chunk, lvs)
+	v := lvs.decode(chunk)
 	lvs.valueCache.Add(h, uint64(len(chunk.Data())), v)
 	return v
 }
 
 // ReadManyValues reads and decodes Values indicated by |hashes| from lvs. On
 // return, |foundValues| will have been fully sent all Values which have been
-// found. Any non-present Values will silently be ignored.
+// found. Any non-present Values will silently be ignored. The chunks read
+// from the BatchStore are decoded by up to GOMAXPROCS goroutines, so Values
+// are sent in no particular order.
 func (lvs *ValueStore) ReadManyValues(hashes hash.HashSet, foundValues chan<- Value) {
 	decode := func(h hash.Hash, chunk *chunks.Chunk, toPending bool) Value {
-		v := DecodeValue(*chunk, lvs)
+		v := lvs.decode(*chunk)
 		lvs.valueCache.Add(h, uint64(len(chunk.Data())), v)
 		return v
 	}
@@ -145,11 +191,13 @@ func (lvs *ValueStore) ReadManyValues(hashes hash.HashSet, foundValues chan<- Va
 	remaining := hash.HashSet{}
 	for h := range hashes {
 		if v, ok := lvs.valueCache.Get(h); ok {
+			lvs.metrics.CacheHit()
 			if v != nil {
 				foundValues <- v.(Value)
 			}
 			continue
 		}
+		lvs.metrics.CacheMiss()
 
 		chunk := func() chunks.Chunk {
 			lvs.bufferMu.RLock()
@@ -171,16 +219,33 @@ func (lvs *ValueStore) ReadManyValues(hashes hash.HashSet, foundValues chan<- Va
 		return
 	}
 
-	// Request remaining hashes from BatchStore, processing the found chunks as they come in.
+	// Request remaining hashes from BatchStore, decoding the found chunks in parallel as they come in.
+	readChunks := make(chan *chunks.Chunk)
 	foundChunks := make(chan *chunks.Chunk, 16)
 	foundHashes := hash.HashSet{}
 
-	go func() { lvs.bs.GetMany(remaining, foundChunks); close(foundChunks) }()
+	go func() { lvs.bs.GetMany(remaining, readChunks); close(readChunks) }()
+	go func() {
+		defer close(foundChunks)
+		for {
+			// Each chunk is charged only the time spent waiting for the BatchStore to read it, not the time spent decoding and sending the Values of the chunks before it.
+			start := time.Now()
+			c, ok := <-readChunks
+			if !ok {
+				return
+			}
+			lvs.metrics.ChunkRead(uint64(len(c.Data())), time.Since(start))
+			foundChunks <- c
+		}
+	}()
+	decoders := newCodecPool(len(remaining))
 	for c := range foundChunks {
 		h := c.Hash()
 		foundHashes[h] = struct{}{}
-		foundValues <- decode(h, c, false)
+		c := c
+		decoders.submit(func() { foundValues <- decode(h, c, false) })
 	}
+	decoders.wait()
 
 	for h := range foundHashes {
 		remaining.Remove(h) // Avoid concurrent access with the call to GetMany above
@@ -195,23 +260,59 @@ func (lvs *ValueStore) ReadManyValues(hashes hash.HashSet, foundValues chan<- Va
 // WriteValue takes a Value, schedules it to be written it to lvs, and returns
 // an appropriately-typed types.Ref. v is not guaranteed to be actually
 // written until after Flush().
+//
+// lvs remembers the hashes of recently written chunks, so writing a Value
+// again (which is common when importing repetitive data) doesn't buffer or
+// send its chunk twice. If v's hash is already known, it isn't even encoded.
 func (lvs *ValueStore) WriteValue(v Value) Ref {
 	d.PanicIfFalse(v != nil)
+	if hc, ok := v.(hashCacher); ok {
+		if h := *hc.hashPointer(); !h.IsEmpty() && lvs.wasWritten(h) {
+			lvs.metrics.DuplicateWrite()
+			return constructRef(h, TypeOf(v), maxChunkHeight(v)+1)
+		}
+	}
+
+	return lvs.bufferEncoded(v, lvs.encode(v))
+}
+
+// writesConcurrently makes ValueStore a concurrentValueWriter: WriteValue can be called by several goroutines at once.
+func (lvs *ValueStore) writesConcurrently() {}
+
+func (lvs *ValueStore) encode(v Value) chunks.Chunk {
 	// Encoding v causes any child chunks, e.g. internal nodes if v is a meta sequence, to get written. That needs to happen before we try to validate v.
+	start := time.Now()
 	c := EncodeValue(v, lvs)
+	lvs.metrics.Encoded(time.Since(start))
 	d.PanicIfTrue(c.IsEmpty())
+	return c
+}
+
+// bufferEncoded buffers c, the encoding of v, unless it's already been written, and returns a Ref to v.
+func (lvs *ValueStore) bufferEncoded(v Value, c chunks.Chunk) Ref {
 	h := c.Hash()
 	height := maxChunkHeight(v) + 1
 	r := constructRef(h, TypeOf(v), height)
+	if lvs.wasWritten(h) {
+		lvs.metrics.DuplicateWrite()
+		return r
+	}
 	if v, ok := lvs.valueCache.Get(h); ok && v != nil {
 		return r
 	}
 
 	lvs.bufferChunk(v, c, height)
+	lvs.metrics.ChunkWritten(uint64(len(c.Data())))
 	lvs.valueCache.Drop(h) // valueCache may have an entry saying h is not present. Clear that.
+	lvs.writtenHashes.Add(h, hash.ByteLen, nil)
 	return r
 }
 
+func (lvs *ValueStore) wasWritten(h hash.Hash) bool {
+	_, ok := lvs.writtenHashes.Get(h)
+	return ok
+}
+
 // bufferChunk enqueues c (which is the serialization of v) within this
 // ValueStore. Buffered chunks are flushed progressively to the underlying
 // BatchStore in a way which attempts to locate children and grandchildren
@@ -227,6 +328,10 @@ func (lvs *ValueStore) bufferChunk(v Value, c chunks.Chunk, height uint64) {
 	defer lvs.bufferMu.Unlock()
 	h := c.Hash()
 	d.PanicIfTrue(height == 0)
+	if _, ok := lvs.bufferedChunks[h]; ok {
+		// Another goroutine wrote the same Value concurrently.
+		return
+	}
 	lvs.bufferedChunks[h] = c
 	lvs.bufferedChunkSize += uint64(len(c.Data()))
 
@@ -311,17 +416,23 @@ func (lvs *ValueStore) Flush(root hash.Hash) {
 		delete(lvs.withBufferedChildren, root) // If not present, this is idempotent
 		lvs.bufferedChunkSize -= put(root, pending)
 	}()
-	lvs.bs.Flush()
+	lvs.flushes.do(lvs.bs.Flush)
 }
 
-// Close closes the underlying BatchStore
+// Close closes the underlying BatchStore. An error cleaning up the opCache
+// is returned, after the BatchStore is closed, rather than panicking.
 func (lvs *ValueStore) Close() error {
+	var opcErr error
 	if lvs.opcStore != nil {
-		err := lvs.opcStore.destroy()
-		d.Chk.NoError(err, "Attempt to clean up opCacheStore failed, error: %s\n", err)
+		if err := lvs.opcStore.destroy(); err != nil {
+			opcErr = &d.Error{Op: "clean up opCacheStore", Err: err}
+		}
 		lvs.opcStore = nil
 	}
-	return lvs.bs.Close()
+	if err := lvs.bs.Close(); err != nil {
+		return err
+	}
+	return opcErr
 }
 
 func (lvs *ValueStore) opCache() opCache {
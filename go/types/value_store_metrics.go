@@ -0,0 +1,119 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package types
+
+import (
+	"expvar"
+	"time"
+
+	"github.com/attic-labs/noms/go/util/metrics"
+)
+
+// ValueStoreMetrics receives notifications about the work a ValueStore does.
+// Implementations must be safe for concurrent use. Install one with
+// ValueStore.SetMetrics(); ExpvarMetrics is provided for exporting via
+// expvar, and other monitoring systems can be hooked up by implementing this
+// interface.
+type ValueStoreMetrics interface {
+	// CacheHit is called when a read is satisfied by the ValueStore's cache
+	// of decoded Values.
+	CacheHit()
+	// CacheMiss is called when a read has to go to buffered or stored chunks.
+	CacheMiss()
+	// ChunkRead is called for each chunk read from the underlying BatchStore.
+	// A zero size means the chunk was not present.
+	ChunkRead(size uint64, elapsed time.Duration)
+	// ChunkWritten is called for each new chunk written to the ValueStore.
+	ChunkWritten(size uint64)
+	// DuplicateWrite is called when a Value which was recently written is
+	// written again, and so isn't buffered or sent a second time.
+	DuplicateWrite()
+	// Decoded is called each time a chunk is decoded into a Value.
+	Decoded(elapsed time.Duration)
+	// Encoded is called each time a Value is encoded into a chunk.
+	Encoded(elapsed time.Duration)
+	// Retried is called each time the underlying BatchStore retries a
+	// request which failed transiently, e.g. to a remote database.
+	Retried()
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) CacheHit()                                    {}
+func (noopMetrics) CacheMiss()                                   {}
+func (noopMetrics) ChunkRead(size uint64, elapsed time.Duration) {}
+func (noopMetrics) ChunkWritten(size uint64)                     {}
+func (noopMetrics) DuplicateWrite()                              {}
+func (noopMetrics) Decoded(elapsed time.Duration)                {}
+func (noopMetrics) Encoded(elapsed time.Duration)                {}
+func (noopMetrics) Retried()                                     {}
+
+// ExpvarMetrics is a ValueStoreMetrics which keeps counters and histograms
+// that can be published with expvar. Durations are recorded in nanoseconds
+// and sizes in bytes.
+type ExpvarMetrics struct {
+	CacheHits     metrics.Counter
+	CacheMisses   metrics.Counter
+	ChunkReads    metrics.Histogram
+	ChunkReadTime metrics.Histogram
+	ChunkWrites   metrics.Histogram
+	DupWrites     metrics.Counter
+	DecodeTime    metrics.Histogram
+	EncodeTime    metrics.Histogram
+	Retries       metrics.Counter
+}
+
+// NewExpvarMetrics returns a new ExpvarMetrics. If name is non-empty, all of
+// its counters and histograms are published as an expvar.Map of that name,
+// which panics if name is already in use.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	m := &ExpvarMetrics{}
+	if name != "" {
+		em := expvar.NewMap(name)
+		em.Set("cacheHits", &m.CacheHits)
+		em.Set("cacheMisses", &m.CacheMisses)
+		em.Set("chunkReads", &m.ChunkReads)
+		em.Set("chunkReadTime", &m.ChunkReadTime)
+		em.Set("chunkWrites", &m.ChunkWrites)
+		em.Set("dupWrites", &m.DupWrites)
+		em.Set("decodeTime", &m.DecodeTime)
+		em.Set("encodeTime", &m.EncodeTime)
+		em.Set("retries", &m.Retries)
+	}
+	return m
+}
+
+func (m *ExpvarMetrics) CacheHit() {
+	m.CacheHits.Add(1)
+}
+
+func (m *ExpvarMetrics) CacheMiss() {
+	m.CacheMisses.Add(1)
+}
+
+func (m *ExpvarMetrics) ChunkRead(size uint64, elapsed time.Duration) {
+	m.ChunkReads.Sample(size)
+	m.ChunkReadTime.Sample(uint64(elapsed))
+}
+
+func (m *ExpvarMetrics) ChunkWritten(size uint64) {
+	m.ChunkWrites.Sample(size)
+}
+
+func (m *ExpvarMetrics) DuplicateWrite() {
+	m.DupWrites.Add(1)
+}
+
+func (m *ExpvarMetrics) Decoded(elapsed time.Duration) {
+	m.DecodeTime.Sample(uint64(elapsed))
+}
+
+func (m *ExpvarMetrics) Encoded(elapsed time.Duration) {
+	m.EncodeTime.Sample(uint64(elapsed))
+}
+
+func (m *ExpvarMetrics) Retried() {
+	m.Retries.Add(1)
+}
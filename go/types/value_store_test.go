@@ -5,7 +5,10 @@
 package types
 
 import (
+	"runtime"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/attic-labs/noms/go/chunks"
 	"github.com/attic-labs/noms/go/hash"
@@ -185,13 +188,18 @@ func TestTolerateTopDown(t *testing.T) {
 
 	ST := NewStruct("", StructData{"r": mlr})
 	str := vs.WriteValue(ST)
-	vs.WriteValue(S)  // S into bufferedChunks
-	vs.WriteValue(ML) // ML into bufferedChunks AND pendingParents
-	bs.expect(mlr, str)
+	vs.WriteValue(S)  // S was already written, so it isn't buffered again
+	vs.WriteValue(ML) // Nor is ML
+	bs.expect(str)
 	vs.Flush(ST.Hash())
 
-	bs.expect(sr)
-	vs.WriteValue(L)
+	// Top-down writes still work in a ValueStore which doesn't remember writing S and ML.
+	vs2 := NewValueStore(bs)
+	str = vs2.WriteValue(ST)
+	vs2.WriteValue(S)  // S into bufferedChunks
+	vs2.WriteValue(ML) // ML into bufferedChunks AND pendingParents
+	bs.expect(mlr, str)
+	vs2.Flush(ST.Hash())
 }
 
 func TestPanicOnReadBadVersion(t *testing.T) {
@@ -211,3 +219,257 @@ type badVersionStore struct {
 func (b *badVersionStore) Version() string {
 	return "BAD"
 }
+
+func TestValueStoreMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	m := NewExpvarMetrics("")
+	vs := NewTestValueStore()
+	vs.SetMetrics(m)
+
+	s := String("hello")
+	h := vs.WriteValue(s).TargetHash()
+	assert.EqualValues(1, m.EncodeTime.Count())
+	assert.EqualValues(1, m.ChunkWrites.Count())
+	vs.Flush(h)
+
+	vs2 := newLocalValueStore(vs.BatchStore().(*BatchStoreAdaptor).cs)
+	vs2.SetMetrics(m)
+	vs2.ReadValue(h)
+	assert.EqualValues(1, m.CacheMisses.Value())
+	assert.EqualValues(1, m.ChunkReads.Count())
+	assert.EqualValues(1, m.DecodeTime.Count())
+
+	vs2.ReadValue(h)
+	assert.EqualValues(1, m.CacheHits.Value())
+	assert.EqualValues(1, m.ChunkReads.Count())
+}
+
+// slowBatchStore is a BatchStore whose GetMany takes delay to find each chunk.
+type slowBatchStore struct {
+	*BatchStoreAdaptor
+	delay time.Duration
+}
+
+func (sbs slowBatchStore) GetMany(hashes hash.HashSet, foundChunks chan *chunks.Chunk) {
+	for h := range hashes {
+		time.Sleep(sbs.delay)
+		if c := sbs.Get(h); !c.IsEmpty() {
+			foundChunks <- &c
+		}
+	}
+}
+
+// readTimeMetrics sums the times of the chunks read.
+type readTimeMetrics struct {
+	noopMetrics
+	mu      sync.Mutex
+	elapsed time.Duration
+}
+
+func (m *readTimeMetrics) ChunkRead(size uint64, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.elapsed += elapsed
+}
+
+func TestValueStoreMetricsReadMany(t *testing.T) {
+	assert := assert.New(t)
+
+	cs := chunks.NewMemoryStore()
+	vs := newLocalValueStore(cs)
+	hashes := hash.HashSet{}
+	for i := 0; i < 20; i++ {
+		h := vs.WriteValue(Number(i)).TargetHash()
+		vs.Flush(h)
+		hashes.Insert(h)
+	}
+
+	// The times of the chunks of a batch add up to no more than the time the batch took.
+	m := &readTimeMetrics{}
+	vs2 := NewValueStore(slowBatchStore{NewBatchStoreAdaptor(cs).(*BatchStoreAdaptor), time.Millisecond})
+	vs2.SetMetrics(m)
+	found := make(chan Value, len(hashes))
+	start := time.Now()
+	vs2.ReadManyValues(hashes, found)
+	elapsed := time.Since(start)
+	assert.Len(found, len(hashes))
+	assert.True(m.elapsed <= elapsed, "chunk reads took %s, but the batch took %s", m.elapsed, elapsed)
+
+	// Time spent decoding and sending the Values isn't charged to the reads.
+	m = &readTimeMetrics{}
+	vs3 := NewValueStore(slowBatchStore{NewBatchStoreAdaptor(cs).(*BatchStoreAdaptor), 0})
+	vs3.SetMetrics(m)
+	found = make(chan Value)
+	consumed := make(chan time.Duration)
+	go func() {
+		start := time.Now()
+		for range found {
+			time.Sleep(5 * time.Millisecond)
+		}
+		consumed <- time.Since(start)
+	}()
+	vs3.ReadManyValues(hashes, found)
+	close(found)
+	consumerTime := <-consumed
+	assert.True(m.elapsed < consumerTime/2, "chunk reads took %s, but the Values took %s to consume", m.elapsed, consumerTime)
+}
+
+func TestValueStoreDuplicateWrites(t *testing.T) {
+	assert := assert.New(t)
+
+	m := NewExpvarMetrics("")
+	vs := NewTestValueStore()
+	vs.SetMetrics(m)
+
+	l := NewList(generateNumbersAsValues(100)...)
+	r := vs.WriteValue(l)
+	assert.EqualValues(1, m.EncodeTime.Count())
+	assert.EqualValues(1, m.ChunkWrites.Count())
+
+	// l's hash is known, so it doesn't need to be encoded again.
+	assert.Equal(r, vs.WriteValue(l))
+	assert.EqualValues(1, m.EncodeTime.Count())
+	assert.EqualValues(1, m.DupWrites.Value())
+
+	// An equal value whose hash hasn't been computed is encoded, but not written again.
+	assert.Equal(r, vs.WriteValue(NewList(generateNumbersAsValues(100)...)))
+	assert.EqualValues(2, m.EncodeTime.Count())
+	assert.EqualValues(1, m.ChunkWrites.Count())
+	assert.EqualValues(2, m.DupWrites.Value())
+
+	// Still true after the chunk has been flushed out of the buffer.
+	vs.Flush(r.TargetHash())
+	assert.Equal(r, vs.WriteValue(l))
+	assert.EqualValues(1, m.ChunkWrites.Count())
+	assert.EqualValues(3, m.DupWrites.Value())
+	assert.True(vs.ReadValue(r.TargetHash()).Equals(l))
+}
+
+func TestValueStoreConcurrentUse(t *testing.T) {
+	assert := assert.New(t)
+
+	cs := chunks.NewMemoryStore()
+	vs := newValueStoreWithCacheAndPending(NewBatchStoreAdaptor(cs), 1<<20, 1<<12)
+	vs.SetMetrics(NewExpvarMetrics(""))
+
+	const workers, lists = 8, 20
+	roots := make([][]hash.Hash, workers)
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < lists; i++ {
+				// Workers write some of the same lists as each other, as well as their own.
+				l := NewList(generateNumbersAsValues(100 + i)...).Append(Number(w % 2))
+				h := vs.WriteValue(NewList(vs.WriteValue(l))).TargetHash()
+				roots[w] = append(roots[w], h)
+				vs.Flush(h)
+				assert.NotNil(vs.ReadValue(h))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	vs = newLocalValueStore(cs)
+	for w := range roots {
+		for i, h := range roots[w] {
+			expected := NewList(generateNumbersAsValues(100 + i)...).Append(Number(w % 2))
+			v := vs.ReadValue(h).(List).Get(0).(Ref).TargetValue(vs)
+			assert.True(expected.Equals(v))
+		}
+	}
+}
+
+func TestValueStoreReadManyValuesParallel(t *testing.T) {
+	assert := assert.New(t)
+
+	vs := NewTestValueStore()
+	refs := []Ref{}
+	for i := 0; i < 100; i++ {
+		refs = append(refs, vs.WriteValue(NewStruct("S", StructData{"n": Number(i), "l": NewList(generateNumbersAsValues(i)...)})))
+	}
+
+	hashes := hash.HashSet{}
+	for _, r := range refs {
+		vs.Flush(r.TargetHash())
+		hashes.Insert(r.TargetHash())
+	}
+
+	// The values are decoded in parallel by a ValueStore whose caches are empty.
+	vs2 := newLocalValueStore(vs.BatchStore().(*BatchStoreAdaptor).cs)
+	foundValues := make(chan Value, len(hashes))
+	vs2.ReadManyValues(hashes, foundValues)
+	close(foundValues)
+	found := 0
+	for v := range foundValues {
+		assert.True(hashes.Has(v.Hash()))
+		found++
+	}
+	assert.Equal(len(hashes), found)
+}
+
+func TestValueStoreWritesChunksConcurrently(t *testing.T) {
+	assert := assert.New(t)
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	vs := NewTestValueStore()
+	values := generateNumbersAsValues(50000)
+	ch := newEmptyListSequenceChunker(vs, vs)
+	assert.True(ch.writesConcurrently())
+	for _, v := range values {
+		ch.Append(v)
+	}
+	l := newList(ch.Done())
+
+	// The List is the same as one built without writing any chunks, and its chunks were all written.
+	expected := NewList(values...)
+	assert.True(expected.Equals(l))
+	assert.True(len(l.sequence().(metaSequence).tuples) > 1)
+	h := vs.WriteValue(l).TargetHash()
+	vs.Flush(h)
+	vs2 := newLocalValueStore(vs.BatchStore().(*BatchStoreAdaptor).cs)
+	assert.True(expected.Equals(vs2.ReadValue(h)))
+}
+
+// panickingValueWriter is a concurrentValueWriter which panics on every write.
+type panickingValueWriter struct{}
+
+func (panickingValueWriter) WriteValue(v Value) Ref { panic("boom") }
+func (panickingValueWriter) writesConcurrently()    {}
+
+func TestSequenceChunkerWritePanics(t *testing.T) {
+	assert := assert.New(t)
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	ch := newEmptyListSequenceChunker(nil, panickingValueWriter{})
+	assert.Panics(func() {
+		for _, v := range generateNumbersAsValues(50000) {
+			ch.Append(v)
+		}
+		ch.Done()
+	})
+}
+
+func TestCodecPoolPanics(t *testing.T) {
+	assert := assert.New(t)
+
+	done := make([]bool, 100)
+	assert.Panics(func() {
+		p := newCodecPool(len(done))
+		for i := range done {
+			i := i
+			p.submit(func() {
+				done[i] = true
+				if i == 50 {
+					panic("boom")
+				}
+			})
+		}
+		p.wait()
+	})
+	for _, d := range done {
+		assert.True(d)
+	}
+}
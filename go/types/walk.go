@@ -96,6 +96,95 @@ func WalkValues(target Value, vr ValueReader, cb SkipValueCallback) {
 	}
 }
 
+// WalkAction tells Walk how to continue after a Visitor visits a value.
+type WalkAction int
+
+const (
+	// WalkChildren continues the walk into the children of the value.
+	WalkChildren WalkAction = iota
+	// SkipChildren continues the walk, but not into the children of the
+	// value.
+	SkipChildren
+	// StopWalk ends the walk.
+	StopWalk
+)
+
+// A Visitor is called by Walk with each value it reaches, and the Path from
+// the root of the walk to it.
+type Visitor func(p Path, v Value) WalkAction
+
+// Walk calls visit on target, then on its children, depth first and in
+// order: the items of Lists and Sets, the keys and values of Maps, the fields
+// of Structs, and the targets of Refs. Each is passed the Path which resolves
+// to it from target, so a Visitor can tell where it is, e.g. to only look at
+// the values of a certain field. The targets of Refs are read from vr, and
+// only visited the first time they're reached, so that shared history isn't
+// walked again. If vr is nil, Refs aren't followed.
+//
+// Unlike WalkValues, Walk can stop part way, and visits the values of chunked
+// collections one chunk at a time, rather than reading many chunks at once.
+func Walk(target Value, vr ValueReader, visit Visitor) {
+	w := walker{vr, visit, hash.HashSet{}}
+	w.walk(Path{}, target)
+}
+
+type walker struct {
+	vr      ValueReader
+	visit   Visitor
+	targets hash.HashSet
+}
+
+// walk visits v and its children, and returns whether the walk was stopped.
+func (w walker) walk(p Path, v Value) (stop bool) {
+	switch w.visit(p, v) {
+	case StopWalk:
+		return true
+	case SkipChildren:
+		return false
+	}
+
+	switch v := v.(type) {
+	case List:
+		v.Iter(func(item Value, idx uint64) bool {
+			stop = w.walk(p.Append(NewIndexPath(Number(idx))), item)
+			return stop
+		})
+	case Set:
+		v.Iter(func(item Value) bool {
+			stop = w.walk(p.Append(itemPath(item, false)), item)
+			return stop
+		})
+	case Map:
+		v.Iter(func(key, value Value) bool {
+			stop = w.walk(p.Append(itemPath(key, true)), key) ||
+				w.walk(p.Append(itemPath(key, false)), value)
+			return stop
+		})
+	case Struct:
+		v.IterFields(func(name string, field Value) {
+			stop = stop || w.walk(p.Append(NewFieldPath(name)), field)
+		})
+	case Ref:
+		if w.vr == nil || w.targets.Has(v.TargetHash()) {
+			return false
+		}
+		w.targets.Insert(v.TargetHash())
+		if target := v.TargetValue(w.vr); target != nil {
+			return w.walk(p.Append(TargetAnnotation{}), target)
+		}
+	}
+	return
+}
+
+// itemPath returns the PathPart which resolves to the Set item or Map key k,
+// or, if intoKey is false, the Map value whose key is k.
+func itemPath(k Value, intoKey bool) PathPart {
+	if ValueCanBePathIndex(k) {
+		return newIndexPath(k, intoKey)
+	}
+	return newHashIndexPath(k.Hash(), intoKey)
+}
+
 func mightContainStructs(t *Type) (mightHaveStructs bool) {
 	if t.TargetKind() == StructKind || t.TargetKind() == ValueKind {
 		mightHaveStructs = true
@@ -10,6 +10,7 @@ import (
 
 	"github.com/attic-labs/noms/go/chunks"
 	"github.com/attic-labs/noms/go/hash"
+	"github.com/attic-labs/testify/assert"
 	"github.com/attic-labs/testify/suite"
 )
 
@@ -373,3 +374,49 @@ func (suite *WalkDifferentStructsTestSuite) TestWalkStructsBasic() {
 	suite.AssertDiffs(nil, l2, []Value{s6, s7, s2, s3}, []Value{})
 	suite.AssertDiffs(l1, l2, []Value{s7, s3}, []Value{s5, s1})
 }
+
+func TestWalkVisitor(t *testing.T) {
+	assert := assert.New(t)
+	vs := NewTestValueStore()
+
+	shared := NewStruct("S", StructData{"n": Number(1)})
+	ref := vs.WriteValue(NewList(String("x")))
+	root := NewStruct("Root", StructData{
+		"list": NewList(Number(0), shared),
+		"map":  NewMap(String("k"), shared, shared, Bool(true)),
+		"ref":  ref,
+		"ref2": ref,
+		"skip": NewList(Number(42)),
+	})
+
+	paths := []string{}
+	Walk(root, vs, func(p Path, v Value) WalkAction {
+		paths = append(paths, p.String())
+		// Each path resolves to the value it was passed with.
+		assert.True(v.Equals(p.Resolve(root, vs)), p.String())
+		if p.String() == ".skip" {
+			return SkipChildren
+		}
+		return WalkChildren
+	})
+	sh := "#" + shared.Hash().String()
+	assert.Equal([]string{
+		"",
+		".list", ".list[0]", ".list[1]", ".list[1].n",
+		".map", `.map["k"]@key`, `.map["k"]`, ".map[\"k\"].n", ".map[" + sh + "]@key", ".map[" + sh + "]@key.n", ".map[" + sh + "]",
+		".ref", ".ref@target", ".ref@target[0]",
+		".ref2",
+		".skip",
+	}, paths)
+
+	// Stopping ends the walk, however deep it is.
+	count := 0
+	Walk(root, nil, func(p Path, v Value) WalkAction {
+		count++
+		if v.Equals(Number(1)) {
+			return StopWalk
+		}
+		return WalkChildren
+	})
+	assert.Equal(5, count)
+}
@@ -0,0 +1,109 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Package metrics provides simple, goroutine-safe counters and histograms that can be exported via expvar.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing count. It implements expvar.Var.
+type Counter struct {
+	n int64
+}
+
+func (c *Counter) Add(delta int64) {
+	atomic.AddInt64(&c.n, delta)
+}
+
+func (c *Counter) Value() int64 {
+	return atomic.LoadInt64(&c.n)
+}
+
+func (c *Counter) String() string {
+	return fmt.Sprintf("%d", c.Value())
+}
+
+// numBuckets is enough power-of-two buckets to cover any uint64 sample.
+const numBuckets = 65
+
+// Histogram records the distribution of uint64 samples (e.g. sizes or durations in nanoseconds) in power-of-two buckets: bucket i holds samples in [2^(i-1), 2^i), and bucket 0 holds zeros. It implements expvar.Var.
+type Histogram struct {
+	mu      sync.Mutex
+	count   uint64
+	sum     uint64
+	buckets [numBuckets]uint64
+}
+
+func (h *Histogram) Sample(v uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.count++
+	h.sum += v
+	h.buckets[bucketIndex(v)]++
+}
+
+func bucketIndex(v uint64) int {
+	i := 0
+	for v > 0 {
+		v >>= 1
+		i++
+	}
+	return i
+}
+
+// Count returns the number of samples recorded.
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// Sum returns the total of all samples recorded.
+func (h *Histogram) Sum() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.sum
+}
+
+// Mean returns the average of all samples recorded, or 0 if there are none.
+func (h *Histogram) Mean() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.count == 0 {
+		return 0
+	}
+	return float64(h.sum) / float64(h.count)
+}
+
+// String returns a JSON object with the count, sum and non-empty buckets, keyed by their exclusive upper bound.
+func (h *Histogram) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, `{"count": %d, "sum": %d, "buckets": {`, h.count, h.sum)
+	first := true
+	for i, n := range h.buckets {
+		if n == 0 {
+			continue
+		}
+		if !first {
+			buf.WriteString(", ")
+		}
+		first = false
+		upper := "1"
+		if i == numBuckets-1 {
+			upper = "+Inf"
+		} else if i > 0 {
+			upper = fmt.Sprintf("%d", uint64(1)<<uint(i))
+		}
+		fmt.Fprintf(buf, `"%s": %d`, upper, n)
+	}
+	buf.WriteString("}}")
+	return buf.String()
+}
@@ -0,0 +1,41 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package metrics
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestCounter(t *testing.T) {
+	assert := assert.New(t)
+	c := &Counter{}
+	c.Add(1)
+	c.Add(41)
+	assert.EqualValues(42, c.Value())
+	assert.Equal("42", c.String())
+}
+
+func TestHistogram(t *testing.T) {
+	assert := assert.New(t)
+	h := &Histogram{}
+	assert.Equal(0.0, h.Mean())
+
+	for _, v := range []uint64{0, 1, 2, 3, 4, 1 << 63} {
+		h.Sample(v)
+	}
+	assert.EqualValues(6, h.Count())
+	assert.Equal(uint64(10+(1<<63)), h.Sum())
+
+	parsed := struct {
+		Count   uint64
+		Buckets map[string]uint64
+	}{}
+	assert.NoError(json.Unmarshal([]byte(h.String()), &parsed))
+	assert.EqualValues(6, parsed.Count)
+	assert.Equal(map[string]uint64{"1": 1, "2": 1, "4": 2, "8": 1, "+Inf": 1}, parsed.Buckets)
+}
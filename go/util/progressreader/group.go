@@ -0,0 +1,75 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package progressreader
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/attic-labs/noms/go/util/status"
+)
+
+// Group aggregates the progress of several Readers, e.g. ones being consumed by parallel workers, into a single Callback. It is safe to read from the Readers of a Group concurrently.
+type Group struct {
+	mu       *sync.Mutex
+	seen     uint64
+	lastTime time.Time
+	cb       Callback
+}
+
+// NewGroup returns a Group which calls cb with the total number of bytes read across all of its Readers, at most once every status.Rate.
+func NewGroup(cb Callback) *Group {
+	return &Group{&sync.Mutex{}, uint64(0), time.Time{}, cb}
+}
+
+// NewGroupWithTotal is like NewGroup, but cb receives a Progress computed against total, the number of bytes all of the Group's Readers are expected to produce together.
+func NewGroupWithTotal(total uint64, cb ProgressCallback) *Group {
+	start := time.Now()
+	return NewGroup(func(seen uint64) {
+		cb(Progress{seen, total, time.Since(start)})
+	})
+}
+
+// Add returns a Reader that reads from inner and counts the bytes read towards the Group's total.
+func (g *Group) Add(inner io.Reader) io.Reader {
+	return &groupReader{inner, g}
+}
+
+// Seen returns the total number of bytes read so far by all Readers in the Group.
+func (g *Group) Seen() uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.seen
+}
+
+// Done calls the Group's callback with the final total. Call it once all the Readers are finished.
+func (g *Group) Done() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.cb(g.seen)
+	g.lastTime = time.Now()
+}
+
+func (g *Group) add(n int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.seen += uint64(n)
+	if now := time.Now(); now.Sub(g.lastTime) >= status.Rate {
+		g.cb(g.seen)
+		g.lastTime = now
+	}
+}
+
+type groupReader struct {
+	inner io.Reader
+	g     *Group
+}
+
+func (r *groupReader) Read(p []byte) (n int, err error) {
+	n, err = r.inner.Read(p)
+	r.g.add(n)
+	return
+}
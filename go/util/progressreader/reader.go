@@ -14,10 +14,55 @@ import (
 
 type Callback func(seen uint64)
 
+// Progress describes how much of a Reader's expected total has been read.
+type Progress struct {
+	// Seen is the number of bytes read so far.
+	Seen uint64
+	// Total is the number of bytes expected, or 0 if unknown.
+	Total uint64
+	// Elapsed is the time since the Reader was created.
+	Elapsed time.Duration
+}
+
+// Rate returns the average number of bytes read per second.
+func (p Progress) Rate() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Seen) / p.Elapsed.Seconds()
+}
+
+// Percent returns how much of Total has been read, from 0 to 100. It returns 0 if Total is unknown.
+func (p Progress) Percent() float64 {
+	if p.Total == 0 {
+		return 0
+	}
+	return float64(p.Seen) / float64(p.Total) * 100
+}
+
+// ETA estimates the time remaining until Total bytes have been read, assuming the average rate so far holds. It returns 0 if Total is unknown or nothing has been read yet.
+func (p Progress) ETA() time.Duration {
+	rate := p.Rate()
+	if p.Total == 0 || rate == 0 || p.Seen >= p.Total {
+		return 0
+	}
+	return time.Duration(float64(p.Total-p.Seen) / rate * float64(time.Second))
+}
+
+type ProgressCallback func(p Progress)
+
 func New(inner io.Reader, cb Callback) io.Reader {
 	return &reader{inner, uint64(0), time.Time{}, cb}
 }
 
+// NewWithTotal is like New, but the callback receives a Progress computed against total, the number of bytes inner is expected to produce. Pass 0 if it isn't known.
+func NewWithTotal(inner io.Reader, total uint64, cb ProgressCallback) io.Reader {
+	start := time.Now()
+	return New(inner, func(seen uint64) {
+		cb(Progress{seen, total, time.Since(start)})
+	})
+}
+
 type reader struct {
 	inner    io.Reader
 	seen     uint64
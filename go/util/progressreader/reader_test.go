@@ -0,0 +1,88 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package progressreader
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	assert := assert.New(t)
+
+	p := Progress{Seen: 25, Total: 100, Elapsed: 5 * time.Second}
+	assert.Equal(5.0, p.Rate())
+	assert.Equal(25.0, p.Percent())
+	assert.Equal(15*time.Second, p.ETA())
+
+	unknown := Progress{Seen: 25, Elapsed: 5 * time.Second}
+	assert.Equal(0.0, unknown.Percent())
+	assert.Equal(time.Duration(0), unknown.ETA())
+
+	assert.Equal(0.0, Progress{}.Rate())
+}
+
+func TestNewWithTotal(t *testing.T) {
+	assert := assert.New(t)
+
+	var last Progress
+	r := NewWithTotal(bytes.NewBufferString("hello"), 5, func(p Progress) { last = p })
+	data, err := ioutil.ReadAll(r)
+	assert.NoError(err)
+	assert.Equal("hello", string(data))
+	assert.EqualValues(5, last.Seen)
+	assert.EqualValues(5, last.Total)
+	assert.Equal(100.0, last.Percent())
+}
+
+func TestGroup(t *testing.T) {
+	assert := assert.New(t)
+
+	var last uint64
+	g := NewGroup(func(seen uint64) { last = seen })
+
+	inputs := []string{"abc", "defgh", "", "ij"}
+	wg := &sync.WaitGroup{}
+	for _, in := range inputs {
+		wg.Add(1)
+		go func(r io.Reader) {
+			defer wg.Done()
+			ioutil.ReadAll(r)
+		}(g.Add(bytes.NewBufferString(in)))
+	}
+	wg.Wait()
+	g.Done()
+
+	assert.EqualValues(10, g.Seen())
+	assert.EqualValues(10, last)
+}
+
+func TestThrottle(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Unix(0, 0)
+	slept := time.Duration(0)
+	clock := func() time.Time { return now }
+	sleep := func(d time.Duration) {
+		slept += d
+		now = now.Add(d)
+	}
+
+	// 10 bytes/sec, so reading 35 bytes takes 2.5s once the initial 10 byte burst is used up.
+	r := newThrottledReader(bytes.NewReader(make([]byte, 35)), 10, clock, sleep)
+	data, err := ioutil.ReadAll(r)
+	assert.NoError(err)
+	assert.Len(data, 35)
+	assert.InDelta(2.5, slept.Seconds(), 0.01)
+
+	in := bytes.NewBufferString("unthrottled")
+	assert.Equal(in, Throttle(in, 0))
+}
@@ -0,0 +1,61 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package progressreader
+
+import (
+	"io"
+	"time"
+)
+
+// Throttle returns an io.Reader that reads from inner no faster than bytesPerSec on average. It uses a token bucket holding up to one second's worth of bytes, so short bursts may exceed the rate. If bytesPerSec is 0, inner is returned unchanged.
+func Throttle(inner io.Reader, bytesPerSec uint64) io.Reader {
+	if bytesPerSec == 0 {
+		return inner
+	}
+	return newThrottledReader(inner, bytesPerSec, time.Now, time.Sleep)
+}
+
+type throttledReader struct {
+	inner  io.Reader
+	rate   float64 // bytes per second
+	burst  int
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+	sleep  func(time.Duration)
+}
+
+func newThrottledReader(inner io.Reader, bytesPerSec uint64, now func() time.Time, sleep func(time.Duration)) *throttledReader {
+	burst := int(bytesPerSec)
+	if uint64(burst) != bytesPerSec || burst < 0 {
+		burst = int(^uint(0) >> 1)
+	}
+	// Start with a full bucket.
+	return &throttledReader{inner, float64(bytesPerSec), burst, float64(bytesPerSec), now(), now, sleep}
+}
+
+func (r *throttledReader) refill() {
+	now := r.now()
+	r.tokens += now.Sub(r.last).Seconds() * r.rate
+	if r.tokens > float64(r.burst) {
+		r.tokens = float64(r.burst)
+	}
+	r.last = now
+}
+
+func (r *throttledReader) Read(p []byte) (n int, err error) {
+	if len(p) > r.burst {
+		p = p[:r.burst]
+	}
+	n, err = r.inner.Read(p)
+	// Pay for what was actually read, waiting out any debt before returning.
+	r.refill()
+	r.tokens -= float64(n)
+	if r.tokens < 0 {
+		r.sleep(time.Duration(-r.tokens / r.rate * float64(time.Second)))
+		r.refill()
+	}
+	return
+}
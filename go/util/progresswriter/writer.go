@@ -0,0 +1,46 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Package progresswriter provides an io.WriteCloser that reports progress to a callback
+package progresswriter
+
+import (
+	"io"
+	"time"
+
+	"github.com/attic-labs/noms/go/util/status"
+)
+
+type Callback func(seen uint64)
+
+// New returns an io.WriteCloser that writes to inner, calling cb with the total number of bytes written so far at most once every status.Rate. Close always calls cb with the final total, and then closes inner if it is an io.Closer.
+func New(inner io.Writer, cb Callback) io.WriteCloser {
+	return &writer{inner, uint64(0), time.Time{}, cb}
+}
+
+type writer struct {
+	inner    io.Writer
+	seen     uint64
+	lastTime time.Time
+	cb       Callback
+}
+
+func (w *writer) Write(p []byte) (n int, err error) {
+	n, err = w.inner.Write(p)
+	w.seen += uint64(n)
+
+	if now := time.Now(); now.Sub(w.lastTime) >= status.Rate {
+		w.cb(w.seen)
+		w.lastTime = now
+	}
+	return
+}
+
+func (w *writer) Close() error {
+	w.cb(w.seen)
+	if c, ok := w.inner.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package progresswriter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/attic-labs/testify/assert"
+)
+
+type closeRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (cr *closeRecorder) Close() error {
+	cr.closed = true
+	return nil
+}
+
+func TestWriter(t *testing.T) {
+	assert := assert.New(t)
+
+	reported := []uint64{}
+	inner := &closeRecorder{}
+	w := New(inner, func(seen uint64) { reported = append(reported, seen) })
+
+	w.Write([]byte("abc"))
+	w.Write([]byte("de"))
+	// The first write always reports, the second is too soon after it.
+	assert.Equal([]uint64{3}, reported)
+
+	assert.NoError(w.Close())
+	assert.Equal([]uint64{3, 5}, reported)
+	assert.Equal("abcde", inner.String())
+	assert.True(inner.closed)
+}
@@ -0,0 +1,96 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Package sectionreader provides an io.ReadSeeker over a byte range of another io.ReadSeeker, e.g. a file or a types.BlobReader. It is like io.SectionReader, but doesn't require io.ReaderAt. To read several sections in parallel, give each its own underlying io.ReadSeeker.
+package sectionreader
+
+import (
+	"errors"
+	"io"
+)
+
+// Reader reads the n bytes starting at off from an underlying io.ReadSeeker.
+type Reader struct {
+	inner  io.ReadSeeker
+	off, n int64
+	pos    int64 // relative to off
+	seeked bool  // whether inner is positioned at off+pos
+}
+
+// New returns a Reader that reads the n bytes of inner starting at off. The position of inner is not changed until the first Read.
+func New(inner io.ReadSeeker, off, n int64) *Reader {
+	return &Reader{inner, off, n, 0, false}
+}
+
+// Size returns the size of the section in bytes.
+func (r *Reader) Size() int64 {
+	return r.n
+}
+
+func (r *Reader) Read(p []byte) (n int, err error) {
+	if r.pos >= r.n {
+		return 0, io.EOF
+	}
+	if !r.seeked {
+		if _, err = r.inner.Seek(r.off+r.pos, 0); err != nil {
+			return 0, err
+		}
+		r.seeked = true
+	}
+	if max := r.n - r.pos; int64(len(p)) > max {
+		p = p[:max]
+	}
+	n, err = r.inner.Read(p)
+	r.pos += int64(n)
+	if err == io.EOF && r.pos < r.n {
+		err = io.ErrUnexpectedEOF
+	}
+	return
+}
+
+// Seek sets the position for the next Read relative to the start of the section. Seeking past the end of the section is allowed; subsequent Reads return io.EOF.
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	abs := r.pos
+	switch whence {
+	case 0:
+		abs = offset
+	case 1:
+		abs += offset
+	case 2:
+		abs = r.n + offset
+	default:
+		return 0, errors.New("sectionreader.Reader.Seek: invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("sectionreader.Reader.Seek: negative position")
+	}
+	if abs != r.pos {
+		r.pos = abs
+		r.seeked = false
+	}
+	return abs, nil
+}
+
+// Section is a byte range [Off, Off+N).
+type Section struct {
+	Off, N int64
+}
+
+// Split divides size bytes into at most n contiguous Sections of nearly equal length, for reading in parallel.
+func Split(size int64, n int) []Section {
+	if n < 1 {
+		n = 1
+	}
+	if size < int64(n) {
+		n = int(size)
+	}
+	sections := make([]Section, 0, n)
+	off := int64(0)
+	for i := 0; i < n; i++ {
+		end := size * int64(i+1) / int64(n)
+		sections = append(sections, Section{off, end - off})
+		off = end
+	}
+	return sections
+}
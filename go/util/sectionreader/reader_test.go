@@ -0,0 +1,79 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package sectionreader
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/progressreader"
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestReader(t *testing.T) {
+	assert := assert.New(t)
+
+	inner := bytes.NewReader([]byte("0123456789"))
+	r := New(inner, 2, 5)
+	assert.EqualValues(5, r.Size())
+
+	data, err := ioutil.ReadAll(r)
+	assert.NoError(err)
+	assert.Equal("23456", string(data))
+
+	pos, err := r.Seek(1, 0)
+	assert.NoError(err)
+	assert.EqualValues(1, pos)
+	buf := make([]byte, 2)
+	_, err = io.ReadFull(r, buf)
+	assert.NoError(err)
+	assert.Equal("34", string(buf))
+
+	pos, err = r.Seek(-1, 2)
+	assert.NoError(err)
+	assert.EqualValues(4, pos)
+	data, err = ioutil.ReadAll(r)
+	assert.NoError(err)
+	assert.Equal("6", string(data))
+
+	_, err = r.Seek(-1, 0)
+	assert.Error(err)
+}
+
+func TestReaderShortInput(t *testing.T) {
+	r := New(bytes.NewReader([]byte("0123")), 2, 5)
+	_, err := ioutil.ReadAll(r)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestReaderBlobWithProgress(t *testing.T) {
+	assert := assert.New(t)
+
+	data := make([]byte, 1<<16)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	blob := types.NewBlob(bytes.NewReader(data))
+
+	seen := uint64(0)
+	for _, s := range Split(int64(len(data)), 3) {
+		r := progressreader.New(New(blob.Reader(), s.Off, s.N), func(n uint64) { seen = n })
+		got, err := ioutil.ReadAll(r)
+		assert.NoError(err)
+		assert.Equal(data[s.Off:s.Off+s.N], got)
+		assert.EqualValues(s.N, seen)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal([]Section{{0, 3}, {3, 3}, {6, 4}}, Split(10, 3))
+	assert.Equal([]Section{{0, 1}, {1, 1}}, Split(2, 5))
+	assert.Equal([]Section{{0, 10}}, Split(10, 0))
+	assert.Equal([]Section{}, Split(0, 4))
+}
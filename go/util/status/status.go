@@ -3,51 +3,165 @@
 // http://www.apache.org/licenses/LICENSE-2.0
 
 // Package status prints status messages to a console, overwriting previous values.
+//
+// When stdout isn't a terminal, messages are instead printed as plain lines at most once every PlainRate. Messages can also be printed as one JSON object per line, for consumption by other programs. See RegisterStatusFlags and SetFormat.
 package status
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"time"
+
+	flag "github.com/juju/gnuflag"
+	goisatty "github.com/mattn/go-isatty"
 )
 
 const (
 	clearLine = "\x1b[2K\r"
 	Rate      = 100 * time.Millisecond
+	PlainRate = time.Second
+)
+
+// Format is how status messages are printed.
+type Format string
+
+const (
+	// FormatAuto uses FormatTTY if stdout is a terminal and FormatPlain otherwise.
+	FormatAuto Format = "auto"
+	// FormatTTY overwrites the previous message on the current line.
+	FormatTTY Format = "tty"
+	// FormatPlain prints each message on its own line, at most once every PlainRate.
+	FormatPlain Format = "plain"
+	// FormatJSON prints each message as a JSON object on its own line, at most once every PlainRate.
+	FormatJSON Format = "json"
 )
 
 var (
 	lastTime   time.Time
 	lastFormat string
 	lastArgs   []interface{}
+
+	statusFormat = FormatAuto
+	out          io.Writer
 )
 
+// jsonStatus is what's printed for each message in FormatJSON.
+type jsonStatus struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+	Done    bool      `json:"done,omitempty"`
+}
+
+// RegisterStatusFlags registers the --status-format flag. An unknown format is a usage error when the flags are parsed.
+func RegisterStatusFlags(flags *flag.FlagSet) {
+	flags.Var(&statusFormat, "status-format", "how to print progress: auto, tty, plain or json")
+}
+
+// String implements flag.Value.
+func (f *Format) String() string {
+	return string(*f)
+}
+
+// Set implements flag.Value, rejecting unknown formats.
+func (f *Format) Set(s string) error {
+	switch v := Format(s); v {
+	case FormatAuto, FormatTTY, FormatPlain, FormatJSON:
+		*f = v
+		return nil
+	default:
+		return fmt.Errorf("unknown status format %q, expected auto, tty, plain or json", s)
+	}
+}
+
+// SetFormat sets how status messages are printed.
+func SetFormat(f Format) {
+	statusFormat = f
+}
+
+// SetOutput sets where status messages are printed. If w is nil, which is the default, they're printed to os.Stdout.
+func SetOutput(w io.Writer) {
+	out = w
+}
+
+func output() io.Writer {
+	if out == nil {
+		return os.Stdout
+	}
+	return out
+}
+
+func currentFormat() Format {
+	switch f := statusFormat; f {
+	case FormatTTY, FormatPlain, FormatJSON:
+		return f
+	case FormatAuto:
+		if f, ok := output().(*os.File); ok && goisatty.IsTerminal(f.Fd()) {
+			return FormatTTY
+		}
+		return FormatPlain
+	default:
+		panic(fmt.Sprintf("Unknown status format: %s", statusFormat))
+	}
+}
+
+func rate() time.Duration {
+	if currentFormat() == FormatTTY {
+		return Rate
+	}
+	return PlainRate
+}
+
 func Clear() {
-	fmt.Print(clearLine)
+	if currentFormat() == FormatTTY {
+		fmt.Fprint(output(), clearLine)
+	}
 	reset(time.Time{})
 }
 
 func WillPrint() bool {
-	return time.Now().Sub(lastTime) >= Rate
+	return time.Now().Sub(lastTime) >= rate()
 }
 
 func Printf(format string, args ...interface{}) {
 	now := time.Now()
-	if now.Sub(lastTime) < Rate {
+	if now.Sub(lastTime) < rate() {
 		lastFormat, lastArgs = format, args
 	} else {
-		fmt.Printf(clearLine+format, args...)
+		printStatus(now, fmt.Sprintf(format, args...), false)
 		reset(now)
 	}
 }
 
 func Done() {
+	f := currentFormat()
 	if lastArgs != nil {
-		fmt.Printf(clearLine+lastFormat, lastArgs...)
+		printStatus(time.Now(), fmt.Sprintf(lastFormat, lastArgs...), true)
+	} else if f == FormatJSON {
+		printStatus(time.Now(), "", true)
+	}
+	if f == FormatTTY {
+		fmt.Fprintln(output())
 	}
-	fmt.Println()
 	reset(time.Time{})
 }
 
+func printStatus(now time.Time, msg string, done bool) {
+	switch currentFormat() {
+	case FormatTTY:
+		fmt.Fprint(output(), clearLine+msg)
+	case FormatPlain:
+		fmt.Fprintln(output(), msg)
+	case FormatJSON:
+		b, err := json.Marshal(jsonStatus{now, msg, done})
+		if err != nil {
+			panic(err)
+		}
+		fmt.Fprintf(output(), "%s\n", b)
+	}
+}
+
 func reset(time time.Time) {
 	lastTime = time
 	lastFormat, lastArgs = "", nil
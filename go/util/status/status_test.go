@@ -0,0 +1,93 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package status
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/attic-labs/testify/assert"
+	flag "github.com/juju/gnuflag"
+)
+
+func withOutput(f Format, cb func(buf *bytes.Buffer)) {
+	buf := &bytes.Buffer{}
+	SetOutput(buf)
+	SetFormat(f)
+	defer func() {
+		SetOutput(nil)
+		SetFormat(FormatAuto)
+		reset(time.Time{})
+	}()
+	cb(buf)
+}
+
+func TestAutoIsPlainWhenNotTTY(t *testing.T) {
+	withOutput(FormatAuto, func(buf *bytes.Buffer) {
+		assert.Equal(t, FormatPlain, currentFormat())
+	})
+}
+
+func TestStatusFormatFlag(t *testing.T) {
+	assert := assert.New(t)
+	defer SetFormat(FormatAuto)
+
+	parse := func(args ...string) error {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(&bytes.Buffer{})
+		RegisterStatusFlags(flags)
+		return flags.Parse(true, args)
+	}
+
+	assert.NoError(parse("--status-format", "json"))
+	assert.Equal(FormatJSON, currentFormat())
+
+	err := parse("--status-format", "xml")
+	assert.Error(err)
+	assert.Contains(err.Error(), `unknown status format "xml"`)
+	assert.Equal(FormatJSON, currentFormat())
+}
+
+func TestTTY(t *testing.T) {
+	withOutput(FormatTTY, func(buf *bytes.Buffer) {
+		Printf("one %d", 1)
+		Printf("two %d", 2)
+		Done()
+		assert.Equal(t, clearLine+"one 1"+clearLine+"two 2\n", buf.String())
+	})
+}
+
+func TestPlain(t *testing.T) {
+	withOutput(FormatPlain, func(buf *bytes.Buffer) {
+		Printf("one %d", 1)
+		Printf("two %d", 2)
+		Clear()
+		Printf("three %d", 3)
+		Done()
+		assert.Equal(t, "one 1\nthree 3\n", buf.String())
+	})
+}
+
+func TestJSON(t *testing.T) {
+	withOutput(FormatJSON, func(buf *bytes.Buffer) {
+		Printf("one %d", 1)
+		Printf("two %d", 2)
+		Done()
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if assert.Len(t, lines, 2) {
+			js := jsonStatus{}
+			assert.NoError(t, json.Unmarshal([]byte(lines[0]), &js))
+			assert.Equal(t, "one 1", js.Message)
+			assert.False(t, js.Done)
+			assert.NoError(t, json.Unmarshal([]byte(lines[1]), &js))
+			assert.Equal(t, "two 2", js.Message)
+			assert.True(t, js.Done)
+		}
+	})
+}
@@ -9,13 +9,16 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"runtime"
+	"sync"
 	"time"
 
 	"github.com/attic-labs/noms/go/config"
 	"github.com/attic-labs/noms/go/d"
 	"github.com/attic-labs/noms/go/types"
 	"github.com/attic-labs/noms/go/util/profile"
-	"github.com/attic-labs/noms/go/util/progressreader"
+	"github.com/attic-labs/noms/go/util/progresswriter"
+	"github.com/attic-labs/noms/go/util/sectionreader"
 	"github.com/attic-labs/noms/go/util/status"
 	"github.com/attic-labs/noms/go/util/verbose"
 	humanize "github.com/dustin/go-humanize"
@@ -28,6 +31,7 @@ func main() {
 		flag.PrintDefaults()
 	}
 
+	concurrency := flag.Int("concurrency", runtime.NumCPU(), "number of ranges of the blob to read concurrently, when writing to a file")
 	verbose.RegisterVerboseFlags(flag.CommandLine)
 	profile.RegisterProfileFlags(flag.CommandLine)
 
@@ -62,25 +66,50 @@ func main() {
 	// Note: overwrites any existing file.
 	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE, 0644)
 	d.CheckErrorNoUsage(err)
-	defer file.Close()
+	d.CheckErrorNoUsage(file.Truncate(int64(blob.Len())))
 
 	start := time.Now()
 	expected := humanize.Bytes(blob.Len())
 
-	// Create a pipe so that we can connect a progress reader
-	preader, pwriter := io.Pipe()
-
-	go func() {
-		blob.Reader().Copy(pwriter)
-		pwriter.Close()
-	}()
+	// Each section of the blob is read by its own BlobReader and written to its own range of the file.
+	sections := sectionreader.Split(int64(blob.Len()), *concurrency)
+	seen := make([]uint64, len(sections))
+	mu := sync.Mutex{}
+	errs := make(chan error, len(sections))
+	for i, sec := range sections {
+		go func(i int, sec sectionreader.Section) {
+			w := progresswriter.New(&fileRangeWriter{file, sec.Off}, func(n uint64) {
+				mu.Lock()
+				defer mu.Unlock()
+				seen[i] = n
+				total := uint64(0)
+				for _, n := range seen {
+					total += n
+				}
+				elapsed := time.Since(start).Seconds()
+				rate := uint64(float64(total) / elapsed)
+				status.Printf("%s of %s written in %ds (%s/s)...", humanize.Bytes(total), expected, int(elapsed), humanize.Bytes(rate))
+			})
+			_, err := io.Copy(w, sectionreader.New(blob.Reader(), sec.Off, sec.N))
+			w.Close()
+			errs <- err
+		}(i, sec)
+	}
+	for range sections {
+		d.CheckErrorNoUsage(<-errs)
+	}
+	d.CheckErrorNoUsage(file.Close())
+	status.Done()
+}
 
-	blobReader := progressreader.New(preader, func(seen uint64) {
-		elapsed := time.Since(start).Seconds()
-		rate := uint64(float64(seen) / elapsed)
-		status.Printf("%s of %s written in %ds (%s/s)...", humanize.Bytes(seen), expected, int(elapsed), humanize.Bytes(rate))
-	})
+// fileRangeWriter writes to f sequentially from off.
+type fileRangeWriter struct {
+	f   *os.File
+	off int64
+}
 
-	io.Copy(file, blobReader)
-	status.Done()
+func (w *fileRangeWriter) Write(p []byte) (n int, err error) {
+	n, err = w.f.WriteAt(p, w.off)
+	w.off += int64(n)
+	return
 }
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"math/rand"
 	"path/filepath"
 	"testing"
 
@@ -49,3 +50,27 @@ func (s *bgSuite) TestBlobGet() {
 	fmt.Println("stdout:", stdout)
 	s.Equal(blobBytes, []byte(stdout))
 }
+
+func (s *bgSuite) TestBlobGetConcurrent() {
+	blobBytes := make([]byte, 1<<20+123)
+	rand.New(rand.NewSource(42)).Read(blobBytes)
+	blob := types.NewBlob(bytes.NewReader(blobBytes))
+
+	sp, err := spec.ForDatabase(s.TempDir)
+	s.NoError(err)
+	defer sp.Close()
+	db := sp.GetDatabase()
+	ref := db.WriteValue(blob)
+	_, err = db.CommitValue(db.GetDataset("datasetID"), ref)
+	s.NoError(err)
+
+	// The file is overwritten, including anything past the end of the blob.
+	filePath := filepath.Join(s.TempDir, "out")
+	s.NoError(ioutil.WriteFile(filePath, make([]byte, 2<<20), 0644))
+	hashSpec := fmt.Sprintf("%s::#%s", s.TempDir, ref.TargetHash().String())
+	s.MustRun(main, []string{"--concurrency", "7", hashSpec, filePath})
+
+	fileBytes, err := ioutil.ReadFile(filePath)
+	s.NoError(err)
+	s.Equal(blobBytes, fileBytes)
+}
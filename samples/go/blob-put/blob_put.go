@@ -10,25 +10,36 @@ import (
 	"io"
 	"os"
 	"runtime"
+	"time"
 
 	"github.com/attic-labs/noms/go/config"
 	"github.com/attic-labs/noms/go/d"
 	"github.com/attic-labs/noms/go/types"
 	"github.com/attic-labs/noms/go/util/profile"
+	"github.com/attic-labs/noms/go/util/progressreader"
+	"github.com/attic-labs/noms/go/util/sectionreader"
+	"github.com/attic-labs/noms/go/util/status"
 	"github.com/attic-labs/noms/go/util/verbose"
+	humanize "github.com/dustin/go-humanize"
 	flag "github.com/juju/gnuflag"
 )
 
+// minSectionSize is the smallest range of the input file that's worth
+// chunking on its own goroutine.
+const minSectionSize = 1 << 20
+
 func main() {
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "usage: %s <file> <dataset>\n", os.Args[0])
 		flag.PrintDefaults()
 	}
 
-	var concurrencyArg = flag.Int("concurrency", runtime.NumCPU(), "number of concurrent HTTP calls to retrieve remote resources")
+	var concurrencyArg = flag.Int("concurrency", runtime.NumCPU(), "number of ranges of the file to read and chunk concurrently")
+	var noProgress = flag.Bool("no-progress", false, "prevents progress from being output if true")
 
 	verbose.RegisterVerboseFlags(flag.CommandLine)
 	profile.RegisterProfileFlags(flag.CommandLine)
+	status.RegisterStatusFlags(flag.CommandLine)
 
 	flag.Parse(true)
 
@@ -41,31 +52,24 @@ func main() {
 		d.CheckErrorNoUsage(errors.New("Empty file path"))
 	}
 
-	info, err := os.Stat(filePath)
+	f, err := os.Open(filePath)
+	d.CheckErrorNoUsage(err)
+	defer f.Close()
+
+	info, err := f.Stat()
 	if err != nil {
 		d.CheckError(errors.New("couldn't stat file"))
 	}
 
 	defer profile.MaybeStartProfile().Stop()
 
-	fileSize := info.Size()
-	chunkSize := fileSize / int64(*concurrencyArg)
-	if chunkSize < (1 << 20) {
-		chunkSize = 1 << 20
-	}
-
-	readers := make([]io.Reader, fileSize/chunkSize)
-	for i := 0; i < len(readers); i++ {
-		r, err := os.Open(filePath)
-		d.CheckErrorNoUsage(err)
-		defer r.Close()
-		r.Seek(int64(i)*chunkSize, 0)
-		limit := chunkSize
-		if i == len(readers)-1 {
-			limit += fileSize % chunkSize // adjust size of last slice to include the final bytes.
+	readers := splitFile(f, info.Size(), *concurrencyArg)
+	var progress *progressreader.Group
+	if !*noProgress {
+		progress = progressreader.NewGroupWithTotal(uint64(info.Size()), printStatus)
+		for i, r := range readers {
+			readers[i] = progress.Add(r)
 		}
-		lr := io.LimitReader(r, limit)
-		readers[i] = lr
 	}
 
 	cfg := config.NewResolver()
@@ -76,7 +80,14 @@ func main() {
 	}
 	defer db.Close()
 
+	// Each reader is chunked on its own goroutine. Because chunk boundaries are
+	// content-defined, the resulting Blob is the same as if the file had been
+	// read sequentially.
 	blob := types.NewStreamingBlob(db, readers...)
+	if progress != nil {
+		progress.Done()
+		status.Done()
+	}
 
 	_, err = db.CommitValue(ds, blob)
 	if err != nil {
@@ -84,3 +95,28 @@ func main() {
 		return
 	}
 }
+
+// splitFile returns up to n Readers which together cover the first size bytes
+// of f in order, split by sectionreader.Split. Each covers at least
+// minSectionSize bytes, other than when the whole file is smaller than that.
+func splitFile(f io.ReaderAt, size int64, n int) []io.Reader {
+	if max := int(size / minSectionSize); n > max {
+		n = max
+	}
+	if n < 1 {
+		n = 1
+	}
+	readers := []io.Reader{}
+	for _, s := range sectionreader.Split(size, n) {
+		readers = append(readers, io.NewSectionReader(f, s.Off, s.N))
+	}
+	return readers
+}
+
+func printStatus(p progressreader.Progress) {
+	status.Printf("%.2f%% of %s (%s/s, %s left)...",
+		p.Percent(),
+		humanize.Bytes(p.Total),
+		humanize.Bytes(uint64(p.Rate())),
+		p.ETA()/time.Second*time.Second)
+}
@@ -0,0 +1,75 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/attic-labs/noms/go/spec"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/clienttest"
+	"github.com/attic-labs/testify/assert"
+	"github.com/attic-labs/testify/suite"
+)
+
+func TestBlobPut(t *testing.T) {
+	suite.Run(t, &bpSuite{})
+}
+
+type bpSuite struct {
+	clienttest.ClientTestSuite
+}
+
+func (s *bpSuite) putAndCheck(data []byte, concurrency string) {
+	filePath := filepath.Join(s.TempDir, "in")
+	s.NoError(ioutil.WriteFile(filePath, data, 0644))
+
+	dsSpec := spec.CreateValueSpecString("nbs", s.DBDir, "blob")
+	s.MustRun(main, []string{"--no-progress", "--concurrency", concurrency, filePath, dsSpec})
+
+	sp, err := spec.ForDataset(dsSpec)
+	s.NoError(err)
+	defer sp.Close()
+	blob := sp.GetDataset().HeadValue().(types.Blob)
+	s.Equal(types.NewBlob(bytes.NewReader(data)).Hash(), blob.Hash())
+}
+
+func (s *bpSuite) TestBlobPutSmallFile() {
+	s.putAndCheck([]byte("hello"), "4")
+}
+
+func (s *bpSuite) TestBlobPutConcurrent() {
+	data := make([]byte, 3*minSectionSize+12345)
+	rand.New(rand.NewSource(42)).Read(data)
+	s.putAndCheck(data, "4")
+}
+
+func TestSplitFile(t *testing.T) {
+	assert := assert.New(t)
+
+	data := make([]byte, 3*minSectionSize+1)
+	rand.New(rand.NewSource(42)).Read(data)
+	r := bytes.NewReader(data)
+
+	assert.Empty(splitFile(r, 0, 4))
+	assert.Len(splitFile(r, 10, 4), 1)
+	assert.Len(splitFile(r, int64(len(data)), 0), 1)
+
+	readers := splitFile(r, int64(len(data)), 2)
+	assert.Len(readers, 2)
+	readers = splitFile(r, int64(len(data)), 8)
+	assert.Len(readers, 3)
+
+	out := &bytes.Buffer{}
+	for _, sr := range readers {
+		_, err := out.ReadFrom(sr)
+		assert.NoError(err)
+	}
+	assert.Equal(data, out.Bytes())
+}
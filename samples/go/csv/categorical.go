@@ -0,0 +1,274 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package csv
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/types"
+)
+
+// CategoricalStructName is the name of the struct Categorize returns. Its
+// rows field is the List or Map of rows, with the categorical fields as
+// Numbers, and its categories field is a Map<String, Map<Number, String>> from
+// the name of each categorical field to its dictionary of codes.
+const CategoricalStructName = "Categorical"
+
+// Categories are the dictionaries of a Categorical struct, by field name.
+type Categories map[string]types.Map
+
+// Categorize finds the String fields of the rows of c, a List or Map of
+// structs like those made by Import, which take at most maxCategories distinct
+// values, and replaces their values with Number codes. Each such field gets a
+// dictionary from code to value, so that a column which repeats a handful of
+// strings millions of times stores only small numbers. The rows and the
+// dictionaries are returned in a Categorical struct, along with the names of
+// the categorical fields. If no field qualifies, c is returned as it is.
+func Categorize(vrw types.ValueReadWriter, c types.Collection, maxCategories int) (types.Value, []string) {
+	if c.Empty() {
+		return c, nil
+	}
+	cz := newCategorizer(maxCategories)
+	return cz.categorical(vrw, mapRows(vrw, c, cz.encode))
+}
+
+// categorizer assigns codes to the values of String fields, as described by
+// Categorize, as rows are read, so that Import can categorize rows while it
+// builds them. Once a field has more than maxCategories distinct values, its
+// later values are left as they are, and categorical restores the values of
+// the rows that were encoded before that.
+type categorizer struct {
+	maxCategories int
+	codes         map[string]map[types.String]types.Number
+	// overflowed are the fields with too many distinct values.
+	overflowed map[string]bool
+	// rows is the number of rows encoded, and encodedOverflowed the number
+	// of those which may have codes for overflowed fields.
+	rows, encodedOverflowed uint64
+}
+
+func newCategorizer(maxCategories int) *categorizer {
+	d.PanicIfFalse(maxCategories > 0)
+	return &categorizer{maxCategories: maxCategories, codes: map[string]map[types.String]types.Number{}, overflowed: map[string]bool{}}
+}
+
+// encode returns row with the values of its String fields replaced by their
+// codes, except for fields which have overflowed.
+func (cz *categorizer) encode(row types.Struct) types.Struct {
+	var names []string
+	var codes types.ValueSlice
+	row.IterFields(func(name string, v types.Value) {
+		s, ok := v.(types.String)
+		if !ok || cz.overflowed[name] {
+			return
+		}
+		fieldCodes := cz.codes[name]
+		if fieldCodes == nil {
+			fieldCodes = map[types.String]types.Number{}
+			cz.codes[name] = fieldCodes
+		}
+		code, ok := fieldCodes[s]
+		if !ok {
+			if len(fieldCodes) == cz.maxCategories {
+				cz.overflowed[name] = true
+				cz.encodedOverflowed = cz.rows
+				return
+			}
+			code = types.Number(len(fieldCodes))
+			fieldCodes[s] = code
+		}
+		names = append(names, name)
+		codes = append(codes, code)
+	})
+	cz.rows++
+	for i, name := range names {
+		row = row.Set(name, codes[i])
+	}
+	return row
+}
+
+// categorical returns rows, whose rows were encoded by cz in order, and the
+// dictionaries of cz in a Categorical struct, along with the names of the
+// categorical fields. The values of overflowed fields are restored first: in
+// a List, only the rows encoded before the last field overflowed are
+// rewritten, but a Map, whose rows aren't in the order they were read, is
+// rewritten whole. If no field qualifies, rows is returned as it is.
+func (cz *categorizer) categorical(vrw types.ValueReadWriter, rows types.Collection) (types.Value, []string) {
+	if len(cz.overflowed) > 0 {
+		restore := map[string]types.Map{}
+		for name := range cz.overflowed {
+			kvs := make(types.ValueSlice, 0, 2*len(cz.codes[name]))
+			for s, code := range cz.codes[name] {
+				kvs = append(kvs, code, s)
+			}
+			restore[name] = types.NewMap(kvs...)
+			delete(cz.codes, name)
+		}
+		rows = restoreRows(vrw, rows, cz.encodedOverflowed, func(row types.Struct) types.Struct {
+			// Rows read after a field overflowed already have its values.
+			for name, dict := range restore {
+				if code, ok := row.MaybeGet(name); ok && code.Kind() == types.NumberKind {
+					row = row.Set(name, dict.Get(code))
+				}
+			}
+			return row
+		})
+	}
+	if len(cz.codes) == 0 {
+		return rows, nil
+	}
+
+	fields := make([]string, 0, len(cz.codes))
+	categories := make(types.ValueSlice, 0, 2*len(cz.codes))
+	for name, fieldCodes := range cz.codes {
+		fields = append(fields, name)
+		kvs := make(types.ValueSlice, 0, 2*len(fieldCodes))
+		for s, code := range fieldCodes {
+			kvs = append(kvs, code, s)
+		}
+		categories = append(categories, types.String(name), types.NewMap(kvs...))
+	}
+	sort.Strings(fields)
+	return types.NewStruct(CategoricalStructName, types.StructData{
+		"rows":       rows,
+		"categories": types.NewMap(categories...),
+	}), fields
+}
+
+// restoreRowsBatch is the number of rows restoreRows splices into a List at a
+// time.
+const restoreRowsBatch = 1 << 10
+
+// restoreRows returns c with f applied to its first n rows if it's a List, or
+// to all of its rows if it's a Map.
+func restoreRows(vrw types.ValueReadWriter, c types.Collection, n uint64, f func(row types.Struct) types.Struct) types.Collection {
+	l, ok := c.(types.List)
+	if !ok {
+		return mapRows(vrw, c, f)
+	}
+	for start := uint64(0); start < n; start += restoreRowsBatch {
+		end := start + restoreRowsBatch
+		if end > n {
+			end = n
+		}
+		batch := make(types.ValueSlice, 0, end-start)
+		it := l.IteratorAt(start)
+		for i := start; i < end; i++ {
+			batch = append(batch, f(it.Next().(types.Struct)))
+		}
+		l = l.Splice(start, end-start, batch...)
+	}
+	return l
+}
+
+// SplitCategorical returns the rows and categories of v if it's a Categorical
+// struct. Otherwise, it returns v and nil.
+func SplitCategorical(v types.Value) (types.Value, Categories) {
+	s, ok := v.(types.Struct)
+	if !ok || s.Name() != CategoricalStructName {
+		return v, nil
+	}
+	cats := Categories{}
+	s.Get("categories").(types.Map).IterAll(func(k, v types.Value) {
+		cats[string(k.(types.String))] = v.(types.Map)
+	})
+	return s.Get("rows"), cats
+}
+
+// Decode replaces the codes of the categorical fields of row with their values.
+func (cats Categories) Decode(row types.Struct) types.Struct {
+	for name, dict := range cats {
+		code, ok := row.MaybeGet(name)
+		if !ok {
+			continue
+		}
+		s, ok := dict.MaybeGet(code)
+		if !ok {
+			d.Panic("Field %s has code %s, which isn't in its categories", name, types.EncodedValue(code))
+		}
+		row = row.Set(name, s)
+	}
+	return row
+}
+
+// Decategorize reverses Categorize, returning a List or Map of rows with the
+// values of their categorical fields restored. If v isn't a Categorical
+// struct, it's returned as it is.
+func Decategorize(vrw types.ValueReadWriter, v types.Value) types.Value {
+	rows, cats := SplitCategorical(v)
+	if cats == nil {
+		return v
+	}
+	c, ok := rows.(types.Collection)
+	if !ok {
+		d.Panic("Expected the rows of a %s to be a List or Map, found %s", CategoricalStructName, rows.Kind())
+	}
+	return mapRows(vrw, c, cats.Decode)
+}
+
+func rowDesc(c types.Collection) types.StructDesc {
+	switch c := c.(type) {
+	case types.List:
+		return getElemDesc(c, 0)
+	case types.Map:
+		return GetMapElemDesc(c, nil)
+	}
+	panic(fmt.Sprintf("Expected ListKind or MapKind, found %s", c.Kind()))
+}
+
+// iterRows calls cb with each row of c, a List of structs or a Map of structs
+// or nested Maps, in order, until cb returns true.
+func iterRows(c types.Collection, cb func(row types.Struct) (stop bool)) (stopped bool) {
+	switch c := c.(type) {
+	case types.List:
+		c.Iter(func(v types.Value, index uint64) bool {
+			stopped = cb(v.(types.Struct))
+			return stopped
+		})
+	case types.Map:
+		c.Iter(func(k, v types.Value) bool {
+			if sub, ok := v.(types.Map); ok {
+				stopped = iterRows(sub, cb)
+			} else {
+				stopped = cb(v.(types.Struct))
+			}
+			return stopped
+		})
+	default:
+		d.Panic("Expected ListKind or MapKind, found %s", c.Kind())
+	}
+	return
+}
+
+// mapRows returns a copy of c, a List of structs or a Map of structs or nested
+// Maps, with each row replaced by f(row).
+func mapRows(vrw types.ValueReadWriter, c types.Collection, f func(row types.Struct) types.Struct) types.Collection {
+	switch c := c.(type) {
+	case types.List:
+		valueChan := make(chan types.Value, 128)
+		listChan := types.NewStreamingList(vrw, valueChan)
+		c.IterAll(func(v types.Value, index uint64) {
+			valueChan <- f(v.(types.Struct))
+		})
+		close(valueChan)
+		return <-listChan
+	case types.Map:
+		kvChan := make(chan types.Value, 128)
+		mapChan := types.NewStreamingMap(vrw, kvChan)
+		c.IterAll(func(k, v types.Value) {
+			kvChan <- k
+			if sub, ok := v.(types.Map); ok {
+				kvChan <- mapRows(vrw, sub, f)
+			} else {
+				kvChan <- f(v.(types.Struct))
+			}
+		})
+		close(kvChan)
+		return <-mapChan
+	}
+	panic(fmt.Sprintf("Expected ListKind or MapKind, found %s", c.Kind()))
+}
@@ -0,0 +1,138 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package csv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+const categoricalData = `name,color,size
+a,red,1
+b,blue,2
+c,red,3
+d,green,4
+e,blue,5
+`
+
+func TestCategorizeList(t *testing.T) {
+	assert := assert.New(t)
+	vs := types.NewTestValueStore()
+
+	r := NewCSVReader(bytes.NewBufferString(categoricalData), ',')
+	headers, err := r.Read()
+	assert.NoError(err)
+	l, _ := ReadToList(r, "Row", headers, KindSlice{types.StringKind, types.StringKind, types.NumberKind}, vs)
+
+	v, fields := Categorize(vs, l, 3)
+	assert.Equal([]string{"color"}, fields)
+	s := v.(types.Struct)
+	assert.Equal(CategoricalStructName, s.Name())
+
+	rows, cats := SplitCategorical(v)
+	assert.Len(cats, 1)
+	assert.True(types.NewMap(
+		types.Number(0), types.String("red"),
+		types.Number(1), types.String("blue"),
+		types.Number(2), types.String("green"),
+	).Equals(cats["color"]))
+
+	row := rows.(types.List).Get(3).(types.Struct)
+	assert.Equal(types.Number(2), row.Get("color"))
+	assert.Equal(types.String("d"), row.Get("name"))
+	assert.Equal(types.String("green"), cats.Decode(row).Get("color"))
+
+	assert.True(l.Equals(Decategorize(vs, v)))
+
+	// Too many distinct values in every String column.
+	v, fields = Categorize(vs, l, 2)
+	assert.Nil(fields)
+	assert.True(l.Equals(v))
+}
+
+func TestCategorizeNestedMap(t *testing.T) {
+	assert := assert.New(t)
+	vs := types.NewTestValueStore()
+
+	r := NewCSVReader(bytes.NewBufferString(categoricalData), ',')
+	headers, err := r.Read()
+	assert.NoError(err)
+	m := ReadToMap(r, "Row", headers, []string{"color", "name"}, nil, vs)
+	v, fields := Categorize(vs, m, 5)
+	assert.Equal([]string{"color", "name", "size"}, fields)
+	assert.True(m.Equals(Decategorize(vs, v)))
+}
+
+func TestCategorizeRestoresOverflowed(t *testing.T) {
+	assert := assert.New(t)
+	vs := types.NewTestValueStore()
+
+	// id overflows after 2000 rows, so the rows before it are restored in
+	// more than one batch.
+	rows := make(types.ValueSlice, 3000)
+	for i := range rows {
+		rows[i] = types.NewStruct("Row", types.StructData{
+			"id":   types.String(fmt.Sprintf("id%d", i)),
+			"kind": types.String(fmt.Sprintf("kind%d", i%3)),
+		})
+	}
+	l := types.NewList(rows...)
+	v, fields := Categorize(vs, l, 2000)
+	assert.Equal([]string{"kind"}, fields)
+	rs, cats := SplitCategorical(v)
+	assert.Len(cats, 1)
+	assert.Equal(types.String("id1500"), rs.(types.List).Get(1500).(types.Struct).Get("id"))
+	assert.Equal(types.Number(0), rs.(types.List).Get(1500).(types.Struct).Get("kind"))
+	assert.True(l.Equals(Decategorize(vs, v)))
+
+	m := types.NewMap()
+	for i, row := range rows[:100] {
+		m = m.Set(types.Number(i), row)
+	}
+	v, fields = Categorize(vs, m, 50)
+	assert.Equal([]string{"kind"}, fields)
+	assert.True(m.Equals(Decategorize(vs, v)))
+}
+
+func TestImportMaxCategories(t *testing.T) {
+	assert := assert.New(t)
+	vs := types.NewTestValueStore()
+
+	v, stats, err := Import(context.Background(), ImportOptions{
+		Input:         bytes.NewBufferString(categoricalData),
+		Dest:          vs,
+		MaxCategories: 3,
+	})
+	assert.NoError(err)
+	assert.Equal([]string{"color"}, stats.Categorical)
+	assert.Equal(uint64(5), stats.RowsImported)
+
+	rows, cats := SplitCategorical(v)
+	assert.NotNil(cats)
+	assert.Equal(uint64(5), rows.(types.List).Len())
+
+	// Fields are written in the order of the struct type.
+	buf := &bytes.Buffer{}
+	WriteCategorical(v.(types.Struct), ',', buf)
+	assert.Equal("color,name,size\nred,a,1\nblue,b,2\nred,c,3\ngreen,d,4\nblue,e,5\n", buf.String())
+}
+
+func TestCategorizeOptionalFields(t *testing.T) {
+	assert := assert.New(t)
+	vs := types.NewTestValueStore()
+
+	l := types.NewList(
+		types.NewStruct("Row", types.StructData{"color": types.String("red")}),
+		types.NewStruct("Row", types.StructData{}),
+	)
+	v, fields := Categorize(vs, l, 2)
+	assert.Equal([]string{"color"}, fields)
+	assert.True(l.Equals(Decategorize(vs, v)))
+}
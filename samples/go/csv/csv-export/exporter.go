@@ -11,6 +11,7 @@ import (
 
 	"github.com/attic-labs/noms/go/config"
 	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/datas"
 	"github.com/attic-labs/noms/go/types"
 	"github.com/attic-labs/noms/go/util/profile"
 	"github.com/attic-labs/noms/go/util/verbose"
@@ -50,14 +51,17 @@ func main() {
 		defer profile.MaybeStartProfile().Stop()
 
 		hv := ds.HeadValue()
+		headers := csv.HeadersFromMeta(ds.Head().Get(datas.MetaField).(types.Struct))
 		if l, ok := hv.(types.List); ok {
 			structDesc := csv.GetListElemDesc(l, db)
-			csv.WriteList(l, structDesc, comma, os.Stdout)
+			csv.WriteListWithHeaders(l, structDesc, headers, comma, os.Stdout)
 		} else if m, ok := hv.(types.Map); ok {
 			structDesc := csv.GetMapElemDesc(m, db)
-			csv.WriteMap(m, structDesc, comma, os.Stdout)
+			csv.WriteMapWithHeaders(m, structDesc, headers, comma, os.Stdout)
+		} else if s, ok := hv.(types.Struct); ok && s.Name() == csv.CategoricalStructName {
+			csv.WriteCategoricalWithHeaders(s, headers, comma, os.Stdout)
 		} else {
-			panic(fmt.Sprintf("Expected ListKind or MapKind, found %s", hv.Kind()))
+			panic(fmt.Sprintf("Expected ListKind, MapKind or a %s struct, found %s", csv.CategoricalStructName, hv.Kind()))
 		}
 	})
 	if err != nil {
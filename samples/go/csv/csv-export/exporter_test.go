@@ -5,7 +5,7 @@
 package main
 
 import (
-	"encoding/csv"
+	gocsv "encoding/csv"
 	"io"
 	"strings"
 	"testing"
@@ -16,6 +16,7 @@ import (
 	"github.com/attic-labs/noms/go/spec"
 	"github.com/attic-labs/noms/go/types"
 	"github.com/attic-labs/noms/go/util/clienttest"
+	"github.com/attic-labs/noms/samples/go/csv"
 	"github.com/attic-labs/testify/suite"
 )
 
@@ -68,7 +69,7 @@ func createTestData(s *testSuite, buildAsMap bool) []types.Value {
 }
 
 func verifyOutput(s *testSuite, stdout string) {
-	csvReader := csv.NewReader(strings.NewReader(stdout))
+	csvReader := gocsv.NewReader(strings.NewReader(stdout))
 
 	row, err := csvReader.Read()
 	d.Chk.NoError(err)
@@ -124,3 +125,25 @@ func (s *testSuite) TestCSVExportFromMap() {
 
 	verifyOutput(s, stdout)
 }
+
+func (s *testSuite) TestCSVExportCategorical() {
+	setName := "csvcategorical"
+
+	// Setup data store
+	db := datas.NewDatabase(nbs.NewLocalStore(s.DBDir, clienttest.DefaultMemTableSize))
+	ds := db.GetDataset(setName)
+
+	// Build data rows, with every column as categories
+	structs := createTestData(s, false)
+	v, fields := csv.Categorize(db, types.NewList(structs...), 3)
+	s.Equal([]string{"a", "b", "c"}, fields)
+	db.CommitValue(ds, v)
+	db.Close()
+
+	// Run exporter
+	dataspec := spec.CreateValueSpecString("nbs", s.DBDir, setName)
+	stdout, stderr := s.MustRun(main, []string{dataspec})
+	s.Equal("", stderr)
+
+	verifyOutput(s, stdout)
+}
@@ -0,0 +1,228 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/attic-labs/noms/go/config"
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/spec"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/verbose"
+	"github.com/attic-labs/noms/samples/go/csv"
+	flag "github.com/juju/gnuflag"
+)
+
+func main() {
+	port := flag.Int("port", 8000, "port to serve on")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Serves an endpoint that imports uploaded CSV files into datasets of a database.\n\n")
+		fmt.Fprintf(os.Stderr, "usage: %s [options] <database>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+
+	verbose.RegisterVerboseFlags(flag.CommandLine)
+
+	flag.Parse(true)
+
+	if flag.NArg() != 1 {
+		d.CheckError(fmt.Errorf("Missing required database argument"))
+	}
+
+	cfg := config.NewResolver()
+	sp, err := spec.ForDatabase(cfg.ResolveDbSpec(flag.Arg(0)))
+	d.CheckErrorNoUsage(err)
+	defer sp.Close()
+
+	http.Handle("/import", importHandler(sp.GetDatabase()))
+	fmt.Printf("Serving CSV imports into %s on http://localhost:%d/import\n", sp.String(), *port)
+	d.CheckErrorNoUsage(http.ListenAndServe(fmt.Sprintf(":%d", *port), nil))
+}
+
+// importResult is the JSON response to a successful import.
+type importResult struct {
+	Commit       string `json:"commit"`
+	RowsImported uint64 `json:"rowsImported"`
+	BytesRead    uint64 `json:"bytesRead"`
+}
+
+// httpError is an error with the HTTP status it should be reported with.
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e httpError) Error() string {
+	return e.err.Error()
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return httpError{http.StatusBadRequest, fmt.Errorf(format, args...)}
+}
+
+// importHandler serves POSTs of multipart/form-data with a "file" part holding
+// the CSV to import. The dataset to commit to is the required "dataset" option.
+// Other options are csv-import's delimiter, record-separator, header, name,
+// column-types, dest-type and skip-records flags. Options are taken from the query string, or
+// from form fields preceding the file.
+//
+// The file is streamed into the database as it's uploaded, then committed to
+// the head of the dataset. The response is an importResult.
+func importHandler(db datas.Database) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		res, err := importUpload(db, req)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if he, ok := err.(httpError); ok {
+				status = he.status
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		d.PanicIfError(json.NewEncoder(w).Encode(res))
+	})
+}
+
+func importUpload(db datas.Database, req *http.Request) (importResult, error) {
+	mr, err := req.MultipartReader()
+	if err != nil {
+		return importResult{}, badRequest("%s", err)
+	}
+
+	params := req.URL.Query()
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return importResult{}, badRequest("Missing file part")
+		} else if err != nil {
+			return importResult{}, badRequest("%s", err)
+		}
+
+		if part.FormName() != "file" {
+			// Bound the size of option fields, which are read into memory.
+			b, err := readAllMax(part, 1<<16)
+			if err != nil {
+				return importResult{}, badRequest("Reading field %s: %s", part.FormName(), err)
+			}
+			params.Set(part.FormName(), string(b))
+			continue
+		}
+		return importFile(db, req, part, part.FileName(), params)
+	}
+}
+
+func importFile(db datas.Database, req *http.Request, r io.Reader, fileName string, params url.Values) (importResult, error) {
+	dsName := params.Get("dataset")
+	if !datas.IsValidDatasetName(dsName) {
+		return importResult{}, badRequest("Invalid dataset: %q", dsName)
+	}
+
+	opts, err := importOptions(params)
+	if err != nil {
+		return importResult{}, err
+	}
+	opts.Input, opts.InputName, opts.Dest = r, fileName, db
+
+	value, stats, err := csv.Import(req.Context(), opts)
+	if err != nil {
+		return importResult{}, badRequest("%s", d.Annotate(err, "", dsName))
+	}
+
+	meta, err := spec.CreateCommitMetaStruct(db, "", "", map[string]string{"inputFile": fileName}, map[string]types.Value{
+		"rowsImported":       types.Number(stats.RowsImported),
+		"rowsSkipped":        types.Number(stats.RowsSkipped),
+		"bytesRead":          types.Number(stats.BytesRead),
+		"durationSeconds":    types.Number(stats.Elapsed.Seconds()),
+		csv.HeadersMetaField: csv.HeadersValue(stats.Headers),
+	})
+	if err != nil {
+		return importResult{}, err
+	}
+
+	ds, err := db.Commit(db.GetDataset(dsName), value, datas.CommitOptions{Meta: meta})
+	if err == datas.ErrMergeNeeded {
+		return importResult{}, httpError{http.StatusConflict, fmt.Errorf("Dataset %s was changed during the import", dsName)}
+	} else if err != nil {
+		return importResult{}, err
+	}
+
+	return importResult{
+		Commit:       ds.HeadRef().TargetHash().String(),
+		RowsImported: stats.RowsImported,
+		BytesRead:    stats.BytesRead,
+	}, nil
+}
+
+// importOptions parses the import options, other than the input and
+// destination, from params.
+func importOptions(params url.Values) (csv.ImportOptions, error) {
+	opts := csv.ImportOptions{
+		StructName: params.Get("name"),
+		DestType:   params.Get("dest-type"),
+	}
+
+	if delim := params.Get("delimiter"); delim != "" {
+		r, err := csv.StringToRune(delim)
+		if err != nil {
+			return opts, badRequest("%s", err)
+		}
+		opts.Delimiter = r
+	}
+	if sep := params.Get("record-separator"); sep != "" {
+		r, err := csv.StringToRune(sep)
+		if err != nil {
+			return opts, badRequest("%s", err)
+		}
+		opts.RecordSeparator = r
+	}
+	if header := params.Get("header"); header != "" {
+		opts.Headers = strings.Split(header, ",")
+	}
+	if columnTypes := params.Get("column-types"); columnTypes != "" {
+		kinds, policies, err := csv.ParseColumnTypes(strings.Split(columnTypes, ","))
+		if err != nil {
+			return opts, badRequest("%s", err)
+		}
+		opts.Kinds, opts.Policies = kinds, policies
+	}
+	if skip := params.Get("skip-records"); skip != "" {
+		n, err := strconv.ParseUint(skip, 10, 0)
+		if err != nil {
+			return opts, badRequest("Invalid skip-records: %s", skip)
+		}
+		opts.SkipRecords = uint(n)
+	}
+	if _, err := csv.ParseDestType(opts.DestType); err != nil {
+		return opts, badRequest("%s", err)
+	}
+	return opts, nil
+}
+
+func readAllMax(r io.Reader, max int64) ([]byte, error) {
+	b, err := ioutil.ReadAll(io.LimitReader(r, max+1))
+	if err == nil && int64(len(b)) > max {
+		err = fmt.Errorf("longer than %d bytes", max)
+	}
+	return b, err
+}
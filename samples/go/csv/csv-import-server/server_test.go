@@ -0,0 +1,91 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+const testCSV = "key,value\na,1\nb,2\n"
+
+// upload POSTs data as the file part of a multipart form, preceded by the given fields.
+func upload(db datas.Database, query string, fields map[string]string, data string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	fw, _ := mw.CreateFormFile("file", "test.csv")
+	fw.Write([]byte(data))
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/import?"+query, body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	importHandler(db).ServeHTTP(w, req)
+	return w
+}
+
+func TestImportUpload(t *testing.T) {
+	assert := assert.New(t)
+	db := datas.NewDatabase(chunks.NewMemoryStore())
+	defer db.Close()
+
+	w := upload(db, "dataset=foo", map[string]string{"dest-type": "map:key", "column-types": "String,Number"}, testCSV)
+	assert.Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var res importResult
+	assert.NoError(json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(uint64(2), res.RowsImported)
+	assert.Equal(uint64(len(testCSV)), res.BytesRead)
+
+	ds := db.GetDataset("foo")
+	assert.Equal(res.Commit, ds.HeadRef().TargetHash().String())
+	m := ds.HeadValue().(types.Map)
+	assert.Equal(types.Number(2), m.Get(types.String("b")).(types.Struct).Get("value"))
+
+	meta := ds.Head().Get(datas.MetaField).(types.Struct)
+	assert.Equal(types.String("test.csv"), meta.Get("inputFile"))
+	assert.Equal(types.Number(2), meta.Get("rowsImported"))
+
+	// A second upload is committed on top of the first.
+	w = upload(db, "dataset=foo&header=x,y&skip-records=1", nil, testCSV)
+	assert.Equal(http.StatusOK, w.Code, w.Body.String())
+	l := db.GetDataset("foo").HeadValue().(types.List)
+	assert.Equal(uint64(2), l.Len())
+	assert.Equal(types.String("b"), l.Get(1).(types.Struct).Get("x"))
+}
+
+func TestImportUploadErrors(t *testing.T) {
+	assert := assert.New(t)
+	db := datas.NewDatabase(chunks.NewMemoryStore())
+	defer db.Close()
+
+	assert.Equal(http.StatusBadRequest, upload(db, "", nil, testCSV).Code)
+	assert.Equal(http.StatusBadRequest, upload(db, "dataset=foo&dest-type=set", nil, testCSV).Code)
+	assert.Equal(http.StatusBadRequest, upload(db, "dataset=foo&column-types=Nope,String", nil, testCSV).Code)
+	assert.Equal(http.StatusBadRequest, upload(db, "dataset=foo&skip-records=10", nil, testCSV).Code)
+	assert.Equal(http.StatusBadRequest, upload(db, "dataset=foo", nil, "a,a\n").Code)
+	_, ok := db.GetDataset("foo").MaybeHead()
+	assert.False(ok)
+
+	w := httptest.NewRecorder()
+	importHandler(db).ServeHTTP(w, httptest.NewRequest("GET", "/import", nil))
+	assert.Equal(http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	importHandler(db).ServeHTTP(w, httptest.NewRequest("POST", "/import?dataset=foo", bytes.NewBufferString(testCSV)))
+	assert.Equal(http.StatusBadRequest, w.Code)
+}
@@ -5,12 +5,20 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	gocsv "encoding/csv"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
+	"unicode/utf8"
 
 	"github.com/attic-labs/noms/go/config"
 	"github.com/attic-labs/noms/go/d"
@@ -26,31 +34,42 @@ import (
 	flag "github.com/juju/gnuflag"
 )
 
-const (
-	destList = iota
-	destMap  = iota
-)
-
 func main() {
 	// Actually the delimiter uses runes, which can be multiple characters long.
 	// https://blog.golang.org/strings
-	delimiter := flag.String("delimiter", ",", "field delimiter for csv file, must be exactly one character long.")
+	delimiter := flag.String("delimiter", ",", "field delimiter for csv file, must be exactly one character long, or 'auto' to detect it from the start of the file.")
+	recordSeparator := flag.String("record-separator", "", "record separator for csv file, exactly one character long. If empty, records end with a newline. With any other separator, newlines are part of the fields they appear in")
 	header := flag.String("header", "", "header row. If empty, we'll use the first row of the file")
 	name := flag.String("name", "Row", "struct name. The user-visible name to give to the struct type that will hold each row of data.")
-	columnTypes := flag.String("column-types", "", "a comma-separated list of types representing the desired type of each column. if absent all types default to be String")
+	columnTypes := flag.String("column-types", "", "a comma-separated list of types representing the desired type of each column. if absent all types default to be String. a type may be followed by what to do with a value which isn't of it: '!' to fail (the default), '?' to omit the field, '|String' to import it as a String, or '=' and a default to import instead, e.g. 'Number!,String,Number?,Bool|String,Number=0'")
 	pathDescription := "noms path to blob to import"
 	path := flag.String("path", "", pathDescription)
 	flag.StringVar(path, "p", "", pathDescription)
 	noProgress := flag.Bool("no-progress", false, "prevents progress from being output if true")
 	destType := flag.String("dest-type", "list", "the destination type to import to. can be 'list' or 'map:<pk>', where <pk> is the index position (0-based) of the column that is a the unique identifier for the column")
 	skipRecords := flag.Uint("skip-records", 0, "number of records to skip at beginning of file")
+	shardBy := flag.String("shard-by", "", "split the import into shards, committed to the datasets <dataset>/part-00001, <dataset>/part-00002, ..., and commit a manifest listing them to <dataset>. Either a column, as a header name or 0-based index, to make a shard per distinct value of it (at most 1024), or 'rows:<N>' for shards of N rows")
+	maxCategories := flag.Int("max-categories", 0, "if non-zero, store String columns with at most this many distinct values as categories: a shared dictionary of the values, and a Number code in each row. csv-export restores the values")
 	performCommit := flag.Bool("commit", true, "commit the data to head of the dataset (otherwise only write the data to the dataset)")
+	maxRate := flag.String("max-rate", "", "maximum rate to read the input at, e.g. 10MB (per second). Unlimited if empty")
+	verify := flag.Bool("verify", false, "after importing, re-read the input and check it against the imported data, exiting with an error on any mismatch")
+	manifestPath := flag.String("manifest", "", "after importing, write the effective import options and a hash of the input to this file, as JSON")
+	fromManifestPath := flag.String("from-manifest", "", "take the import options from a file written by -manifest, instead of from flags")
+	verifySamples := flag.Uint64("verify-samples", 1000, "with -verify, the approximate number of rows to compare in full, or 0 for all of them; the rest are only counted")
+	watch := flag.String("watch", "", "instead of importing <csvfile>, watch this directory and import each new file that appears in it as a new commit to <dataset>, until interrupted")
+	watchPattern := flag.String("watch-pattern", "*.csv", "with -watch, the pattern that the names of files to import must match")
+	watchInterval := flag.Duration("watch-interval", 5*time.Second, "with -watch, how often to look for new files")
+	keepSource := flag.Bool("keep-source", false, "first store <csvfile> in the database as a Blob, chunked at record boundaries, then import from the Blob. The commit's meta refers to the Blob as 'source', so the import can be reproduced from the database alone")
+	statusAddr := flag.String("status-addr", "", "serve the progress of the import as JSON at http://<addr>/status, e.g. localhost:8002. A POST to http://<addr>/stop ends the import early, committing the rows imported so far")
 	spec.RegisterCommitMetaFlags(flag.CommandLine)
 	verbose.RegisterVerboseFlags(flag.CommandLine)
+	datas.RegisterRetryFlags(flag.CommandLine)
 	profile.RegisterProfileFlags(flag.CommandLine)
+	status.RegisterStatusFlags(flag.CommandLine)
 
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: csv-import [options] <csvfile> <dataset>\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: csv-import [options] <csvfile> <dataset>\n")
+		fmt.Fprintf(os.Stderr, "       csv-import [options] --watch <dir> <dataset>\n\n")
 		flag.PrintDefaults()
 	}
 
@@ -58,6 +77,18 @@ func main() {
 
 	var err error
 	switch {
+	case *watch != "" && (flag.NArg() != 1 || *path != ""):
+		err = errors.New("With --watch, specify only the dataset")
+	case *watch != "" && (*verify || *manifestPath != "" || !*performCommit || *keepSource || *statusAddr != ""):
+		err = errors.New("Cannot use --verify, --manifest, --commit=false, --keep-source or --status-addr with --watch")
+	case *watch != "" && *delimiter == "auto":
+		err = errors.New("Cannot use --delimiter=auto with --watch")
+	case *keepSource && *path != "":
+		err = errors.New("Cannot use --keep-source with a noms path, which is already stored")
+	case *shardBy != "" && (*watch != "" || *verify || !*performCommit):
+		err = errors.New("Cannot use --watch, --verify or --commit=false with --shard-by")
+	case *watch != "":
+		// The only argument is the dataset.
 	case flag.NArg() == 0:
 		err = errors.New("Maybe you put options after the dataset?")
 	case flag.NArg() == 1 && *path == "":
@@ -69,11 +100,74 @@ func main() {
 	}
 	d.CheckError(err)
 
+	var fromManifest *importManifest
+	if *fromManifestPath != "" {
+		m, err := readManifest(*fromManifestPath)
+		d.CheckErrorNoUsage(err)
+		*delimiter, *recordSeparator, *name, *destType, *skipRecords = m.Delimiter, m.RecordSeparator, m.Name, m.DestType, m.SkipRecords
+		*columnTypes = strings.Join(m.ColumnTypes, ",")
+		*maxCategories, *shardBy = m.MaxCategories, m.ShardBy
+		fromManifest = &m
+	}
+
+	var delim rune
+	if *delimiter != "auto" {
+		delim, err = csv.StringToRune(*delimiter)
+		d.CheckErrorNoUsage(err)
+	}
+	sep := '\n'
+	if *recordSeparator != "" {
+		sep, err = csv.StringToRune(*recordSeparator)
+		d.CheckErrorNoUsage(err)
+	}
+
+	opts := csv.ImportOptions{
+		Delimiter:       delim,
+		RecordSeparator: sep,
+		StructName:      *name,
+		DestType:        *destType,
+		SkipRecords:     *skipRecords,
+		MaxCategories:   *maxCategories,
+	}
+	if fromManifest != nil {
+		opts.Headers = fromManifest.Headers
+		opts.MatchHeaderRow = fromManifest.HeaderRow
+	} else if *header != "" {
+		opts.Headers = strings.Split(*header, ",")
+	}
+	hasHeaderRow := len(opts.Headers) == 0 || opts.MatchHeaderRow
+	if *columnTypes != "" {
+		opts.Kinds, opts.Policies, err = csv.ParseColumnTypes(strings.Split(*columnTypes, ","))
+		d.CheckErrorNoUsage(err)
+	}
+	_, err = csv.ParseDestType(*destType)
+	d.CheckErrorNoUsage(err)
+	var sb csv.ShardBy
+	if *shardBy != "" {
+		sb, err = csv.ParseShardBy(*shardBy)
+		d.CheckErrorNoUsage(err)
+	}
+
+	if *watch != "" {
+		db, ds, err := config.NewResolver().GetDataset(flag.Arg(0))
+		d.CheckError(err)
+		defer db.Close()
+		w, err := newWatcher(*watch, *watchPattern)
+		d.CheckErrorNoUsage(err)
+		fmt.Printf("Watching %s for new files matching %s\n", *watch, *watchPattern)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		cancelOnInterrupt(cancel)
+		d.CheckErrorNoUsage(watchAndImport(ctx, w, *watchInterval, db, ds, opts))
+		return
+	}
+
 	defer profile.MaybeStartProfile().Stop()
 
 	var r io.Reader
 	var size uint64
 	var filePath string
+	var blob types.Blob
 	var dataSetArgN int
 
 	cfg := config.NewResolver()
@@ -83,7 +177,8 @@ func main() {
 		if val == nil {
 			d.CheckError(fmt.Errorf("Path %s not found\n", *path))
 		}
-		blob, ok := val.(types.Blob)
+		var ok bool
+		blob, ok = val.(types.Blob)
 		if !ok {
 			d.CheckError(fmt.Errorf("Path %s not a Blob: %s\n", *path, types.EncodedValue(types.TypeOf(val))))
 		}
@@ -108,74 +203,130 @@ func main() {
 		dataSetArgN = 1
 	}
 
-	if !*noProgress {
-		r = progressreader.New(r, getStatusPrinter(size))
+	if *delimiter == "auto" {
+		br := bufio.NewReaderSize(r, csv.DefaultDialectSampleBytes)
+		sample, err := br.Peek(csv.DefaultDialectSampleBytes)
+		if err != nil && err != io.EOF {
+			d.CheckErrorNoUsage(err)
+		}
+		dialect, err := csv.DetectDialect(bytes.NewReader(sample), len(sample))
+		d.CheckErrorNoUsage(err)
+		delim, opts.Delimiter = dialect.Delimiter, dialect.Delimiter
+		*delimiter = string(delim)
+		if !*noProgress {
+			fmt.Fprintf(os.Stderr, "Detected delimiter %q\n", dialect.Delimiter)
+		}
+		r = br
 	}
 
-	delim, err := csv.StringToRune(*delimiter)
-	d.CheckErrorNoUsage(err)
+	db, ds, err := cfg.GetDataset(flag.Arg(dataSetArgN))
+	d.CheckError(err)
+	defer db.Close()
 
-	var dest int
-	var strPks []string
-	if *destType == "list" {
-		dest = destList
-	} else if strings.HasPrefix(*destType, "map:") {
-		dest = destMap
-		strPks = strings.Split(strings.TrimPrefix(*destType, "map:"), ",")
-		if len(strPks) == 0 {
-			fmt.Println("Invalid dest-type map: ", *destType)
-			return
+	var importProgress *importStatus
+	if *statusAddr != "" {
+		importProgress = newImportStatus(size)
+		addr, err := serveStatus(importProgress, *statusAddr)
+		d.CheckErrorNoUsage(err)
+		if !*noProgress {
+			fmt.Fprintf(os.Stderr, "Serving status at http://%s/status\n", addr)
+		}
+	}
+	setPhase := func(phase string) {
+		if importProgress != nil {
+			importProgress.setPhase(phase)
 		}
-	} else {
-		fmt.Println("Invalid dest-type: ", *destType)
-		return
 	}
 
-	cr := csv.NewCSVReader(r, delim)
-	err = csv.SkipRecords(cr, *skipRecords)
+	var sourceRef types.Ref
+	if *keepSource {
+		setPhase(phaseStoringSource)
+		blob = storeSource(db, r, filePath, size, sep, *noProgress)
+		sourceRef = db.WriteValue(blob)
+		r = blob.Reader()
+	}
 
-	if err == io.EOF {
-		err = fmt.Errorf("skip-records skipped past EOF")
+	var hasher sourceHasher
+	if *manifestPath != "" {
+		hasher = newSourceHasher()
+		r = io.TeeReader(r, hasher)
 	}
-	d.CheckErrorNoUsage(err)
 
-	var headers []string
-	if *header == "" {
-		headers, err = cr.Read()
-		d.PanicIfError(err)
-	} else {
-		headers = strings.Split(*header, ",")
+	if *maxRate != "" {
+		bytesPerSec, err := humanize.ParseBytes(*maxRate)
+		d.CheckErrorNoUsage(err)
+		r = progressreader.Throttle(r, bytesPerSec)
 	}
 
-	uniqueHeaders := make(map[string]bool)
-	for _, header := range headers {
-		uniqueHeaders[header] = true
+	if !*noProgress {
+		r = progressreader.NewWithTotal(r, size, printStatus)
 	}
-	if len(uniqueHeaders) != len(headers) {
-		d.CheckErrorNoUsage(fmt.Errorf("Invalid headers specified, headers must be unique"))
+	if importProgress != nil {
+		r = progressreader.New(r, importProgress.setBytes)
+		opts.Stop = importProgress.stop
+		opts.RowProgress = importProgress.setRows
 	}
 
-	kinds := []types.NomsKind{}
-	if *columnTypes != "" {
-		kinds = csv.StringsToKinds(strings.Split(*columnTypes, ","))
-		if len(kinds) != len(uniqueHeaders) {
-			d.CheckErrorNoUsage(fmt.Errorf("Invalid column-types specified, column types do not correspond to number of headers"))
-		}
+	opts.Input = r
+	opts.InputName = filePath
+	if *path != "" {
+		opts.InputName = *path
 	}
 
-	db, ds, err := cfg.GetDataset(flag.Arg(dataSetArgN))
-	d.CheckError(err)
-	defer db.Close()
+	opts.Dest = db
+
+	// Stop reading, without committing anything, on interrupt.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopInterrupt := cancelOnInterrupt(cancel)
+
+	metrics := types.NewExpvarMetrics("")
+	if ms, ok := db.(valueStoreWithMetrics); ok {
+		ms.SetMetrics(metrics)
+	}
 
 	var value types.Value
-	if dest == destList {
-		value, _ = csv.ReadToList(cr, *name, headers, kinds, db)
+	var shards []csv.Shard
+	var stats csv.Stats
+	setPhase(phaseImporting)
+	if *shardBy != "" {
+		shards, stats, err = csv.ImportShards(ctx, opts, sb)
 	} else {
-		value = csv.ReadToMap(cr, *name, headers, strPks, kinds, db)
+		value, stats, err = csv.Import(ctx, opts)
+	}
+	stopInterrupt()
+	if err == context.Canceled {
+		err = errors.New("Import cancelled")
 	}
+	d.CheckErrorNoUsage(d.Annotate(err, "", flag.Arg(dataSetArgN)))
 
-	if *performCommit {
-		meta, err := spec.CreateCommitMetaStruct(ds.Database(), "", "", additionalMetaInfo(filePath, *path), nil)
+	if stats.Stopped {
+		if !*noProgress {
+			status.Clear()
+		}
+		if stats.RowsImported == 0 {
+			// e.g. stopped while the source was being stored: there's nothing worth committing.
+			d.CheckErrorNoUsage(errors.New("Import stopped before any rows were imported, so nothing was committed"))
+		}
+		fmt.Fprintf(os.Stderr, "Import stopped after %d rows\n", stats.RowsImported)
+	}
+
+	setPhase(phaseCommitting)
+	metaValues := importStats(stats, metrics)
+	metaValues[csv.HeadersMetaField] = csv.HeadersValue(stats.Headers)
+	if *keepSource {
+		metaValues["source"] = sourceRef
+	}
+	if *shardBy != "" {
+		meta, err := spec.CreateCommitMetaStruct(ds.Database(), "", "", additionalMetaInfo(filePath, *path), metaValues)
+		d.CheckErrorNoUsage(err)
+		_, err = commitShards(db, ds, shards, sb, meta)
+		if !*noProgress {
+			status.Clear()
+		}
+		d.PanicIfError(err)
+	} else if *performCommit {
+		meta, err := spec.CreateCommitMetaStruct(ds.Database(), "", "", additionalMetaInfo(filePath, *path), metaValues)
 		d.CheckErrorNoUsage(err)
 		_, err = db.Commit(ds, value, datas.CommitOptions{Meta: meta})
 		if !*noProgress {
@@ -189,6 +340,62 @@ func main() {
 		}
 		fmt.Fprintf(os.Stdout, "#%s\n", ref.TargetHash().String())
 	}
+
+	if *manifestPath != "" && !stats.Stopped {
+		d.CheckErrorNoUsage(writeManifest(*manifestPath, importManifest{
+			Headers:         stats.Headers,
+			HeaderRow:       hasHeaderRow,
+			ColumnTypes:     csv.FormatColumnTypes(opts.Kinds, opts.Policies),
+			Delimiter:       *delimiter,
+			RecordSeparator: *recordSeparator,
+			DestType:        *destType,
+			SkipRecords:     *skipRecords,
+			MaxCategories:   *maxCategories,
+			ShardBy:         *shardBy,
+			Name:            *name,
+			SourceHash:      hasher.String(),
+		}))
+	}
+
+	if *verify && !stats.Stopped {
+		setPhase(phaseVerifying)
+		var src io.ReadCloser
+		if filePath != "" {
+			src, err = os.Open(filePath)
+			d.CheckErrorNoUsage(err)
+		} else {
+			src = ioutil.NopCloser(blob.Reader())
+		}
+		defer src.Close()
+
+		cr := csv.NewCSVReaderWithRecordSeparator(src, delim, sep)
+		d.CheckErrorNoUsage(csv.SkipRecords(cr, *skipRecords))
+		if hasHeaderRow {
+			_, err = cr.Read()
+			d.CheckErrorNoUsage(err)
+		}
+		d.CheckErrorNoUsage(verifyImport(cr, *name, stats.Headers, stats.PrimaryKeys, opts.Kinds, opts.Policies, csv.Decategorize(db, value), *verifySamples))
+	}
+	setPhase(phaseDone)
+}
+
+// verifyImport checks imported, which was read from cr, against the rows that
+// remain in cr.
+func verifyImport(cr *gocsv.Reader, structName string, headers, strPks []string, kinds csv.KindSlice, policies []csv.ParsePolicy, imported types.Value, samples uint64) error {
+	sampleEvery := func(n uint64) uint64 {
+		if samples == 0 || n <= samples {
+			return 1
+		}
+		return n / samples
+	}
+
+	switch v := imported.(type) {
+	case types.List:
+		return csv.VerifyList(cr, structName, headers, kinds, policies, v, sampleEvery(v.Len()))
+	case types.Map:
+		return csv.VerifyMap(cr, structName, headers, strPks, kinds, policies, v, sampleEvery(v.Len()))
+	}
+	return fmt.Errorf("Imported value is a %s, not a List or Map", types.TypeOf(imported).Describe())
 }
 
 func additionalMetaInfo(filePath, nomsPath string) map[string]string {
@@ -201,16 +408,84 @@ func additionalMetaInfo(filePath, nomsPath string) map[string]string {
 	return map[string]string{fileOrNomsPath: path}
 }
 
-func getStatusPrinter(expected uint64) progressreader.Callback {
-	startTime := time.Now()
-	return func(seen uint64) {
-		percent := float64(seen) / float64(expected) * 100
-		elapsed := time.Since(startTime)
-		rate := float64(seen) / elapsed.Seconds()
+// storeSource writes the size bytes of CSV read from r to db as a Blob. If
+// records end with a single byte, the Blob's chunks end at records, so that
+// they can be parsed independently, and a file which grows by appending
+// records shares all but its last chunk with the Blob of its previous version.
+// If r was opened from filePath, the file is hashed first, and if db already
+// has its Blob, e.g. because it was imported before, that's returned without
+// reading r.
+func storeSource(db datas.Database, r io.Reader, filePath string, size uint64, sep rune, noProgress bool) types.Blob {
+	chunking := types.CSVChunking{Quote: '"', RecordSeparator: byte(sep)}
+	if filePath != "" {
+		bh := types.NewCSVBlobHasher(chunking)
+		if sep >= utf8.RuneSelf {
+			bh = types.NewBlobHasher()
+		}
+		f, err := os.Open(filePath)
+		d.CheckErrorNoUsage(err)
+		_, err = io.Copy(bh, f)
+		f.Close()
+		d.CheckErrorNoUsage(err)
+		h, _ := bh.Sum()
+		if blob, ok := db.ReadValue(h).(types.Blob); ok {
+			return blob
+		}
+	}
+
+	if !noProgress {
+		r = progressreader.NewWithTotal(r, size, printStatus)
+		defer status.Clear()
+	}
+	if sep >= utf8.RuneSelf {
+		return types.NewStreamingBlob(db, r)
+	}
+	return types.NewCSVBlob(db, chunking, r)
+}
+
+type valueStoreWithMetrics interface {
+	SetMetrics(m types.ValueStoreMetrics)
+}
+
+// importStats returns the statistics about an import that are recorded in the
+// commit meta, so that a dataset's history doubles as a log of its imports.
+// An import which was stopped early is marked as "stopped".
+func importStats(stats csv.Stats, metrics *types.ExpvarMetrics) map[string]types.Value {
+	m := map[string]types.Value{
+		"rowsImported":      types.Number(stats.RowsImported),
+		"rowsSkipped":       types.Number(stats.RowsSkipped),
+		"bytesRead":         types.Number(stats.BytesRead),
+		"durationSeconds":   types.Number(stats.Elapsed.Seconds()),
+		"chunksWritten":     types.Number(metrics.ChunkWrites.Count()),
+		"chunkBytesWritten": types.Number(metrics.ChunkWrites.Sum()),
+		"requestsRetried":   types.Number(metrics.Retries.Value()),
+	}
+	if stats.Stopped {
+		m["stopped"] = types.Bool(true)
+	}
+	return m
+}
 
-		status.Printf("%.2f%% of %s (%s/s)...",
-			percent,
-			humanize.Bytes(expected),
-			humanize.Bytes(uint64(rate)))
+// cancelOnInterrupt calls cancel on SIGINT or SIGTERM, until the returned
+// function is called.
+func cancelOnInterrupt(cancel func()) (stop func()) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		if _, ok := <-sigChan; ok {
+			cancel()
+		}
+	}()
+	return func() {
+		signal.Stop(sigChan)
+		close(sigChan)
 	}
 }
+
+func printStatus(p progressreader.Progress) {
+	status.Printf("%.2f%% of %s (%s/s, %s left)...",
+		p.Percent(),
+		humanize.Bytes(p.Total),
+		humanize.Bytes(uint64(p.Rate())),
+		p.ETA()/time.Second*time.Second)
+}
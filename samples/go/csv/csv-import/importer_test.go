@@ -10,14 +10,18 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/attic-labs/noms/go/d"
 	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/hash"
 	"github.com/attic-labs/noms/go/nbs"
 	"github.com/attic-labs/noms/go/spec"
 	"github.com/attic-labs/noms/go/types"
 	"github.com/attic-labs/noms/go/util/clienttest"
+	"github.com/attic-labs/noms/samples/go/csv"
 	"github.com/attic-labs/testify/suite"
 )
 
@@ -117,6 +121,172 @@ func (s *testSuite) TestCSVImporter() {
 	ds := db.GetDataset(setName)
 
 	validateList(s, ds.HeadValue().(types.List))
+
+	meta := ds.Head().Get(datas.MetaField).(types.Struct)
+	s.Equal(types.Number(TEST_DATA_SIZE), meta.Get("rowsImported"))
+	s.Equal(types.Number(0), meta.Get("rowsSkipped"))
+	fi, err := os.Stat(s.tmpFileName)
+	s.NoError(err)
+	s.Equal(types.Number(fi.Size()), meta.Get("bytesRead"))
+	s.True(meta.Get("chunksWritten").(types.Number) > 0)
+	s.True(meta.Get("chunkBytesWritten").(types.Number) > 0)
+	s.True(meta.Get("durationSeconds").(types.Number) > 0)
+	s.Equal(types.String(s.tmpFileName), meta.Get("inputFile"))
+}
+
+func (s *testSuite) TestCSVImporterVerify() {
+	defer os.RemoveAll(s.DBDir)
+	dataspec := spec.CreateValueSpecString("nbs", s.DBDir, "csv")
+
+	stdout, stderr := s.MustRun(main, []string{"--no-progress", "--verify", "--verify-samples", "10", "--column-types", TEST_FIELDS, s.tmpFileName, dataspec})
+	s.Equal("", stdout)
+	s.Equal("", stderr)
+
+	stdout, stderr = s.MustRun(main, []string{"--no-progress", "--verify", "--column-types", TEST_FIELDS, "--dest-type", "map:0,1", s.tmpFileName, dataspec})
+	s.Equal("", stdout)
+	s.Equal("", stderr)
+}
+
+func (s *testSuite) TestCSVImporterMaxCategories() {
+	setName := "csv"
+	dataspec := spec.CreateValueSpecString("nbs", s.DBDir, setName)
+	stdout, stderr := s.MustRun(main, []string{"--no-progress", "--verify", "--max-categories", "3", s.tmpFileName, dataspec})
+	s.Equal("", stdout)
+	s.Equal("", stderr)
+
+	db := datas.NewDatabase(nbs.NewLocalStore(s.DBDir, clienttest.DefaultMemTableSize))
+	defer os.RemoveAll(s.DBDir)
+	defer db.Close()
+	ds := db.GetDataset(setName)
+
+	// Only year has as few as 3 distinct values.
+	rows, cats := csv.SplitCategorical(ds.HeadValue())
+	s.Len(cats, 1)
+	s.Equal(uint64(3), cats["year"].Len())
+	row := rows.(types.List).Get(4).(types.Struct)
+	s.Equal(types.Number(1), row.Get("year"))
+	s.Equal(types.String("a4"), row.Get("a"))
+	s.Equal(types.String("2013"), cats.Decode(row).Get("year"))
+}
+
+func (s *testSuite) TestCSVImporterShardBy() {
+	dataspec := spec.CreateValueSpecString("nbs", s.DBDir, "csv")
+	stdout, stderr := s.MustRun(main, []string{"--no-progress", "--column-types", TEST_FIELDS, "--shard-by", "year", s.tmpFileName, dataspec})
+	s.Equal("", stdout)
+	s.Equal("", stderr)
+
+	db := datas.NewDatabase(nbs.NewLocalStore(s.DBDir, clienttest.DefaultMemTableSize))
+	defer os.RemoveAll(s.DBDir)
+	defer db.Close()
+
+	manifest := db.GetDataset("csv").HeadValue().(types.Struct)
+	s.Equal(types.String("year"), manifest.Get("shardBy"))
+	shards := manifest.Get("shards").(types.List)
+	s.Equal(uint64(3), shards.Len())
+	shards.IterAll(func(v types.Value, i uint64) {
+		shard := v.(types.Struct)
+		id := fmt.Sprintf("csv/part-%05d", i+1)
+		s.Equal(types.String(id), shard.Get("dataset"))
+		s.Equal(types.String(fmt.Sprintf("%d", TEST_YEAR+i)), shard.Get("key"))
+
+		l := db.GetDataset(id).HeadValue().(types.List)
+		s.Equal(shard.Get("rows"), types.Number(l.Len()))
+		s.Equal(types.Number(TEST_YEAR+i), l.Get(0).(types.Struct).Get("year"))
+	})
+}
+
+func (s *testSuite) TestCSVImporterShardByDeletesStaleShards() {
+	defer os.RemoveAll(s.DBDir)
+	dataspec := spec.CreateValueSpecString("nbs", s.DBDir, "csv")
+	s.MustRun(main, []string{"--no-progress", "--column-types", TEST_FIELDS, "--shard-by", "year", s.tmpFileName, dataspec})
+	s.MustRun(main, []string{"--no-progress", "--column-types", TEST_FIELDS, "--shard-by", "rows:1000", s.tmpFileName, dataspec})
+
+	db := datas.NewDatabase(nbs.NewLocalStore(s.DBDir, clienttest.DefaultMemTableSize))
+	defer db.Close()
+	s.Equal(uint64(1), db.GetDataset("csv").HeadValue().(types.Struct).Get("shards").(types.List).Len())
+	s.True(db.GetDataset("csv/part-00001").HasHead())
+	s.False(db.GetDataset("csv/part-00002").HasHead())
+	s.False(db.GetDataset("csv/part-00003").HasHead())
+}
+
+func (s *testSuite) TestCSVImporterRecordSeparator() {
+	defer os.RemoveAll(s.DBDir)
+	s.NoError(ioutil.WriteFile(s.tmpFileName, []byte("a,b\x1e1,two\nlines\x1e2,x\x1e"), 0644))
+	dataspec := spec.CreateValueSpecString("nbs", s.DBDir, "csv")
+
+	stdout, stderr := s.MustRun(main, []string{"--no-progress", "--verify", "--record-separator", "\x1e", s.tmpFileName, dataspec})
+	s.Equal("", stdout)
+	s.Equal("", stderr)
+
+	db := datas.NewDatabase(nbs.NewLocalStore(s.DBDir, clienttest.DefaultMemTableSize))
+	defer db.Close()
+	l := db.GetDataset("csv").HeadValue().(types.List)
+	s.Equal(uint64(2), l.Len())
+	s.Equal(types.String("two\nlines"), l.Get(0).(types.Struct).Get("b"))
+}
+
+func (s *testSuite) TestCSVImporterManifest() {
+	defer os.RemoveAll(s.DBDir)
+	manifestPath := filepath.Join(s.TempDir, "manifest.json")
+	defer os.Remove(manifestPath)
+
+	dataspec := spec.CreateValueSpecString("nbs", s.DBDir, "csv")
+	s.MustRun(main, []string{"--no-progress", "--column-types", TEST_FIELDS, "--dest-type", "map:0,1", "--manifest", manifestPath, s.tmpFileName, dataspec})
+
+	m, err := readManifest(manifestPath)
+	s.NoError(err)
+	s.Equal([]string{"year", "a", "b", "c"}, m.Headers)
+	s.True(m.HeaderRow)
+	s.Equal(strings.Split(TEST_FIELDS, ","), m.ColumnTypes)
+	s.Equal("map:0,1", m.DestType)
+	data, err := ioutil.ReadFile(s.tmpFileName)
+	s.NoError(err)
+	s.Equal(hash.Of(data).String(), m.SourceHash)
+
+	// Replaying the manifest, with none of the original flags, imports a new file the same way.
+	otherSpec := spec.CreateValueSpecString("nbs", s.DBDir, "csv2")
+	s.MustRun(main, []string{"--no-progress", "--from-manifest", manifestPath, s.tmpFileName, otherSpec})
+
+	db := datas.NewDatabase(nbs.NewLocalStore(s.DBDir, clienttest.DefaultMemTableSize))
+	defer db.Close()
+	validateNestedMap(s, db.GetDataset("csv2").HeadValue().(types.Map))
+	s.True(db.GetDataset("csv").HeadValue().Equals(db.GetDataset("csv2").HeadValue()))
+
+	// A header row which doesn't match the manifest is an error.
+	s.NoError(ioutil.WriteFile(s.tmpFileName, []byte("x,y,z,w\n1,a,2,3\n"), 0644))
+	_, _, exitErr := s.Run(main, []string{"--no-progress", "--from-manifest", manifestPath, s.tmpFileName, otherSpec})
+	s.Equal(clienttest.ExitError{1}, exitErr)
+}
+
+func (s *testSuite) TestCSVImporterKeepSource() {
+	defer os.RemoveAll(s.DBDir)
+	dataspec := spec.CreateValueSpecString("nbs", s.DBDir, "csv")
+	stdout, stderr := s.MustRun(main, []string{"--no-progress", "--keep-source", "--column-types", TEST_FIELDS, s.tmpFileName, dataspec})
+	s.Equal("", stdout)
+	s.Equal("", stderr)
+
+	db := datas.NewDatabase(nbs.NewLocalStore(s.DBDir, clienttest.DefaultMemTableSize))
+	defer db.Close()
+	ds := db.GetDataset("csv")
+	validateList(s, ds.HeadValue().(types.List))
+
+	meta := ds.Head().Get(datas.MetaField).(types.Struct)
+	source := meta.Get("source").(types.Ref).TargetValue(db).(types.Blob)
+	expected, err := ioutil.ReadFile(s.tmpFileName)
+	s.NoError(err)
+	actual, err := ioutil.ReadAll(source.Reader())
+	s.NoError(err)
+	s.Equal(expected, actual)
+
+	// Importing the same file again finds its Blob in the database.
+	s.MustRun(main, []string{"--no-progress", "--keep-source", "--column-types", TEST_FIELDS, s.tmpFileName, spec.CreateValueSpecString("nbs", s.DBDir, "again")})
+	db2 := datas.NewDatabase(nbs.NewLocalStore(s.DBDir, clienttest.DefaultMemTableSize))
+	defer db2.Close()
+	again := db2.GetDataset("again").Head().Get(datas.MetaField).(types.Struct).Get("source")
+	s.True(meta.Get("source").Equals(again))
+
+	_, _, exitErr := s.Run(main, []string{"--no-progress", "--keep-source", "-p", spec.CreateValueSpecString("nbs", s.DBDir, "csv.value"), dataspec})
+	s.Equal(clienttest.ExitError{1}, exitErr)
 }
 
 func (s *testSuite) TestCSVImporterFromBlob() {
@@ -229,6 +399,32 @@ func (s *testSuite) TestCSVImporterWithPipe() {
 	s.Equal(types.Number(2), st.Get("b"))
 }
 
+func (s *testSuite) TestCSVImporterWithAutoDelimiter() {
+	input, err := ioutil.TempFile(s.TempDir, "")
+	d.Chk.NoError(err)
+	defer input.Close()
+	defer os.Remove(input.Name())
+
+	_, err = input.WriteString("a;b\r\n\"1,5\";2\r\n3;4\r\n")
+	d.Chk.NoError(err)
+
+	setName := "csv"
+	dataspec := spec.CreateValueSpecString("nbs", s.DBDir, setName)
+	stdout, stderr := s.MustRun(main, []string{"--no-progress", "--verify", "--column-types", "String,Number", "--delimiter", "auto", input.Name(), dataspec})
+	s.Equal("", stdout)
+	s.Equal("", stderr)
+
+	db := datas.NewDatabase(nbs.NewLocalStore(s.DBDir, clienttest.DefaultMemTableSize))
+	defer os.RemoveAll(s.DBDir)
+	defer db.Close()
+
+	l := db.GetDataset(setName).HeadValue().(types.List)
+	s.Equal(uint64(2), l.Len())
+	st := l.Get(0).(types.Struct)
+	s.Equal(types.String("1,5"), st.Get("a"))
+	s.Equal(types.Number(2), st.Get("b"))
+}
+
 func (s *testSuite) TestCSVImporterWithExternalHeader() {
 	input, err := ioutil.TempFile(s.TempDir, "")
 	d.Chk.NoError(err)
@@ -0,0 +1,70 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"crypto/sha512"
+	"encoding/json"
+	"hash"
+	"io/ioutil"
+	"os"
+
+	nomshash "github.com/attic-labs/noms/go/hash"
+)
+
+// importManifest records the effective options of an import, so that the
+// same import can be repeated on new input with --from-manifest.
+type importManifest struct {
+	// Headers are the column names used, whether they came from the input or
+	// from --header.
+	Headers []string `json:"headers"`
+	// HeaderRow is true if the first row of the input, after any skipped
+	// records, is a header row rather than data.
+	HeaderRow   bool     `json:"headerRow"`
+	ColumnTypes []string `json:"columnTypes,omitempty"`
+	Delimiter   string   `json:"delimiter"`
+	// RecordSeparator is empty if records end with newlines.
+	RecordSeparator string `json:"recordSeparator,omitempty"`
+	DestType        string `json:"destType"`
+	SkipRecords     uint   `json:"skipRecords"`
+	MaxCategories   int    `json:"maxCategories,omitempty"`
+	ShardBy         string `json:"shardBy,omitempty"`
+	Name            string `json:"name"`
+	// SourceHash is the noms hash of the bytes of the input this manifest was
+	// written for. It's informational and isn't checked on replay.
+	SourceHash string `json:"sourceHash,omitempty"`
+}
+
+func readManifest(path string) (importManifest, error) {
+	m := importManifest{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+func writeManifest(path string, m importManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), os.FileMode(0644))
+}
+
+// sourceHasher computes the same hash as hash.Of() over everything written to
+// it.
+type sourceHasher struct {
+	hash.Hash
+}
+
+func newSourceHasher() sourceHasher {
+	return sourceHasher{sha512.New()}
+}
+
+func (sh sourceHasher) String() string {
+	return nomshash.New(sh.Sum(nil)[:nomshash.ByteLen]).String()
+}
@@ -5,42 +5,40 @@
 package main
 
 import (
+	"context"
+	gocsv "encoding/csv"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path"
 	"testing"
 
 	"github.com/attic-labs/noms/go/perf/suite"
 	"github.com/attic-labs/noms/go/types"
 	"github.com/attic-labs/noms/samples/go/csv"
-	"github.com/attic-labs/testify/assert"
 	humanize "github.com/dustin/go-humanize"
 )
 
-// CSV perf suites require the testdata directory to be checked out at $GOPATH/src/github.com/attic-labs/testdata (i.e. ../testdata relative to the noms directory).
+// CSV perf suites use the testdata directory checked out at $GOPATH/src/github.com/attic-labs/testdata (i.e. ../testdata relative to the noms directory). If it isn't there, they fall back to generated data shaped roughly like it.
+
+var (
+	sfCrimeSpec  = suite.CSVSpec{Rows: 200000, Columns: 13, Cardinality: 5000, RunLength: 4, Seed: 1}
+	sfRegBusSpec = suite.CSVSpec{
+		Rows:    100000,
+		Columns: 6,
+		Header:  []string{"Location_Id", "Business_Account_Number", "DBA_Name", "Zip_Code", "Business_Start_Date", "Neighborhood"},
+		Seed:    2,
+	}
+)
 
 type perfSuite struct {
 	suite.PerfSuite
-	csvImportExe string
-}
-
-func (s *perfSuite) SetupSuite() {
-	// Trick the temp file logic into creating a unique path for the csv-import binary.
-	f := s.TempFile()
-	f.Close()
-	os.Remove(f.Name())
-
-	s.csvImportExe = f.Name()
-	err := exec.Command("go", "build", "-o", s.csvImportExe, "github.com/attic-labs/noms/samples/go/csv/csv-import").Run()
-	assert.NoError(s.T, err)
 }
 
 func (s *perfSuite) Test01ImportSfCrimeBlobFromTestdata() {
 	assert := s.NewAssert()
 
-	files := s.OpenGlob(s.Testdata, "sf-crime", "2016-07-28.*")
+	files := s.OpenGlobOrGenerate(sfCrimeSpec, s.Testdata, "sf-crime", "2016-07-28.*")
 	defer s.CloseGlob(files)
 
 	blob := types.NewBlob(files...)
@@ -52,13 +50,13 @@ func (s *perfSuite) Test01ImportSfCrimeBlobFromTestdata() {
 }
 
 func (s *perfSuite) Test02ImportSfCrimeCSVFromBlob() {
-	s.execCsvImportExe("sf-crime")
+	s.importFromBlob("sf-crime", "list")
 }
 
 func (s *perfSuite) Test03ImportSfRegisteredBusinessesFromBlobAsMap() {
 	assert := s.NewAssert()
 
-	files := s.OpenGlob(s.Testdata, "sf-registered-businesses", "2016-07-25.csv")
+	files := s.OpenGlobOrGenerate(sfRegBusSpec, s.Testdata, "sf-registered-businesses", "2016-07-25.csv")
 	defer s.CloseGlob(files)
 
 	blob := types.NewBlob(files...)
@@ -68,30 +66,41 @@ func (s *perfSuite) Test03ImportSfRegisteredBusinessesFromBlobAsMap() {
 	_, err := s.Database.CommitValue(ds, blob)
 	assert.NoError(err)
 
-	s.execCsvImportExe("sf-reg-bus", "--dest-type", "map:0")
+	s.importFromBlob("sf-reg-bus", "map:0")
 }
 
 func (s *perfSuite) Test04ImportSfRegisteredBusinessesFromBlobAsMultiKeyMap() {
-	s.execCsvImportExe("sf-reg-bus", "--dest-type", "map:Zip_Code,Business_Start_Date")
+	s.importFromBlob("sf-reg-bus", "map:Zip_Code,Business_Start_Date")
 }
 
-func (s *perfSuite) execCsvImportExe(dsName string, args ...string) {
+// importFromBlob imports the CSV blob at the head of the <dsName>/raw dataset into the <dsName> dataset, as csv-import -p would.
+func (s *perfSuite) importFromBlob(dsName, destType string) {
 	assert := s.NewAssert()
 
-	blobSpec := fmt.Sprintf("%s::%s/raw.value", s.DatabaseSpec, dsName)
-	destSpec := fmt.Sprintf("%s::%s", s.DatabaseSpec, dsName)
-	args = append(args, "-p", blobSpec, destSpec)
-	importCmd := exec.Command(s.csvImportExe, args...)
-	importCmd.Stdout = s.W
-	importCmd.Stderr = os.Stderr
+	raw, ok := s.Database.GetDataset(dsName + "/raw").MaybeHeadValue()
+	assert.True(ok)
+
+	allocsBefore := types.GetAllocStats()
+	value, stats, err := csv.Import(context.Background(), csv.ImportOptions{
+		Input:    raw.(types.Blob).Reader(),
+		Dest:     s.Database,
+		DestType: destType,
+	})
+	assert.NoError(err)
+	allocs := types.GetAllocStats()
+	fmt.Fprintf(s.W, "	imported %d rows from %s\n", stats.RowsImported, humanize.Bytes(stats.BytesRead))
+	fmt.Fprintf(s.W, "	encode buffers: %d allocated, %d reused\n", allocs.WritersAllocated-allocsBefore.WritersAllocated, allocs.WritersReused-allocsBefore.WritersReused)
 
-	assert.NoError(importCmd.Run())
+	_, err = s.Database.CommitValue(s.Database.GetDataset(dsName), value)
+	assert.NoError(err)
 }
 
+// The TestParse, TestConvert and TestReadToList tests run successively more of the import pipeline on the same input, so that a regression can be attributed to CSV parsing, converting fields to noms values, building and chunking the list, or writing it to the database.
+
 func (s *perfSuite) TestParseSfCrime() {
 	assert := s.NewAssert()
 
-	files := s.OpenGlob(path.Join(s.Testdata, "sf-crime", "2016-07-28.*"))
+	files := s.OpenGlobOrGenerate(sfCrimeSpec, path.Join(s.Testdata, "sf-crime", "2016-07-28.*"))
 	defer s.CloseGlob(files)
 
 	reader := csv.NewCSVReader(io.MultiReader(files...), ',')
@@ -104,6 +113,71 @@ func (s *perfSuite) TestParseSfCrime() {
 	}
 }
 
+func (s *perfSuite) TestConvertSfCrime() {
+	assert := s.NewAssert()
+
+	reader, _, kinds, closeFn := s.openSfCrime()
+	defer closeFn()
+
+	for {
+		row, err := reader.Read()
+		if err != nil {
+			assert.Equal(io.EOF, err)
+			break
+		}
+		for i, field := range row {
+			_, err := csv.StringToValue(field, kinds[i])
+			assert.NoError(err)
+		}
+	}
+}
+
+func (s *perfSuite) TestReadToListSfCrime() {
+	assert := s.NewAssert()
+
+	reader, headers, kinds, closeFn := s.openSfCrime()
+	defer closeFn()
+
+	l, _ := csv.ReadToList(reader, "Row", headers, kinds, types.NewTestValueStore())
+	assert.True(l.Len() > 0)
+}
+
+func (s *perfSuite) TestReadToListSfCrimeToDatabase() {
+	assert := s.NewAssert()
+
+	reader, headers, kinds, closeFn := s.openSfCrime()
+	defer closeFn()
+
+	l, _ := csv.ReadToList(reader, "Row", headers, kinds, s.Database)
+	ds := s.Database.GetDataset("sf-crime/list")
+	_, err := s.Database.CommitValue(ds, l)
+	assert.NoError(err)
+}
+
+// openSfCrime returns a reader over the sf-crime rows, positioned after the header, along with the headers and the kinds inferred for each column. Inferring the kinds needs a separate pass over the data, which is excluded from the test time.
+func (s *perfSuite) openSfCrime() (reader *gocsv.Reader, headers []string, kinds csv.KindSlice, closeFn func()) {
+	assert := s.NewAssert()
+
+	// OpenGlobOrGenerate excludes any generating from the test time itself, so it mustn't be called within Pause.
+	files := s.OpenGlobOrGenerate(sfCrimeSpec, path.Join(s.Testdata, "sf-crime", "2016-07-28.*"))
+	s.Pause(func() {
+		r := csv.NewCSVReader(io.MultiReader(files...), ',')
+		var err error
+		headers, err = r.Read()
+		assert.NoError(err)
+		kinds = csv.GetSchema(r, 1<<30, len(headers))
+		for _, f := range files {
+			_, err = f.(*os.File).Seek(0, 0)
+			assert.NoError(err)
+		}
+	})
+
+	reader = csv.NewCSVReader(io.MultiReader(files...), ',')
+	_, err := reader.Read()
+	assert.NoError(err)
+	return reader, headers, kinds, func() { s.CloseGlob(files) }
+}
+
 func TestPerf(t *testing.T) {
 	suite.Run("csv-import", t, &perfSuite{})
 }
@@ -0,0 +1,88 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/samples/go/csv"
+)
+
+// shardDatasetID is the ID of the dataset of the i'th (0-based) shard of an
+// import to the dataset id.
+func shardDatasetID(id string, i int) string {
+	return fmt.Sprintf("%s%05d", shardDatasetPrefix(id), i+1)
+}
+
+// shardDatasetPrefix is the prefix of the IDs of every shard dataset of id.
+func shardDatasetPrefix(id string) string {
+	return id + "/part-"
+}
+
+// commitShards commits each shard to a dataset of its own, named for ds by
+// shardDatasetID, and a manifest of the shards to ds, all in one CommitMany so
+// that readers never see a manifest without its shards:
+//
+//	struct ShardManifest {
+//	  shardBy: String,
+//	  shards: List<struct Shard {
+//	    dataset: String,
+//	    key: String, // "" if sharded by rows
+//	    rows: Number,
+//	  }>,
+//	}
+//
+// The manifest names the shard datasets rather than referring to their values,
+// so that syncing ds doesn't sync every shard. Shard datasets left over from an
+// earlier import into ds with more shards are deleted afterwards.
+func commitShards(db datas.Database, ds datas.Dataset, shards []csv.Shard, shardBy csv.ShardBy, meta types.Struct) (datas.Dataset, error) {
+	commits := make([]datas.DatasetCommit, 0, len(shards)+1)
+	entries := make(types.ValueSlice, len(shards))
+	for i, shard := range shards {
+		id := shardDatasetID(ds.ID(), i)
+		commits = append(commits, datas.DatasetCommit{Dataset: db.GetDataset(id), Value: shard.Value, Options: datas.CommitOptions{Meta: meta}})
+		entries[i] = types.NewStruct("Shard", types.StructData{
+			"dataset": types.String(id),
+			"key":     types.String(shard.Key),
+			"rows":    types.Number(shard.Rows),
+		})
+	}
+	manifest := types.NewStruct("ShardManifest", types.StructData{
+		"shardBy": types.String(shardBy.String()),
+		"shards":  types.NewList(entries...),
+	})
+	commits = append(commits, datas.DatasetCommit{Dataset: ds, Value: manifest, Options: datas.CommitOptions{Meta: meta}})
+	committed, err := db.CommitMany(commits)
+	if err != nil {
+		return ds, err
+	}
+	if err = deleteStaleShards(db, ds.ID(), len(shards)); err != nil {
+		return ds, err
+	}
+	return committed[len(committed)-1], nil
+}
+
+// deleteStaleShards deletes the shard datasets of id from the n'th (0-based)
+// on. Shard dataset IDs are zero-padded, so they sort in shard order.
+func deleteStaleShards(db datas.Database, id string, n int) error {
+	prefix := shardDatasetPrefix(id)
+	stale := []string{}
+	db.Datasets().IterFrom(types.String(shardDatasetID(id, n)), func(k, v types.Value) bool {
+		if !strings.HasPrefix(string(k.(types.String)), prefix) {
+			return true
+		}
+		stale = append(stale, string(k.(types.String)))
+		return false
+	})
+	for _, sid := range stale {
+		if _, err := db.Delete(db.GetDataset(sid)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/util/progressreader"
+)
+
+// The phases of an import, as reported by importStatus.
+const (
+	phaseStoringSource = "storing source"
+	phaseImporting     = "importing"
+	phaseVerifying     = "verifying"
+	phaseCommitting    = "committing"
+	phaseDone          = "done"
+)
+
+// importStatus is the progress of an import, which is served as JSON by
+// ServeHTTP, so that a long import running as a service can be observed. It
+// also takes requests to stop the import early, which then commits the rows
+// imported so far, so that such an import can be drained. An import stopped
+// before any rows are imported, e.g. while its source is being stored, commits
+// nothing.
+type importStatus struct {
+	mu         sync.Mutex
+	phase      string
+	rows       uint64
+	bytes      uint64
+	totalBytes uint64
+	start      time.Time
+	stop       chan struct{}
+	stopping   bool
+}
+
+// statusReport is the JSON served by importStatus.
+type statusReport struct {
+	Phase          string  `json:"phase"`
+	Rows           uint64  `json:"rows"`
+	Bytes          uint64  `json:"bytes"`
+	TotalBytes     uint64  `json:"totalBytes,omitempty"`
+	RowsPerSecond  float64 `json:"rowsPerSecond"`
+	BytesPerSecond float64 `json:"bytesPerSecond"`
+	// ETASeconds is the estimated time left to read the input, or 0 if
+	// unknown.
+	ETASeconds float64 `json:"etaSeconds"`
+	Stopping   bool    `json:"stopping"`
+}
+
+func newImportStatus(totalBytes uint64) *importStatus {
+	return &importStatus{phase: phaseImporting, totalBytes: totalBytes, start: time.Now(), stop: make(chan struct{})}
+}
+
+// setPhase starts phase. The rates of the importing phase are measured from its start.
+func (s *importStatus) setPhase(phase string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.phase = phase
+	if phase == phaseImporting {
+		s.start = time.Now()
+	}
+}
+
+func (s *importStatus) setRows(rows uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rows = rows
+}
+
+func (s *importStatus) setBytes(bytes uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bytes = bytes
+}
+
+func (s *importStatus) report() statusReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := progressreader.Progress{Seen: s.bytes, Total: s.totalBytes, Elapsed: time.Since(s.start)}
+	r := statusReport{
+		Phase:          s.phase,
+		Rows:           s.rows,
+		Bytes:          s.bytes,
+		TotalBytes:     s.totalBytes,
+		BytesPerSecond: p.Rate(),
+		ETASeconds:     p.ETA().Seconds(),
+		Stopping:       s.stopping,
+	}
+	if secs := p.Elapsed.Seconds(); secs > 0 {
+		r.RowsPerSecond = float64(s.rows) / secs
+	}
+	return r
+}
+
+// requestStop closes s.stop, the first time it's called.
+func (s *importStatus) requestStop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.stopping {
+		s.stopping = true
+		close(s.stop)
+	}
+}
+
+// ServeHTTP serves the status as JSON at /status, and stops the import on a
+// POST to /stop.
+func (s *importStatus) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.URL.Path {
+	case "/status":
+	case "/stop":
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.requestStop()
+	default:
+		http.NotFound(w, req)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	d.PanicIfError(json.NewEncoder(w).Encode(s.report()))
+}
+
+// serveStatus serves s at addr until the process exits, returning the
+// address it's listening on.
+func serveStatus(s *importStatus, addr string) (net.Addr, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	go http.Serve(l, s)
+	return l.Addr(), nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestImportStatus(t *testing.T) {
+	assert := assert.New(t)
+	s := newImportStatus(1000)
+	s.setRows(10)
+	s.setBytes(250)
+
+	get := func(method, path string) (int, statusReport) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		r := statusReport{}
+		if w.Code == http.StatusOK {
+			assert.NoError(json.Unmarshal(w.Body.Bytes(), &r))
+		}
+		return w.Code, r
+	}
+
+	code, r := get("GET", "/status")
+	assert.Equal(http.StatusOK, code)
+	assert.Equal(phaseImporting, r.Phase)
+	assert.Equal(uint64(10), r.Rows)
+	assert.Equal(uint64(250), r.Bytes)
+	assert.Equal(uint64(1000), r.TotalBytes)
+	assert.True(r.BytesPerSecond > 0)
+	assert.True(r.ETASeconds > 0)
+	assert.False(r.Stopping)
+
+	code, _ = get("GET", "/stop")
+	assert.Equal(http.StatusMethodNotAllowed, code)
+	code, _ = get("GET", "/nope")
+	assert.Equal(http.StatusNotFound, code)
+	select {
+	case <-s.stop:
+		assert.Fail("stopped by a GET")
+	default:
+	}
+
+	// Stopping twice is fine.
+	for i := 0; i < 2; i++ {
+		code, r = get("POST", "/stop")
+		assert.Equal(http.StatusOK, code)
+		assert.True(r.Stopping)
+	}
+	<-s.stop
+
+	s.setPhase(phaseCommitting)
+	_, r = get("GET", "/status")
+	assert.Equal(phaseCommitting, r.Phase)
+}
@@ -0,0 +1,145 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/spec"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/samples/go/csv"
+)
+
+// watcher polls a directory for new files whose names match a pattern. A new
+// file is only reported once its size and modification time are the same in
+// two successive polls, so that it isn't imported while it's still being
+// written.
+type watcher struct {
+	dir, pattern string
+	files        map[string]watchedFile
+}
+
+type watchedFile struct {
+	size    int64
+	modTime time.Time
+	done    bool
+}
+
+// newWatcher returns a watcher of dir which ignores the files already in it.
+func newWatcher(dir, pattern string) (*watcher, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	if fi, err := os.Stat(dir); err != nil {
+		return nil, err
+	} else if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	w := &watcher{dir, pattern, map[string]watchedFile{}}
+	if _, err := w.poll(); err != nil {
+		return nil, err
+	}
+	for p, f := range w.files {
+		f.done = true
+		w.files[p] = f
+	}
+	return w, nil
+}
+
+// poll returns the paths of the files which have become ready to import since
+// the last poll, in name order.
+func (w *watcher) poll() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(w.dir, w.pattern))
+	if err != nil {
+		return nil, err
+	}
+
+	ready := []string{}
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil || !fi.Mode().IsRegular() {
+			// Removed since the glob, or a directory.
+			continue
+		}
+		prev, ok := w.files[p]
+		f := watchedFile{fi.Size(), fi.ModTime(), prev.done}
+		if ok && !f.done && f.size == prev.size && f.modTime.Equal(prev.modTime) {
+			f.done = true
+			ready = append(ready, p)
+		}
+		w.files[p] = f
+	}
+	return ready, nil
+}
+
+// watchAndImport imports each file reported by w as a new commit to ds, until
+// ctx is cancelled. A file which fails to import is reported and skipped.
+func watchAndImport(ctx context.Context, w *watcher, interval time.Duration, db datas.Database, ds datas.Dataset, opts csv.ImportOptions) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		paths, err := w.poll()
+		if err != nil {
+			return err
+		}
+		for _, p := range paths {
+			ds, err = importWatchedFile(ctx, db, ds, p, opts)
+			if ctx.Err() != nil {
+				return nil
+			} else if err != nil {
+				fmt.Fprintf(os.Stderr, "Error importing %s: %s\n", p, err)
+			} else {
+				fmt.Printf("Imported %s as #%s\n", p, ds.HeadRef().TargetHash())
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// importWatchedFile imports the file at path as a new commit to ds, with the
+// file name and import stats in its meta. On error, ds is returned unchanged.
+func importWatchedFile(ctx context.Context, db datas.Database, ds datas.Dataset, path string, opts csv.ImportOptions) (datas.Dataset, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ds, err
+	}
+	defer f.Close()
+
+	metrics := types.NewExpvarMetrics("")
+	if ms, ok := db.(valueStoreWithMetrics); ok {
+		ms.SetMetrics(metrics)
+	}
+
+	opts.Input, opts.InputName, opts.Dest = f, path, db
+	value, stats, err := csv.Import(ctx, opts)
+	if err != nil {
+		return ds, d.Annotate(err, "", ds.ID())
+	}
+
+	metaValues := importStats(stats, metrics)
+	metaValues[csv.HeadersMetaField] = csv.HeadersValue(stats.Headers)
+	meta, err := spec.CreateCommitMetaStruct(db, "", "", additionalMetaInfo(path, ""), metaValues)
+	if err != nil {
+		return ds, err
+	}
+	newDS, err := db.Commit(ds, value, datas.CommitOptions{Meta: meta})
+	if err != nil {
+		return ds, err
+	}
+	return newDS, nil
+}
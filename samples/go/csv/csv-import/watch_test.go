@@ -0,0 +1,87 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/samples/go/csv"
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestWatcherPoll(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "watch")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	write := func(name, data string) string {
+		p := filepath.Join(dir, name)
+		assert.NoError(ioutil.WriteFile(p, []byte(data), 0644))
+		return p
+	}
+
+	write("old.csv", "a\n1\n")
+	w, err := newWatcher(dir, "*.csv")
+	assert.NoError(err)
+	assert.Empty(mustPoll(t, w))
+
+	// New files are only ready once they've been seen unchanged.
+	p := write("new.csv", "a\n")
+	write("new.txt", "a\n")
+	assert.Empty(mustPoll(t, w))
+	assert.Equal([]string{p}, mustPoll(t, w))
+	assert.Empty(mustPoll(t, w))
+
+	_, err = newWatcher(filepath.Join(dir, "nope"), "*.csv")
+	assert.Error(err)
+	_, err = newWatcher(dir, "[")
+	assert.Error(err)
+}
+
+func mustPoll(t *testing.T, w *watcher) []string {
+	ready, err := w.poll()
+	assert.NoError(t, err)
+	return ready
+}
+
+func TestWatchAndImport(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "watch")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	w, err := newWatcher(dir, "*.csv")
+	assert.NoError(err)
+
+	p1 := filepath.Join(dir, "1.csv")
+	assert.NoError(ioutil.WriteFile(p1, []byte("a,b\n1,2\n"), 0644))
+	assert.NoError(ioutil.WriteFile(filepath.Join(dir, "2.csv"), []byte("a,a\n1,2\n"), 0644))
+	p3 := filepath.Join(dir, "3.csv")
+	assert.NoError(ioutil.WriteFile(p3, []byte("a,b\n3,4\n5,6\n"), 0644))
+
+	db := datas.NewDatabase(chunks.NewMemoryStore())
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	assert.NoError(watchAndImport(ctx, w, 10*time.Millisecond, db, db.GetDataset("csv"), csv.ImportOptions{}))
+
+	// 2.csv has duplicate headers, so it's skipped.
+	head := db.GetDataset("csv").Head()
+	assert.Equal(uint64(2), head.Get(datas.ValueField).(types.List).Len())
+	assert.Equal(types.String(p3), head.Get(datas.MetaField).(types.Struct).Get("inputFile"))
+
+	parent := head.Get(datas.ParentsField).(types.Set).First().(types.Ref).TargetValue(db).(types.Struct)
+	assert.Equal(types.String(p1), parent.Get(datas.MetaField).(types.Struct).Get("inputFile"))
+}
@@ -6,8 +6,10 @@ package csv
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/csv"
 	"io"
+	"unicode/utf8"
 )
 
 var (
@@ -51,3 +53,140 @@ func NewCSVReader(res io.Reader, comma rune) *csv.Reader {
 	r.FieldsPerRecord = -1 // Don't enforce number of fields.
 	return r
 }
+
+// NewCSVReaderWithRecordSeparator is like NewCSVReader, but records end with
+// sep rather than with a newline. Newlines, and any other characters besides
+// comma, sep and quotes, are part of the fields they appear in.
+func NewCSVReaderWithRecordSeparator(res io.Reader, comma, sep rune) *csv.Reader {
+	if sep == '\n' {
+		return NewCSVReader(res, comma)
+	}
+	r := csv.NewReader(&recordSeparatorReader{r: bufio.NewReader(res), comma: comma, sep: sep})
+	r.Comma = comma
+	r.FieldsPerRecord = -1 // Don't enforce number of fields.
+	return r
+}
+
+type recordSeparatorState int
+
+const (
+	fieldStart recordSeparatorState = iota
+	inField
+	inQuotedField
+	quoteInQuotedField
+)
+
+// recordSeparatorReader rewrites CSV whose records end with sep into CSV whose
+// records end with newlines, for csv.Reader to parse. Each field is quoted in
+// the output, so that newlines in the input remain part of their fields.
+type recordSeparatorReader struct {
+	r             *bufio.Reader
+	comma, sep    rune
+	state         recordSeparatorState
+	recordStarted bool
+	// raw is the encoding of the rune being rewritten, which is copied to out
+	// as is, so that bytes which aren't valid UTF-8 aren't changed.
+	raw []byte
+	out bytes.Buffer
+	err error
+}
+
+func (r *recordSeparatorReader) Read(p []byte) (n int, err error) {
+	for r.out.Len() < len(p) && r.err == nil {
+		var buf []byte
+		buf, r.err = r.r.Peek(utf8.UTFMax)
+		if len(buf) == 0 {
+			if r.err == io.EOF {
+				r.endInput()
+			}
+			continue
+		}
+		r.err = nil
+		c, size := utf8.DecodeRune(buf)
+		r.raw = append(r.raw[:0], buf[:size]...)
+		r.r.Discard(size)
+		r.next(c)
+	}
+	if r.out.Len() > 0 {
+		return r.out.Read(p)
+	}
+	return 0, r.err
+}
+
+func (r *recordSeparatorReader) next(c rune) {
+	switch r.state {
+	case fieldStart:
+		if c == '"' {
+			r.out.WriteByte('"')
+			r.recordStarted = true
+			r.state = inQuotedField
+			return
+		}
+		if c == r.sep && !r.recordStarted {
+			// Skip empty records, as csv.Reader skips empty lines.
+			return
+		}
+		r.out.WriteByte('"')
+		r.recordStarted = true
+		r.state = inField
+		r.nextInField(c)
+	case inField:
+		r.nextInField(c)
+	case inQuotedField:
+		if c == '"' {
+			r.state = quoteInQuotedField
+		} else {
+			r.out.Write(r.raw)
+		}
+	case quoteInQuotedField:
+		if c == '"' {
+			r.out.WriteString(`""`)
+			r.state = inQuotedField
+			return
+		}
+		r.out.WriteByte('"')
+		r.endField(c)
+	}
+}
+
+func (r *recordSeparatorReader) nextInField(c rune) {
+	switch c {
+	case r.comma, r.sep:
+		r.out.WriteByte('"')
+		r.endField(c)
+	case '"':
+		r.out.WriteString(`""`)
+	default:
+		r.out.Write(r.raw)
+	}
+}
+
+// endField writes c, which follows a field that has just been closed.
+func (r *recordSeparatorReader) endField(c rune) {
+	r.state = fieldStart
+	switch c {
+	case r.comma:
+		r.out.Write(r.raw)
+	case r.sep:
+		r.out.WriteByte('\n')
+		r.recordStarted = false
+	default:
+		// Text after a closing quote, which csv.Reader reports as an error.
+		r.out.Write(r.raw)
+		r.state = inField
+	}
+}
+
+func (r *recordSeparatorReader) endInput() {
+	switch r.state {
+	case fieldStart:
+		if r.recordStarted {
+			// The record ended with an empty field.
+			r.out.WriteString("\"\"\n")
+		}
+	case inField, quoteInQuotedField:
+		r.out.WriteString("\"\n")
+	case inQuotedField:
+		// Leave the quote open for csv.Reader to report.
+	}
+}
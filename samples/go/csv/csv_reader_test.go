@@ -81,3 +81,40 @@ func TestCRLFEndOfBufferLength(t *testing.T) {
 		t.Errorf("Wrong number of lines. Expected 2, got %d", len(lines))
 	}
 }
+
+func TestRecordSeparator(t *testing.T) {
+	assert := assert.New(t)
+
+	read := func(data string, comma, sep rune) ([][]string, error) {
+		return NewCSVReaderWithRecordSeparator(strings.NewReader(data), comma, sep).ReadAll()
+	}
+
+	lines, err := read("a,b\x1e1,two\nlines\x1e\x1e3,\"quoted\x1e,\"\"x\"\"\"\x1e", ',', '\x1e')
+	assert.NoError(err)
+	assert.Equal([][]string{{"a", "b"}, {"1", "two\nlines"}, {"3", "quoted\x1e,\"x\""}}, lines)
+
+	// No trailing separator, and an empty last field.
+	lines, err = read("a|b;c|\n;d|", '|', ';')
+	assert.NoError(err)
+	assert.Equal([][]string{{"a", "b"}, {"c", "\n"}, {"d", ""}}, lines)
+
+	// Quotes in unquoted fields are kept.
+	lines, err = read(`a"b,c;`, ',', ';')
+	assert.NoError(err)
+	assert.Equal([][]string{{`a"b`, "c"}}, lines)
+
+	_, err = read(`"a"b,c;`, ',', ';')
+	assert.Error(err)
+	_, err = read(`"a,c;`, ',', ';')
+	assert.Error(err)
+
+	// Bytes which aren't valid UTF-8 are kept, as csv.Reader keeps them.
+	lines, err = read("a\xff,\xe2\x82;", ',', ';')
+	assert.NoError(err)
+	assert.Equal([][]string{{"a\xff", "\xe2\x82"}}, lines)
+
+	// A newline separator is the same as NewCSVReader.
+	lines, err = read("a,b\r1,2\r", ',', '\n')
+	assert.NoError(err)
+	assert.Equal([][]string{{"a", "b"}, {"1", "2"}}, lines)
+}
@@ -0,0 +1,195 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package csv
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/attic-labs/noms/go/types"
+)
+
+// DialectCandidates are the delimiters DetectDialect chooses from, in order
+// of preference when the sample fits more than one equally well.
+var DialectCandidates = []rune{',', '\t', ';', '|', ':'}
+
+// DefaultDialectSampleBytes is how much of its input csv-import reads to
+// detect its dialect, with --delimiter=auto.
+const DefaultDialectSampleBytes = 1 << 16
+
+// Dialect describes how a CSV file is written.
+type Dialect struct {
+	// Delimiter separates fields.
+	Delimiter rune
+	// Quoted is whether any field in the sample is quoted.
+	Quoted bool
+	// HasHeader is whether the first record looks like a header row, rather
+	// than data.
+	HasHeader bool
+	// LineEnding is "\n", "\r\n" or "\r", whichever ends most of the lines in
+	// the sample. NewCSVReader accepts all three.
+	LineEnding string
+}
+
+// DetectDialect guesses the Dialect of the CSV data read from r, from at most
+// sampleBytes of it. If the sample ends partway through a record, that record
+// is ignored. It returns an error only if r does, and otherwise the Dialect of
+// a plain CSV file if the sample is empty.
+//
+// The delimiter is the one of DialectCandidates which splits the most records
+// into the same number of fields, more than one. A header row is detected if
+// any column of the other records is all Numbers or Bools but the first
+// record's value of it isn't, or, failing that, if all of the first record's
+// values are distinct Strings, none of which appears again in its column.
+//
+// DetectDialect consumes what it reads of r. To read the sample again, pass it
+// a bytes.Reader of what's been peeked from a bufio.Reader, as csv-import
+// does.
+func DetectDialect(r io.Reader, sampleBytes int) (Dialect, error) {
+	sample := make([]byte, sampleBytes)
+	n, err := io.ReadFull(r, sample)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	} else if err != nil {
+		return Dialect{}, err
+	} else if i := bytes.LastIndexAny(sample, "\r\n"); i >= 0 {
+		// The sample is full, so its last line is probably incomplete.
+		n = i + 1
+	}
+	sample = sample[:n]
+
+	dialect := Dialect{Delimiter: DialectCandidates[0], LineEnding: detectLineEnding(sample)}
+	bestScore := 0
+	var bestRecords [][]string
+	for _, delim := range DialectCandidates {
+		records := sampleRecords(sample, delim)
+		if score := delimiterScore(records); score > bestScore {
+			bestScore = score
+			bestRecords = records
+			dialect.Delimiter = delim
+		}
+	}
+	if bestRecords == nil {
+		bestRecords = sampleRecords(sample, dialect.Delimiter)
+	}
+	dialect.Quoted = hasQuotedField(sample, dialect.Delimiter)
+	dialect.HasHeader = looksLikeHeader(bestRecords)
+	return dialect, nil
+}
+
+// sampleRecords returns the records of sample split by delim, up to the first
+// one which can't be parsed.
+func sampleRecords(sample []byte, delim rune) (records [][]string) {
+	cr := NewCSVReader(bytes.NewReader(sample), delim)
+	for {
+		row, err := cr.Read()
+		if err != nil {
+			return
+		}
+		records = append(records, row)
+	}
+}
+
+// delimiterScore is the number of records with the most common field count,
+// or 0 if that's 1.
+func delimiterScore(records [][]string) int {
+	counts := map[int]int{}
+	best, fields := 0, 0
+	for _, row := range records {
+		counts[len(row)]++
+		if c := counts[len(row)]; c > best || (c == best && len(row) > fields) {
+			best, fields = c, len(row)
+		}
+	}
+	if fields <= 1 {
+		return 0
+	}
+	return best
+}
+
+func detectLineEnding(sample []byte) string {
+	counts := map[string]int{}
+	inQuotes := false
+	for i := 0; i < len(sample); i++ {
+		switch sample[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case '\r':
+			if inQuotes {
+				continue
+			}
+			if i+1 < len(sample) && sample[i+1] == '\n' {
+				counts["\r\n"]++
+				i++
+			} else {
+				counts["\r"]++
+			}
+		case '\n':
+			if !inQuotes {
+				counts["\n"]++
+			}
+		}
+	}
+	ending := "\n"
+	for _, e := range []string{"\r\n", "\r"} {
+		if counts[e] > counts[ending] {
+			ending = e
+		}
+	}
+	return ending
+}
+
+// hasQuotedField is whether a field in sample starts with a quote, i.e. a
+// quote is at the start of a line or follows delim.
+func hasQuotedField(sample []byte, delim rune) bool {
+	prev := '\n'
+	for _, c := range string(sample) {
+		if c == '"' && (prev == delim || prev == '\n' || prev == '\r') {
+			return true
+		}
+		prev = c
+	}
+	return false
+}
+
+func looksLikeHeader(records [][]string) bool {
+	if len(records) < 2 {
+		return false
+	}
+	first, rest := records[0], records[1:]
+
+	so := newSchemaOptions(len(first))
+	for _, row := range rest {
+		so.Test(row)
+	}
+	for i, k := range so.MostSpecificKinds() {
+		if k == types.StringKind {
+			continue
+		}
+		tc := &typeCanFit{true, true, true}
+		tc.Test(first[i])
+		if tc.MostSpecificKind() == types.StringKind {
+			return true
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, h := range first {
+		tc := &typeCanFit{true, true, true}
+		tc.Test(h)
+		if h == "" || seen[h] || tc.MostSpecificKind() != types.StringKind {
+			return false
+		}
+		seen[h] = true
+	}
+	for _, row := range rest {
+		for i, v := range row {
+			if i < len(first) && v == first[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package csv
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestDetectDialect(t *testing.T) {
+	assert := assert.New(t)
+	test := func(input string, expect Dialect) {
+		dialect, err := DetectDialect(strings.NewReader(input), DefaultDialectSampleBytes)
+		assert.NoError(err)
+		assert.Equal(expect, dialect, "%q", input)
+	}
+
+	test("", Dialect{Delimiter: ',', LineEnding: "\n"})
+	test("a,b,c\n1,2,3\n4,5,6\n", Dialect{Delimiter: ',', HasHeader: true, LineEnding: "\n"})
+	test("a\tb\tc\r\n1\t2\t3\r\n4\t5\t6\r\n", Dialect{Delimiter: '\t', HasHeader: true, LineEnding: "\r\n"})
+	test("x;y\r1;true\r2;false\r", Dialect{Delimiter: ';', HasHeader: true, LineEnding: "\r"})
+
+	// A comma in quotes doesn't count, nor does a newline.
+	test("name|note\n\"smith, j\"|\"a\nb\"\n\"jones, k\"|c\n", Dialect{Delimiter: '|', Quoted: true, HasHeader: true, LineEnding: "\n"})
+
+	// Without a header, every record is data.
+	test("1,2,3\n4,5,6\n7,8,9\n", Dialect{Delimiter: ',', LineEnding: "\n"})
+	test("a,b\nc,d\na,e\n", Dialect{Delimiter: ',', LineEnding: "\n"})
+	test("city,state\nSF,CA\nNYC,NY\n", Dialect{Delimiter: ',', HasHeader: true, LineEnding: "\n"})
+}
+
+func TestDetectDialectSample(t *testing.T) {
+	assert := assert.New(t)
+
+	// The partial record at the end of the sample is ignored, so it doesn't count against the delimiter.
+	input := "a;b\n1;2\n3;4\n5,6,7,8"
+	dialect, err := DetectDialect(strings.NewReader(input), len(input)-1)
+	assert.NoError(err)
+	assert.Equal(';', dialect.Delimiter)
+
+	_, err = DetectDialect(&errorReader{errors.New("boom")}, 10)
+	assert.Error(err)
+}
+
+type errorReader struct {
+	err error
+}
+
+func (r *errorReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,133 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package csv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+var updateGolden = flag.Bool("golden.update", false, "rewrite testdata/golden.json with the hashes the golden tests get, rather than checking them")
+
+const goldenFile = "testdata/golden.json"
+
+// goldenCase imports a fixture, whose hashes are expected to be those in
+// goldenFile.
+type goldenCase struct {
+	name string
+	// file is a fixture in testdata. If empty, gen makes the input instead.
+	file string
+	gen  func() []byte
+	opts ImportOptions
+}
+
+// generateRepeats makes a CSV of n rows whose fields repeat with different
+// periods, long enough to be chunked into a tree of several levels.
+func generateRepeats(n int) func() []byte {
+	return func() []byte {
+		colors := []string{"yellow", "red", "green", `"dark, blue"`}
+		buf := &bytes.Buffer{}
+		buf.WriteString("id,color,size,flag\n")
+		for i := 0; i < n; i++ {
+			fmt.Fprintf(buf, "%d,%s,%d,%t\n", i, colors[(i/100)%len(colors)], i%7, i%3 == 0)
+		}
+		return buf.Bytes()
+	}
+}
+
+var goldenCases = []goldenCase{
+	{name: "basic-list", file: "basic.csv", opts: ImportOptions{
+		Kinds: KindSlice{types.NumberKind, types.StringKind, types.NumberKind, types.BoolKind},
+	}},
+	{name: "basic-map", file: "basic.csv", opts: ImportOptions{DestType: "map:id"}},
+	{name: "basic-skip-headers", file: "basic.csv", opts: ImportOptions{
+		SkipRecords: 1, Headers: []string{"a", "b", "c", "d"}, StructName: "Person",
+	}},
+	{name: "semicolon", file: "semicolon.csv", opts: ImportOptions{Delimiter: ';'}},
+	{name: "quoted", file: "quoted.csv"},
+	{name: "ragged", file: "ragged.csv"},
+	{name: "record-separator", file: "pipes.csv", opts: ImportOptions{RecordSeparator: '|'}},
+	{name: "repeats-list", gen: generateRepeats(20000), opts: ImportOptions{
+		Kinds: KindSlice{types.NumberKind, types.StringKind, types.NumberKind, types.BoolKind},
+	}},
+	{name: "repeats-map", gen: generateRepeats(20000), opts: ImportOptions{DestType: "map:color,id"}},
+	{name: "repeats-categorical", gen: generateRepeats(20000), opts: ImportOptions{MaxCategories: 10}},
+}
+
+// TestGoldenHashes imports each of goldenCases, and checks the hashes of the
+// imported value, of the commit of it, and of the input stored as a Blob,
+// against goldenFile. A change to chunking or encoding which changes them
+// fails this test, and must be made deliberately, by running it with
+// -golden.update and committing the new goldenFile.
+func TestGoldenHashes(t *testing.T) {
+	assert := assert.New(t)
+
+	got := map[string]string{}
+	for _, c := range goldenCases {
+		var input []byte
+		if c.file != "" {
+			var err error
+			input, err = ioutil.ReadFile(filepath.Join("testdata", c.file))
+			assert.NoError(err)
+		} else {
+			input = c.gen()
+		}
+
+		db := datas.NewDatabase(chunks.NewMemoryStore())
+		opts := c.opts
+		opts.Input = bytes.NewReader(input)
+		opts.InputName = c.name
+		opts.Dest = db
+		v, _, err := Import(context.Background(), opts)
+		if !assert.NoError(err, c.name) {
+			continue
+		}
+		// Commits have no meta, so that their hashes don't depend on the time.
+		ds, err := db.CommitValue(db.GetDataset(c.name), v)
+		assert.NoError(err, c.name)
+		source := types.NewCSVBlob(db, types.DefaultCSVChunking, bytes.NewReader(input))
+
+		got[c.name+"/value"] = v.Hash().String()
+		got[c.name+"/commit"] = ds.HeadRef().TargetHash().String()
+		got[c.name+"/source"] = source.Hash().String()
+		db.Close()
+	}
+
+	if *updateGolden {
+		data, err := json.MarshalIndent(got, "", "  ")
+		assert.NoError(err)
+		assert.NoError(ioutil.WriteFile(goldenFile, append(data, '\n'), 0644))
+		return
+	}
+
+	data, err := ioutil.ReadFile(goldenFile)
+	if !assert.NoError(err) {
+		return
+	}
+	expected := map[string]string{}
+	assert.NoError(json.Unmarshal(data, &expected))
+
+	names := []string{}
+	for name := range got {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		assert.Equal(expected[name], got[name], "%s changed; if that's intended, run go test -golden.update", name)
+	}
+	assert.Equal(len(expected), len(got), "%s has hashes of cases which no longer exist", goldenFile)
+}
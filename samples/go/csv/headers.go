@@ -0,0 +1,50 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package csv
+
+import "github.com/attic-labs/noms/go/types"
+
+// HeadersMetaField is the field of a commit's meta in which csv-import
+// records the headers of the CSV it imported, in column order, as a List of
+// Strings. Headers which aren't valid struct field names, e.g. "Incident
+// Date", are imported as the fields EscapeStructFieldFromCSV makes of them,
+// e.g. "incidentDate", so the recorded headers let csv-export restore the
+// original header row, and the original order of the columns.
+const HeadersMetaField = "headers"
+
+// HeadersValue returns headers as the value of HeadersMetaField.
+func HeadersValue(headers []string) types.List {
+	values := make([]types.Value, len(headers))
+	for i, h := range headers {
+		values[i] = types.String(h)
+	}
+	return types.NewList(values...)
+}
+
+// HeadersFromMeta returns the headers recorded in the HeadersMetaField of
+// meta, a commit's meta, or nil if there are none.
+func HeadersFromMeta(meta types.Struct) []string {
+	v, ok := meta.MaybeGet(HeadersMetaField)
+	if !ok {
+		return nil
+	}
+	l, ok := v.(types.List)
+	if !ok {
+		return nil
+	}
+	headers := []string{}
+	ok = true
+	l.IterAll(func(v types.Value, idx uint64) {
+		if s, isString := v.(types.String); isString {
+			headers = append(headers, string(s))
+		} else {
+			ok = false
+		}
+	})
+	if !ok {
+		return nil
+	}
+	return headers
+}
@@ -0,0 +1,274 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package csv
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/types"
+)
+
+// ImportOptions describes a CSV import. The zero value of each field other
+// than Input and Dest is a sensible default.
+type ImportOptions struct {
+	// Input is the CSV data to import.
+	Input io.Reader
+	// InputName names Input, e.g. its path, in errors.
+	InputName string
+	// Dest is where the imported rows are written.
+	Dest types.ValueReadWriter
+	// Delimiter separates fields. Defaults to ','.
+	Delimiter rune
+	// RecordSeparator ends records. Defaults to '\n', which also accepts
+	// "\r\n" and '\r'.
+	RecordSeparator rune
+	// Headers names the columns. If empty, the first row of Input (after
+	// SkipRecords) is used.
+	Headers []string
+	// MatchHeaderRow, if Headers is non-empty, says that Input still has a
+	// header row, which must match Headers.
+	MatchHeaderRow bool
+	// StructName is the name of the struct each row is imported as. Defaults
+	// to "Row".
+	StructName string
+	// Kinds is the kind of each column. If empty, every column is a String.
+	Kinds KindSlice
+	// Policies, if non-empty, is the ParsePolicy of each column, for cells
+	// which can't be parsed as its kind. Defaults to ParseError.
+	Policies []ParsePolicy
+	// DestType is "list", or "map:<pk>[,<pk>...]" where each pk is a header
+	// name or a 0-based column index. Defaults to "list".
+	DestType string
+	// SkipRecords is the number of records to skip at the start of Input.
+	SkipRecords uint
+	// MaxCategories, if non-zero, stores String columns with at most this
+	// many distinct values as categories: the imported value is then a
+	// Categorical struct, as returned by Categorize.
+	MaxCategories int
+	// Stop, if non-nil, ends the import early once it's closed. The rows read
+	// until then are imported, and Stats.Stopped is set.
+	Stop <-chan struct{}
+	// RowProgress, if non-nil, is called with the number of rows read so far
+	// after each row.
+	RowProgress func(rows uint64)
+}
+
+// Stats describes a finished import.
+type Stats struct {
+	// Headers are the column names the rows were imported with.
+	Headers []string
+	// PrimaryKeys are the primary keys from a map DestType, or nil for a list.
+	PrimaryKeys []string
+	// Categorical are the fields stored as categories, if any.
+	Categorical  []string
+	RowsImported uint64
+	RowsSkipped  uint64
+	BytesRead    uint64
+	Elapsed      time.Duration
+	// Stopped is whether the import was ended early by ImportOptions.Stop.
+	Stopped bool
+}
+
+// rowHooks are how readToList and readToMap are stopped early, report
+// progress and categorize rows, as ImportOptions.Stop, RowProgress and
+// MaxCategories describe. A nil *rowHooks does none of these.
+type rowHooks struct {
+	stop     <-chan struct{}
+	progress func(rows uint64)
+	// categories, if not nil, encodes each row as it's read.
+	categories *categorizer
+	// stopped is set once reading is stopped early.
+	stopped bool
+}
+
+func newRowHooks(opts ImportOptions) *rowHooks {
+	return &rowHooks{stop: opts.Stop, progress: opts.RowProgress}
+}
+
+// stopNow returns whether reading should stop before the next row.
+func (h *rowHooks) stopNow() bool {
+	if h == nil {
+		return false
+	}
+	select {
+	case <-h.stop:
+		h.stopped = true
+		return true
+	default:
+		return false
+	}
+}
+
+// row returns row as it should be stored.
+func (h *rowHooks) row(row types.Struct) types.Struct {
+	if h == nil || h.categories == nil {
+		return row
+	}
+	return h.categories.encode(row)
+}
+
+// rowRead reports that rows rows have been read.
+func (h *rowHooks) rowRead(rows uint64) {
+	if h != nil && h.progress != nil {
+		h.progress(rows)
+	}
+}
+
+// ParseDestType parses an ImportOptions.DestType, returning the primary keys
+// for a map, or nil for a list.
+func ParseDestType(destType string) (primaryKeys []string, err error) {
+	switch {
+	case destType == "" || destType == "list":
+		return nil, nil
+	case strings.HasPrefix(destType, "map:"):
+		if pks := strings.TrimPrefix(destType, "map:"); pks != "" {
+			return strings.Split(pks, ","), nil
+		}
+	}
+	return nil, fmt.Errorf("Invalid dest-type: %s", destType)
+}
+
+// Import reads opts.Input as CSV into a List or Map of structs, or a
+// Categorical struct of one, as described by opts, and writes it to
+// opts.Dest. The returned value isn't committed. Import stops reading if ctx
+// is cancelled, returning ctx.Err(). An error reading a row is a *d.Error,
+// whose Offset is the record it's in.
+func Import(ctx context.Context, opts ImportOptions) (types.Value, Stats, error) {
+	start := time.Now()
+	stats := Stats{}
+
+	in, err := startImport(opts)
+	if err != nil {
+		return nil, stats, err
+	}
+
+	var value types.Collection
+	hooks := newRowHooks(opts)
+	if opts.MaxCategories > 0 {
+		hooks.categories = newCategorizer(opts.MaxCategories)
+	}
+	if in.pks == nil {
+		value, _, err = readToList(ctx, in.cr, in.structName, in.headers, opts.Kinds, opts.Policies, hooks, opts.Dest)
+	} else {
+		value, err = readToMap(ctx, in.cr, in.structName, in.headers, in.pks, opts.Kinds, opts.Policies, hooks, opts.Dest)
+	}
+	if err != nil {
+		return nil, stats, in.annotate(err)
+	}
+
+	var result types.Value = value
+	var categorical []string
+	if opts.MaxCategories > 0 {
+		result, categorical = hooks.categories.categorical(opts.Dest, value)
+	}
+
+	stats = Stats{
+		Headers:      in.headers,
+		PrimaryKeys:  in.pks,
+		Categorical:  categorical,
+		RowsImported: value.Len(),
+		RowsSkipped:  uint64(opts.SkipRecords),
+		BytesRead:    in.counter.n,
+		Elapsed:      time.Since(start),
+		Stopped:      hooks.stopped,
+	}
+	return result, stats, nil
+}
+
+// importInput is an import's input, positioned at its first row of data.
+type importInput struct {
+	cr         *csv.Reader
+	counter    *countingReader
+	headers    []string
+	pks        []string
+	structName string
+	name       string
+	// preamble is the number of records before the first row of data.
+	preamble uint64
+}
+
+// annotate adds the input's name to err, if it's a *d.Error, and makes its
+// Offset, a row of data, the record in the input.
+func (in importInput) annotate(err error) error {
+	if e, ok := err.(*d.Error); ok && e.Offset != 0 {
+		e.Offset += in.preamble
+	}
+	return d.Annotate(err, in.name, "")
+}
+
+// startImport checks opts, and skips records and reads the header row of
+// opts.Input, as described by opts.
+func startImport(opts ImportOptions) (importInput, error) {
+	in := importInput{structName: opts.StructName, name: opts.InputName, preamble: uint64(opts.SkipRecords)}
+	pks, err := ParseDestType(opts.DestType)
+	if err != nil {
+		return in, err
+	}
+	in.pks = pks
+	delim := opts.Delimiter
+	if delim == 0 {
+		delim = ','
+	}
+	sep := opts.RecordSeparator
+	if sep == 0 {
+		sep = '\n'
+	}
+	if in.structName == "" {
+		in.structName = "Row"
+	}
+
+	in.counter = &countingReader{r: opts.Input}
+	in.cr = NewCSVReaderWithRecordSeparator(in.counter, delim, sep)
+	if err = SkipRecords(in.cr, opts.SkipRecords); err == io.EOF {
+		return in, errors.New("skip-records skipped past EOF")
+	} else if err != nil {
+		return in, err
+	}
+
+	headers := opts.Headers
+	if len(headers) == 0 || opts.MatchHeaderRow {
+		in.preamble++
+		row, err := in.cr.Read()
+		if err != nil {
+			return in, &d.Error{Op: "read header row", File: in.name, Offset: in.preamble, Err: err}
+		}
+		if len(headers) == 0 {
+			headers = row
+		} else if strings.Join(row, ",") != strings.Join(headers, ",") {
+			return in, fmt.Errorf("Header row %v doesn't match the headers %v", row, headers)
+		}
+	}
+	in.headers = headers
+
+	uniqueHeaders := map[string]bool{}
+	for _, h := range headers {
+		uniqueHeaders[h] = true
+	}
+	if len(uniqueHeaders) != len(headers) {
+		return in, errors.New("Invalid headers specified, headers must be unique")
+	}
+	if len(opts.Kinds) != 0 && len(opts.Kinds) != len(headers) {
+		return in, errors.New("Invalid column-types specified, column types do not correspond to number of headers")
+	}
+	return in, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n uint64
+}
+
+func (cr *countingReader) Read(p []byte) (n int, err error) {
+	n, err = cr.r.Read(p)
+	cr.n += uint64(n)
+	return
+}
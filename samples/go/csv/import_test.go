@@ -0,0 +1,200 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package csv
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+const importData = `skipped
+A,B,C
+a,1,true
+b,2,false
+`
+
+func TestImportList(t *testing.T) {
+	assert := assert.New(t)
+	vs := types.NewTestValueStore()
+
+	v, stats, err := Import(context.Background(), ImportOptions{
+		Input:       bytes.NewBufferString(importData),
+		Dest:        vs,
+		Kinds:       KindSlice{types.StringKind, types.NumberKind, types.BoolKind},
+		SkipRecords: 1,
+	})
+	assert.NoError(err)
+
+	l := v.(types.List)
+	assert.Equal(uint64(2), l.Len())
+	row := l.Get(1).(types.Struct)
+	assert.Equal("Row", row.Name())
+	assert.Equal(types.Number(2), row.Get("B"))
+
+	assert.Equal([]string{"A", "B", "C"}, stats.Headers)
+	assert.Nil(stats.PrimaryKeys)
+	assert.Equal(uint64(2), stats.RowsImported)
+	assert.Equal(uint64(1), stats.RowsSkipped)
+	assert.Equal(uint64(len(importData)), stats.BytesRead)
+}
+
+func TestImportMap(t *testing.T) {
+	assert := assert.New(t)
+	vs := types.NewTestValueStore()
+
+	v, stats, err := Import(context.Background(), ImportOptions{
+		Input:      bytes.NewBufferString("a;1\nb;2\n"),
+		Dest:       vs,
+		Delimiter:  ';',
+		Headers:    []string{"key", "value"},
+		StructName: "Pair",
+		DestType:   "map:key",
+	})
+	assert.NoError(err)
+
+	m := v.(types.Map)
+	assert.Equal(uint64(2), m.Len())
+	assert.Equal(types.String("2"), m.Get(types.String("b")).(types.Struct).Get("value"))
+	assert.Equal([]string{"key"}, stats.PrimaryKeys)
+}
+
+func TestImportErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	run := func(opts ImportOptions) error {
+		opts.Dest = types.NewTestValueStore()
+		if opts.Input == nil {
+			opts.Input = bytes.NewBufferString(importData)
+		}
+		_, _, err := Import(context.Background(), opts)
+		return err
+	}
+
+	assert.Error(run(ImportOptions{DestType: "set"}))
+	assert.Error(run(ImportOptions{DestType: "map:"}))
+	assert.Error(run(ImportOptions{SkipRecords: 10}))
+	assert.Error(run(ImportOptions{Input: bytes.NewBufferString("A,A\n")}))
+	assert.Error(run(ImportOptions{SkipRecords: 1, Kinds: KindSlice{types.StringKind}}))
+
+	// A header row that's expected must match the headers.
+	assert.NoError(run(ImportOptions{SkipRecords: 1, Headers: []string{"A", "B", "C"}, MatchHeaderRow: true}))
+	assert.Error(run(ImportOptions{SkipRecords: 1, Headers: []string{"X", "B", "C"}, MatchHeaderRow: true}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := Import(ctx, ImportOptions{Input: bytes.NewBufferString(importData), Dest: types.NewTestValueStore()})
+	assert.Equal(context.Canceled, err)
+}
+
+func TestImportRowError(t *testing.T) {
+	assert := assert.New(t)
+
+	// The offset of a bad row counts the skipped record and the header row.
+	_, _, err := Import(context.Background(), ImportOptions{
+		Input:       bytes.NewBufferString(importData + "c,x,true\n"),
+		InputName:   "data.csv",
+		Dest:        types.NewTestValueStore(),
+		Kinds:       KindSlice{types.StringKind, types.NumberKind, types.BoolKind},
+		SkipRecords: 1,
+	})
+	e, ok := err.(*d.Error)
+	assert.True(ok)
+	assert.Equal("data.csv", e.File)
+	assert.Equal(uint64(5), e.Offset)
+
+	_, _, err = ImportShards(context.Background(), ImportOptions{
+		Input:       bytes.NewBufferString(importData + "c,x,true\n"),
+		InputName:   "data.csv",
+		Dest:        types.NewTestValueStore(),
+		Kinds:       KindSlice{types.StringKind, types.NumberKind, types.BoolKind},
+		SkipRecords: 1,
+	}, ShardBy{Rows: 1})
+	e, ok = err.(*d.Error)
+	assert.True(ok)
+	assert.Equal("data.csv", e.File)
+	assert.Equal(uint64(5), e.Offset)
+}
+
+func TestImportStop(t *testing.T) {
+	assert := assert.New(t)
+
+	data := "n\n1\n2\n3\n4\n"
+	for _, destType := range []string{"list", "map:n"} {
+		stop := make(chan struct{})
+		progress := []uint64{}
+		v, stats, err := Import(context.Background(), ImportOptions{
+			Input:    bytes.NewBufferString(data),
+			Dest:     types.NewTestValueStore(),
+			DestType: destType,
+			Stop:     stop,
+			RowProgress: func(rows uint64) {
+				progress = append(progress, rows)
+				if rows == 2 {
+					close(stop)
+				}
+			},
+		})
+		assert.NoError(err)
+		assert.True(stats.Stopped)
+		assert.Equal(uint64(2), stats.RowsImported)
+		assert.Equal(uint64(2), v.(types.Collection).Len())
+		assert.Equal([]uint64{1, 2}, progress)
+	}
+
+	stop := make(chan struct{})
+	shards, stats, err := ImportShards(context.Background(), ImportOptions{
+		Input: bytes.NewBufferString(data),
+		Dest:  types.NewTestValueStore(),
+		Stop:  stop,
+		RowProgress: func(rows uint64) {
+			if rows == 3 {
+				close(stop)
+			}
+		},
+	}, ShardBy{Rows: 2})
+	assert.NoError(err)
+	assert.True(stats.Stopped)
+	assert.Equal(uint64(3), stats.RowsImported)
+	assert.Len(shards, 2)
+
+	// An import stopped before it starts imports no rows, which csv-import doesn't commit.
+	stop = make(chan struct{})
+	close(stop)
+	_, stats, err = Import(context.Background(), ImportOptions{
+		Input: bytes.NewBufferString(data),
+		Dest:  types.NewTestValueStore(),
+		Stop:  stop,
+	})
+	assert.NoError(err)
+	assert.True(stats.Stopped)
+	assert.Equal(uint64(0), stats.RowsImported)
+
+	// An import whose Stop isn't closed reads every row.
+	_, stats, err = Import(context.Background(), ImportOptions{
+		Input: bytes.NewBufferString(data),
+		Dest:  types.NewTestValueStore(),
+		Stop:  make(chan struct{}),
+	})
+	assert.NoError(err)
+	assert.False(stats.Stopped)
+	assert.Equal(uint64(4), stats.RowsImported)
+}
+
+func TestParseDestType(t *testing.T) {
+	assert := assert.New(t)
+
+	pks, err := ParseDestType("list")
+	assert.NoError(err)
+	assert.Nil(pks)
+
+	pks, err = ParseDestType("map:0,b")
+	assert.NoError(err)
+	assert.Equal([]string{"0", "b"}, pks)
+}
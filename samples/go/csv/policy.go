@@ -0,0 +1,137 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package csv
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/attic-labs/noms/go/types"
+)
+
+// ParseAction is what's done with a cell which can't be parsed as the kind of its column, e.g. "n/a" in a Number column.
+type ParseAction uint8
+
+const (
+	// ParseError fails the import. It's the default, written as the kind alone, e.g. "Number", or with a "!", e.g. "Number!".
+	ParseError ParseAction = iota
+	// ParseOmit leaves the cell's field out of its row's struct, so the field is optional. Written e.g. "Number?".
+	ParseOmit
+	// ParseWiden imports the cell as a String, so the field is a union of the column's kind and String. Written e.g. "Number|String".
+	ParseWiden
+	// ParseDefault imports the cell as the policy's Default. Written e.g. "Number=0".
+	ParseDefault
+)
+
+// ParsePolicy says what's done with a cell which can't be parsed as the kind of its column.
+type ParsePolicy struct {
+	Action ParseAction
+	// Default is the value of such cells, if Action is ParseDefault.
+	Default types.Value
+}
+
+// ParseColumnTypes parses column types as given to csv-import's --column-types, returning the kind of each column and the policy for cells which can't be parsed as it. Each is a kind, e.g. "Number", optionally followed by a policy: "!" for ParseError, "?" for ParseOmit, "|String" for ParseWiden, or "=" and a value of the kind for ParseDefault, e.g. "Number=0".
+func ParseColumnTypes(strs []string) (KindSlice, []ParsePolicy, error) {
+	kinds := make(KindSlice, len(strs))
+	policies := make([]ParsePolicy, len(strs))
+	for i, str := range strs {
+		name, policy := str, ParsePolicy{}
+		switch idx := strings.IndexAny(str, "!?|="); {
+		case idx < 0:
+		case str[idx:] == "!":
+			name = str[:idx]
+		case str[idx:] == "?":
+			name, policy.Action = str[:idx], ParseOmit
+		case str[idx:] == "|String":
+			name, policy.Action = str[:idx], ParseWiden
+		case str[idx] == '=':
+			name, policy.Action = str[:idx], ParseDefault
+		default:
+			return nil, nil, fmt.Errorf("Invalid column type: %s", str)
+		}
+
+		k, ok := StringToKind[name]
+		if !ok || (k != types.NumberKind && k != types.BoolKind && k != types.StringKind) {
+			return nil, nil, fmt.Errorf("Invalid column type: %s", str)
+		}
+		if policy.Action == ParseWiden && k == types.StringKind {
+			return nil, nil, fmt.Errorf("Invalid column type: %s, a String column can't be widened", str)
+		}
+		if policy.Action == ParseDefault {
+			def, err := StringToValue(str[len(name)+1:], k)
+			if err != nil {
+				return nil, nil, fmt.Errorf("Invalid default of column type %s: %s", str, err)
+			}
+			policy.Default = def
+		}
+		kinds[i], policies[i] = k, policy
+	}
+	return kinds, policies, nil
+}
+
+// FormatColumnTypes is the inverse of ParseColumnTypes. If policies is empty, every column's policy is ParseError.
+func FormatColumnTypes(kinds KindSlice, policies []ParsePolicy) []string {
+	strs := KindsToStrings(kinds)
+	for i, p := range policies {
+		switch p.Action {
+		case ParseOmit:
+			strs[i] += "?"
+		case ParseWiden:
+			strs[i] += "|String"
+		case ParseDefault:
+			strs[i] += "=" + valueToString(p.Default)
+		}
+	}
+	return strs
+}
+
+// valueToString is the inverse of StringToValue.
+func valueToString(v types.Value) string {
+	switch v := v.(type) {
+	case types.Number:
+		return strconv.FormatFloat(float64(v), 'g', -1, 64)
+	case types.Bool:
+		return strconv.FormatBool(bool(v))
+	case types.String:
+		return string(v)
+	}
+	panic(fmt.Sprintf("Invalid column value kind: %s", v.Kind()))
+}
+
+// column says how the cells of a column are parsed.
+type column struct {
+	kind   types.NomsKind
+	policy ParsePolicy
+}
+
+// fieldType returns the type of the struct field that c is imported as, and whether it's optional.
+func (c column) fieldType() (t *types.Type, optional bool) {
+	t = types.MakePrimitiveType(c.kind)
+	switch c.policy.Action {
+	case ParseOmit:
+		optional = true
+	case ParseWiden:
+		t = types.MakeUnionType(t, types.StringType)
+	}
+	return
+}
+
+// parse parses s, a cell of c, applying c's policy if s isn't of c's kind. The value is nil if the cell's field is to be omitted.
+func (c column) parse(s string) (types.Value, error) {
+	v, err := StringToValue(s, c.kind)
+	if err == nil {
+		return v, nil
+	}
+	switch c.policy.Action {
+	case ParseOmit:
+		return nil, nil
+	case ParseWiden:
+		return types.String(s), nil
+	case ParseDefault:
+		return c.policy.Default, nil
+	}
+	return nil, err
+}
@@ -0,0 +1,86 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package csv
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+func TestParseColumnTypes(t *testing.T) {
+	assert := assert.New(t)
+
+	strs := []string{"Number!", "String", "Number?", "Bool|String", "Number=-1.5", "Bool=true"}
+	kinds, policies, err := ParseColumnTypes(strs)
+	assert.NoError(err)
+	assert.Equal(KindSlice{types.NumberKind, types.StringKind, types.NumberKind, types.BoolKind, types.NumberKind, types.BoolKind}, kinds)
+	assert.Equal([]ParsePolicy{
+		{},
+		{},
+		{Action: ParseOmit},
+		{Action: ParseWiden},
+		{Action: ParseDefault, Default: types.Number(-1.5)},
+		{Action: ParseDefault, Default: types.Bool(true)},
+	}, policies)
+	assert.Equal([]string{"Number", "String", "Number?", "Bool|String", "Number=-1.5", "Bool=true"}, FormatColumnTypes(kinds, policies))
+	assert.Equal([]string{"Number", "Bool"}, FormatColumnTypes(KindSlice{types.NumberKind, types.BoolKind}, nil))
+
+	for _, s := range []string{"Nmbr", "Blob", "Number!?", "Number|Bool", "String|String", "Number=x", "Bool=x"} {
+		_, _, err := ParseColumnTypes([]string{s})
+		assert.Error(err, s)
+	}
+}
+
+func TestImportParsePolicies(t *testing.T) {
+	assert := assert.New(t)
+	vs := types.NewTestValueStore()
+
+	data := "id,a,b,c,d\n1,2,3,4,5\n2,x,x,x,x\n"
+	kinds, policies, err := ParseColumnTypes(strings.Split("Number,Number!,Number?,Number|String,Number=0", ","))
+	assert.NoError(err)
+	_, _, err = Import(context.Background(), ImportOptions{
+		Input:    bytes.NewBufferString(data),
+		Dest:     vs,
+		Kinds:    kinds,
+		Policies: policies,
+	})
+	assert.Error(err)
+
+	kinds[1], policies[1] = types.StringKind, ParsePolicy{}
+	v, _, err := Import(context.Background(), ImportOptions{
+		Input:    bytes.NewBufferString(data),
+		Dest:     vs,
+		Kinds:    kinds,
+		Policies: policies,
+	})
+	assert.NoError(err)
+	l := v.(types.List)
+	assert.True(types.NewStruct("Row", types.StructData{
+		"id": types.Number(1), "a": types.String("2"), "b": types.Number(3), "c": types.Number(4), "d": types.Number(5),
+	}).Equals(l.Get(0)))
+	assert.True(types.NewStruct("Row", types.StructData{
+		"id": types.Number(2), "a": types.String("x"), "c": types.String("x"), "d": types.Number(0),
+	}).Equals(l.Get(1)))
+
+	// Every row must have its primary keys.
+	_, _, err = Import(context.Background(), ImportOptions{
+		Input:    bytes.NewBufferString(data),
+		Dest:     vs,
+		Kinds:    kinds,
+		Policies: policies,
+		DestType: "map:b",
+	})
+	assert.Error(err)
+
+	// Omitted fields are exported as empty.
+	w := &bytes.Buffer{}
+	WriteList(l, GetListElemDesc(l, vs), ',', w)
+	assert.Equal("a,b,c,d,id\n2,3,4,5,1\nx,,x,0,2\n", w.String())
+}
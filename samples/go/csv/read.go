@@ -5,7 +5,9 @@
 package csv
 
 import (
+	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"sort"
@@ -47,47 +49,71 @@ func KindsToStrings(kinds KindSlice) []string {
 }
 
 //EscapeStructFieldFromCSV removes special characters and replaces spaces with camelCasing (camel case turns to camelCase)
+// If that leaves a name which doesn't start with a letter, e.g. for "2016 Total", it's prefixed with "col", as in "col2016Total".
 func EscapeStructFieldFromCSV(input string) string {
 	if types.IsValidStructFieldName(input) {
 		return input
 	}
-	return types.CamelCaseFieldName(input)
+	if fn := types.CamelCaseFieldName(input); fn != "" {
+		return fn
+	}
+	return types.CamelCaseFieldName("col " + input)
 }
 
 // MakeStructTypeFromHeaders creates a struct type from the headers using |kinds| as the type of each field. If |kinds| is empty, default to strings.
 func MakeStructTypeFromHeaders(headers []string, structName string, kinds KindSlice) (typ *types.Type, fieldOrder []int, kindMap []types.NomsKind) {
+	typ, fieldOrder, cols, err := makeStructTypeFromHeaders(headers, structName, kinds, nil)
+	d.PanicIfError(err)
+	kindMap = make([]types.NomsKind, len(cols))
+	for i, c := range cols {
+		kindMap[i] = c.kind
+	}
+	return
+}
+
+// makeStructTypeFromHeaders is like MakeStructTypeFromHeaders, but returns an error if the headers can't be made into a struct type. Cells which can't be parsed as the kind of their column are handled as policies says, which if non-empty has a policy per column, like kinds. Rather than kinds, it returns how the cells of each field, in the order of the fields of typ, are parsed.
+func makeStructTypeFromHeaders(headers []string, structName string, kinds KindSlice, policies []ParsePolicy) (typ *types.Type, fieldOrder []int, cols []column, err error) {
 	useStringType := len(kinds) == 0
-	d.PanicIfFalse(useStringType || len(headers) == len(kinds))
+	if !useStringType && len(headers) != len(kinds) {
+		return nil, nil, nil, fmt.Errorf("Expected %d column types, found %d", len(headers), len(kinds))
+	}
+	if !useStringType && len(policies) != 0 && len(policies) != len(kinds) {
+		return nil, nil, nil, fmt.Errorf("Expected %d column policies, found %d", len(kinds), len(policies))
+	}
 
-	fieldMap := make(types.TypeMap, len(headers))
+	colMap := make(map[string]column, len(headers))
 	origOrder := make(map[string]int, len(headers))
 	fieldNames := make(sort.StringSlice, len(headers))
 
 	for i, key := range headers {
 		fn := EscapeStructFieldFromCSV(key)
 		origOrder[fn] = i
-		kind := types.StringKind
+		col := column{kind: types.StringKind}
 		if !useStringType {
-			kind = kinds[i]
+			col.kind = kinds[i]
+			if len(policies) != 0 {
+				col.policy = policies[i]
+			}
 		}
-		_, ok := fieldMap[fn]
+		_, ok := colMap[fn]
 		if ok {
-			d.Panic(`Duplicate field name "%s"`, key)
+			return nil, nil, nil, fmt.Errorf(`Duplicate field name "%s"`, key)
 		}
-		fieldMap[fn] = types.MakePrimitiveType(kind)
+		colMap[fn] = col
 		fieldNames[i] = fn
 	}
 
 	sort.Sort(fieldNames)
 
-	kindMap = make([]types.NomsKind, len(fieldMap))
-	fieldOrder = make([]int, len(fieldMap))
+	cols = make([]column, len(colMap))
+	fieldOrder = make([]int, len(colMap))
 	fields := make([]types.StructField, len(fieldNames))
 
 	for i, fn := range fieldNames {
-		typ := fieldMap[fn]
-		fields[i] = types.StructField{Name: fn, Type: typ}
-		kindMap[i] = typ.TargetKind()
+		col := colMap[fn]
+		t, optional := col.fieldType()
+		fields[i] = types.StructField{Name: fn, Type: t, Optional: optional}
+		cols[i] = col
 		fieldOrder[origOrder[fn]] = i
 	}
 
@@ -99,30 +125,49 @@ func MakeStructTypeFromHeaders(headers []string, structName string, kinds KindSl
 // If kinds is non-empty, it will be used to type the fields in the generated structs; otherwise, they will be left as string-fields.
 // In addition to the list, ReadToList returns the typeDef of the structs in the list.
 func ReadToList(r *csv.Reader, structName string, headers []string, kinds KindSlice, vrw types.ValueReadWriter) (l types.List, t *types.Type) {
-	t, fieldOrder, kindMap := MakeStructTypeFromHeaders(headers, structName, kinds)
+	l, t, err := ReadToListContext(context.Background(), r, structName, headers, kinds, vrw)
+	if err != nil {
+		panic(d.RootCause(err))
+	}
+	return l, t
+}
+
+// ReadToListContext is like ReadToList, but stops reading if ctx is cancelled, returning ctx.Err(). ctx is checked before each row is read; writes to vrw aren't themselves cancelled. Errors reading or parsing a row are returned, rather than panicking, as a *d.Error whose Offset is the row, counting from the first data row.
+func ReadToListContext(ctx context.Context, r *csv.Reader, structName string, headers []string, kinds KindSlice, vrw types.ValueReadWriter) (l types.List, t *types.Type, err error) {
+	return readToList(ctx, r, structName, headers, kinds, nil, nil, vrw)
+}
+
+// readToList is ReadToListContext, with cells which can't be parsed as the kind of their column handled as policies says, and stopping early and reporting progress as hooks says.
+func readToList(ctx context.Context, r *csv.Reader, structName string, headers []string, kinds KindSlice, policies []ParsePolicy, hooks *rowHooks, vrw types.ValueReadWriter) (l types.List, t *types.Type, err error) {
+	t, fieldOrder, cols, err := makeStructTypeFromHeaders(headers, structName, kinds, policies)
+	if err != nil {
+		return types.List{}, nil, err
+	}
 	valueChan := make(chan types.Value, 128) // TODO: Make this a function param?
 	listChan := types.NewStreamingList(vrw, valueChan)
 
-	for {
-		row, err := r.Read()
+	for row := uint64(1); ; row++ {
+		if err = ctx.Err(); err != nil || hooks.stopNow() {
+			break
+		}
+		var fields types.ValueSlice
+		fields, err = readRow(r, row, headers, fieldOrder, cols)
 		if err == io.EOF {
-			close(valueChan)
+			err = nil
 			break
 		} else if err != nil {
-			panic(err)
+			break
 		}
-
-		fields := readFieldsFromRow(row, headers, fieldOrder, kindMap)
-		data := make(types.StructData, len(fields))
-		i := 0
-		t.Desc.(types.StructDesc).IterFields(func(name string, t *types.Type, optional bool) {
-			data[name] = fields[i]
-			i++
-		})
-		valueChan <- types.NewStruct(structName, data)
+		valueChan <- hooks.row(structFromFields(structName, t, fields))
+		hooks.rowRead(row)
 	}
 
-	return <-listChan, t
+	close(valueChan)
+	l = <-listChan
+	if err != nil {
+		return types.List{}, nil, err
+	}
+	return l, t, nil
 }
 
 // getFieldIndexByHeaderName takes the collection of headers and the name to search for and returns the index of name within the headers or -1 if not found
@@ -136,7 +181,10 @@ func getFieldIndexByHeaderName(headers []string, name string) int {
 }
 
 // getPkIndices takes collection of primary keys as strings and determines if they are integers, if so then use those ints as the indices, otherwise it looks up the strings in the headers to find the indices; returning the collection of int indices representing the primary keys maintaining the order of strPks to the return collection
-func getPkIndices(strPks []string, headers []string) []int {
+func getPkIndices(strPks []string, headers []string) ([]int, error) {
+	if len(strPks) == 0 {
+		return nil, errors.New("No primary key defined when reading into map")
+	}
 	result := make([]int, len(strPks))
 	for i, pk := range strPks {
 		pkIdx, ok := strconv.Atoi(pk)
@@ -145,26 +193,69 @@ func getPkIndices(strPks []string, headers []string) []int {
 		} else {
 			result[i] = getFieldIndexByHeaderName(headers, pk)
 		}
-		if result[i] < 0 {
-			d.Chk.Fail(fmt.Sprintf("Invalid pk: %v", pk))
+		if result[i] < 0 || result[i] >= len(headers) {
+			return nil, fmt.Errorf("Invalid pk: %v", pk)
+		}
+	}
+	return result, nil
+}
+
+// checkPkPolicies returns an error if the policy of any of the primary key columns, pkIndices, is ParseOmit, since every row must have a key.
+func checkPkPolicies(pkIndices []int, headers []string, policies []ParsePolicy) error {
+	for _, idx := range pkIndices {
+		if idx < len(policies) && policies[idx].Action == ParseOmit {
+			return fmt.Errorf("Primary key %s can't be omitted", headers[idx])
 		}
 	}
-	return result
+	return nil
+}
+
+// readRow reads the next row from r, which is the row'th, and parses its fields. Errors other than io.EOF are returned as a *d.Error.
+func readRow(r *csv.Reader, row uint64, headers []string, fieldOrder []int, cols []column) (types.ValueSlice, error) {
+	record, err := r.Read()
+	if err == io.EOF {
+		return nil, err
+	} else if err != nil {
+		return nil, &d.Error{Op: "read row", Offset: row, Err: err}
+	}
+	fields, err := readFieldsFromRow(record, headers, fieldOrder, cols)
+	if err != nil {
+		return nil, &d.Error{Op: "read row", Offset: row, Err: err}
+	}
+	return fields, nil
 }
 
-func readFieldsFromRow(row []string, headers []string, fieldOrder []int, kindMap []types.NomsKind) types.ValueSlice {
+// readFieldsFromRow parses the fields of row. Fields past the headers are
+// ignored, and missing fields of a short row are parsed as if empty. Fields
+// which are to be omitted, by a ParseOmit policy, are nil.
+func readFieldsFromRow(row []string, headers []string, fieldOrder []int, cols []column) (types.ValueSlice, error) {
 	fields := make(types.ValueSlice, len(headers))
-	for i, v := range row {
-		if i < len(headers) {
-			fieldOrigIndex := fieldOrder[i]
-			val, err := StringToValue(v, kindMap[fieldOrigIndex])
-			if err != nil {
-				d.Chk.Fail(fmt.Sprintf("Error parsing value for column '%s': %s", headers[i], err))
-			}
-			fields[fieldOrigIndex] = val
+	for i := range headers {
+		v := ""
+		if i < len(row) {
+			v = row[i]
 		}
+		fieldOrigIndex := fieldOrder[i]
+		val, err := cols[fieldOrigIndex].parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing value for column '%s': %s", headers[i], err)
+		}
+		fields[fieldOrigIndex] = val
 	}
-	return fields
+	return fields, nil
+}
+
+// structFromFields makes the struct for a row, given its fields in the order of the fields of t. Nil fields are omitted.
+func structFromFields(structName string, t *types.Type, fields types.ValueSlice) types.Struct {
+	data := make(types.StructData, len(fields))
+	i := 0
+	t.Desc.(types.StructDesc).IterFields(func(name string, t *types.Type, optional bool) {
+		if fields[i] != nil {
+			data[name] = fields[i]
+		}
+		i++
+	})
+	return types.NewStruct(structName, data)
 }
 
 // primaryKeyValuesFromFields extracts the values of the primaryKey fields into
@@ -196,28 +287,50 @@ func primaryKeyValuesFromFields(fields types.ValueSlice, fieldOrder, pkIndices [
 // ReadToMap takes a CSV reader and reads data into a typed Map of structs. Each row gets read into a struct named structName, described by headers. If the original data contained headers it is expected that the input reader has already read those and are pointing at the first data row.
 // If kinds is non-empty, it will be used to type the fields in the generated structs; otherwise, they will be left as string-fields.
 func ReadToMap(r *csv.Reader, structName string, headersRaw []string, primaryKeys []string, kinds KindSlice, vrw types.ValueReadWriter) types.Map {
-	t, fieldOrder, kindMap := MakeStructTypeFromHeaders(headersRaw, structName, kinds)
-	pkIndices := getPkIndices(primaryKeys, headersRaw)
-	d.Chk.True(len(pkIndices) >= 1, "No primary key defined when reading into map")
+	m, err := ReadToMapContext(context.Background(), r, structName, headersRaw, primaryKeys, kinds, vrw)
+	if err != nil {
+		panic(d.RootCause(err))
+	}
+	return m
+}
+
+// ReadToMapContext is like ReadToMap, but stops reading if ctx is cancelled, returning ctx.Err(). Errors are returned rather than panicking, as in ReadToListContext.
+func ReadToMapContext(ctx context.Context, r *csv.Reader, structName string, headersRaw []string, primaryKeys []string, kinds KindSlice, vrw types.ValueReadWriter) (types.Map, error) {
+	return readToMap(ctx, r, structName, headersRaw, primaryKeys, kinds, nil, nil, vrw)
+}
+
+// readToMap is ReadToMapContext, with cells which can't be parsed as the kind of their column handled as policies says, and stopping early and reporting progress as hooks says.
+func readToMap(ctx context.Context, r *csv.Reader, structName string, headersRaw []string, primaryKeys []string, kinds KindSlice, policies []ParsePolicy, hooks *rowHooks, vrw types.ValueReadWriter) (types.Map, error) {
+	t, fieldOrder, cols, err := makeStructTypeFromHeaders(headersRaw, structName, kinds, policies)
+	if err != nil {
+		return types.Map{}, err
+	}
+	pkIndices, err := getPkIndices(primaryKeys, headersRaw)
+	if err == nil {
+		err = checkPkPolicies(pkIndices, headersRaw, policies)
+	}
+	if err != nil {
+		return types.Map{}, err
+	}
 	gb := types.NewGraphBuilder(vrw, types.MapKind, false)
 
-	for {
-		row, err := r.Read()
+	for row := uint64(1); ; row++ {
+		if err := ctx.Err(); err != nil {
+			return types.Map{}, err
+		}
+		if hooks.stopNow() {
+			break
+		}
+		fields, err := readRow(r, row, headersRaw, fieldOrder, cols)
 		if err == io.EOF {
 			break
 		} else if err != nil {
-			panic(err)
+			return types.Map{}, err
 		}
 
-		fields := readFieldsFromRow(row, headersRaw, fieldOrder, kindMap)
 		graphKeys, mapKey := primaryKeyValuesFromFields(fields, fieldOrder, pkIndices)
-		data := make(types.StructData, len(fields))
-		i := 0
-		t.Desc.(types.StructDesc).IterFields(func(name string, t *types.Type, optional bool) {
-			data[name] = fields[i]
-			i++
-		})
-		gb.MapSet(graphKeys, mapKey, types.NewStruct(structName, data))
+		gb.MapSet(graphKeys, mapKey, hooks.row(structFromFields(structName, t, fields)))
+		hooks.rowRead(row)
 	}
-	return gb.Build().(types.Map)
+	return gb.Build().(types.Map), nil
 }
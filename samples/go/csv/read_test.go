@@ -6,10 +6,12 @@ package csv
 
 import (
 	"bytes"
+	"context"
 	"encoding/csv"
 	"testing"
 
 	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/d"
 	"github.com/attic-labs/noms/go/datas"
 	"github.com/attic-labs/noms/go/types"
 	"github.com/attic-labs/testify/assert"
@@ -55,6 +57,44 @@ b,2,false
 	assert.True(l.Get(1).(types.Struct).Get("C").Equals(types.Bool(false)))
 }
 
+func TestReadToListContextCancelled(t *testing.T) {
+	assert := assert.New(t)
+	ds := datas.NewDatabase(chunks.NewMemoryStore())
+
+	r := NewCSVReader(bytes.NewBufferString("a,1\nb,2\n"), ',')
+	headers := []string{"A", "B"}
+	kinds := KindSlice{types.StringKind, types.NumberKind}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := ReadToListContext(ctx, r, "test", headers, kinds, ds)
+	assert.Equal(context.Canceled, err)
+
+	_, err = ReadToMapContext(ctx, r, "test", headers, []string{"A"}, kinds, ds)
+	assert.Equal(context.Canceled, err)
+}
+
+func TestReadToListContextError(t *testing.T) {
+	assert := assert.New(t)
+	ds := datas.NewDatabase(chunks.NewMemoryStore())
+
+	headers := []string{"A", "B"}
+	kinds := KindSlice{types.StringKind, types.NumberKind}
+	r := NewCSVReader(bytes.NewBufferString("a,1\nb,x\n"), ',')
+	_, _, err := ReadToListContext(context.Background(), r, "test", headers, kinds, ds)
+	e, ok := err.(*d.Error)
+	assert.True(ok)
+	assert.Equal(uint64(2), e.Offset)
+	assert.Equal("read row (offset 2): Error parsing value for column 'B': Could not parse 'x' into number (strconv.ParseFloat: parsing \"x\": invalid syntax)", err.Error())
+
+	r = NewCSVReader(bytes.NewBufferString("a,1\n"), ',')
+	_, err = ReadToMapContext(context.Background(), r, "test", headers, []string{"C"}, kinds, ds)
+	assert.EqualError(err, "Invalid pk: C")
+
+	_, _, err = ReadToListContext(context.Background(), r, "test", []string{"A", "A"}, nil, ds)
+	assert.EqualError(err, `Duplicate field name "A"`)
+}
+
 func TestReadToMap(t *testing.T) {
 	assert := assert.New(t)
 	ds := datas.NewDatabase(chunks.NewMemoryStore())
@@ -156,8 +196,10 @@ func TestEscapeStructFieldFromCSV(t *testing.T) {
 		"Few ¢ents Short", "fewEntsShort",
 		"CAMEL💩case letTerS", "camelcaseLetters",
 		"https://picasaweb.google.com/data", "httpspicasawebgooglecomdata",
-		"💩", "",
-		"11 1💩", "",
+		"💩", "col",
+		"11 1💩", "col111",
+		"2016 Total", "col2016Total",
+		"Incident Date", "incidentDate",
 		"-- A B", "aB",
 		"-- A --", "a",
 		"-- A -- B", "aB",
@@ -250,3 +292,19 @@ func TestBooleanStrings(t *testing.T) {
 		assert.True(types.Bool(false).Equals(row.Get("F")))
 	}
 }
+
+func TestReadRaggedRows(t *testing.T) {
+	assert := assert.New(t)
+	ds := datas.NewDatabase(chunks.NewMemoryStore())
+	defer ds.Close()
+
+	r := NewCSVReader(bytes.NewBufferString("a,1,true,extra\nb\n"), ',')
+	headers := []string{"A", "B", "C"}
+	kinds := KindSlice{types.StringKind, types.NumberKind, types.BoolKind}
+	l, _ := ReadToList(r, "test", headers, kinds, ds)
+
+	// Extra fields are ignored, and missing ones are read as if empty.
+	assert.Equal(uint64(2), l.Len())
+	assert.True(types.NewStruct("test", types.StructData{"A": types.String("a"), "B": types.Number(1), "C": types.Bool(true)}).Equals(l.Get(0)))
+	assert.True(types.NewStruct("test", types.StructData{"A": types.String("b"), "B": types.Number(0), "C": types.Bool(false)}).Equals(l.Get(1)))
+}
@@ -0,0 +1,256 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package csv
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/types"
+)
+
+// ShardBy describes how ImportShards splits the rows of an import: by the
+// value of Column, or into shards of Rows rows each.
+type ShardBy struct {
+	// Column is a header name or a 0-based column index, or "" to shard by Rows.
+	Column string
+	Rows   uint64
+}
+
+func (sb ShardBy) String() string {
+	if sb.Column != "" {
+		return sb.Column
+	}
+	return fmt.Sprintf("rows:%d", sb.Rows)
+}
+
+// ParseShardBy parses "<col>", where col is a header name or a 0-based column
+// index, or "rows:<N>".
+func ParseShardBy(s string) (ShardBy, error) {
+	if strings.HasPrefix(s, "rows:") {
+		n, err := strconv.ParseUint(strings.TrimPrefix(s, "rows:"), 10, 64)
+		if err != nil || n == 0 {
+			return ShardBy{}, fmt.Errorf("Invalid shard-by: %s", s)
+		}
+		return ShardBy{Rows: n}, nil
+	}
+	if s == "" {
+		return ShardBy{}, fmt.Errorf("Invalid shard-by: %s", s)
+	}
+	return ShardBy{Column: s}, nil
+}
+
+// maxColumnShards caps the number of shards of an import sharded by a column.
+// Each shard is open, with its List or Map being built, until every row has
+// been read, so a column with very many distinct values would otherwise
+// exhaust memory.
+var maxColumnShards = 1024
+
+// Shard is one part of a sharded import.
+type Shard struct {
+	// Key is the value of the ShardBy Column in every row of the shard, or ""
+	// if the import was sharded by rows.
+	Key   string
+	Value types.Value
+	Rows  uint64
+}
+
+// ImportShards is like Import, but splits the rows into shards as described
+// by shardBy, each a List or Map (or Categorical struct) of its own. Shards
+// are returned in the order their first rows were read. A shard of Rows rows
+// is finished as soon as it's full. A Column may have at most 1024 distinct
+// values. Sharding keeps the collections of a very large import to a
+// practical size, and lets them be synced separately.
+func ImportShards(ctx context.Context, opts ImportOptions, shardBy ShardBy) ([]Shard, Stats, error) {
+	start := time.Now()
+	stats := Stats{}
+
+	in, err := startImport(opts)
+	if err != nil {
+		return nil, stats, err
+	}
+	col := -1
+	if shardBy.Column != "" {
+		if col, err = strconv.Atoi(shardBy.Column); err != nil {
+			col = getFieldIndexByHeaderName(in.headers, shardBy.Column)
+		}
+		if col < 0 || col >= len(in.headers) {
+			return nil, stats, fmt.Errorf("Invalid shard-by column: %s", shardBy.Column)
+		}
+	} else if shardBy.Rows == 0 {
+		return nil, stats, fmt.Errorf("Invalid shard-by: %s", shardBy)
+	}
+
+	t, fieldOrder, cols, err := makeStructTypeFromHeaders(in.headers, in.structName, opts.Kinds, opts.Policies)
+	if err != nil {
+		return nil, stats, err
+	}
+	var pkIndices []int
+	if in.pks != nil {
+		if pkIndices, err = getPkIndices(in.pks, in.headers); err != nil {
+			return nil, stats, err
+		}
+		if err = checkPkPolicies(pkIndices, in.headers, opts.Policies); err != nil {
+			return nil, stats, err
+		}
+	}
+
+	hooks := newRowHooks(opts)
+	builders := []*shardBuilder{}
+	byKey := map[string]*shardBuilder{}
+	var current *shardBuilder
+	finish := func() []types.Collection {
+		values := make([]types.Collection, len(builders))
+		for i, b := range builders {
+			values[i] = b.finish()
+		}
+		return values
+	}
+
+	for rows := uint64(1); ; rows++ {
+		if err = ctx.Err(); err != nil {
+			finish()
+			return nil, stats, err
+		}
+		if hooks.stopNow() {
+			break
+		}
+		row, err := in.cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			finish()
+			return nil, stats, in.annotate(&d.Error{Op: "read row", Offset: rows, Err: err})
+		}
+
+		var b *shardBuilder
+		if col >= 0 {
+			key := ""
+			if col < len(row) {
+				key = row[col]
+			}
+			if b = byKey[key]; b == nil {
+				if len(builders) == maxColumnShards {
+					finish()
+					return nil, stats, in.annotate(&d.Error{Op: "shard row", Offset: rows, Err: fmt.Errorf("shard-by column %s has more than %d distinct values; shard by rows:<N> instead", shardBy.Column, maxColumnShards)})
+				}
+				b = newShardBuilder(opts.Dest, key, pkIndices != nil, opts.MaxCategories)
+				byKey[key] = b
+				builders = append(builders, b)
+			}
+		} else {
+			if current == nil {
+				current = newShardBuilder(opts.Dest, "", pkIndices != nil, opts.MaxCategories)
+				builders = append(builders, current)
+			}
+			b = current
+		}
+
+		fields, err := readFieldsFromRow(row, in.headers, fieldOrder, cols)
+		if err != nil {
+			finish()
+			return nil, stats, in.annotate(&d.Error{Op: "read row", Offset: rows, Err: err})
+		}
+		st := structFromFields(in.structName, t, fields)
+		if pkIndices == nil {
+			b.add(nil, nil, st)
+		} else {
+			graphKeys, mapKey := primaryKeyValuesFromFields(fields, fieldOrder, pkIndices)
+			b.add(graphKeys, mapKey, st)
+		}
+		if b == current && b.rows == shardBy.Rows {
+			b.finish()
+			current = nil
+		}
+		hooks.rowRead(rows)
+	}
+
+	shards := make([]Shard, len(builders))
+	categorical := map[string]bool{}
+	for i, v := range finish() {
+		var value types.Value = v
+		if c := builders[i].categories; c != nil {
+			var fields []string
+			value, fields = c.categorical(opts.Dest, v)
+			for _, f := range fields {
+				categorical[f] = true
+			}
+		}
+		shards[i] = Shard{builders[i].key, value, builders[i].rows}
+		stats.RowsImported += builders[i].rows
+	}
+
+	stats.Headers = in.headers
+	stats.PrimaryKeys = in.pks
+	for f := range categorical {
+		stats.Categorical = append(stats.Categorical, f)
+	}
+	sort.Strings(stats.Categorical)
+	stats.RowsSkipped = uint64(opts.SkipRecords)
+	stats.BytesRead = in.counter.n
+	stats.Elapsed = time.Since(start)
+	stats.Stopped = hooks.stopped
+	return shards, stats, nil
+}
+
+// shardBuilder builds the List or Map of one shard as its rows are read.
+type shardBuilder struct {
+	key       string
+	rows      uint64
+	valueChan chan types.Value
+	listChan  <-chan types.List
+	gb        *types.GraphBuilder
+	// value is set once the shard is finished.
+	value types.Collection
+	// categories, if not nil, encodes the rows of the shard, which has
+	// dictionaries of its own.
+	categories *categorizer
+}
+
+func newShardBuilder(vrw types.ValueReadWriter, key string, isMap bool, maxCategories int) *shardBuilder {
+	b := &shardBuilder{key: key}
+	if maxCategories > 0 {
+		b.categories = newCategorizer(maxCategories)
+	}
+	if isMap {
+		b.gb = types.NewGraphBuilder(vrw, types.MapKind, false)
+	} else {
+		b.valueChan = make(chan types.Value, 128)
+		b.listChan = types.NewStreamingList(vrw, b.valueChan)
+	}
+	return b
+}
+
+func (b *shardBuilder) add(graphKeys types.ValueSlice, mapKey types.Value, row types.Struct) {
+	b.rows++
+	if b.categories != nil {
+		row = b.categories.encode(row)
+	}
+	if b.gb != nil {
+		b.gb.MapSet(graphKeys, mapKey, row)
+	} else {
+		b.valueChan <- row
+	}
+}
+
+// finish returns the List or Map of the shard, which must have no more rows
+// added to it.
+func (b *shardBuilder) finish() types.Collection {
+	if b.value == nil {
+		if b.gb != nil {
+			b.value = b.gb.Build().(types.Map)
+		} else {
+			close(b.valueChan)
+			b.value = <-b.listChan
+		}
+	}
+	return b.value
+}
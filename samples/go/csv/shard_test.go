@@ -0,0 +1,103 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package csv
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+const shardData = `id,color
+1,red
+2,blue
+3,red
+4,green
+5,blue
+`
+
+func TestParseShardBy(t *testing.T) {
+	assert := assert.New(t)
+
+	sb, err := ParseShardBy("rows:100")
+	assert.NoError(err)
+	assert.Equal(ShardBy{Rows: 100}, sb)
+	assert.Equal("rows:100", sb.String())
+
+	sb, err = ParseShardBy("color")
+	assert.NoError(err)
+	assert.Equal(ShardBy{Column: "color"}, sb)
+	assert.Equal("color", sb.String())
+
+	for _, s := range []string{"", "rows:", "rows:0", "rows:x"} {
+		_, err = ParseShardBy(s)
+		assert.Error(err, s)
+	}
+}
+
+func TestImportShardsByRows(t *testing.T) {
+	assert := assert.New(t)
+	vs := types.NewTestValueStore()
+
+	shards, stats, err := ImportShards(context.Background(), ImportOptions{
+		Input: bytes.NewBufferString(shardData),
+		Dest:  vs,
+		Kinds: KindSlice{types.NumberKind, types.StringKind},
+	}, ShardBy{Rows: 2})
+	assert.NoError(err)
+	assert.Equal(uint64(5), stats.RowsImported)
+
+	assert.Len(shards, 3)
+	for i, rows := range []uint64{2, 2, 1} {
+		assert.Equal("", shards[i].Key)
+		assert.Equal(rows, shards[i].Rows)
+		assert.Equal(rows, shards[i].Value.(types.List).Len())
+	}
+	assert.Equal(types.Number(3), shards[1].Value.(types.List).Get(0).(types.Struct).Get("id"))
+}
+
+func TestImportShardsByColumn(t *testing.T) {
+	assert := assert.New(t)
+	vs := types.NewTestValueStore()
+
+	shards, stats, err := ImportShards(context.Background(), ImportOptions{
+		Input:    bytes.NewBufferString(shardData),
+		Dest:     vs,
+		DestType: "map:id",
+	}, ShardBy{Column: "1"})
+	assert.NoError(err)
+	assert.Equal(uint64(5), stats.RowsImported)
+	assert.Equal([]string{"id"}, stats.PrimaryKeys)
+
+	assert.Len(shards, 3)
+	for i, key := range []string{"red", "blue", "green"} {
+		assert.Equal(key, shards[i].Key)
+	}
+	red := shards[0].Value.(types.Map)
+	assert.Equal(uint64(2), red.Len())
+	assert.True(red.Has(types.String("3")))
+
+	_, _, err = ImportShards(context.Background(), ImportOptions{
+		Input: bytes.NewBufferString(shardData),
+		Dest:  vs,
+	}, ShardBy{Column: "size"})
+	assert.Error(err)
+}
+
+func TestImportShardsTooManyShards(t *testing.T) {
+	assert := assert.New(t)
+	defer func(max int) { maxColumnShards = max }(maxColumnShards)
+	maxColumnShards = 2
+
+	_, _, err := ImportShards(context.Background(), ImportOptions{
+		Input: bytes.NewBufferString(shardData),
+		Dest:  types.NewTestValueStore(),
+	}, ShardBy{Column: "1"})
+	assert.Error(err)
+	assert.Contains(err.Error(), "more than 2 distinct values")
+}
@@ -0,0 +1,112 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package csv
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/types"
+)
+
+// VerifyList checks that l is what ReadToList would produce from r, or Import with the ParsePolicies policies. It re-reads every row from r, which must be positioned at the first data row, and checks that l has exactly one struct per row. Every sampleEvery'th row, starting with the first, is also compared to the struct at the same index in l.
+func VerifyList(r *csv.Reader, structName string, headers []string, kinds KindSlice, policies []ParsePolicy, l types.List, sampleEvery uint64) error {
+	t, fieldOrder, cols, err := makeStructTypeFromHeaders(headers, structName, kinds, policies)
+	if err != nil {
+		return err
+	}
+	if sampleEvery == 0 {
+		sampleEvery = 1
+	}
+
+	rows := uint64(0)
+	for ; ; rows++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		if rows%sampleEvery != 0 || rows >= l.Len() {
+			continue
+		}
+		fields, err := readFieldsFromRow(row, headers, fieldOrder, cols)
+		if err != nil {
+			return &d.Error{Op: "verify row", Offset: rows + 1, Err: err}
+		}
+		expected := structFromFields(structName, t, fields)
+		if actual := l.Get(rows); !expected.Equals(actual) {
+			return fmt.Errorf("Row %d doesn't match: expected %s, found %s", rows, types.EncodedValue(expected), types.EncodedValue(actual))
+		}
+	}
+
+	if rows != l.Len() {
+		return fmt.Errorf("Expected %d rows, but the list has %d", rows, l.Len())
+	}
+	return nil
+}
+
+// VerifyMap checks m against the rows in r like VerifyList, for a Map produced by ReadToMap with primaryKeys. Because a later row replaces any earlier row with the same keys, only the presence of the sampled rows' keys is checked, and that m has no more entries than there are rows.
+func VerifyMap(r *csv.Reader, structName string, headers []string, primaryKeys []string, kinds KindSlice, policies []ParsePolicy, m types.Map, sampleEvery uint64) error {
+	_, fieldOrder, cols, err := makeStructTypeFromHeaders(headers, structName, kinds, policies)
+	if err != nil {
+		return err
+	}
+	pkIndices, err := getPkIndices(primaryKeys, headers)
+	if err != nil {
+		return err
+	}
+	if sampleEvery == 0 {
+		sampleEvery = 1
+	}
+
+	rows := uint64(0)
+	for ; ; rows++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		if rows%sampleEvery != 0 {
+			continue
+		}
+		fields, err := readFieldsFromRow(row, headers, fieldOrder, cols)
+		if err != nil {
+			return &d.Error{Op: "verify row", Offset: rows + 1, Err: err}
+		}
+		graphKeys, mapKey := primaryKeyValuesFromFields(fields, fieldOrder, pkIndices)
+		inner, ok := m, true
+		for _, k := range graphKeys {
+			if inner, ok = inner.Get(k).(types.Map); !ok {
+				break
+			}
+		}
+		if !ok || !inner.Has(mapKey) {
+			return fmt.Errorf("Row %d is missing from the map", rows)
+		}
+	}
+
+	if entries := countMapLeaves(m, len(pkIndices)-1); entries > rows {
+		return fmt.Errorf("Expected at most %d entries, but the map has %d", rows, entries)
+	}
+	return nil
+}
+
+// countMapLeaves counts the entries at the bottom of m, which has depth levels of nested Maps.
+func countMapLeaves(m types.Map, depth int) uint64 {
+	if depth == 0 {
+		return m.Len()
+	}
+	count := uint64(0)
+	m.IterAll(func(k, v types.Value) {
+		count += countMapLeaves(v.(types.Map), depth-1)
+	})
+	return count
+}
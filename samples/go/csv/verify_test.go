@@ -0,0 +1,68 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package csv
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/attic-labs/noms/go/chunks"
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/testify/assert"
+)
+
+const verifyData = `a,1,true
+b,2,false
+c,3,true
+`
+
+var (
+	verifyHeaders = []string{"A", "B", "C"}
+	verifyKinds   = KindSlice{types.StringKind, types.NumberKind, types.BoolKind}
+)
+
+func TestVerifyList(t *testing.T) {
+	assert := assert.New(t)
+	db := datas.NewDatabase(chunks.NewMemoryStore())
+	defer db.Close()
+
+	l, _ := ReadToList(NewCSVReader(bytes.NewBufferString(verifyData), ','), "test", verifyHeaders, verifyKinds, db)
+
+	verify := func(data string, l types.List, sampleEvery uint64) error {
+		return VerifyList(NewCSVReader(bytes.NewBufferString(data), ','), "test", verifyHeaders, verifyKinds, nil, l, sampleEvery)
+	}
+
+	assert.NoError(verify(verifyData, l, 1))
+	assert.NoError(verify(verifyData, l, 0))
+
+	// Missing and extra rows.
+	assert.Error(verify(verifyData, l.RemoveAt(2), 1))
+	assert.Error(verify(verifyData+"d,4,false\n", l, 1))
+
+	// Differing rows are only found if they're sampled.
+	changed := l.Set(1, l.Get(0))
+	assert.Error(verify(verifyData, changed, 1))
+	assert.NoError(verify(verifyData, changed, 2))
+}
+
+func TestVerifyMap(t *testing.T) {
+	assert := assert.New(t)
+	db := datas.NewDatabase(chunks.NewMemoryStore())
+	defer db.Close()
+
+	verify := func(pks []string, m types.Map) error {
+		return VerifyMap(NewCSVReader(bytes.NewBufferString(verifyData), ','), "test", verifyHeaders, pks, verifyKinds, nil, m, 1)
+	}
+
+	for _, pks := range [][]string{{"A"}, {"C", "A"}} {
+		m := ReadToMap(NewCSVReader(bytes.NewBufferString(verifyData), ','), "test", verifyHeaders, pks, verifyKinds, db)
+		assert.NoError(verify(pks, m))
+	}
+
+	m := ReadToMap(NewCSVReader(bytes.NewBufferString(verifyData), ','), "test", verifyHeaders, []string{"A"}, verifyKinds, db)
+	assert.Error(verify([]string{"A"}, m.Remove(types.String("b"))))
+	assert.Error(verify([]string{"A"}, m.Set(types.String("z"), m.Get(types.String("a")))))
+}
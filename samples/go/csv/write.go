@@ -39,17 +39,22 @@ func GetMapElemDesc(m types.Map, vr types.ValueReader) types.StructDesc {
 	panic(fmt.Sprintf("Expected StructKind or MapKind, found %s", t.Kind().String()))
 }
 
-func writeValuesFromChan(structChan chan types.Struct, sd types.StructDesc, comma rune, output io.Writer) {
-	fieldNames := getFieldNamesFromStruct(sd)
+func writeValuesFromChan(structChan chan types.Struct, sd types.StructDesc, headers []string, comma rune, output io.Writer) {
+	header, fieldNames := columns(sd, headers)
 	csvWriter := csv.NewWriter(output)
 	csvWriter.Comma = comma
-	if csvWriter.Write(fieldNames) != nil {
-		d.Panic("Failed to write header %v", fieldNames)
+	if csvWriter.Write(header) != nil {
+		d.Panic("Failed to write header %v", header)
 	}
 	record := make([]string, len(fieldNames))
 	for s := range structChan {
 		for i, f := range fieldNames {
-			record[i] = fmt.Sprintf("%v", s.Get(f))
+			if v, ok := s.MaybeGet(f); ok {
+				record[i] = fmt.Sprintf("%v", v)
+			} else {
+				// An optional field, omitted on import by a ParseOmit policy.
+				record[i] = ""
+			}
 		}
 		if csvWriter.Write(record) != nil {
 			d.Panic("Failed to write record %v", record)
@@ -62,8 +67,34 @@ func writeValuesFromChan(structChan chan types.Struct, sd types.StructDesc, comm
 	}
 }
 
+// columns returns the header row to write for structs described by sd, and the field each column is written from. If headers is empty, each field is a column, headed by its name. Otherwise headers are the headers of the CSV the structs were imported from, as recorded in HeadersMetaField, and each is the header of the field EscapeStructFieldFromCSV makes of it, in the order of headers. Fields which none of headers map to come after them, headed by their names.
+func columns(sd types.StructDesc, headers []string) (header, fieldNames []string) {
+	all := getFieldNamesFromStruct(sd)
+	written := map[string]bool{}
+	for _, h := range headers {
+		fn := EscapeStructFieldFromCSV(h)
+		if t, _ := sd.Field(fn); t != nil && !written[fn] {
+			header = append(header, h)
+			fieldNames = append(fieldNames, fn)
+			written[fn] = true
+		}
+	}
+	for _, fn := range all {
+		if !written[fn] {
+			header = append(header, fn)
+			fieldNames = append(fieldNames, fn)
+		}
+	}
+	return
+}
+
 // WriteList takes a types.List l of structs (described by sd) and writes it to output as comma-delineated values.
 func WriteList(l types.List, sd types.StructDesc, comma rune, output io.Writer) {
+	WriteListWithHeaders(l, sd, nil, comma, output)
+}
+
+// WriteListWithHeaders is like WriteList, but restores headers, the original headers of the CSV l was imported from, as described by HeadersMetaField.
+func WriteListWithHeaders(l types.List, sd types.StructDesc, headers []string, comma rune, output io.Writer) {
 	structChan := make(chan types.Struct, 1024)
 	go func() {
 		l.IterAll(func(v types.Value, index uint64) {
@@ -71,7 +102,7 @@ func WriteList(l types.List, sd types.StructDesc, comma rune, output io.Writer)
 		})
 		close(structChan)
 	}()
-	writeValuesFromChan(structChan, sd, comma, output)
+	writeValuesFromChan(structChan, sd, headers, comma, output)
 }
 
 func sendMapValuesToChan(m types.Map, structChan chan<- types.Struct) {
@@ -86,20 +117,61 @@ func sendMapValuesToChan(m types.Map, structChan chan<- types.Struct) {
 
 // WriteMap takes a types.Map m of structs (described by sd) and writes it to output as comma-delineated values.
 func WriteMap(m types.Map, sd types.StructDesc, comma rune, output io.Writer) {
+	WriteMapWithHeaders(m, sd, nil, comma, output)
+}
+
+// WriteMapWithHeaders is like WriteMap, but restores headers, the original headers of the CSV m was imported from, as described by HeadersMetaField.
+func WriteMapWithHeaders(m types.Map, sd types.StructDesc, headers []string, comma rune, output io.Writer) {
 	structChan := make(chan types.Struct, 1024)
 	go func() {
 		sendMapValuesToChan(m, structChan)
 		close(structChan)
 	}()
-	writeValuesFromChan(structChan, sd, comma, output)
+	writeValuesFromChan(structChan, sd, headers, comma, output)
+}
+
+// WriteCategorical takes a Categorical struct s, as made by Categorize, and writes its rows to output as comma-delineated values, with the values of their categorical fields restored.
+func WriteCategorical(s types.Struct, comma rune, output io.Writer) {
+	WriteCategoricalWithHeaders(s, nil, comma, output)
+}
+
+// WriteCategoricalWithHeaders is like WriteCategorical, but restores headers, the original headers of the CSV s was imported from, as described by HeadersMetaField.
+func WriteCategoricalWithHeaders(s types.Struct, headers []string, comma rune, output io.Writer) {
+	rows, cats := SplitCategorical(s)
+	if cats == nil {
+		d.Panic("Expected a %s struct, found %s", CategoricalStructName, s.Name())
+	}
+	c := rows.(types.Collection)
+	structChan := make(chan types.Struct, 1024)
+	go func() {
+		iterRows(c, func(row types.Struct) bool {
+			structChan <- cats.Decode(row)
+			return false
+		})
+		close(structChan)
+	}()
+	writeValuesFromChan(structChan, rowDesc(c), headers, comma, output)
 }
 
 func getFieldNamesFromStruct(structDesc types.StructDesc) (fieldNames []string) {
 	structDesc.IterFields(func(name string, t *types.Type, optional bool) {
-		if !types.IsPrimitiveKind(t.TargetKind()) {
+		if !isPrimitiveType(t) {
 			d.Panic("Expected primitive kind, found %s", t.TargetKind().String())
 		}
 		fieldNames = append(fieldNames, name)
 	})
 	return
 }
+
+// isPrimitiveType returns whether t is a primitive type, or a union of them, as imported by a ParseWiden policy.
+func isPrimitiveType(t *types.Type) bool {
+	if t.TargetKind() != types.UnionKind {
+		return types.IsPrimitiveKind(t.TargetKind())
+	}
+	for _, et := range t.Desc.(types.CompoundDesc).ElemTypes {
+		if !types.IsPrimitiveKind(et.TargetKind()) {
+			return false
+		}
+	}
+	return true
+}
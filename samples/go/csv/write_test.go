@@ -19,6 +19,7 @@ import (
 	"github.com/attic-labs/noms/go/datas"
 	"github.com/attic-labs/noms/go/types"
 	"github.com/attic-labs/noms/go/util/clienttest"
+	"github.com/attic-labs/testify/assert"
 	"github.com/attic-labs/testify/suite"
 )
 
@@ -146,3 +147,36 @@ func (s *csvWriteTestSuite) TestCSVWriteNestedMap() {
 	WriteMap(m, s.rowStructDesc, s.comma, w)
 	verifyOutput(s, w)
 }
+
+func TestCSVWriteWithHeaders(t *testing.T) {
+	assert := assert.New(t)
+	db := datas.NewDatabase(chunks.NewMemoryStore())
+	defer db.Close()
+
+	input := "Incident Date,2016 Total,id\n2016-01-01,3,a\n"
+	headers := []string{"Incident Date", "2016 Total", "id"}
+	r := NewCSVReader(strings.NewReader(input), ',')
+	_, err := r.Read()
+	assert.NoError(err)
+	l, typ := ReadToList(r, "row", headers, nil, db)
+	sd := typ.Desc.(types.StructDesc)
+
+	// Without the headers, fields are written in their sorted order, with
+	// their escaped names.
+	w := &bytes.Buffer{}
+	WriteList(l, sd, ',', w)
+	assert.Equal("col2016Total,id,incidentDate\n3,a,2016-01-01\n", w.String())
+
+	w = &bytes.Buffer{}
+	meta := types.NewStruct("", types.StructData{HeadersMetaField: HeadersValue(headers)})
+	WriteListWithHeaders(l, sd, HeadersFromMeta(meta), ',', w)
+	assert.Equal(input, w.String())
+
+	// Headers which don't map to a field are dropped, and fields which have no
+	// header are written after the rest.
+	w = &bytes.Buffer{}
+	WriteListWithHeaders(l, sd, []string{"id", "Missing"}, ',', w)
+	assert.Equal("id,col2016Total,incidentDate\na,3,2016-01-01\n", w.String())
+
+	assert.Nil(HeadersFromMeta(types.NewStruct("", types.StructData{})))
+}
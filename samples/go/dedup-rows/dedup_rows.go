@@ -0,0 +1,157 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/attic-labs/noms/go/config"
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/spec"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/profile"
+	"github.com/attic-labs/noms/go/util/verbose"
+	flag "github.com/juju/gnuflag"
+)
+
+func main() {
+	keys := flag.String("keys", "", "comma-separated fields which identify a row. If empty, rows are only duplicates if all their fields are the same")
+	outDSName := flag.String("out-ds-name", "", "dataset, in the same database, to commit the deduplicated rows to. Defaults to the input dataset")
+	spec.RegisterCommitMetaFlags(flag.CommandLine)
+	verbose.RegisterVerboseFlags(flag.CommandLine)
+	profile.RegisterProfileFlags(flag.CommandLine)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Removes duplicate rows from a List of structs, keeping the first of each, and commits the result.\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: dedup-rows [options] <dataset>\n\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse(true)
+
+	if flag.NArg() != 1 {
+		d.CheckError(errors.New("expected a dataset"))
+	}
+	var keyFields []string
+	if *keys != "" {
+		keyFields = strings.Split(*keys, ",")
+	}
+
+	cfg := config.NewResolver()
+	db, ds, err := cfg.GetDataset(flag.Arg(0))
+	d.CheckError(err)
+	defer db.Close()
+
+	outDS := ds
+	if *outDSName != "" {
+		if !datas.DatasetFullRe.MatchString(*outDSName) {
+			d.CheckError(fmt.Errorf("Invalid output dataset name: %s", *outDSName))
+		}
+		outDS = db.GetDataset(*outDSName)
+	}
+
+	head, ok := ds.MaybeHeadValue()
+	if !ok {
+		d.CheckErrorNoUsage(fmt.Errorf("Dataset %s does not exist", flag.Arg(0)))
+	}
+	l, ok := head.(types.List)
+	if !ok {
+		d.CheckErrorNoUsage(fmt.Errorf("Expected a List, found a %s", head.Kind()))
+	}
+
+	defer profile.MaybeStartProfile().Stop()
+
+	deduped, err := dedupRows(db, l, keyFields)
+	d.CheckErrorNoUsage(err)
+
+	removed := l.Len() - deduped.Len()
+	meta, err := spec.CreateCommitMetaStruct(db, "", "", map[string]string{"keys": *keys}, map[string]types.Value{
+		"rowsBefore":        types.Number(l.Len()),
+		"rowsAfter":         types.Number(deduped.Len()),
+		"duplicatesRemoved": types.Number(removed),
+	})
+	d.CheckErrorNoUsage(err)
+	_, err = db.Commit(outDS, deduped, datas.CommitOptions{Meta: meta})
+	d.CheckErrorNoUsage(err)
+
+	fmt.Printf("Removed %d duplicates of %d rows\n", removed, l.Len())
+}
+
+// dedupRows streams the rows of l into a new List, leaving out each row whose
+// key has been seen before. The key of a row is the row itself if keyFields
+// is empty, and the values of keyFields otherwise. Nothing is kept in memory
+// per row: the keys are first built into a Map of each key to the Set of the
+// indices of its rows, from which a Set of the index of the first row of each
+// key is built. l is then streamed again, keeping the rows in that Set.
+func dedupRows(vrw types.ValueReadWriter, l types.List, keyFields []string) (types.List, error) {
+	gb := types.NewGraphBuilder(vrw, types.MapKind, false)
+	var err error
+	l.Iter(func(v types.Value, i uint64) bool {
+		var key types.Value
+		if key, err = rowKey(v, keyFields); err != nil {
+			err = fmt.Errorf("Row %d: %s", i, err)
+			return true
+		}
+		gb.SetInsert(types.ValueSlice{key}, types.Number(i))
+		return false
+	})
+	keys := gb.Build().(types.Map)
+	if err != nil {
+		return types.List{}, err
+	}
+
+	firstChan := make(chan types.Value, 128)
+	keepChan := types.NewStreamingSet(vrw, firstChan)
+	keys.IterAll(func(k, v types.Value) {
+		firstChan <- v.(types.Set).First()
+	})
+	close(firstChan)
+	keep := (<-keepChan).Iterator()
+
+	valueChan := make(chan types.Value, 128)
+	listChan := types.NewStreamingList(vrw, valueChan)
+	next := keep.Next()
+	l.Iter(func(v types.Value, i uint64) bool {
+		if next == nil {
+			return true
+		}
+		if next.Equals(types.Number(i)) {
+			valueChan <- v
+			next = keep.Next()
+		}
+		return false
+	})
+	close(valueChan)
+	return <-listChan, nil
+}
+
+func rowKey(row types.Value, keyFields []string) (types.Value, error) {
+	if len(keyFields) == 0 {
+		return row, nil
+	}
+	st, ok := row.(types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("Expected a struct, found a %s", row.Kind())
+	}
+	if len(keyFields) == 1 {
+		if v, ok := st.MaybeGet(keyFields[0]); ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("No field %s", keyFields[0])
+	}
+	vals := make(types.ValueSlice, len(keyFields))
+	for i, f := range keyFields {
+		v, ok := st.MaybeGet(f)
+		if !ok {
+			return nil, fmt.Errorf("No field %s", f)
+		}
+		vals[i] = v
+	}
+	return types.NewList(vals...), nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/nbs"
+	"github.com/attic-labs/noms/go/spec"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/clienttest"
+	"github.com/attic-labs/testify/suite"
+)
+
+func TestDedupRows(t *testing.T) {
+	suite.Run(t, &testSuite{})
+}
+
+type testSuite struct {
+	clienttest.ClientTestSuite
+}
+
+func row(id string, n float64) types.Struct {
+	return types.NewStruct("Row", types.StructData{
+		"id": types.String(id),
+		"n":  types.Number(n),
+	})
+}
+
+func (s *testSuite) setUp() string {
+	db := datas.NewDatabase(nbs.NewLocalStore(s.DBDir, clienttest.DefaultMemTableSize))
+	defer db.Close()
+	_, err := db.CommitValue(db.GetDataset("rows"), types.NewList(
+		row("a", 1),
+		row("b", 2),
+		row("a", 1),
+		row("a", 3),
+		row("c", 2),
+		row("b", 2),
+	))
+	s.NoError(err)
+	return spec.CreateValueSpecString("nbs", s.DBDir, "rows")
+}
+
+func (s *testSuite) head(name string) types.Struct {
+	sp, err := spec.ForDataset(spec.CreateValueSpecString("nbs", s.DBDir, name))
+	s.NoError(err)
+	defer sp.Close()
+	return sp.GetDataset().Head()
+}
+
+func (s *testSuite) TestFullRow() {
+	ds := s.setUp()
+	stdout, stderr := s.MustRun(main, []string{ds})
+	s.Equal("", stderr)
+	s.Equal("Removed 2 duplicates of 6 rows\n", stdout)
+
+	head := s.head("rows")
+	s.True(types.NewList(row("a", 1), row("b", 2), row("a", 3), row("c", 2)).Equals(head.Get(datas.ValueField)))
+	meta := head.Get(datas.MetaField).(types.Struct)
+	s.Equal(types.Number(6), meta.Get("rowsBefore"))
+	s.Equal(types.Number(4), meta.Get("rowsAfter"))
+	s.Equal(types.Number(2), meta.Get("duplicatesRemoved"))
+}
+
+func (s *testSuite) TestKeys() {
+	ds := s.setUp()
+
+	s.MustRun(main, []string{"--keys", "id", "--out-ds-name", "byID", ds})
+	s.True(types.NewList(row("a", 1), row("b", 2), row("c", 2)).Equals(s.head("byID").Get(datas.ValueField)))
+
+	s.MustRun(main, []string{"--keys", "n,id", "--out-ds-name", "byBoth", ds})
+	s.True(types.NewList(row("a", 1), row("b", 2), row("a", 3), row("c", 2)).Equals(s.head("byBoth").Get(datas.ValueField)))
+
+	_, _, err := s.Run(main, []string{"--keys", "name", ds})
+	s.Equal(clienttest.ExitError{Code: 1}, err)
+}
+
+func (s *testSuite) TestManyRows() {
+	db := datas.NewDatabase(nbs.NewLocalStore(s.DBDir, clienttest.DefaultMemTableSize))
+	defer db.Close()
+
+	rows, want := types.ValueSlice{}, types.ValueSlice{}
+	for i := 0; i < 5000; i++ {
+		r := row(fmt.Sprintf("%d", i%700), float64(i))
+		rows = append(rows, r)
+		if i < 700 {
+			want = append(want, r)
+		}
+	}
+	deduped, err := dedupRows(db, types.NewList(rows...), []string{"id"})
+	s.NoError(err)
+	s.True(types.NewList(want...).Equals(deduped))
+}
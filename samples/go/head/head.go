@@ -0,0 +1,179 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/attic-labs/noms/go/config"
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/verbose"
+	flag "github.com/juju/gnuflag"
+)
+
+func main() {
+	n := flag.Uint64("n", 10, "number of elements to extract")
+	tail := flag.Bool("tail", false, "extract the last elements, instead of the first")
+	random := flag.Bool("random", false, "extract a random sample of elements, in order, instead of the first")
+	seed := flag.Int64("seed", 0, "with --random, the seed of the sample. If 0, the sample is different each time")
+	outDSName := flag.String("out-ds-name", "", "dataset, in the same database, to commit the elements to as a List or Map, instead of printing them")
+	verbose.RegisterVerboseFlags(flag.CommandLine)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Extracts the first, last or a random sample of the elements of a List or Map, seeking directly to each one, so that only the chunks they're in are read.\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: head [options] <dataset>\n\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse(true)
+
+	if flag.NArg() != 1 {
+		d.CheckError(errors.New("expected a dataset"))
+	}
+	if *tail && *random {
+		d.CheckError(errors.New("Cannot use both --tail and --random"))
+	}
+
+	cfg := config.NewResolver()
+	db, ds, err := cfg.GetDataset(flag.Arg(0))
+	d.CheckError(err)
+	defer db.Close()
+
+	head, ok := ds.MaybeHeadValue()
+	if !ok {
+		d.CheckErrorNoUsage(fmt.Errorf("Dataset %s does not exist", flag.Arg(0)))
+	}
+	c, ok := head.(types.Collection)
+	if !ok || (c.Kind() != types.ListKind && c.Kind() != types.MapKind) {
+		d.CheckErrorNoUsage(fmt.Errorf("Expected a List or Map, found a %s", head.Kind()))
+	}
+
+	var indices []uint64
+	switch {
+	case *random:
+		if *seed == 0 {
+			*seed = time.Now().UnixNano()
+		}
+		indices = sampleIndices(c.Len(), *n, rand.New(rand.NewSource(*seed)))
+	case *tail:
+		indices = rangeIndices(c.Len()-min(*n, c.Len()), c.Len())
+	default:
+		indices = rangeIndices(0, min(*n, c.Len()))
+	}
+	elems := extract(c, indices)
+
+	if *outDSName == "" {
+		d.PanicIfError(printElems(os.Stdout, c, elems))
+		return
+	}
+	if !datas.DatasetFullRe.MatchString(*outDSName) {
+		d.CheckError(fmt.Errorf("Invalid output dataset name: %s", *outDSName))
+	}
+	var out types.Value
+	if c.Kind() == types.ListKind {
+		out = types.NewList(elems...)
+	} else {
+		out = types.NewMap(elems...)
+	}
+	_, err = db.CommitValue(db.GetDataset(*outDSName), out)
+	d.CheckErrorNoUsage(err)
+}
+
+func min(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func rangeIndices(start, end uint64) []uint64 {
+	indices := make([]uint64, 0, end-start)
+	for i := start; i < end; i++ {
+		indices = append(indices, i)
+	}
+	return indices
+}
+
+// sampleIndices returns n distinct indices less than length, chosen at random,
+// in order. If n is at least length, every index is returned.
+func sampleIndices(length, n uint64, r *rand.Rand) []uint64 {
+	if n >= length {
+		return rangeIndices(0, length)
+	}
+	// Floyd's algorithm: one random number per index, however large length is.
+	chosen := map[uint64]bool{}
+	for j := length - n; j < length; j++ {
+		t := uint64(r.Int63n(int64(j + 1)))
+		if chosen[t] {
+			t = j
+		}
+		chosen[t] = true
+	}
+	indices := make([]uint64, 0, n)
+	for i := range chosen {
+		indices = append(indices, i)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+	return indices
+}
+
+// extract returns the elements of c, a List or Map, at indices, which are in
+// order. Runs of consecutive indices are read with a single iterator, and each
+// run starts with a seek which uses the counts in the tree to skip to it.
+func extract(c types.Collection, indices []uint64) types.ValueSlice {
+	elems := types.ValueSlice{}
+	for i := 0; i < len(indices); {
+		switch c := c.(type) {
+		case types.List:
+			it := c.IteratorAt(indices[i])
+			elems = append(elems, it.Next())
+			for i++; i < len(indices) && indices[i] == indices[i-1]+1; i++ {
+				elems = append(elems, it.Next())
+			}
+		case types.Map:
+			it := c.IteratorAt(indices[i])
+			k, v := it.Next()
+			elems = append(elems, k, v)
+			for i++; i < len(indices) && indices[i] == indices[i-1]+1; i++ {
+				k, v = it.Next()
+				elems = append(elems, k, v)
+			}
+		}
+	}
+	return elems
+}
+
+// printElems writes each element of a List, or entry of a Map, on a line.
+func printElems(w io.Writer, c types.Collection, elems types.ValueSlice) error {
+	step := 1
+	if c.Kind() == types.MapKind {
+		step = 2
+	}
+	for i := 0; i < len(elems); i += step {
+		if step == 2 {
+			if err := types.WriteEncodedValue(w, elems[i]); err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, ": "); err != nil {
+				return err
+			}
+		}
+		if err := types.WriteEncodedValue(w, elems[i+step-1]); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/nbs"
+	"github.com/attic-labs/noms/go/spec"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/clienttest"
+	"github.com/attic-labs/testify/assert"
+	"github.com/attic-labs/testify/suite"
+)
+
+func TestHead(t *testing.T) {
+	suite.Run(t, &testSuite{})
+}
+
+type testSuite struct {
+	clienttest.ClientTestSuite
+}
+
+const testLen = 5000
+
+func (s *testSuite) setUp() {
+	db := datas.NewDatabase(nbs.NewLocalStore(s.DBDir, clienttest.DefaultMemTableSize))
+	defer db.Close()
+
+	nums := make(types.ValueSlice, testLen)
+	kvs := make(types.ValueSlice, 0, 2*testLen)
+	for i := range nums {
+		nums[i] = types.Number(i)
+		kvs = append(kvs, types.Number(i), types.Number(i*i))
+	}
+	_, err := db.CommitValue(db.GetDataset("list"), types.NewList(nums...))
+	s.NoError(err)
+	_, err = db.CommitValue(db.GetDataset("map"), types.NewMap(kvs...))
+	s.NoError(err)
+}
+
+func (s *testSuite) TestList() {
+	s.setUp()
+	ds := spec.CreateValueSpecString("nbs", s.DBDir, "list")
+
+	stdout, stderr := s.MustRun(main, []string{"-n", "3", ds})
+	s.Equal("", stderr)
+	s.Equal("0\n1\n2\n", stdout)
+
+	stdout, _ = s.MustRun(main, []string{"-n", "2", "--tail", ds})
+	s.Equal("4998\n4999\n", stdout)
+
+	stdout, _ = s.MustRun(main, []string{"-n", "100000", "--tail", ds})
+	s.Equal(testLen, strings.Count(stdout, "\n"))
+}
+
+func (s *testSuite) TestMap() {
+	s.setUp()
+	ds := spec.CreateValueSpecString("nbs", s.DBDir, "map")
+
+	stdout, _ := s.MustRun(main, []string{"-n", "2", "--tail", ds})
+	s.Equal("4998: 2.4980004e+07\n4999: 2.4990001e+07\n", stdout)
+
+	s.MustRun(main, []string{"-n", "20", "--random", "--seed", "7", "--out-ds-name", "sample", ds})
+	sp, err := spec.ForDataset(spec.CreateValueSpecString("nbs", s.DBDir, "sample"))
+	s.NoError(err)
+	defer sp.Close()
+	m := sp.GetDataset().HeadValue().(types.Map)
+	s.Equal(uint64(20), m.Len())
+	m.IterAll(func(k, v types.Value) {
+		s.Equal(k.(types.Number)*k.(types.Number), v)
+	})
+}
+
+func TestSampleIndices(t *testing.T) {
+	assert := assert.New(t)
+	r := rand.New(rand.NewSource(1))
+
+	indices := sampleIndices(1000, 50, r)
+	assert.Len(indices, 50)
+	for i := 1; i < len(indices); i++ {
+		assert.True(indices[i-1] < indices[i])
+	}
+	assert.True(indices[len(indices)-1] < 1000)
+
+	assert.Equal([]uint64{0, 1, 2}, sampleIndices(3, 5, r))
+	assert.Equal([]uint64{}, sampleIndices(0, 5, r))
+}
+
+func TestExtract(t *testing.T) {
+	assert := assert.New(t)
+	l := types.NewList(types.Number(0), types.Number(1), types.Number(2), types.Number(3), types.Number(4))
+	assert.Equal(types.ValueSlice{types.Number(0), types.Number(2), types.Number(3)}, extract(l, []uint64{0, 2, 3}))
+}
@@ -0,0 +1,200 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/attic-labs/noms/go/config"
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/spec"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/profile"
+	"github.com/attic-labs/noms/go/util/verbose"
+	flag "github.com/juju/gnuflag"
+)
+
+func main() {
+	key := flag.String("key", "", "the field to join on, for both datasets. A Map's rows are joined on their keys, so this is only needed for a List")
+	leftKey := flag.String("left-key", "", "the field of the left dataset to join on, if it's a List. Defaults to --key")
+	rightKey := flag.String("right-key", "", "the field of the right dataset to join on, if it's a List. Defaults to --key")
+	how := flag.String("how", "inner", "'inner' to keep only the rows with a key in both datasets, 'left' to also keep the rows with a key only in the left dataset, or 'outer' to keep every row")
+	rightPrefix := flag.String("right-prefix", "right_", "prefix for the fields of the right rows which have the same name, but not the same value, as a field of the left row")
+	spec.RegisterCommitMetaFlags(flag.CommandLine)
+	verbose.RegisterVerboseFlags(flag.CommandLine)
+	profile.RegisterProfileFlags(flag.CommandLine)
+
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Joins the rows of two datasets which have the same key, committing a Map from each key to the fields of both rows to a new dataset.")
+		fmt.Fprintf(os.Stderr, "Each dataset must be a Map<K, Struct>, such as csv-import --dest-type map:<pk> makes, or a List<Struct> with a key field.\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: join [options] <left-dataset> <right-dataset> <output-dataset>\n\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse(true)
+
+	if flag.NArg() != 3 {
+		d.CheckError(errors.New("expected left, right and output datasets"))
+	}
+	if *how != "inner" && *how != "left" && *how != "outer" {
+		d.CheckError(fmt.Errorf("Invalid --how: %s", *how))
+	}
+	if !types.IsValidStructFieldName(*rightPrefix + "a") {
+		d.CheckError(fmt.Errorf("Invalid --right-prefix: %s", *rightPrefix))
+	}
+	if *leftKey == "" {
+		*leftKey = *key
+	}
+	if *rightKey == "" {
+		*rightKey = *key
+	}
+
+	cfg := config.NewResolver()
+	leftDB, leftDS, err := cfg.GetDataset(flag.Arg(0))
+	d.CheckError(err)
+	defer leftDB.Close()
+	rightDB, rightDS, err := cfg.GetDataset(flag.Arg(1))
+	d.CheckError(err)
+	defer rightDB.Close()
+	outDB, outDS, err := cfg.GetDataset(flag.Arg(2))
+	d.CheckError(err)
+	defer outDB.Close()
+
+	defer profile.MaybeStartProfile().Stop()
+
+	left, err := keyedRows(leftDB, leftDS, *leftKey)
+	d.CheckErrorNoUsage(err)
+	right, err := keyedRows(rightDB, rightDS, *rightKey)
+	d.CheckErrorNoUsage(err)
+
+	joined, stats := join(outDB, left, right, *how, *rightPrefix)
+
+	meta, err := spec.CreateCommitMetaStruct(outDB, "", "", map[string]string{
+		"left":  flag.Arg(0),
+		"right": flag.Arg(1),
+		"how":   *how,
+	}, map[string]types.Value{
+		"rowsMatched":   types.Number(stats.matched),
+		"rowsLeftOnly":  types.Number(stats.leftOnly),
+		"rowsRightOnly": types.Number(stats.rightOnly),
+	})
+	d.CheckErrorNoUsage(err)
+	_, err = outDB.Commit(outDS, joined, datas.CommitOptions{Meta: meta})
+	d.CheckErrorNoUsage(err)
+
+	fmt.Printf("Joined %d rows (%d matched, %d only in %s, %d only in %s)\n", joined.Len(), stats.matched, stats.leftOnly, flag.Arg(0), stats.rightOnly, flag.Arg(1))
+}
+
+// keyedRows returns the head of ds as a Map from key to row. A Map is
+// returned as it is. A List is keyed by the field key of its rows, which
+// must be unique.
+func keyedRows(vrw types.ValueReadWriter, ds datas.Dataset, key string) (types.Map, error) {
+	head, ok := ds.MaybeHeadValue()
+	if !ok {
+		return types.Map{}, fmt.Errorf("Dataset %s does not exist", ds.ID())
+	}
+
+	switch v := head.(type) {
+	case types.Map:
+		if _, first := v.First(); first != nil {
+			if _, ok := first.(types.Struct); !ok {
+				return types.Map{}, fmt.Errorf("%s is a Map of %s, not of structs", ds.ID(), first.Kind())
+			}
+		}
+		return v, nil
+	case types.List:
+		if key == "" {
+			return types.Map{}, fmt.Errorf("%s is a List, so --key is needed to join it", ds.ID())
+		}
+		gb := types.NewGraphBuilder(vrw, types.MapKind, false)
+		var err error
+		v.Iter(func(row types.Value, i uint64) bool {
+			st, ok := row.(types.Struct)
+			if !ok {
+				err = fmt.Errorf("%s is a List of %s, not of structs", ds.ID(), row.Kind())
+				return true
+			}
+			k, ok := st.MaybeGet(key)
+			if !ok {
+				err = fmt.Errorf("Row %d of %s has no field %s", i, ds.ID(), key)
+				return true
+			}
+			gb.MapSet(nil, k, st)
+			return false
+		})
+		m := gb.Build().(types.Map)
+		if err != nil {
+			return types.Map{}, err
+		}
+		if m.Len() != v.Len() {
+			return types.Map{}, fmt.Errorf("Field %s of %s isn't unique", key, ds.ID())
+		}
+		return m, nil
+	}
+	return types.Map{}, fmt.Errorf("%s is a %s, not a Map or List", ds.ID(), head.Kind())
+}
+
+type joinStats struct {
+	matched, leftOnly, rightOnly uint64
+}
+
+// join merges left and right, which are both ordered by key, in a single
+// pass over each, returning a Map from each key kept by how to its joined row.
+func join(vrw types.ValueReadWriter, left, right types.Map, how, rightPrefix string) (types.Map, joinStats) {
+	stats := joinStats{}
+	kvs := make(chan types.Value, 128)
+	mapChan := types.NewStreamingMap(vrw, kvs)
+
+	li, ri := left.Iterator(), right.Iterator()
+	lk, lv := li.Next()
+	rk, rv := ri.Next()
+	for lk != nil || rk != nil {
+		switch {
+		case rk == nil || (lk != nil && lk.Less(rk)):
+			stats.leftOnly++
+			if how != "inner" {
+				kvs <- lk
+				kvs <- lv
+			}
+			lk, lv = li.Next()
+		case lk == nil || rk.Less(lk):
+			stats.rightOnly++
+			if how == "outer" {
+				kvs <- rk
+				kvs <- rv
+			}
+			rk, rv = ri.Next()
+		default:
+			stats.matched++
+			kvs <- lk
+			kvs <- joinRows(lv.(types.Struct), rv.(types.Struct), rightPrefix)
+			lk, lv = li.Next()
+			rk, rv = ri.Next()
+		}
+	}
+	close(kvs)
+	return <-mapChan, stats
+}
+
+// joinRows returns a struct, named like l, with the fields of both l and r. A
+// field of r with the same name as a field of l is left out if it has the same
+// value, e.g. the key, and is renamed with rightPrefix otherwise.
+func joinRows(l, r types.Struct, rightPrefix string) types.Struct {
+	data := types.StructData{}
+	l.IterFields(func(name string, v types.Value) {
+		data[name] = v
+	})
+	r.IterFields(func(name string, v types.Value) {
+		if lv, ok := data[name]; !ok {
+			data[name] = v
+		} else if !lv.Equals(v) {
+			data[rightPrefix+name] = v
+		}
+	})
+	return types.NewStruct(l.Name(), data)
+}
@@ -0,0 +1,113 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"testing"
+
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/nbs"
+	"github.com/attic-labs/noms/go/spec"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/clienttest"
+	"github.com/attic-labs/testify/suite"
+)
+
+func TestJoin(t *testing.T) {
+	suite.Run(t, &testSuite{})
+}
+
+type testSuite struct {
+	clienttest.ClientTestSuite
+}
+
+func person(id, name, city string) types.Struct {
+	return types.NewStruct("Person", types.StructData{
+		"id":   types.String(id),
+		"name": types.String(name),
+		"city": types.String(city),
+	})
+}
+
+func visit(id, city string) types.Struct {
+	return types.NewStruct("Visit", types.StructData{
+		"id":   types.String(id),
+		"city": types.String(city),
+	})
+}
+
+func (s *testSuite) setUp() {
+	db := datas.NewDatabase(nbs.NewLocalStore(s.DBDir, clienttest.DefaultMemTableSize))
+	defer db.Close()
+
+	_, err := db.CommitValue(db.GetDataset("people"), types.NewMap(
+		types.String("1"), person("1", "ann", "sf"),
+		types.String("2"), person("2", "bob", "ny"),
+		types.String("3"), person("3", "cat", "la"),
+	))
+	s.NoError(err)
+	_, err = db.CommitValue(db.GetDataset("visits"), types.NewList(
+		visit("3", "sf"),
+		visit("1", "sf"),
+		visit("4", "ny"),
+	))
+	s.NoError(err)
+}
+
+func (s *testSuite) joined(args ...string) (types.Map, string) {
+	outSpec := spec.CreateValueSpecString("nbs", s.DBDir, "out")
+	args = append(args, spec.CreateValueSpecString("nbs", s.DBDir, "people"), spec.CreateValueSpecString("nbs", s.DBDir, "visits"), outSpec)
+	stdout, stderr := s.MustRun(main, args)
+	s.Equal("", stderr)
+
+	sp, err := spec.ForDataset(outSpec)
+	s.NoError(err)
+	defer sp.Close()
+	return sp.GetDataset().HeadValue().(types.Map), stdout
+}
+
+func (s *testSuite) TestInner() {
+	s.setUp()
+	m, stdout := s.joined("--key", "id")
+	s.Contains(stdout, "Joined 2 rows (2 matched, 1 only in")
+	s.Equal(uint64(2), m.Len())
+	s.True(m.Get(types.String("1")).Equals(person("1", "ann", "sf")))
+	s.True(m.Get(types.String("3")).Equals(types.NewStruct("Person", types.StructData{
+		"id":         types.String("3"),
+		"name":       types.String("cat"),
+		"city":       types.String("la"),
+		"right_city": types.String("sf"),
+	})))
+}
+
+func (s *testSuite) TestLeftAndOuter() {
+	s.setUp()
+
+	m, _ := s.joined("--key", "id", "--how", "left", "--right-prefix", "visit_")
+	s.Equal(uint64(3), m.Len())
+	s.True(m.Get(types.String("2")).Equals(person("2", "bob", "ny")))
+	s.Equal(types.String("sf"), m.Get(types.String("3")).(types.Struct).Get("visit_city"))
+
+	m, _ = s.joined("--key", "id", "--how", "outer")
+	s.Equal(uint64(4), m.Len())
+	s.True(m.Get(types.String("4")).Equals(visit("4", "ny")))
+}
+
+func (s *testSuite) TestErrors() {
+	s.setUp()
+	people := spec.CreateValueSpecString("nbs", s.DBDir, "people")
+	visits := spec.CreateValueSpecString("nbs", s.DBDir, "visits")
+	out := spec.CreateValueSpecString("nbs", s.DBDir, "out")
+
+	// A List needs a key.
+	_, _, err := s.Run(main, []string{people, visits, out})
+	s.Equal(clienttest.ExitError{Code: 1}, err)
+
+	_, _, err = s.Run(main, []string{"--key", "city", people, visits, out})
+	s.Equal(clienttest.ExitError{Code: 1}, err)
+
+	_, _, err = s.Run(main, []string{"--how", "sideways", "--key", "id", people, visits, out})
+	s.Equal(clienttest.ExitError{Code: 1}, err)
+}
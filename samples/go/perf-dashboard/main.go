@@ -0,0 +1,256 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/attic-labs/noms/go/config"
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/verbose"
+	flag "github.com/juju/gnuflag"
+)
+
+func main() {
+	port := flag.Int("port", 8000, "port to serve the dashboard on")
+	limit := flag.Int("limit", 100, "maximum number of runs to show, most recent first")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Serves a chart of perf test timings from a dataset written by go/perf/suite.\n\n")
+		fmt.Fprintf(os.Stderr, "usage: %s [options] <dataset>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+
+	verbose.RegisterVerboseFlags(flag.CommandLine)
+
+	flag.Parse(true)
+
+	if flag.NArg() != 1 {
+		d.CheckError(fmt.Errorf("Missing required dataset argument"))
+	}
+
+	cfg := config.NewResolver()
+	db, ds, err := cfg.GetDataset(flag.Arg(0))
+	d.CheckErrorNoUsage(err)
+	db.Close()
+
+	http.Handle("/", dashboardHandler(cfg, flag.Arg(0), *limit))
+	fmt.Printf("Serving %s on http://localhost:%d\n", ds.ID(), *port)
+	d.CheckErrorNoUsage(http.ListenAndServe(fmt.Sprintf(":%d", *port), nil))
+}
+
+// run is the results of a single commit to the perf dataset.
+type run struct {
+	NomsRevision string
+	// Tests maps test name to its mean elapsed time, in nanoseconds.
+	Tests map[string]float64
+}
+
+// loadRuns walks back through the first-parent history of the perf dataset from its head, returning at most limit runs, oldest first.
+func loadRuns(db datas.Database, datasetID string, limit int) []run {
+	runs := []run{}
+	commit, ok := db.GetDataset(datasetID).MaybeHead()
+	for ok && len(runs) < limit {
+		runs = append(runs, runFromRecord(commit.Get(datas.ValueField)))
+		commit, ok = firstParent(db, commit)
+	}
+	for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+		runs[i], runs[j] = runs[j], runs[i]
+	}
+	return runs
+}
+
+// firstParent returns the parent of commit with the greatest height, which for the linear history that perf runs produce is the previous run.
+func firstParent(db datas.Database, commit types.Struct) (types.Struct, bool) {
+	var best types.Ref
+	found := false
+	commit.Get(datas.ParentsField).(types.Set).IterAll(func(v types.Value) {
+		if r := v.(types.Ref); !found || r.Height() > best.Height() {
+			best, found = r, true
+		}
+	})
+	if !found {
+		return types.Struct{}, false
+	}
+	return best.TargetValue(db).(types.Struct), true
+}
+
+// runFromRecord reads a record written by suite.Run. Mean times come from its "stats" field if present, otherwise they're computed from "reps".
+func runFromRecord(v types.Value) run {
+	r := run{Tests: map[string]float64{}}
+	record, ok := v.(types.Struct)
+	if !ok {
+		return r
+	}
+	if rev, ok := record.MaybeGet("nomsRevision"); ok {
+		r.NomsRevision = string(rev.(types.String))
+	}
+
+	if stats, ok := record.MaybeGet("stats"); ok {
+		stats.(types.Map).IterAll(func(k, v types.Value) {
+			if mean, ok := v.(types.Struct).MaybeGet("mean"); ok {
+				r.Tests[string(k.(types.String))] = float64(mean.(types.Number))
+			}
+		})
+		return r
+	}
+
+	reps, ok := record.MaybeGet("reps")
+	if !ok {
+		return r
+	}
+	counts := map[string]int{}
+	reps.(types.List).IterAll(func(rep types.Value, _ uint64) {
+		rep.(types.Map).IterAll(func(k, v types.Value) {
+			if elapsed, ok := v.(types.Struct).MaybeGet("elapsed"); ok {
+				name := string(k.(types.String))
+				r.Tests[name] += float64(elapsed.(types.Number))
+				counts[name]++
+			}
+		})
+	})
+	for name, n := range counts {
+		r.Tests[name] /= float64(n)
+	}
+	return r
+}
+
+// dashboardHandler serves the dashboard of the dataset dsSpec, which it opens for each request so that runs committed since the last one are shown.
+func dashboardHandler(cfg *config.Resolver, dsSpec string, limit int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/" {
+			http.NotFound(w, req)
+			return
+		}
+		err := d.Try(func() {
+			db, ds, err := cfg.GetDataset(dsSpec)
+			d.PanicIfError(err)
+			defer db.Close()
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			d.PanicIfError(renderDashboard(w, ds.ID(), loadRuns(db, ds.ID(), limit)))
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+const (
+	chartWidth  = 600
+	chartHeight = 150
+)
+
+type point struct {
+	X, Y    float64
+	Label   string
+	Seconds float64
+}
+
+type chart struct {
+	Name    string
+	Points  []point
+	Line    string
+	MaxSecs float64
+}
+
+// makeCharts returns a chart per test, sorted by name, plotting its mean elapsed time for each run in which it appears.
+func makeCharts(runs []run) []chart {
+	names := map[string]bool{}
+	for _, r := range runs {
+		for name := range r.Tests {
+			names[name] = true
+		}
+	}
+
+	charts := make([]chart, 0, len(names))
+	for name := range names {
+		c := chart{Name: name}
+		for _, r := range runs {
+			if ns, ok := r.Tests[name]; ok && ns/1e9 > c.MaxSecs {
+				c.MaxSecs = ns / 1e9
+			}
+		}
+
+		line := []string{}
+		for i, r := range runs {
+			ns, ok := r.Tests[name]
+			if !ok {
+				continue
+			}
+			p := point{Label: shortRevision(r.NomsRevision), Seconds: ns / 1e9}
+			p.X = chartWidth / 2
+			if len(runs) > 1 {
+				p.X = float64(i) * chartWidth / float64(len(runs)-1)
+			}
+			p.Y = chartHeight
+			if c.MaxSecs > 0 {
+				p.Y = chartHeight - p.Seconds/c.MaxSecs*chartHeight
+			}
+			c.Points = append(c.Points, p)
+			line = append(line, fmt.Sprintf("%.1f,%.1f", p.X, p.Y))
+		}
+		c.Line = strings.Join(line, " ")
+		charts = append(charts, c)
+	}
+
+	sort.Slice(charts, func(i, j int) bool {
+		return charts[i].Name < charts[j].Name
+	})
+	return charts
+}
+
+func shortRevision(rev string) string {
+	if rev == "" {
+		return "unknown"
+	}
+	if len(rev) > 7 {
+		return rev[:7]
+	}
+	return rev
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>{{.Dataset}} perf</title>
+<style>
+body { font-family: sans-serif; }
+svg { border: 1px solid #ccc; overflow: visible; margin: 0 0 2em 1em; }
+polyline { fill: none; stroke: #4682b4; stroke-width: 2; }
+circle { fill: #4682b4; }
+</style>
+</head>
+<body>
+<h1>{{.Dataset}}</h1>
+<p>{{len .Runs}} run(s), oldest to newest. Hover over a point for its noms revision.</p>
+{{range .Charts}}
+<h2>{{.Name}} <small>(max {{printf "%.3f" .MaxSecs}}s)</small></h2>
+<svg width="{{$.Width}}" height="{{$.Height}}">
+<polyline points="{{.Line}}"/>
+{{range .Points}}<circle cx="{{printf "%.1f" .X}}" cy="{{printf "%.1f" .Y}}" r="3"><title>{{.Label}}: {{printf "%.3f" .Seconds}}s</title></circle>
+{{end}}</svg>
+{{else}}
+<p>No results yet.</p>
+{{end}}
+</body>
+</html>
+`))
+
+func renderDashboard(w io.Writer, datasetID string, runs []run) error {
+	return dashboardTemplate.Execute(w, struct {
+		Dataset       string
+		Runs          []run
+		Charts        []chart
+		Width, Height int
+	}{datasetID, runs, makeCharts(runs), chartWidth, chartHeight})
+}
@@ -0,0 +1,100 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/attic-labs/noms/go/config"
+	"github.com/attic-labs/noms/go/spec"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/clienttest"
+	"github.com/attic-labs/testify/suite"
+)
+
+func TestPerfDashboard(t *testing.T) {
+	suite.Run(t, &testSuite{})
+}
+
+type testSuite struct {
+	clienttest.ClientTestSuite
+}
+
+func elapsed(ns float64) types.Struct {
+	return types.NewStruct("", types.StructData{
+		"elapsed": types.Number(ns),
+		"paused":  types.Number(0),
+		"total":   types.Number(ns),
+	})
+}
+
+func (s *testSuite) TestLoadAndRender() {
+	sp, err := spec.ForDatabase(spec.CreateDatabaseSpecString("nbs", s.DBDir))
+	s.NoError(err)
+	defer sp.Close()
+	db := sp.GetDatabase()
+
+	// An old-style record with only reps...
+	ds := db.GetDataset("perf")
+	ds, err = db.CommitValue(ds, types.NewStruct("", types.StructData{
+		"nomsRevision": types.String("0123456789abcdef"),
+		"reps": types.NewList(
+			types.NewMap(types.String("Foo"), elapsed(1e9), types.String("Bar"), elapsed(2e9)),
+			types.NewMap(types.String("Foo"), elapsed(3e9), types.String("Bar"), elapsed(2e9)),
+		),
+	}))
+	s.NoError(err)
+
+	// ...followed by one with stats, which take precedence.
+	ds, err = db.CommitValue(ds, types.NewStruct("", types.StructData{
+		"nomsRevision": types.String("fedcba9876543210"),
+		"reps":         types.NewList(types.NewMap(types.String("Foo"), elapsed(1))),
+		"stats": types.NewMap(types.String("Foo"), types.NewStruct("", types.StructData{
+			"min": types.Number(1e9), "median": types.Number(1e9), "mean": types.Number(4e9), "stddev": types.Number(0),
+		})),
+	}))
+	s.NoError(err)
+
+	runs := loadRuns(db, "perf", 10)
+	s.Equal([]run{
+		{"0123456789abcdef", map[string]float64{"Foo": 2e9, "Bar": 2e9}},
+		{"fedcba9876543210", map[string]float64{"Foo": 4e9}},
+	}, runs)
+	s.Len(loadRuns(db, "perf", 1), 1)
+	s.Empty(loadRuns(db, "nothing", 10))
+
+	charts := makeCharts(runs)
+	s.Len(charts, 2)
+	s.Equal("Bar", charts[0].Name)
+	s.Equal("0.0,0.0", charts[0].Line)
+	s.Equal("Foo", charts[1].Name)
+	s.Equal(4.0, charts[1].MaxSecs)
+	s.Equal("0.0,75.0 600.0,0.0", charts[1].Line)
+
+	handler := dashboardHandler(config.NewResolver(), spec.CreateValueSpecString("nbs", s.DBDir, "perf"), 10)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	s.Equal(http.StatusOK, w.Code)
+	body := w.Body.String()
+	s.True(strings.Contains(body, "<h2>Foo"))
+	s.True(strings.Contains(body, "fedcba9: 4.000s"))
+
+	// Runs committed after the handler was made show up on the next request.
+	ds, err = db.CommitValue(ds, types.NewStruct("", types.StructData{
+		"nomsRevision": types.String("aaaaaaa000000000"),
+		"reps":         types.NewList(types.NewMap(types.String("Foo"), elapsed(5e9))),
+	}))
+	s.NoError(err)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	s.True(strings.Contains(w.Body.String(), "aaaaaaa: 5.000s"))
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/nope", nil))
+	s.Equal(http.StatusNotFound, w.Code)
+}
@@ -10,7 +10,6 @@ import (
 	"net/http"
 	"os"
 	"strings"
-	"time"
 
 	"github.com/attic-labs/noms/go/config"
 	"github.com/attic-labs/noms/go/d"
@@ -25,10 +24,6 @@ import (
 	flag "github.com/juju/gnuflag"
 )
 
-var (
-	start time.Time
-)
-
 func main() {
 	noProgress := flag.Bool("no-progress", false, "prevents progress from being output if true")
 	performCommit := flag.Bool("commit", true, "commit the data to head of the dataset (otherwise only write the data to the dataset)")
@@ -48,8 +43,6 @@ func main() {
 		exit.Fail()
 	}
 
-	start = time.Now()
-
 	cfg := config.NewResolver()
 	db, ds, err := cfg.GetDataset(flag.Arg(flag.NArg() - 1))
 	d.CheckErrorNoUsage(err)
@@ -98,7 +91,11 @@ func main() {
 	}
 
 	if !*noProgress {
-		r = progressreader.New(r, getStatusPrinter(contentLength))
+		total := uint64(0)
+		if contentLength > 0 {
+			total = uint64(contentLength)
+		}
+		r = progressreader.NewWithTotal(r, total, printStatus)
 	}
 	b := types.NewStreamingBlob(db, r)
 
@@ -127,22 +124,15 @@ func main() {
 	}
 }
 
-func getStatusPrinter(expectedLen int64) progressreader.Callback {
-	return func(seenLen uint64) {
-		var expected string
-		if expectedLen < 0 {
-			expected = "(unknown)"
-		} else {
-			expected = human.Bytes(uint64(expectedLen))
-		}
-
-		elapsed := time.Now().Sub(start)
-		rate := uint64(float64(seenLen) / elapsed.Seconds())
-
-		status.Printf("%s of %s written in %ds (%s/s)...",
-			human.Bytes(seenLen),
-			expected,
-			uint64(elapsed.Seconds()),
-			human.Bytes(rate))
+func printStatus(p progressreader.Progress) {
+	expected := "(unknown)"
+	if p.Total > 0 {
+		expected = human.Bytes(p.Total)
 	}
+
+	status.Printf("%s of %s written in %ds (%s/s)...",
+		human.Bytes(p.Seen),
+		expected,
+		uint64(p.Elapsed.Seconds()),
+		human.Bytes(uint64(p.Rate())))
 }
@@ -30,7 +30,7 @@ func (s *perfSuite) SetupSuite() {
 	sfCrime := s.TempFile()
 	s.sfCrimePath = sfCrime.Name()
 
-	sfCrimeFiles := s.OpenGlob(s.Testdata, "sf-crime", "2016-07-28.*")
+	sfCrimeFiles := s.OpenGlobOrGenerate(suite.CSVSpec{Rows: 200000, Columns: 13, Cardinality: 5000, RunLength: 4, Seed: 1}, s.Testdata, "sf-crime", "2016-07-28.*")
 	defer s.CloseGlob(sfCrimeFiles)
 
 	_, err := io.Copy(sfCrime, io.MultiReader(sfCrimeFiles...))
@@ -0,0 +1,124 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sync"
+
+	"github.com/attic-labs/noms/go/hash"
+	"github.com/attic-labs/noms/go/types"
+)
+
+// rule is a check on one field of every row. The checks other than Unique
+// only apply to rows which have the field.
+type rule struct {
+	Field string `json:"field"`
+	// Required means every row must have the field.
+	Required bool `json:"required,omitempty"`
+	// Regex must match the field, which must be a String.
+	Regex string `json:"regex,omitempty"`
+	// Min and Max bound the field, which must be a Number.
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+	// Unique means no two rows may have the same value of the field.
+	Unique bool `json:"unique,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// readRules reads a JSON array of rules from path.
+func readRules(path string) ([]*rule, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rules := []*rule{}
+	if err = json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("Invalid rules in %s: %s", path, err)
+	}
+	for i, r := range rules {
+		if r.Field == "" {
+			return nil, fmt.Errorf("Rule %d in %s has no field", i, path)
+		}
+		if r.Regex != "" {
+			if r.re, err = regexp.Compile(r.Regex); err != nil {
+				return nil, fmt.Errorf("Rule %d in %s has an invalid regex: %s", i, path, err)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// violation is a row which breaks a rule.
+type violation struct {
+	Row     uint64
+	Field   string
+	Rule    string
+	Message string
+}
+
+func (v violation) toStruct() types.Struct {
+	return types.NewStruct("Violation", types.StructData{
+		"row":     types.Number(v.Row),
+		"field":   types.String(v.Field),
+		"rule":    types.String(v.Rule),
+		"message": types.String(v.Message),
+	})
+}
+
+// check returns the violations of r by row, the i'th of the dataset. Unique
+// is checked across rows, by checkUnique.
+func (r *rule) check(i uint64, row types.Struct) []violation {
+	v, ok := row.MaybeGet(r.Field)
+	if !ok {
+		if r.Required {
+			return []violation{{i, r.Field, "required", "Field is missing"}}
+		}
+		return nil
+	}
+
+	violations := []violation{}
+	if r.re != nil {
+		if s, ok := v.(types.String); !ok {
+			violations = append(violations, violation{i, r.Field, "regex", fmt.Sprintf("Field is a %s, not a String", v.Kind())})
+		} else if !r.re.MatchString(string(s)) {
+			violations = append(violations, violation{i, r.Field, "regex", fmt.Sprintf("%q doesn't match %s", string(s), r.Regex)})
+		}
+	}
+	if r.Min != nil || r.Max != nil {
+		if n, ok := v.(types.Number); !ok {
+			violations = append(violations, violation{i, r.Field, "range", fmt.Sprintf("Field is a %s, not a Number", v.Kind())})
+		} else if r.Min != nil && float64(n) < *r.Min {
+			violations = append(violations, violation{i, r.Field, "range", fmt.Sprintf("%v is less than %v", float64(n), *r.Min)})
+		} else if r.Max != nil && float64(n) > *r.Max {
+			violations = append(violations, violation{i, r.Field, "range", fmt.Sprintf("%v is greater than %v", float64(n), *r.Max)})
+		}
+	}
+	return violations
+}
+
+// uniqueIndex records the first row with each value of a field with a Unique
+// rule. It's safe for concurrent use.
+type uniqueIndex struct {
+	mu    sync.Mutex
+	first map[hash.Hash]uint64
+}
+
+// add records that row i has v, returning the row which had it first if it's
+// a duplicate. Rows may be added out of order, so the first row is the lowest.
+func (ui *uniqueIndex) add(i uint64, v types.Value) (first uint64, dup bool) {
+	h := v.Hash()
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	first, dup = ui.first[h]
+	if !dup || i < first {
+		ui.first[h] = i
+	}
+	return
+}
@@ -0,0 +1,211 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/attic-labs/noms/go/config"
+	"github.com/attic-labs/noms/go/d"
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/hash"
+	"github.com/attic-labs/noms/go/spec"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/exit"
+	"github.com/attic-labs/noms/go/util/profile"
+	"github.com/attic-labs/noms/go/util/verbose"
+	flag "github.com/juju/gnuflag"
+)
+
+// rangeSize is the number of rows each worker checks at a time.
+const rangeSize = 1 << 12
+
+func main() {
+	rulesPath := flag.String("rules", "", "JSON file of the rules to check: an array of objects with a \"field\", and any of \"required\": true, \"regex\": \"<re>\", \"min\": <n>, \"max\": <n> and \"unique\": true")
+	report := flag.String("report", "", "dataset, in the same database, to commit a List of the violations to")
+	parallelism := flag.Int("p", 16, "number of ranges of rows to check in parallel")
+	spec.RegisterCommitMetaFlags(flag.CommandLine)
+	verbose.RegisterVerboseFlags(flag.CommandLine)
+	profile.RegisterProfileFlags(flag.CommandLine)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Checks the rows of a List or Map of structs, such as csv-import makes, against rules, and exits with status 1 if any row breaks one.\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: validate --rules <file> [options] <dataset>\n\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse(true)
+
+	if flag.NArg() != 1 {
+		d.CheckError(errors.New("expected a dataset"))
+	}
+	if *rulesPath == "" {
+		d.CheckError(errors.New("--rules is required"))
+	}
+	if *parallelism < 1 {
+		d.CheckError(errors.New("-p must be at least 1"))
+	}
+	rules, err := readRules(*rulesPath)
+	d.CheckErrorNoUsage(err)
+
+	cfg := config.NewResolver()
+	db, ds, err := cfg.GetDataset(flag.Arg(0))
+	d.CheckError(err)
+	defer db.Close()
+	var reportDS datas.Dataset
+	if *report != "" {
+		if !datas.DatasetFullRe.MatchString(*report) {
+			d.CheckError(fmt.Errorf("Invalid report dataset name: %s", *report))
+		}
+		reportDS = db.GetDataset(*report)
+	}
+
+	head, ok := ds.MaybeHeadValue()
+	if !ok {
+		d.CheckErrorNoUsage(fmt.Errorf("Dataset %s does not exist", flag.Arg(0)))
+	}
+
+	defer profile.MaybeStartProfile().Stop()
+
+	rows, err := rowsOf(head)
+	d.CheckErrorNoUsage(err)
+	violations := validate(rows, rules, *parallelism)
+
+	if *report != "" {
+		vs := make(types.ValueSlice, len(violations))
+		for i, v := range violations {
+			vs[i] = v.toStruct()
+		}
+		meta, err := spec.CreateCommitMetaStruct(db, "", "", map[string]string{"dataset": flag.Arg(0), "rules": *rulesPath}, map[string]types.Value{
+			"rowsChecked": types.Number(rows.Len()),
+			"violations":  types.Number(len(violations)),
+		})
+		d.CheckErrorNoUsage(err)
+		_, err = db.Commit(reportDS, types.NewList(vs...), datas.CommitOptions{Meta: meta})
+		d.CheckErrorNoUsage(err)
+	}
+
+	for _, v := range violations {
+		fmt.Printf("row %d: %s (%s): %s\n", v.Row, v.Field, v.Rule, v.Message)
+	}
+	fmt.Printf("Checked %d rows against %d rules: %d violations\n", rows.Len(), len(rules), len(violations))
+	if len(violations) > 0 {
+		exit.Fail()
+	}
+}
+
+// rowIterator iterates over the rows of a List or Map.
+type rowIterator interface {
+	Len() uint64
+	// iterAt returns a function which returns each row from the i'th on.
+	iterAt(i uint64) func() types.Value
+}
+
+type listRows struct{ types.List }
+
+func (l listRows) iterAt(i uint64) func() types.Value {
+	it := l.IteratorAt(i)
+	return it.Next
+}
+
+type mapRows struct{ types.Map }
+
+func (m mapRows) iterAt(i uint64) func() types.Value {
+	it := m.IteratorAt(i)
+	return func() types.Value {
+		_, v := it.Next()
+		return v
+	}
+}
+
+func rowsOf(v types.Value) (rowIterator, error) {
+	switch v := v.(type) {
+	case types.List:
+		return listRows{v}, nil
+	case types.Map:
+		return mapRows{v}, nil
+	}
+	return nil, fmt.Errorf("Expected a List or Map, found a %s", v.Kind())
+}
+
+// validate checks every row against rules, returning the violations in row
+// order. The rows are split into ranges, which are checked by parallelism
+// goroutines at a time, each iterating directly to the start of its range.
+func validate(rows rowIterator, rules []*rule, parallelism int) []violation {
+	unique := map[*rule]*uniqueIndex{}
+	for _, r := range rules {
+		if r.Unique {
+			unique[r] = &uniqueIndex{first: map[hash.Hash]uint64{}}
+		}
+	}
+
+	mu := &sync.Mutex{}
+	violations := []violation{}
+	starts := make(chan uint64)
+	go func() {
+		for i := uint64(0); i < rows.Len(); i += rangeSize {
+			starts <- i
+		}
+		close(starts)
+	}()
+
+	wg := &sync.WaitGroup{}
+	wg.Add(parallelism)
+	for p := 0; p < parallelism; p++ {
+		go func() {
+			defer wg.Done()
+			for start := range starts {
+				found := []violation{}
+				next := rows.iterAt(start)
+				for i := start; i < start+rangeSize && i < rows.Len(); i++ {
+					row, ok := next().(types.Struct)
+					if !ok {
+						found = append(found, violation{i, "", "struct", "Row isn't a struct"})
+						continue
+					}
+					for _, r := range rules {
+						found = append(found, r.check(i, row)...)
+						if ui := unique[r]; ui != nil {
+							found = append(found, checkUnique(ui, r, i, row)...)
+						}
+					}
+				}
+				mu.Lock()
+				violations = append(violations, found...)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Row != violations[j].Row {
+			return violations[i].Row < violations[j].Row
+		}
+		return violations[i].Field < violations[j].Field
+	})
+	return violations
+}
+
+// checkUnique reports a duplicate value as a violation of the later of the two
+// rows which have it, since ranges are checked out of order.
+func checkUnique(ui *uniqueIndex, r *rule, i uint64, row types.Struct) []violation {
+	v, ok := row.MaybeGet(r.Field)
+	if !ok {
+		return nil
+	}
+	other, dup := ui.add(i, v)
+	if !dup {
+		return nil
+	}
+	if other > i {
+		i, other = other, i
+	}
+	return []violation{{i, r.Field, "unique", fmt.Sprintf("Same value as row %d", other)}}
+}
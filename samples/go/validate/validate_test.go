@@ -0,0 +1,109 @@
+// Copyright 2016 Attic Labs, Inc. All rights reserved.
+// Licensed under the Apache License, version 2.0:
+// http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/attic-labs/noms/go/datas"
+	"github.com/attic-labs/noms/go/nbs"
+	"github.com/attic-labs/noms/go/spec"
+	"github.com/attic-labs/noms/go/types"
+	"github.com/attic-labs/noms/go/util/clienttest"
+	"github.com/attic-labs/testify/suite"
+)
+
+func TestValidate(t *testing.T) {
+	suite.Run(t, &testSuite{})
+}
+
+type testSuite struct {
+	clienttest.ClientTestSuite
+}
+
+const testRules = `[
+  {"field": "id", "required": true, "unique": true},
+  {"field": "zip", "regex": "^[0-9]{5}$"},
+  {"field": "age", "min": 0, "max": 150}
+]`
+
+func row(id, zip string, age float64) types.Struct {
+	return types.NewStruct("Row", types.StructData{
+		"id":  types.String(id),
+		"zip": types.String(zip),
+		"age": types.Number(age),
+	})
+}
+
+func (s *testSuite) writeRules() string {
+	path := filepath.Join(s.TempDir, "rules.json")
+	s.NoError(ioutil.WriteFile(path, []byte(testRules), 0644))
+	return path
+}
+
+func (s *testSuite) commit(v types.Value) string {
+	db := datas.NewDatabase(nbs.NewLocalStore(s.DBDir, clienttest.DefaultMemTableSize))
+	defer db.Close()
+	_, err := db.CommitValue(db.GetDataset("rows"), v)
+	s.NoError(err)
+	return spec.CreateValueSpecString("nbs", s.DBDir, "rows")
+}
+
+func (s *testSuite) TestValid() {
+	rows := make(types.ValueSlice, 2*rangeSize+10)
+	for i := range rows {
+		rows[i] = row(fmt.Sprintf("%d", i), "94110", float64(i%100))
+	}
+	ds := s.commit(types.NewList(rows...))
+
+	stdout, stderr := s.MustRun(main, []string{"--rules", s.writeRules(), "-p", "3", ds})
+	s.Equal("", stderr)
+	s.Equal(fmt.Sprintf("Checked %d rows against 3 rules: 0 violations\n", len(rows)), stdout)
+}
+
+func (s *testSuite) TestViolations() {
+	ds := s.commit(types.NewMap(
+		types.Number(1), row("a", "94110", 30),
+		types.Number(2), row("b", "9411", 30),
+		types.Number(3), row("a", "94110", 200),
+		types.Number(4), types.NewStruct("Row", types.StructData{"zip": types.Number(94110)}),
+	))
+
+	stdout, _, exitErr := s.Run(main, []string{"--rules", s.writeRules(), "--report", "violations", ds})
+	s.Equal(clienttest.ExitError{Code: 1}, exitErr)
+	s.Equal(`row 1: zip (regex): "9411" doesn't match ^[0-9]{5}$
+row 2: age (range): 200 is greater than 150
+row 2: id (unique): Same value as row 0
+row 3: id (required): Field is missing
+row 3: zip (regex): Field is a Number, not a String
+Checked 4 rows against 3 rules: 5 violations
+`, stdout)
+
+	sp, err := spec.ForDataset(spec.CreateValueSpecString("nbs", s.DBDir, "violations"))
+	s.NoError(err)
+	defer sp.Close()
+	report := sp.GetDataset().HeadValue().(types.List)
+	s.Equal(uint64(5), report.Len())
+	s.True(report.Get(2).Equals(types.NewStruct("Violation", types.StructData{
+		"row":     types.Number(2),
+		"field":   types.String("id"),
+		"rule":    types.String("unique"),
+		"message": types.String("Same value as row 0"),
+	})))
+}
+
+func (s *testSuite) TestInvalidRules() {
+	path := filepath.Join(s.TempDir, "bad.json")
+	s.NoError(ioutil.WriteFile(path, []byte(`[{"field": "zip", "regex": "("}]`), 0644))
+	_, err := readRules(path)
+	s.Error(err)
+
+	s.NoError(ioutil.WriteFile(path, []byte(`[{"regex": "a"}]`), 0644))
+	_, err = readRules(path)
+	s.Error(err)
+}